@@ -0,0 +1,186 @@
+/*
+Copyright © 2025 Yby Team
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var aiCmd = &cobra.Command{
+	Use:   "ai",
+	Short: "Utilitários dos provedores de IA (uso, custo e orçamento)",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var aiUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Relatório de uso e custo estimado de IA",
+	Long: `Lê o ledger de uso em ~/.yby/ai/usage e agrupa as chamadas de IA do CLI e
+dos plugins por dia, plugin, modelo, provider ou operação. Quando ai.budget
+estiver configurado, exibe também o gasto do dia e do mês contra os limites.`,
+	Example: `  yby ai usage
+  yby ai usage --by plugin --days 7
+  yby ai usage --by model --json`,
+	RunE: runAIUsage,
+}
+
+var aiCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Gerencia o cache de respostas de IA (ai.cache)",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var aiCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove todas as respostas do cache de IA",
+	RunE:  runAICacheClear,
+}
+
+// aiUsageLedger permite substituir o ledger nos testes.
+var aiUsageLedger = ai.DefaultUsageLedger
+
+func init() {
+	rootCmd.AddCommand(aiCmd)
+	aiCmd.AddCommand(aiUsageCmd)
+	aiCmd.AddCommand(aiCacheCmd)
+	aiCacheCmd.AddCommand(aiCacheClearCmd)
+
+	aiUsageCmd.Flags().String("by", "day", "Agrupamento: day, plugin, model, provider ou operation")
+	aiUsageCmd.Flags().Int("days", 30, "Quantidade de dias a considerar")
+	aiUsageCmd.Flags().Bool("json", false, "Saída em JSON")
+}
+
+func runAIUsage(cmd *cobra.Command, args []string) error {
+	by, _ := cmd.Flags().GetString("by")
+	days, _ := cmd.Flags().GetInt("days")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if days <= 0 {
+		return fmt.Errorf("--days deve ser maior que zero")
+	}
+
+	ledger := aiUsageLedger()
+	if ledger == nil {
+		return fmt.Errorf("não foi possível localizar o diretório do ledger de uso")
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	records, err := ledger.Load(today.AddDate(0, 0, -(days-1)), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	summaries, err := ai.SummarizeUsage(records, by)
+	if err != nil {
+		return err
+	}
+
+	total := ai.UsageSummary{Key: "TOTAL"}
+	for _, s := range summaries {
+		total.Calls += s.Calls
+		total.PromptTokens += s.PromptTokens
+		total.CompletionTokens += s.CompletionTokens
+		total.TotalTokens += s.TotalTokens
+		total.CostUSD += s.CostUSD
+		total.CacheHits += s.CacheHits
+		total.SavedUSD += s.SavedUSD
+	}
+
+	var budget config.BudgetConfig
+	if cfg, err := config.Load(); err == nil {
+		budget = cfg.AI.Budget
+	}
+	daySpent, monthSpent, err := ledger.Spent(now)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"by":        by,
+			"days":      days,
+			"groups":    summaries,
+			"total":     total,
+			"today_usd": daySpent,
+			"month_usd": monthSpent,
+			"budget":    budget,
+		})
+	}
+
+	if len(summaries) == 0 {
+		fmt.Fprintln(out, grayStyle.Render(fmt.Sprintf("Nenhum uso de IA registrado nos últimos %d dia(s).", days)))
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(w, "%s\tCHAMADAS\tTOKENS ENTRADA\tTOKENS SAÍDA\tTOKENS\tCUSTO (USD)\tCACHE\tECONOMIA (USD)\n", usageColumn(by))
+		fmt.Fprintln(w, "-----\t--------\t--------------\t------------\t------\t-----------\t-----\t--------------")
+		for _, s := range append(summaries, total) {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.4f\t%d\t%.4f\n",
+				s.Key, s.Calls, s.PromptTokens, s.CompletionTokens, s.TotalTokens, s.CostUSD, s.CacheHits, s.SavedUSD)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Hoje: %s\n", budgetStatus(daySpent, budget.DailyUSD))
+	fmt.Fprintf(out, "Mês:  %s\n", budgetStatus(monthSpent, budget.MonthlyUSD))
+	return nil
+}
+
+func runAICacheClear(cmd *cobra.Command, args []string) error {
+	var cacheCfg config.CacheConfig
+	if cfg, err := config.Load(); err == nil {
+		cacheCfg = cfg.AI.Cache
+	}
+	cache := ai.DefaultCompletionCache(cacheCfg)
+	if cache == nil {
+		return fmt.Errorf("não foi possível localizar o diretório do cache de IA")
+	}
+	removed, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%d resposta(s) removida(s) de %s\n", removed, cache.Dir)
+	return nil
+}
+
+// usageColumn retorna o cabeçalho da coluna de agrupamento.
+func usageColumn(by string) string {
+	switch by {
+	case "day":
+		return "DIA"
+	case "plugin":
+		return "PLUGIN"
+	case "model":
+		return "MODELO"
+	case "provider":
+		return "PROVIDER"
+	default:
+		return "OPERAÇÃO"
+	}
+}
+
+// budgetStatus formata o gasto de um período contra o limite configurado.
+func budgetStatus(spent, limit float64) string {
+	if limit <= 0 {
+		return fmt.Sprintf("US$ %.4f (sem limite)", spent)
+	}
+	status := fmt.Sprintf("US$ %.4f de US$ %.2f (%.0f%%)", spent, limit, spent/limit*100)
+	if spent >= limit {
+		return warningStyle.Render(status + " - orçamento excedido")
+	}
+	return status
+}
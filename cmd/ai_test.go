@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAIUsage aponta o comando para um ledger temporário com dois registros
+// de hoje e um antigo, e um config com orçamento diário de 1 US$.
+func setupAIUsage(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".yby"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".yby", "config.yaml"),
+		[]byte("ai:\n  budget:\n    daily_usd: 1\n"), 0644))
+
+	ledger := &ai.UsageLedger{Dir: t.TempDir()}
+	now := time.Now()
+	require.NoError(t, ledger.Record(ai.UsageRecord{Time: now, Plugin: "bard", Model: "gpt-4o", TotalTokens: 100, CostUSD: 0.8}))
+	require.NoError(t, ledger.Record(ai.UsageRecord{Time: now, Plugin: "sentinel", Model: "gpt-4o-mini", TotalTokens: 40, CostUSD: 0.3}))
+	require.NoError(t, ledger.Record(ai.UsageRecord{Time: now.AddDate(0, 0, -60), Plugin: "bard", Model: "gpt-4o", TotalTokens: 999, CostUSD: 9}))
+
+	orig := aiUsageLedger
+	aiUsageLedger = func() *ai.UsageLedger { return ledger }
+	t.Cleanup(func() { aiUsageLedger = orig })
+}
+
+func runAIUsageWith(t *testing.T, flags map[string]string) (string, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	aiUsageCmd.SetOut(&buf)
+	t.Cleanup(func() {
+		aiUsageCmd.SetOut(nil)
+		aiUsageCmd.Flags().Set("by", "day")
+		aiUsageCmd.Flags().Set("days", "30")
+		aiUsageCmd.Flags().Set("json", "false")
+	})
+	for k, v := range flags {
+		require.NoError(t, aiUsageCmd.Flags().Set(k, v))
+	}
+	err := runAIUsage(aiUsageCmd, nil)
+	return buf.String(), err
+}
+
+func TestAIUsage_PorPlugin(t *testing.T) {
+	setupAIUsage(t)
+
+	out, err := runAIUsageWith(t, map[string]string{"by": "plugin"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "PLUGIN")
+	assert.Contains(t, out, "bard")
+	assert.Contains(t, out, "sentinel")
+	assert.Contains(t, out, "TOTAL")
+	assert.Contains(t, out, "1.1000")
+	assert.NotContains(t, out, "999", "registros fora da janela não deveriam entrar")
+	assert.Contains(t, out, "orçamento excedido")
+}
+
+func TestAIUsage_JSON(t *testing.T) {
+	setupAIUsage(t)
+
+	out, err := runAIUsageWith(t, map[string]string{"by": "model", "json": "true"})
+	require.NoError(t, err)
+
+	var report struct {
+		Groups []ai.UsageSummary `json:"groups"`
+		Total  ai.UsageSummary   `json:"total"`
+		Today  float64           `json:"today_usd"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Groups, 2)
+	assert.Equal(t, "gpt-4o", report.Groups[0].Key)
+	assert.Equal(t, 2, report.Total.Calls)
+	assert.InDelta(t, 1.1, report.Today, 1e-9)
+}
+
+func TestAIUsage_FlagsInvalidas(t *testing.T) {
+	setupAIUsage(t)
+
+	_, err := runAIUsageWith(t, map[string]string{"by": "semana"})
+	assert.Error(t, err)
+
+	_, err = runAIUsageWith(t, map[string]string{"days": "0"})
+	assert.Error(t, err)
+}
+
+func TestBudgetStatus(t *testing.T) {
+	assert.Contains(t, budgetStatus(0.5, 0), "sem limite")
+	assert.Contains(t, budgetStatus(0.5, 1), "50%")
+	assert.Contains(t, budgetStatus(2, 1), "excedido")
+}
+
+func TestAIUsage_ColunasDeCache(t *testing.T) {
+	setupAIUsage(t)
+	ledger := aiUsageLedger()
+	require.NoError(t, ledger.Record(ai.UsageRecord{Time: time.Now(), Plugin: "atlas", Model: "gpt-4o", CacheHit: true, SavedUSD: 0.2}))
+
+	out, err := runAIUsageWith(t, map[string]string{"by": "plugin"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "ECONOMIA (USD)")
+	assert.Contains(t, out, "0.2000")
+}
+
+func TestAICacheClear(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YBY_AI_CACHE_DIR", dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "abc.json"), []byte("{}"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "def.json"), []byte("{}"), 0600))
+
+	var buf bytes.Buffer
+	aiCacheClearCmd.SetOut(&buf)
+	defer aiCacheClearCmd.SetOut(nil)
+	require.NoError(t, runAICacheClear(aiCacheClearCmd, nil))
+	assert.Contains(t, buf.String(), "2 resposta(s) removida(s)")
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Empty(t, files)
+}
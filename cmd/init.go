@@ -254,7 +254,7 @@ Suporta execução interativa (Wizard) ou Headless (Flags).`,
 
 			if description != "" {
 				fmt.Printf("🧠 Processando... (Analisando: '%s')\n", description)
-				blueprint, err := generateGovernanceViaCompletion(bgCtx, aiProvider, description)
+				blueprint, err := ai.GenerateGovernanceBlueprint(bgCtx, aiProvider, description)
 				if err != nil {
 					fmt.Printf("⚠️ Falha na geração por IA: %v. Usando templates estáticos.\n", err)
 				} else {
@@ -5,7 +5,6 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,7 +12,6 @@ import (
 	"time"
 
 	"github.com/casheiro/yby-cli/pkg/ai"
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 	"github.com/casheiro/yby-cli/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -72,8 +70,8 @@ Exemplo:
 		fmt.Printf("%s Usando provedor: %s\n", checkStyle.String(), provider.Name())
 		fmt.Println(stepStyle.Render("🤔 Analisando e estruturando conhecimento... (Isso pode levar alguns segundos)"))
 
-		// 3. Generate via Completion
-		blueprint, err := generateGovernanceViaCompletion(ctx, provider, description)
+		// 3. Generate via CompleteJSON (modo JSON nativo, validação e reparo)
+		blueprint, err := ai.GenerateGovernanceBlueprint(ctx, provider, description)
 		if err != nil {
 			return errors.Wrap(err, errors.ErrCodeExec, "Erro na geração AI")
 		}
@@ -119,29 +117,6 @@ Exemplo:
 	},
 }
 
-// generateGovernanceViaCompletion usa Completion com o system prompt de governança
-// para gerar um GovernanceBlueprint, substituindo o uso direto de GenerateGovernance.
-func generateGovernanceViaCompletion(ctx context.Context, provider ai.Provider, description string) (*ai.GovernanceBlueprint, error) {
-	userPrompt := fmt.Sprintf("Descrição do Projeto: %s", description)
-	result, err := provider.Completion(ctx, prompts.Get("governance.system"), userPrompt)
-	if err != nil {
-		return nil, err
-	}
-
-	// Limpar possíveis fences de markdown na resposta
-	cleanJSON := strings.TrimPrefix(result, "```json")
-	cleanJSON = strings.TrimPrefix(cleanJSON, "```")
-	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
-	cleanJSON = strings.TrimSpace(cleanJSON)
-
-	var blueprint ai.GovernanceBlueprint
-	if err := json.Unmarshal([]byte(cleanJSON), &blueprint); err != nil {
-		return nil, fmt.Errorf("falha ao analisar json do blueprint: %w", err)
-	}
-
-	return &blueprint, nil
-}
-
 func init() {
 	rootCmd.AddCommand(ukiCmd)
 	ukiCmd.AddCommand(captureCmd)
@@ -15,7 +15,7 @@ import (
 
 // mockAIProvider implementa ai.Provider para testes.
 // Completion retorna o blueprint serializado como JSON para compatibilidade
-// com generateGovernanceViaCompletion.
+// com ai.GenerateGovernanceBlueprint.
 type mockAIProvider struct {
 	name      string
 	blueprint *ai.GovernanceBlueprint
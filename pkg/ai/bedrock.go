@@ -12,8 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 )
 
 // BedrockClient define a interface do cliente Bedrock para facilitar testes com mock.
@@ -73,6 +73,13 @@ func (p *BedrockProvider) Name() string {
 	return "AWS Bedrock (Cloud)"
 }
 
+// withModel retorna uma cópia do provider usando outro modelo.
+func (p *BedrockProvider) withModel(model string) Provider {
+	c := *p
+	c.Model = model
+	return &c
+}
+
 func (p *BedrockProvider) IsAvailable(ctx context.Context) bool {
 	_, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(p.Region))
 	return err == nil
@@ -219,15 +226,86 @@ func (p *BedrockProvider) EmbedDocuments(ctx context.Context, texts []string) ([
 }
 
 func (p *BedrockProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
-	result, err := p.Completion(ctx, prompts.Get("governance.system"), fmt.Sprintf("Descrição do Projeto: %s", description))
+	return GenerateGovernanceBlueprint(ctx, p, description)
+}
+
+// CompletionWithTools usa o tool use da API Converse.
+func (p *BedrockProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	toolConfig := &types.ToolConfiguration{}
+	for _, t := range tools {
+		// O encoder de documentos do smithy não lê tags json: converter para map
+		var schema map[string]interface{}
+		raw, _ := json.Marshal(t.Parameters)
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("falha ao serializar schema de %s: %w", t.Name, err)
+		}
+		toolConfig.Tools = append(toolConfig.Tools, &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+			Name:        aws.String(t.Name),
+			Description: aws.String(t.Description),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(schema)},
+		}})
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(p.Model),
+		System: []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{Value: systemPrompt},
+		},
+		Messages: []types.Message{
+			{
+				Role: types.ConversationRoleUser,
+				Content: []types.ContentBlock{
+					&types.ContentBlockMemberText{Value: userPrompt},
+				},
+			},
+		},
+		ToolConfig: toolConfig,
+	}
+
+	output, err := p.client.Converse(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("falha ao chamar bedrock converse: %w", err)
+	}
+
+	if output.Usage != nil {
+		SetUsage(ctx, &UsageMetadata{
+			PromptTokens:     int(aws.ToInt32(output.Usage.InputTokens)),
+			CompletionTokens: int(aws.ToInt32(output.Usage.OutputTokens)),
+			TotalTokens:      int(aws.ToInt32(output.Usage.TotalTokens)),
+			Provider:         "bedrock",
+			Model:            p.Model,
+			Operation:        "tools",
+		})
 	}
 
-	var blueprint GovernanceBlueprint
-	if err := json.Unmarshal([]byte(result), &blueprint); err != nil {
-		return nil, fmt.Errorf("falha ao analisar json do blueprint: %w", err)
+	msg, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("resposta vazia do bedrock")
 	}
 
-	return &blueprint, nil
+	result := &ToolResponse{}
+	for _, block := range msg.Value.Content {
+		switch b := block.(type) {
+		case *types.ContentBlockMemberText:
+			result.Content += b.Value
+		case *types.ContentBlockMemberToolUse:
+			// Decodificar via JSON para que números virem float64, como nos demais providers
+			var args map[string]interface{}
+			if b.Value.Input != nil {
+				raw, err := b.Value.Input.MarshalSmithyDocument()
+				if err == nil {
+					err = json.Unmarshal(raw, &args)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("argumentos invalidos na chamada de %s: %w", aws.ToString(b.Value.Name), err)
+				}
+			}
+			result.ToolCalls = append(result.ToolCalls, ToolCall{
+				ID:        aws.ToString(b.Value.ToolUseId),
+				Name:      aws.ToString(b.Value.Name),
+				Arguments: args,
+			})
+		}
+	}
+	return result, nil
 }
@@ -8,7 +8,7 @@ func init() {
 	registerProvider("bedrock", func(ctx context.Context) Provider {
 		p := NewBedrockProvider()
 		if p != nil && p.IsAvailable(ctx) {
-			return wrapProvider(p, p.Model)
+			return wrapWithBudget("bedrock", p, p.Model)
 		}
 		return nil
 	})
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
 )
 
@@ -196,3 +197,52 @@ func TestBedrockProvider_StreamCompletion(t *testing.T) {
 		t.Error("StreamCompletion() não chamou ConverseStream")
 	}
 }
+
+func TestBedrockProvider_CompletionWithTools(t *testing.T) {
+	mock := &mockBedrockClient{
+		converseFunc: func(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+			if params.ToolConfig == nil || len(params.ToolConfig.Tools) != 1 {
+				t.Fatalf("ToolConfig inesperado: %+v", params.ToolConfig)
+			}
+			spec := params.ToolConfig.Tools[0].(*types.ToolMemberToolSpec).Value
+			if *spec.Name != "kubectl_logs" {
+				t.Errorf("nome da ferramenta = %q", *spec.Name)
+			}
+			name, id := "kubectl_logs", "tooluse_1"
+			return &bedrockruntime.ConverseOutput{
+				Output: &types.ConverseOutputMemberMessage{
+					Value: types.Message{
+						Role: types.ConversationRoleAssistant,
+						Content: []types.ContentBlock{
+							&types.ContentBlockMemberText{Value: "Buscando logs."},
+							&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+								Name:      &name,
+								ToolUseId: &id,
+								Input:     document.NewLazyDocument(map[string]interface{}{"pod": "api", "tail": 20}),
+							}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	p := newBedrockProviderWithClient(mock, "test-model", "us-east-1")
+	tools := []ToolDefinition{{
+		Name: "kubectl_logs",
+		Parameters: ToolSchema{Type: "object", Properties: map[string]ToolProperty{
+			"pod":  {Type: "string"},
+			"tail": {Type: "integer"},
+		}, Required: []string{"pod"}},
+	}}
+	resp, err := CompleteWithTools(context.Background(), p, "system", "logs do api", tools)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !resp.Native || resp.Content != "Buscando logs." {
+		t.Errorf("resposta inesperada: %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "tooluse_1" || resp.ToolCalls[0].Arguments["tail"] != float64(20) {
+		t.Errorf("tool calls inesperadas: %+v", resp.ToolCalls)
+	}
+}
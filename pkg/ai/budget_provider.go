@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/config"
+)
+
+// ErrBudgetExceeded indica que o orçamento de IA (ai.budget) foi estourado.
+var ErrBudgetExceeded = errors.New("orcamento de IA excedido")
+
+// BudgetExceededError detalha o período e os valores do orçamento estourado.
+type BudgetExceededError struct {
+	Period string // "diario" ou "mensal"
+	Spent  float64
+	Limit  float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("orcamento %s de IA excedido: US$ %.4f de US$ %.2f (ajuste ai.budget em ~/.yby/config.yaml)", e.Period, e.Spent, e.Limit)
+}
+
+func (e *BudgetExceededError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// modelSwitcher é implementado pelos providers que podem ser recriados com
+// outro modelo, usado no downgrade por orçamento.
+type modelSwitcher interface {
+	withModel(model string) Provider
+}
+
+// BudgetProvider é um decorator que consulta o ledger de uso antes de cada
+// chamada. Com o orçamento estourado, interrompe a chamada (on_exceed: stop)
+// ou a redireciona para o provider com modelo mais barato (on_exceed: downgrade).
+type BudgetProvider struct {
+	inner   Provider
+	cheaper Provider
+	budget  config.BudgetConfig
+	ledger  *UsageLedger
+	now     func() time.Time
+
+	warnOnce sync.Once
+}
+
+// NewBudgetProvider cria um BudgetProvider. cheaper pode ser nil quando não há
+// modelo de downgrade configurado para o provider.
+func NewBudgetProvider(inner, cheaper Provider, budget config.BudgetConfig, ledger *UsageLedger) *BudgetProvider {
+	return &BudgetProvider{inner: inner, cheaper: cheaper, budget: budget, ledger: ledger, now: time.Now}
+}
+
+func (b *BudgetProvider) Name() string                         { return b.inner.Name() }
+func (b *BudgetProvider) IsAvailable(ctx context.Context) bool { return b.inner.IsAvailable(ctx) }
+
+// Unwrap retorna o provider envolvido.
+func (b *BudgetProvider) Unwrap() Provider { return b.inner }
+
+// target escolhe o provider da chamada segundo o gasto atual.
+func (b *BudgetProvider) target() (Provider, error) {
+	exceeded := b.check()
+	if exceeded == nil {
+		return b.inner, nil
+	}
+	if b.budget.OnExceed == "downgrade" && b.cheaper != nil {
+		b.warnOnce.Do(func() {
+			slog.Warn("orcamento de IA excedido, usando modelo mais barato", "provider", b.inner.Name(), "periodo", exceeded.Period)
+		})
+		return b.cheaper, nil
+	}
+	return nil, exceeded
+}
+
+// check retorna o período estourado, ou nil. Falhas de leitura do ledger não
+// bloqueiam a chamada.
+func (b *BudgetProvider) check() *BudgetExceededError {
+	if b.ledger == nil {
+		return nil
+	}
+	day, month, err := b.ledger.Spent(b.now())
+	if err != nil {
+		slog.Debug("falha ao consultar ledger de uso", "erro", err)
+		return nil
+	}
+	if b.budget.DailyUSD > 0 && day >= b.budget.DailyUSD {
+		return &BudgetExceededError{Period: "diario", Spent: day, Limit: b.budget.DailyUSD}
+	}
+	if b.budget.MonthlyUSD > 0 && month >= b.budget.MonthlyUSD {
+		return &BudgetExceededError{Period: "mensal", Spent: month, Limit: b.budget.MonthlyUSD}
+	}
+	return nil
+}
+
+func (b *BudgetProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p, err := b.target()
+	if err != nil {
+		return "", err
+	}
+	return p.Completion(ctx, systemPrompt, userPrompt)
+}
+
+func (b *BudgetProvider) StreamCompletion(ctx context.Context, systemPrompt, userPrompt string, out io.Writer) error {
+	p, err := b.target()
+	if err != nil {
+		return err
+	}
+	return p.StreamCompletion(ctx, systemPrompt, userPrompt, out)
+}
+
+// EmbedDocuments não faz downgrade: o modelo de embedding precisa ser o mesmo
+// do índice existente.
+func (b *BudgetProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if _, err := b.target(); err != nil {
+		return nil, err
+	}
+	return b.inner.EmbedDocuments(ctx, texts)
+}
+
+func (b *BudgetProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
+	p, err := b.target()
+	if err != nil {
+		return nil, err
+	}
+	return p.GenerateGovernance(ctx, description)
+}
+
+func (b *BudgetProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	p, err := b.target()
+	if err != nil {
+		return nil, err
+	}
+	return innerCompletionWithTools(ctx, p, systemPrompt, userPrompt, tools)
+}
+
+func (b *BudgetProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	p, err := b.target()
+	if err != nil {
+		return "", err
+	}
+	return innerCompletionJSON(ctx, p, systemPrompt, userPrompt, schema)
+}
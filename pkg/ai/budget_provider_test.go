@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetFixture cria um ledger com gasto de 1 US$ hoje e 5 US$ no mês.
+func budgetFixture(t *testing.T) (*UsageLedger, time.Time) {
+	t.Helper()
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.AddDate(0, 0, -5), CostUSD: 4}))
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.Add(-time.Hour), CostUSD: 1}))
+	return ledger, now
+}
+
+func TestBudgetProvider_DentroDoOrcamento(t *testing.T) {
+	ledger, now := budgetFixture(t)
+	b := NewBudgetProvider(okProvider("caro", "ok"), nil, config.BudgetConfig{DailyUSD: 2, MonthlyUSD: 10}, ledger)
+	b.now = func() time.Time { return now }
+
+	result, err := b.Completion(context.Background(), "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, "caro", b.Name())
+}
+
+func TestBudgetProvider_Stop(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget config.BudgetConfig
+		period string
+	}{
+		{"diario", config.BudgetConfig{DailyUSD: 1}, "diario"},
+		{"mensal", config.BudgetConfig{DailyUSD: 3, MonthlyUSD: 5}, "mensal"},
+		{"downgrade sem modelo barato", config.BudgetConfig{DailyUSD: 0.5, OnExceed: "downgrade"}, "diario"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, now := budgetFixture(t)
+			called := false
+			inner := &mockProvider{name: "caro", completionFunc: func(_ context.Context, _, _ string) (string, error) {
+				called = true
+				return "", nil
+			}}
+			b := NewBudgetProvider(inner, nil, tt.budget, ledger)
+			b.now = func() time.Time { return now }
+
+			_, err := b.Completion(context.Background(), "sys", "usr")
+			assert.ErrorIs(t, err, ErrBudgetExceeded)
+			var exceeded *BudgetExceededError
+			require.ErrorAs(t, err, &exceeded)
+			assert.Equal(t, tt.period, exceeded.Period)
+			assert.False(t, called)
+		})
+	}
+}
+
+func TestBudgetProvider_Downgrade(t *testing.T) {
+	ledger, now := budgetFixture(t)
+	embedded := false
+	inner := &mockProvider{name: "caro", embedFunc: func(_ context.Context, _ []string) ([][]float32, error) {
+		embedded = true
+		return nil, nil
+	}}
+	b := NewBudgetProvider(inner, okProvider("barato", "de barato"), config.BudgetConfig{MonthlyUSD: 5, OnExceed: "downgrade"}, ledger)
+	b.now = func() time.Time { return now }
+
+	result, err := b.Completion(context.Background(), "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "de barato", result)
+
+	// Embeddings continuam no modelo original para não invalidar o índice
+	_, err = b.EmbedDocuments(context.Background(), []string{"x"})
+	require.NoError(t, err)
+	assert.True(t, embedded)
+}
+
+func TestBudgetProvider_LedgerRecebeChamadas(t *testing.T) {
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	inner := &mockProvider{name: "openai", completionFunc: func(ctx context.Context, _, _ string) (string, error) {
+		SetUsage(ctx, &UsageMetadata{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, TotalTokens: 2_000_000, Provider: "openai", Model: "gpt-4o-mini"})
+		return "ok", nil
+	}}
+	ct := NewCostTrackingProvider(inner, "gpt-4o-mini")
+	ct.ledger = ledger
+	b := NewBudgetProvider(ct, nil, config.BudgetConfig{DailyUSD: 1}, ledger)
+
+	_, err := b.Completion(context.Background(), "sys", "usr")
+	require.NoError(t, err, "primeira chamada ainda está dentro do orçamento")
+	_, err = b.Completion(context.Background(), "sys", "usr")
+	require.NoError(t, err, "0.75 gasto, ainda abaixo de 1")
+	_, err = b.Completion(context.Background(), "sys", "usr")
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+}
+
+func TestFallbackProvider_OrcamentoNaoFazFallback(t *testing.T) {
+	f, created := fallbackChain(failingProvider("a", &BudgetExceededError{Period: "diario", Spent: 2, Limit: 1}), map[string]Provider{
+		"gemini": okProvider("b", "de b"),
+	}, "gemini")
+
+	_, err := f.Completion(context.Background(), "sys", "usr")
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.Empty(t, *created)
+}
+
+func TestWrapWithBudget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	p := &OpenAIProvider{APIKey: "key", Model: "gpt-4o"}
+	_, isBudget := wrapWithBudget("openai", p, p.Model).(*BudgetProvider)
+	assert.False(t, isBudget, "sem limites configurados não deveria haver BudgetProvider")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".yby"), 0755))
+	cfg := "ai:\n  budget:\n    daily_usd: 1\n    on_exceed: downgrade\n    downgrade:\n      openai: gpt-4o-mini\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".yby", "config.yaml"), []byte(cfg), 0644))
+
+	b, ok := wrapWithBudget("openai", p, p.Model).(*BudgetProvider)
+	require.True(t, ok)
+	require.NotNil(t, b.cheaper)
+	assert.Equal(t, "gpt-4o-mini", innermost(b.cheaper).(*OpenAIProvider).Model)
+	assert.Equal(t, "gpt-4o", p.Model, "o provider original não deveria ser alterado")
+	assert.True(t, SupportsTools(b))
+}
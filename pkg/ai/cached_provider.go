@@ -131,6 +131,17 @@ func (c *CachedEmbeddingProvider) GenerateGovernance(ctx context.Context, descri
 	return c.inner.GenerateGovernance(ctx, description)
 }
 
+func (c *CachedEmbeddingProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	return innerCompletionWithTools(ctx, c.inner, systemPrompt, userPrompt, tools)
+}
+
+func (c *CachedEmbeddingProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	return innerCompletionJSON(ctx, c.inner, systemPrompt, userPrompt, schema)
+}
+
+// Unwrap retorna o provider envolvido.
+func (c *CachedEmbeddingProvider) Unwrap() Provider { return c.inner }
+
 // EmbedDocuments verifica o cache para cada texto, chama o provider apenas para misses,
 // e retorna os resultados na ordem original.
 func (c *CachedEmbeddingProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
@@ -0,0 +1,322 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/config"
+)
+
+const (
+	defaultCompletionCacheTTL     = 24 * time.Hour
+	defaultCompletionCacheSizeMB  = 100
+	completionCacheEntryExtension = ".json"
+)
+
+type noCacheKey struct{}
+
+// WithoutCache marca o contexto para que a chamada ignore o cache de
+// completions, sem ler nem gravar entradas.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(noCacheKey{}).(bool)
+	return bypass
+}
+
+// completionCacheEntry é o conteúdo de um arquivo do cache.
+type completionCacheEntry struct {
+	CreatedAt        time.Time `json:"created_at"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Response         string    `json:"response"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+}
+
+// CompletionCache guarda respostas de completion em disco, um arquivo por
+// chave, com TTL e limite de tamanho total. Ao passar do limite, as entradas
+// mais antigas são removidas.
+type CompletionCache struct {
+	Dir      string
+	TTL      time.Duration
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+// DefaultCompletionCache retorna o cache em ~/.yby/ai/cache (ou em
+// YBY_AI_CACHE_DIR) com o TTL e o tamanho de ai.cache, aplicando os padrões
+// de 24h e 100 MB. Retorna nil se o diretório home não puder ser resolvido.
+func DefaultCompletionCache(cfg config.CacheConfig) *CompletionCache {
+	dir := os.Getenv("YBY_AI_CACHE_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, ".yby", "ai", "cache")
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultCompletionCacheTTL
+	}
+	sizeMB := cfg.MaxSizeMB
+	if sizeMB <= 0 {
+		sizeMB = defaultCompletionCacheSizeMB
+	}
+	return &CompletionCache{Dir: dir, TTL: ttl, MaxBytes: int64(sizeMB) << 20}
+}
+
+func (c *CompletionCache) path(key string) string {
+	return filepath.Join(c.Dir, key+completionCacheEntryExtension)
+}
+
+// get retorna a entrada da chave se presente e dentro do TTL.
+func (c *CompletionCache) get(key string) (*completionCacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry completionCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.CreatedAt) > c.TTL {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	return &entry, true
+}
+
+// put grava a entrada e aplica o limite de tamanho.
+func (c *CompletionCache) put(key string, entry completionCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório do cache: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar entrada do cache: %w", err)
+	}
+	// Grava em arquivo temporário e renomeia para não deixar entradas pela metade
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("falha ao gravar cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		return fmt.Errorf("falha ao gravar cache: %w", err)
+	}
+	c.prune()
+	return nil
+}
+
+// prune remove entradas expiradas e, se o total passar de MaxBytes, as mais
+// antigas até caber no limite.
+func (c *CompletionCache) prune() {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), completionCacheEntryExtension) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.Dir, e.Name())
+		if time.Since(info.ModTime()) > c.TTL {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, cached{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if c.MaxBytes <= 0 || total <= c.MaxBytes {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+}
+
+// Clear remove todas as entradas do cache e retorna quantas foram removidas.
+func (c *CompletionCache) Clear() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("falha ao ler diretório do cache: %w", err)
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), completionCacheEntryExtension) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("falha ao remover entrada do cache: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// CachedCompletionProvider é um decorator que serve Completion,
+// StreamCompletion e CompletionJSON do CompletionCache. A chave combina
+// provider, modelo, operação e o hash dos prompts (e do schema, no modo JSON).
+// Tool calling, governança e embeddings passam direto.
+type CachedCompletionProvider struct {
+	inner Provider
+	model string
+	cache *CompletionCache
+}
+
+// NewCachedCompletionProvider cria um CachedCompletionProvider.
+func NewCachedCompletionProvider(inner Provider, model string, cache *CompletionCache) *CachedCompletionProvider {
+	return &CachedCompletionProvider{inner: inner, model: model, cache: cache}
+}
+
+func (c *CachedCompletionProvider) Name() string { return c.inner.Name() }
+func (c *CachedCompletionProvider) IsAvailable(ctx context.Context) bool {
+	return c.inner.IsAvailable(ctx)
+}
+
+// Unwrap retorna o provider envolvido.
+func (c *CachedCompletionProvider) Unwrap() Provider { return c.inner }
+
+func (c *CachedCompletionProvider) key(operation string, parts ...string) string {
+	h := sha256.New()
+	for _, p := range append([]string{c.inner.Name(), c.model, operation}, parts...) {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup retorna a resposta em cache e preenche o UsageMetadata como cache hit.
+func (c *CachedCompletionProvider) lookup(ctx context.Context, key, operation string) (string, bool) {
+	if cacheBypassed(ctx) {
+		return "", false
+	}
+	entry, ok := c.cache.get(key)
+	if !ok {
+		return "", false
+	}
+	slog.Debug("cache.completion: hit", "provider", entry.Provider, "model", entry.Model, "operation", operation)
+	SetUsage(ctx, &UsageMetadata{
+		PromptTokens:     entry.PromptTokens,
+		CompletionTokens: entry.CompletionTokens,
+		TotalTokens:      entry.TotalTokens,
+		Provider:         entry.Provider,
+		Model:            entry.Model,
+		Operation:        operation,
+		CacheHit:         true,
+	})
+	return entry.Response, true
+}
+
+// store grava a resposta com o uso reportado pelo provider na chamada.
+func (c *CachedCompletionProvider) store(ctx context.Context, key, response string) {
+	if cacheBypassed(ctx) || strings.TrimSpace(response) == "" {
+		return
+	}
+	entry := completionCacheEntry{CreatedAt: time.Now(), Response: response, Model: c.model}
+	if usage := GetUsage(ctx); usage != nil {
+		entry.Provider = usage.Provider
+		entry.PromptTokens = usage.PromptTokens
+		entry.CompletionTokens = usage.CompletionTokens
+		entry.TotalTokens = usage.TotalTokens
+		if usage.Model != "" {
+			entry.Model = usage.Model
+		}
+	}
+	if err := c.cache.put(key, entry); err != nil {
+		slog.Debug("falha ao gravar cache de completion", "erro", err)
+	}
+}
+
+func (c *CachedCompletionProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	key := c.key("completion", systemPrompt, userPrompt)
+	if result, ok := c.lookup(ctx, key, "completion"); ok {
+		return result, nil
+	}
+	result, err := c.inner.Completion(ctx, systemPrompt, userPrompt)
+	if err == nil {
+		c.store(ctx, key, result)
+	}
+	return result, err
+}
+
+// StreamCompletion escreve a resposta em cache de uma vez; em um miss, copia
+// o que o provider transmite para gravar ao final.
+func (c *CachedCompletionProvider) StreamCompletion(ctx context.Context, systemPrompt, userPrompt string, out io.Writer) error {
+	key := c.key("completion", systemPrompt, userPrompt)
+	if result, ok := c.lookup(ctx, key, "streaming"); ok {
+		_, err := io.WriteString(out, result)
+		return err
+	}
+	var buf bytes.Buffer
+	err := c.inner.StreamCompletion(ctx, systemPrompt, userPrompt, io.MultiWriter(out, &buf))
+	if err == nil {
+		c.store(ctx, key, buf.String())
+	}
+	return err
+}
+
+func (c *CachedCompletionProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	schemaJSON, _ := json.Marshal(schema)
+	key := c.key("json", systemPrompt, userPrompt, string(schemaJSON))
+	if result, ok := c.lookup(ctx, key, "json"); ok {
+		return result, nil
+	}
+	result, err := innerCompletionJSON(ctx, c.inner, systemPrompt, userPrompt, schema)
+	if err == nil {
+		c.store(ctx, key, result)
+	}
+	return result, err
+}
+
+func (c *CachedCompletionProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
+	return c.inner.GenerateGovernance(ctx, description)
+}
+
+func (c *CachedCompletionProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.inner.EmbedDocuments(ctx, texts)
+}
+
+func (c *CachedCompletionProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	return innerCompletionWithTools(ctx, c.inner, systemPrompt, userPrompt, tools)
+}
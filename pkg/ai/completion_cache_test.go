@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider conta as chamadas e reporta uso como um provider real.
+func countingProvider(calls *int) *jsonCompleterMock {
+	return &jsonCompleterMock{
+		mockProvider: mockProvider{
+			name: "openai",
+			completionFunc: func(ctx context.Context, _, usr string) (string, error) {
+				*calls++
+				SetUsage(ctx, &UsageMetadata{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, TotalTokens: 2_000_000, Provider: "openai", Model: "gpt-4o-mini"})
+				return "resposta para " + usr, nil
+			},
+			streamFunc: func(ctx context.Context, _, usr string, out io.Writer) error {
+				*calls++
+				_, err := io.WriteString(out, "resposta para "+usr)
+				return err
+			},
+		},
+		jsonFunc: func(_ context.Context, _, _ string, _ map[string]interface{}) (string, error) {
+			*calls++
+			return `{"ok":true}`, nil
+		},
+	}
+}
+
+func newTestCompletionCache(t *testing.T) *CompletionCache {
+	return &CompletionCache{Dir: t.TempDir(), TTL: time.Hour, MaxBytes: 1 << 20}
+}
+
+func TestCachedCompletionProvider_HitEMiss(t *testing.T) {
+	calls := 0
+	c := NewCachedCompletionProvider(countingProvider(&calls), "gpt-4o-mini", newTestCompletionCache(t))
+
+	ctx, usage := WithUsage(context.Background())
+	first, err := c.Completion(ctx, "sys", "a")
+	require.NoError(t, err)
+	assert.False(t, usage.CacheHit)
+
+	ctx, usage = WithUsage(context.Background())
+	second, err := c.Completion(ctx, "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, calls)
+	assert.True(t, usage.CacheHit)
+	assert.Equal(t, 1_000_000, usage.PromptTokens)
+	assert.Equal(t, "openai", usage.Provider)
+
+	// Prompt diferente é outra chave
+	_, err = c.Completion(context.Background(), "sys", "b")
+	require.NoError(t, err)
+	_, err = c.Completion(context.Background(), "outro sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCachedCompletionProvider_Bypass(t *testing.T) {
+	calls := 0
+	c := NewCachedCompletionProvider(countingProvider(&calls), "gpt-4o-mini", newTestCompletionCache(t))
+
+	ctx := WithoutCache(context.Background())
+	_, err := c.Completion(ctx, "sys", "a")
+	require.NoError(t, err)
+	_, err = c.Completion(context.Background(), "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "a chamada com bypass não deveria gravar no cache")
+
+	_, err = c.Completion(ctx, "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "a chamada com bypass não deveria ler do cache")
+}
+
+func TestCachedCompletionProvider_TTL(t *testing.T) {
+	calls := 0
+	cache := newTestCompletionCache(t)
+	cache.TTL = time.Millisecond
+	c := NewCachedCompletionProvider(countingProvider(&calls), "gpt-4o-mini", cache)
+
+	_, err := c.Completion(context.Background(), "sys", "a")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = c.Completion(context.Background(), "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCachedCompletionProvider_StreamingEJSON(t *testing.T) {
+	calls := 0
+	c := NewCachedCompletionProvider(countingProvider(&calls), "gpt-4o-mini", newTestCompletionCache(t))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.StreamCompletion(context.Background(), "sys", "a", &buf))
+	buf.Reset()
+	require.NoError(t, c.StreamCompletion(context.Background(), "sys", "a", &buf))
+	assert.Equal(t, "resposta para a", buf.String())
+	assert.Equal(t, 1, calls)
+
+	// Completion reaproveita a resposta do streaming
+	result, err := c.Completion(context.Background(), "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "resposta para a", result)
+	assert.Equal(t, 1, calls)
+
+	schema := map[string]interface{}{"type": "object"}
+	_, err = c.CompletionJSON(context.Background(), "sys", "a", schema)
+	require.NoError(t, err)
+	_, err = c.CompletionJSON(context.Background(), "sys", "a", schema)
+	require.NoError(t, err)
+	_, err = c.CompletionJSON(context.Background(), "sys", "a", map[string]interface{}{"type": "array"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCachedCompletionProvider_ErroNaoEntraNoCache(t *testing.T) {
+	calls := 0
+	inner := &mockProvider{name: "x", completionFunc: func(_ context.Context, _, _ string) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", &APIError{Provider: "x", StatusCode: 500}
+		}
+		return "ok", nil
+	}}
+	c := NewCachedCompletionProvider(inner, "m", newTestCompletionCache(t))
+
+	_, err := c.Completion(context.Background(), "sys", "a")
+	assert.Error(t, err)
+	result, err := c.Completion(context.Background(), "sys", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestCompletionCache_LimiteDeTamanho(t *testing.T) {
+	cache := newTestCompletionCache(t)
+	cache.MaxBytes = 1200
+
+	big := strings.Repeat("x", 400)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, cache.put(key, completionCacheEntry{CreatedAt: time.Now(), Response: big}))
+		// Garante mtimes distintos para a ordem de remoção
+		past := time.Now().Add(time.Duration(i-10) * time.Second)
+		require.NoError(t, os.Chtimes(cache.path(key), past, past))
+	}
+	require.NoError(t, cache.put("e", completionCacheEntry{CreatedAt: time.Now(), Response: big}))
+
+	_, ok := cache.get("a")
+	assert.False(t, ok, "a entrada mais antiga deveria ter sido removida")
+	_, ok = cache.get("e")
+	assert.True(t, ok)
+
+	var total int64
+	files, _ := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		require.NoError(t, err)
+		total += info.Size()
+	}
+	assert.LessOrEqual(t, total, cache.MaxBytes)
+
+	removed, err := cache.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, len(files), removed)
+}
+
+func TestCachedCompletionProvider_LedgerRegistraEconomia(t *testing.T) {
+	calls := 0
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	cached := NewCachedCompletionProvider(countingProvider(&calls), "gpt-4o-mini", newTestCompletionCache(t))
+	ct := NewCostTrackingProvider(cached, "gpt-4o-mini")
+	ct.ledger = ledger
+
+	for i := 0; i < 2; i++ {
+		_, err := ct.Completion(context.Background(), "sys", "a")
+		require.NoError(t, err)
+	}
+
+	records, err := ledger.Load(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.False(t, records[0].CacheHit)
+	assert.InDelta(t, 0.75, records[0].CostUSD, 0.001)
+	assert.True(t, records[1].CacheHit)
+	assert.Zero(t, records[1].CostUSD)
+	assert.InDelta(t, 0.75, records[1].SavedUSD, 0.001)
+
+	summary, err := SummarizeUsage(records, "model")
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary[0].CacheHits)
+	assert.InDelta(t, 0.75, summary[0].SavedUSD, 0.001)
+}
+
+func TestWrapProvider_CacheOptIn(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	p := &OpenAIProvider{APIKey: "k", Model: "gpt-4o-mini"}
+
+	hasCache := func(p Provider) bool {
+		for p != nil {
+			if _, ok := p.(*CachedCompletionProvider); ok {
+				return true
+			}
+			u, ok := p.(interface{ Unwrap() Provider })
+			if !ok {
+				return false
+			}
+			p = u.Unwrap()
+		}
+		return false
+	}
+	assert.False(t, hasCache(wrapProvider(p, p.Model)))
+
+	t.Setenv("YBY_AI_CACHE", "true")
+	assert.True(t, hasCache(wrapProvider(p, p.Model)))
+}
+
+func TestDefaultCompletionCache(t *testing.T) {
+	t.Setenv("YBY_AI_CACHE_DIR", "/tmp/cache-yby")
+	c := DefaultCompletionCache(config.CacheConfig{})
+	assert.Equal(t, "/tmp/cache-yby", c.Dir)
+	assert.Equal(t, defaultCompletionCacheTTL, c.TTL)
+	assert.Equal(t, int64(100<<20), c.MaxBytes)
+
+	c = DefaultCompletionCache(config.CacheConfig{TTL: time.Minute, MaxSizeMB: 5})
+	assert.Equal(t, time.Minute, c.TTL)
+	assert.Equal(t, int64(5<<20), c.MaxBytes)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"time"
 )
 
 // modelPricing armazena preços por 1M tokens (USD).
@@ -41,11 +42,13 @@ var knownPricing = map[string]modelPricing{
 	"codellama": {InputPer1M: 0, OutputPer1M: 0},
 }
 
-// CostTrackingProvider é um decorator que intercepta chamadas ao provider
-// e loga informações de uso de tokens e custo estimado.
+// CostTrackingProvider é um decorator que intercepta chamadas ao provider,
+// loga informações de uso de tokens e custo estimado e, se houver ledger,
+// persiste cada chamada para relatórios e orçamento.
 type CostTrackingProvider struct {
-	inner Provider
-	model string
+	inner  Provider
+	model  string
+	ledger *UsageLedger
 }
 
 // NewCostTrackingProvider cria um CostTrackingProvider que envolve o provider informado.
@@ -57,37 +60,75 @@ func (c *CostTrackingProvider) Name() string                         { return c.
 func (c *CostTrackingProvider) IsAvailable(ctx context.Context) bool { return c.inner.IsAvailable(ctx) }
 
 func (c *CostTrackingProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	ctx = prepareUsage(ctx)
 	result, err := c.inner.Completion(ctx, systemPrompt, userPrompt)
 	c.logUsage(ctx, "completion")
 	return result, err
 }
 
 func (c *CostTrackingProvider) StreamCompletion(ctx context.Context, systemPrompt, userPrompt string, out io.Writer) error {
+	ctx = prepareUsage(ctx)
 	err := c.inner.StreamCompletion(ctx, systemPrompt, userPrompt, out)
 	c.logUsage(ctx, "streaming")
 	return err
 }
 
 func (c *CostTrackingProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx = prepareUsage(ctx)
 	result, err := c.inner.EmbedDocuments(ctx, texts)
 	c.logUsage(ctx, "embedding")
 	return result, err
 }
 
 func (c *CostTrackingProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
+	ctx = prepareUsage(ctx)
 	result, err := c.inner.GenerateGovernance(ctx, description)
 	c.logUsage(ctx, "governance")
 	return result, err
 }
 
-// logUsage loga informações de uso se disponíveis no contexto.
+func (c *CostTrackingProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	ctx = prepareUsage(ctx)
+	result, err := innerCompletionWithTools(ctx, c.inner, systemPrompt, userPrompt, tools)
+	c.logUsage(ctx, "tools")
+	return result, err
+}
+
+func (c *CostTrackingProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	ctx = prepareUsage(ctx)
+	result, err := innerCompletionJSON(ctx, c.inner, systemPrompt, userPrompt, schema)
+	c.logUsage(ctx, "json")
+	return result, err
+}
+
+// Unwrap retorna o provider envolvido.
+func (c *CostTrackingProvider) Unwrap() Provider { return c.inner }
+
+// prepareUsage garante um UsageMetadata no contexto para o provider
+// preencher. Se o chamador já preparou um (WithUsage), ele é reaproveitado e
+// zerado, para refletir apenas a chamada atual.
+func prepareUsage(ctx context.Context) context.Context {
+	if usage := GetUsage(ctx); usage != nil {
+		*usage = UsageMetadata{}
+		return ctx
+	}
+	ctx, _ = WithUsage(ctx)
+	return ctx
+}
+
+// logUsage loga informações de uso se disponíveis no contexto e as registra
+// no ledger.
 func (c *CostTrackingProvider) logUsage(ctx context.Context, operation string) {
 	usage := GetUsage(ctx)
-	if usage == nil {
+	if usage == nil || (usage.Provider == "" && usage.TotalTokens == 0) {
 		return
 	}
 
-	cost := c.estimateCost(usage)
+	// Respostas do cache não custam nada; o valor estimado vira economia
+	cost, saved := c.estimateCost(usage), 0.0
+	if usage.CacheHit {
+		cost, saved = 0, cost
+	}
 
 	slog.Info("ai.usage",
 		"provider", usage.Provider,
@@ -97,7 +138,32 @@ func (c *CostTrackingProvider) logUsage(ctx context.Context, operation string) {
 		"completion_tokens", usage.CompletionTokens,
 		"total_tokens", usage.TotalTokens,
 		"estimated_cost_usd", cost,
+		"cache_hit", usage.CacheHit,
 	)
+
+	if c.ledger == nil {
+		return
+	}
+	model := usage.Model
+	if model == "" {
+		model = c.model
+	}
+	err := c.ledger.Record(UsageRecord{
+		Time:             time.Now(),
+		Plugin:           usagePlugin(),
+		Provider:         usage.Provider,
+		Model:            model,
+		Operation:        operation,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CostUSD:          cost,
+		CacheHit:         usage.CacheHit,
+		SavedUSD:         saved,
+	})
+	if err != nil {
+		slog.Debug("falha ao registrar uso de IA no ledger", "erro", err)
+	}
 }
 
 // estimateCost calcula o custo estimado baseado na tabela de preços.
@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -95,3 +96,35 @@ func TestUsageMetadata_ContextRoundTrip(t *testing.T) {
 	assert.Equal(t, 42, got.PromptTokens)
 	assert.Equal(t, "test", got.Provider)
 }
+
+func TestCostTrackingProvider_RegistraNoLedger(t *testing.T) {
+	t.Setenv("YBY_AI_PLUGIN", "sentinel")
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	inner := &mockProvider{
+		name: "test",
+		completionFunc: func(ctx context.Context, _, _ string) (string, error) {
+			SetUsage(ctx, &UsageMetadata{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, TotalTokens: 2_000_000, Provider: "openai"})
+			return "ok", nil
+		},
+		embedFunc: func(_ context.Context, _ []string) ([][]float32, error) { return nil, nil },
+	}
+	ct := NewCostTrackingProvider(inner, "gpt-4o-mini")
+	ct.ledger = ledger
+
+	ctx, usage := WithUsage(context.Background())
+	_, err := ct.Completion(ctx, "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "openai", usage.Provider)
+
+	// Chamadas sem uso informado não geram registro
+	_, err = ct.EmbedDocuments(context.Background(), []string{"x"})
+	require.NoError(t, err)
+
+	records, err := ledger.Load(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "sentinel", records[0].Plugin)
+	assert.Equal(t, "gpt-4o-mini", records[0].Model)
+	assert.Equal(t, "completion", records[0].Operation)
+	assert.InDelta(t, 0.75, records[0].CostUSD, 0.001)
+}
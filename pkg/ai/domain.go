@@ -1,6 +1,11 @@
 package ai
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+)
 
 // UsageMetadata contém informações de uso de tokens de uma operação de IA.
 type UsageMetadata struct {
@@ -10,12 +15,33 @@ type UsageMetadata struct {
 	Provider         string
 	Model            string
 	Operation        string // "completion", "streaming", "embedding", "governance"
+	// ServedBy é o nome (Name()) do provider que atendeu a chamada. Preenchido
+	// pelo FallbackProvider, inclusive para providers que não reportam tokens.
+	ServedBy string
+	// FallbackFrom lista os providers que falharam antes de ServedBy.
+	FallbackFrom []string
+	// CacheHit indica resposta servida pelo cache de completions; os tokens são
+	// os da chamada original e o custo é contabilizado como economia.
+	CacheHit bool
 }
 
 type usageKey struct{}
 
-// SetUsage armazena metadados de uso no contexto.
+// WithUsage prepara o contexto para receber os metadados de uso da próxima
+// chamada. O chamador lê o *UsageMetadata retornado após a chamada.
+func WithUsage(ctx context.Context) (context.Context, *UsageMetadata) {
+	usage := &UsageMetadata{}
+	return context.WithValue(ctx, usageKey{}, usage), usage
+}
+
+// SetUsage armazena metadados de uso no contexto. Se o contexto já foi
+// preparado com WithUsage, os metadados são copiados para o valor existente,
+// de forma que o chamador os enxergue mesmo sem usar o contexto retornado.
 func SetUsage(ctx context.Context, usage *UsageMetadata) context.Context {
+	if existing := GetUsage(ctx); existing != nil && usage != nil {
+		*existing = *usage
+		return ctx
+	}
 	return context.WithValue(ctx, usageKey{}, usage)
 }
 
@@ -40,3 +66,15 @@ type GovernanceBlueprint struct {
 	Summary   string          `json:"summary"`
 	Files     []GeneratedFile `json:"files"`
 }
+
+// GenerateGovernanceBlueprint gera o blueprint de governança via CompleteJSON,
+// com validação e reparo da resposta. Os providers implementam GenerateGovernance
+// por meio desta função.
+func GenerateGovernanceBlueprint(ctx context.Context, p Provider, description string) (*GovernanceBlueprint, error) {
+	var blueprint GovernanceBlueprint
+	err := CompleteJSON(ctx, p, prompts.Get("governance.system"), fmt.Sprintf("Descrição do Projeto: %s", description), &blueprint, JSONOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao gerar blueprint: %w", err)
+	}
+	return &blueprint, nil
+}
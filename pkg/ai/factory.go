@@ -69,10 +69,11 @@ func GetEmbeddingModel(providerName string) string {
 }
 
 // defaultPriority define a ordem padrão de tentativa dos providers.
-// Ollama primeiro (local, sem custo), CLIs depois (modelo potente, auth resolvida),
-// APIs por último (dependem de key + rede).
+// Ollama e openai-compatible primeiro (locais ou configurados explicitamente),
+// CLIs depois (modelo potente, auth resolvida), APIs por último (dependem de key + rede).
 var defaultPriority = []string{
 	"ollama",
+	"openai-compatible",
 	"claude-cli",
 	"gemini-cli",
 	"gemini",
@@ -96,17 +97,17 @@ func createProvider(ctx context.Context, name string) Provider {
 	case "ollama":
 		p := NewOllamaProvider()
 		if p.IsAvailable(ctx) {
-			return wrapProvider(p, p.Model)
+			return wrapWithBudget(name, p, p.Model)
 		}
 	case "gemini":
 		p := NewGeminiProvider()
 		if p != nil && p.IsAvailable(ctx) {
-			return wrapProvider(p, p.Model)
+			return wrapWithBudget(name, p, p.Model)
 		}
 	case "openai":
 		p := NewOpenAIProvider()
 		if p != nil && p.IsAvailable(ctx) {
-			return wrapProvider(p, p.Model)
+			return wrapWithBudget(name, p, p.Model)
 		}
 	case "claude-cli":
 		p := NewClaudeCLIProvider()
@@ -128,6 +129,8 @@ func createProvider(ctx context.Context, name string) Provider {
 }
 
 // GetProvider retorna o primeiro provider disponível, respeitando a ordem de prioridade.
+// No modo automático o provider vem envolvido em um FallbackProvider, que repete
+// chamadas que falharam nos próximos providers da ordem.
 // Se preferred for especificado (não "auto" nem ""), tenta apenas esse provider.
 func GetProvider(ctx context.Context, preferred string) Provider {
 	// 1. Preferência explícita via argumento ou env var
@@ -142,10 +145,15 @@ func GetProvider(ctx context.Context, preferred string) Provider {
 		return createProvider(ctx, target)
 	}
 
-	// 2. Seguir ordem de prioridade configurada
-	for _, name := range getProviderPriority() {
+	// 2. Seguir ordem de prioridade configurada; os providers seguintes ao
+	// escolhido ficam como fallback por chamada
+	priority := getProviderPriority()
+	for i, name := range priority {
 		if p := createProvider(ctx, name); p != nil {
-			return p
+			if i == len(priority)-1 {
+				return p
+			}
+			return NewFallbackProvider(p, priority[i+1:], createProvider)
 		}
 	}
 
@@ -167,10 +175,11 @@ func GetAllAvailableProviders(ctx context.Context) []Provider {
 // embeddingCapableProviders lista os providers que suportam EmbedDocuments.
 // CLIs (claude-cli, gemini-cli) não suportam embeddings.
 var embeddingCapableProviders = map[string]bool{
-	"ollama":  true,
-	"gemini":  true,
-	"openai":  true,
-	"bedrock": true,
+	"ollama":            true,
+	"gemini":            true,
+	"openai":            true,
+	"openai-compatible": true,
+	"bedrock":           true,
 }
 
 // GetEmbeddingProvider retorna o provider de embeddings mais adequado.
@@ -207,19 +216,46 @@ func GetEmbeddingProvider(ctx context.Context) Provider {
 }
 
 // wrapProvider encadeia os decorators na ordem:
-// Raw -> CachedEmbedding -> TokenAware -> CostTracking -> RateLimit -> Retry.
+// Raw -> CachedEmbedding -> CachedCompletion (se ai.cache.enabled) -> TokenAware
+// -> CostTracking -> RateLimit -> Retry.
 func wrapProvider(p Provider, model string) Provider {
 	if p == nil {
 		return nil
 	}
-	cached := NewCachedEmbeddingProvider(p, defaultEmbeddingCacheSize, defaultEmbeddingCacheTTL)
+	var cached Provider = NewCachedEmbeddingProvider(p, defaultEmbeddingCacheSize, defaultEmbeddingCacheTTL)
+	if cfg, err := config.Load(); err == nil && cfg.AI.Cache.Enabled {
+		if cache := DefaultCompletionCache(cfg.AI.Cache); cache != nil {
+			cached = NewCachedCompletionProvider(cached, model, cache)
+		}
+	}
 	tokenAware := NewTokenAwareProvider(cached, model)
 	costTracking := NewCostTrackingProvider(tokenAware, model)
+	costTracking.ledger = DefaultUsageLedger()
 	rps := getRateLimitConfig(p.Name())
 	rateLimited := NewRateLimitProvider(costTracking, rps)
 	return NewRetryProvider(rateLimited, retry.DefaultOptions(), nil)
 }
 
+// wrapWithBudget aplica wrapProvider e, se ai.budget tiver algum limite,
+// envolve o resultado em um BudgetProvider. O provider com o modelo de
+// ai.budget.downgrade[name] é montado para o caso on_exceed: downgrade.
+func wrapWithBudget(name string, p Provider, model string) Provider {
+	wrapped := wrapProvider(p, model)
+	cfg, err := config.Load()
+	if err != nil || (cfg.AI.Budget.DailyUSD <= 0 && cfg.AI.Budget.MonthlyUSD <= 0) {
+		return wrapped
+	}
+	budget := cfg.AI.Budget
+
+	var cheaper Provider
+	if cheapModel := budget.Downgrade[name]; cheapModel != "" && cheapModel != model {
+		if s, ok := p.(modelSwitcher); ok {
+			cheaper = wrapProvider(s.withModel(cheapModel), cheapModel)
+		}
+	}
+	return NewBudgetProvider(wrapped, cheaper, budget, DefaultUsageLedger())
+}
+
 // getRateLimitConfig retorna a taxa de req/s para o provider,
 // priorizando configuração do usuário sobre o default.
 func getRateLimitConfig(providerName string) float64 {
@@ -0,0 +1,212 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// FallbackProvider é um decorator que repete a mesma chamada no próximo
+// provider da ordem de prioridade quando o provider atual falha de forma
+// persistente: quota (429), erro 5xx após os retries, circuit breaker aberto
+// ou falha de rede. Os providers seguintes só são criados quando necessários.
+type FallbackProvider struct {
+	primary Provider
+	// next são os nomes (getProviderPriority) a tentar depois do primário.
+	next   []string
+	create func(ctx context.Context, name string) Provider
+
+	mu       sync.Mutex
+	resolved int // quantos nomes de next já foram resolvidos
+	chain    []Provider
+}
+
+// NewFallbackProvider cria um FallbackProvider que usa primary e, em caso de
+// falha, os providers de next na ordem, criados via create.
+func NewFallbackProvider(primary Provider, next []string, create func(ctx context.Context, name string) Provider) *FallbackProvider {
+	return &FallbackProvider{
+		primary: primary,
+		next:    next,
+		create:  create,
+		chain:   []Provider{primary},
+	}
+}
+
+// Name retorna o nome do provider primário.
+func (f *FallbackProvider) Name() string { return f.primary.Name() }
+
+func (f *FallbackProvider) IsAvailable(ctx context.Context) bool {
+	return f.provider(ctx, 0) != nil
+}
+
+// Unwrap retorna o provider primário, para que SupportsTools e afins reflitam
+// as capacidades de quem atende a maioria das chamadas.
+func (f *FallbackProvider) Unwrap() Provider { return f.primary }
+
+// provider retorna o i-ésimo provider da cadeia, criando os seguintes sob
+// demanda. Retorna nil quando não há mais providers disponíveis.
+func (f *FallbackProvider) provider(ctx context.Context, i int) Provider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.chain) <= i && f.resolved < len(f.next) {
+		name := f.next[f.resolved]
+		f.resolved++
+		if p := f.create(ctx, name); p != nil {
+			f.chain = append(f.chain, p)
+		}
+	}
+	if i < len(f.chain) {
+		return f.chain[i]
+	}
+	return nil
+}
+
+// do executa call em cada provider da cadeia até um sucesso ou um erro que não
+// justifica fallback. Providers sem a capacidade pedida (skip) são pulados; se
+// nenhum provider atender, o erro de capacidade tem precedência para que o
+// chamador use o caminho em texto.
+func (f *FallbackProvider) do(ctx context.Context, operation string, call func(Provider) error, skip func(error) bool) error {
+	var failed []string
+	var lastErr, skipErr error
+	for i := 0; ; i++ {
+		p := f.provider(ctx, i)
+		if p == nil {
+			break
+		}
+
+		err := call(p)
+		if err == nil {
+			f.recordServed(ctx, p, failed)
+			return nil
+		}
+		if skip != nil && skip(err) {
+			skipErr = err
+			continue
+		}
+		if !shouldFallback(ctx, err) {
+			return err
+		}
+
+		slog.Warn("ai.fallback: provider falhou, tentando o proximo",
+			"provider", p.Name(),
+			"operation", operation,
+			"erro", err,
+		)
+		failed = append(failed, p.Name())
+		lastErr = err
+	}
+	if skipErr != nil {
+		return skipErr
+	}
+	return lastErr
+}
+
+// recordServed registra no UsageMetadata do contexto quem atendeu a chamada.
+func (f *FallbackProvider) recordServed(ctx context.Context, p Provider, failed []string) {
+	if len(failed) > 0 {
+		slog.Info("ai.fallback: chamada atendida", "provider", p.Name(), "falharam", failed)
+	}
+	usage := GetUsage(ctx)
+	if usage == nil {
+		return
+	}
+	usage.ServedBy = p.Name()
+	usage.FallbackFrom = failed
+	if usage.Provider == "" {
+		usage.Provider = p.Name()
+	}
+}
+
+// shouldFallback indica se o erro justifica tentar o próximo provider:
+// quota (429), erros 5xx (incluindo o circuit breaker aberto) e falhas de
+// rede. Erros 4xx, orçamento excedido e cancelamento do chamador são
+// devolvidos sem fallback.
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var partial *partialStreamError
+	if errors.As(err, &partial) || errors.Is(err, ErrBudgetExceeded) ||
+		errors.Is(err, ErrToolsNotSupported) || errors.Is(err, ErrJSONModeNotSupported) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 429 || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+func (f *FallbackProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	var result string
+	err := f.do(ctx, "completion", func(p Provider) error {
+		res, err := p.Completion(ctx, systemPrompt, userPrompt)
+		result = res
+		return err
+	}, nil)
+	return result, err
+}
+
+// StreamCompletion só faz fallback se o provider que falhou ainda não escreveu
+// nada no writer, para não misturar respostas.
+func (f *FallbackProvider) StreamCompletion(ctx context.Context, systemPrompt, userPrompt string, out io.Writer) error {
+	sw := &safeWriter{w: out}
+	err := f.do(ctx, "streaming", func(p Provider) error {
+		err := p.StreamCompletion(ctx, systemPrompt, userPrompt, sw)
+		if err != nil && sw.hasWritten.Load() {
+			return &partialStreamError{err: err}
+		}
+		return err
+	}, nil)
+	var partial *partialStreamError
+	if errors.As(err, &partial) {
+		return partial.err
+	}
+	return err
+}
+
+// partialStreamError marca falhas após escrita parcial do streaming.
+type partialStreamError struct{ err error }
+
+func (e *partialStreamError) Error() string { return e.err.Error() }
+
+// EmbedDocuments não faz fallback: vetores de modelos diferentes não são
+// comparáveis entre si nem com o índice existente.
+func (f *FallbackProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return f.primary.EmbedDocuments(ctx, texts)
+}
+
+func (f *FallbackProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
+	var result *GovernanceBlueprint
+	err := f.do(ctx, "governance", func(p Provider) error {
+		res, err := p.GenerateGovernance(ctx, description)
+		result = res
+		return err
+	}, nil)
+	return result, err
+}
+
+// CompletionWithTools pula os providers da cadeia sem tool calling nativo.
+func (f *FallbackProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	var result *ToolResponse
+	err := f.do(ctx, "tools", func(p Provider) error {
+		res, err := innerCompletionWithTools(ctx, p, systemPrompt, userPrompt, tools)
+		result = res
+		return err
+	}, func(err error) bool { return errors.Is(err, ErrToolsNotSupported) })
+	return result, err
+}
+
+// CompletionJSON pula os providers da cadeia sem modo JSON nativo.
+func (f *FallbackProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	var result string
+	err := f.do(ctx, "json", func(p Provider) error {
+		res, err := innerCompletionJSON(ctx, p, systemPrompt, userPrompt, schema)
+		result = res
+		return err
+	}, func(err error) bool { return errors.Is(err, ErrJSONModeNotSupported) })
+	return result, err
+}
@@ -0,0 +1,192 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fallbackChain cria um FallbackProvider cujos providers seguintes vêm de um mapa.
+func fallbackChain(primary Provider, next map[string]Provider, order ...string) (*FallbackProvider, *[]string) {
+	var created []string
+	create := func(_ context.Context, name string) Provider {
+		created = append(created, name)
+		if p, ok := next[name]; ok {
+			return p
+		}
+		return nil
+	}
+	return NewFallbackProvider(primary, order, create), &created
+}
+
+func failingProvider(name string, err error) *mockProvider {
+	return &mockProvider{name: name, completionFunc: func(_ context.Context, _, _ string) (string, error) {
+		return "", err
+	}}
+}
+
+func okProvider(name, result string) *mockProvider {
+	return &mockProvider{name: name, completionFunc: func(ctx context.Context, _, _ string) (string, error) {
+		SetUsage(ctx, &UsageMetadata{PromptTokens: 10, Provider: name, Model: "m"})
+		return result, nil
+	}}
+}
+
+func TestFallbackProvider_PrimarioAtende(t *testing.T) {
+	f, created := fallbackChain(okProvider("primario", "ok"), nil, "openai")
+
+	ctx, usage := WithUsage(context.Background())
+	result, err := f.Completion(ctx, "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Empty(t, *created, "providers seguintes não deveriam ser criados sem falha")
+	assert.Equal(t, "primario", usage.ServedBy)
+	assert.Empty(t, usage.FallbackFrom)
+	assert.Equal(t, 10, usage.PromptTokens)
+	assert.Equal(t, "primario", f.Name())
+}
+
+func TestFallbackProvider_FalhaPersistente(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"quota", &APIError{Provider: "a", StatusCode: 429}},
+		{"erro do servidor", &APIError{Provider: "a", StatusCode: 502}},
+		{"circuit breaker", &APIError{Provider: "a", StatusCode: 503, Body: "circuit breaker aberto"}},
+		{"rede", errors.New("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, created := fallbackChain(failingProvider("a", tt.err), map[string]Provider{
+				"gemini": okProvider("b", "de b"),
+			}, "ollama", "gemini", "openai")
+
+			ctx, usage := WithUsage(context.Background())
+			result, err := f.Completion(ctx, "sys", "usr")
+			require.NoError(t, err)
+			assert.Equal(t, "de b", result)
+			assert.Equal(t, []string{"ollama", "gemini"}, *created)
+			assert.Equal(t, "b", usage.ServedBy)
+			assert.Equal(t, []string{"a"}, usage.FallbackFrom)
+		})
+	}
+}
+
+func TestFallbackProvider_ErroNaoRetentavel(t *testing.T) {
+	f, created := fallbackChain(failingProvider("a", &APIError{Provider: "a", StatusCode: 400}), map[string]Provider{
+		"gemini": okProvider("b", "de b"),
+	}, "gemini")
+
+	_, err := f.Completion(context.Background(), "sys", "usr")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Empty(t, *created)
+}
+
+func TestFallbackProvider_TodosFalham(t *testing.T) {
+	last := &APIError{Provider: "b", StatusCode: 500}
+	f, _ := fallbackChain(failingProvider("a", errors.New("timeout")), map[string]Provider{
+		"gemini": failingProvider("b", last),
+	}, "gemini", "openai")
+
+	_, err := f.Completion(context.Background(), "sys", "usr")
+	assert.Equal(t, last, err)
+}
+
+func TestFallbackProvider_ContextoCancelado(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f, created := fallbackChain(failingProvider("a", context.Canceled), map[string]Provider{
+		"gemini": okProvider("b", "de b"),
+	}, "gemini")
+
+	_, err := f.Completion(ctx, "sys", "usr")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, *created)
+}
+
+func TestFallbackProvider_Streaming(t *testing.T) {
+	partial := &mockProvider{name: "a", streamFunc: func(_ context.Context, _, _ string, out io.Writer) error {
+		out.Write([]byte("meia "))
+		return errors.New("conexao caiu")
+	}}
+	empty := &mockProvider{name: "a", streamFunc: func(_ context.Context, _, _ string, _ io.Writer) error {
+		return &APIError{Provider: "a", StatusCode: 503}
+	}}
+	b := &mockProvider{name: "b", streamFunc: func(_ context.Context, _, _ string, out io.Writer) error {
+		_, err := out.Write([]byte("completa"))
+		return err
+	}}
+
+	var buf bytes.Buffer
+	f, _ := fallbackChain(empty, map[string]Provider{"gemini": b}, "gemini")
+	require.NoError(t, f.StreamCompletion(context.Background(), "sys", "usr", &buf))
+	assert.Equal(t, "completa", buf.String())
+
+	buf.Reset()
+	f, created := fallbackChain(partial, map[string]Provider{"gemini": b}, "gemini")
+	err := f.StreamCompletion(context.Background(), "sys", "usr", &buf)
+	assert.EqualError(t, err, "conexao caiu")
+	assert.Equal(t, "meia ", buf.String())
+	assert.Empty(t, *created, "não deveria fazer fallback após escrita parcial")
+}
+
+func TestFallbackProvider_EmbeddingsSemFallback(t *testing.T) {
+	primary := &mockProvider{name: "a", embedFunc: func(_ context.Context, _ []string) ([][]float32, error) {
+		return nil, &APIError{Provider: "a", StatusCode: 503}
+	}}
+	f, created := fallbackChain(primary, map[string]Provider{"gemini": &mockProvider{name: "b"}}, "gemini")
+
+	_, err := f.EmbedDocuments(context.Background(), []string{"x"})
+	assert.Error(t, err)
+	assert.Empty(t, *created)
+}
+
+func TestFallbackProvider_ToolsPulaProviderSemSuporte(t *testing.T) {
+	primary := &toolCallerMock{
+		mockProvider: mockProvider{name: "a"},
+		toolsFunc: func(_ context.Context, _, _ string, _ []ToolDefinition) (*ToolResponse, error) {
+			return nil, &APIError{Provider: "a", StatusCode: 429}
+		},
+	}
+	native := &toolCallerMock{
+		mockProvider: mockProvider{name: "c"},
+		toolsFunc: func(_ context.Context, _, _ string, _ []ToolDefinition) (*ToolResponse, error) {
+			return &ToolResponse{Content: "de c"}, nil
+		},
+	}
+	f, _ := fallbackChain(primary, map[string]Provider{
+		"claude-cli": &mockProvider{name: "b"},
+		"openai":     native,
+	}, "claude-cli", "openai")
+
+	ctx, usage := WithUsage(context.Background())
+	resp, err := f.CompletionWithTools(ctx, "sys", "usr", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "de c", resp.Content)
+	assert.Equal(t, "c", usage.ServedBy)
+	assert.Equal(t, []string{"a"}, usage.FallbackFrom)
+	assert.True(t, SupportsTools(f))
+
+	// Sem provider com suporte nativo, o chamador recebe ErrToolsNotSupported
+	// e usa o protocolo em texto
+	f, _ = fallbackChain(primary, map[string]Provider{"claude-cli": &mockProvider{name: "b"}}, "claude-cli")
+	_, err = f.CompletionWithTools(context.Background(), "sys", "usr", nil)
+	assert.ErrorIs(t, err, ErrToolsNotSupported)
+}
+
+func TestSetUsage_PreencheContextoPreparado(t *testing.T) {
+	ctx, usage := WithUsage(context.Background())
+	returned := SetUsage(ctx, &UsageMetadata{PromptTokens: 5, Provider: "openai"})
+
+	assert.Equal(t, ctx, returned)
+	assert.Equal(t, 5, usage.PromptTokens)
+	assert.Equal(t, "openai", GetUsage(ctx).Provider)
+}
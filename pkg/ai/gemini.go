@@ -11,8 +11,6 @@ import (
 	"os"
 	"strings"
 	"time"
-
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 )
 
 type GeminiProvider struct {
@@ -46,6 +44,13 @@ func (p *GeminiProvider) Name() string {
 	return "Google Gemini (Cloud)"
 }
 
+// withModel retorna uma cópia do provider usando outro modelo.
+func (p *GeminiProvider) withModel(model string) Provider {
+	c := *p
+	c.Model = model
+	return &c
+}
+
 func (p *GeminiProvider) IsAvailable(ctx context.Context) bool {
 	return p.APIKey != ""
 }
@@ -85,70 +90,20 @@ type geminiResponse struct {
 }
 
 func (p *GeminiProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
-	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", p.BaseURL, p.Model, p.APIKey)
-
-	fullPrompt := fmt.Sprintf("%s\n\nDESCRIÇÃO DO PROJETO: %s", prompts.Get("governance.system"), description)
-
-	reqBody := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Parts: []geminiPart{
-					{Text: fullPrompt},
-				},
-			},
-		},
-		GenerationConfig: geminiConfig{
-			ResponseMimeType: "application/json",
-		},
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-	client := http.Client{Timeout: 60 * time.Second}
-
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("falha ao chamar gemini: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIErrorFromResponse("gemini", resp, body)
-	}
-
-	var gResp geminiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&gResp); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta do gemini: %w", err)
-	}
-
-	SetUsage(ctx, &UsageMetadata{
-		PromptTokens:     gResp.UsageMetadataResponse.PromptTokenCount,
-		CompletionTokens: gResp.UsageMetadataResponse.CandidatesTokenCount,
-		TotalTokens:      gResp.UsageMetadataResponse.TotalTokenCount,
-		Provider:         "gemini",
-		Model:            p.Model,
-		Operation:        "governance",
-	})
-
-	if len(gResp.Candidates) == 0 || len(gResp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("resposta vazia do gemini")
-	}
-
-	rawJSON := gResp.Candidates[0].Content.Parts[0].Text
-	// Clean markdown fences if present (Gemini sometimes adds them even with mimeType set)
-	cleanJSON := strings.TrimPrefix(rawJSON, "```json")
-	cleanJSON = strings.TrimPrefix(cleanJSON, "```") // sometimes just ```
-	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
+	return GenerateGovernanceBlueprint(ctx, p, description)
+}
 
-	var blueprint GovernanceBlueprint
-	if err := json.Unmarshal([]byte(cleanJSON), &blueprint); err != nil {
-		return nil, fmt.Errorf("falha ao analisar json do blueprint: %w", err)
-	}
+func (p *GeminiProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return p.generate(ctx, systemPrompt, userPrompt, geminiConfig{}, "completion")
+}
 
-	return &blueprint, nil
+// CompletionJSON usa responseMimeType application/json. O schema segue no
+// system prompt: o responseSchema do Gemini aceita apenas um subconjunto.
+func (p *GeminiProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, _ map[string]interface{}) (string, error) {
+	return p.generate(ctx, systemPrompt, userPrompt, geminiConfig{ResponseMimeType: "application/json"}, "json")
 }
 
-func (p *GeminiProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+func (p *GeminiProvider) generate(ctx context.Context, systemPrompt, userPrompt string, config geminiConfig, operation string) (string, error) {
 	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", p.BaseURL, p.Model, p.APIKey)
 
 	// Context + User Prompt
@@ -164,6 +119,7 @@ func (p *GeminiProvider) Completion(ctx context.Context, systemPrompt, userPromp
 				},
 			},
 		},
+		GenerationConfig: config,
 	}
 
 	jsonBody, _ := json.Marshal(reqBody)
@@ -191,7 +147,7 @@ func (p *GeminiProvider) Completion(ctx context.Context, systemPrompt, userPromp
 		TotalTokens:      gResp.UsageMetadataResponse.TotalTokenCount,
 		Provider:         "gemini",
 		Model:            p.Model,
-		Operation:        "completion",
+		Operation:        operation,
 	})
 
 	if len(gResp.Candidates) == 0 || len(gResp.Candidates[0].Content.Parts) == 0 {
@@ -349,3 +305,117 @@ func (p *GeminiProvider) EmbedDocuments(ctx context.Context, texts []string) ([]
 
 	return allEmbeddings, nil
 }
+
+type geminiToolRequest struct {
+	SystemInstruction *geminiContent    `json:"systemInstruction,omitempty"`
+	Contents          []geminiToolTurn  `json:"contents"`
+	Tools             []geminiToolGroup `json:"tools"`
+}
+
+type geminiToolTurn struct {
+	Role  string       `json:"role"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiToolGroup struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  *ToolSchema `json:"parameters,omitempty"`
+}
+
+type geminiToolResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text         string `json:"text"`
+				FunctionCall *struct {
+					Name string                 `json:"name"`
+					Args map[string]interface{} `json:"args"`
+				} `json:"functionCall"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadataResponse struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// CompletionWithTools usa function calling (functionDeclarations) do Gemini.
+func (p *GeminiProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	url := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", p.BaseURL, p.Model, p.APIKey)
+
+	lang := GetLanguage()
+	systemPrompt = fmt.Sprintf("%s\n\n(IMPORTANT: You MUST output your analysis/response entirely in %s language.)", systemPrompt, lang)
+
+	group := geminiToolGroup{}
+	for _, t := range tools {
+		decl := geminiFunctionDeclaration{Name: t.Name, Description: t.Description}
+		// Gemini rejeita schemas de objeto sem propriedades
+		if len(t.Parameters.Properties) > 0 {
+			params := t.Parameters
+			decl.Parameters = &params
+		}
+		group.FunctionDeclarations = append(group.FunctionDeclarations, decl)
+	}
+
+	reqBody := geminiToolRequest{
+		SystemInstruction: &geminiContent{Parts: []geminiPart{{Text: systemPrompt}}},
+		Contents:          []geminiToolTurn{{Role: "user", Parts: []geminiPart{{Text: userPrompt}}}},
+		Tools:             []geminiToolGroup{group},
+	}
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar request gemini: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao chamar gemini: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, NewAPIErrorFromResponse("gemini", resp, body)
+	}
+
+	var gResp geminiToolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gResp); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar resposta do gemini: %w", err)
+	}
+
+	SetUsage(ctx, &UsageMetadata{
+		PromptTokens:     gResp.UsageMetadataResponse.PromptTokenCount,
+		CompletionTokens: gResp.UsageMetadataResponse.CandidatesTokenCount,
+		TotalTokens:      gResp.UsageMetadataResponse.TotalTokenCount,
+		Provider:         "gemini",
+		Model:            p.Model,
+		Operation:        "tools",
+	})
+
+	if len(gResp.Candidates) == 0 {
+		return nil, fmt.Errorf("resposta vazia do gemini")
+	}
+
+	result := &ToolResponse{}
+	var text strings.Builder
+	for _, part := range gResp.Candidates[0].Content.Parts {
+		if part.FunctionCall != nil {
+			result.ToolCalls = append(result.ToolCalls, ToolCall{Name: part.FunctionCall.Name, Arguments: part.FunctionCall.Args})
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+	result.Content = text.String()
+	return result, nil
+}
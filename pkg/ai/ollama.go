@@ -11,8 +11,6 @@ import (
 	"os"
 	"strings"
 	"time"
-
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 )
 
 type OllamaProvider struct {
@@ -65,6 +63,14 @@ func (p *OllamaProvider) Name() string {
 	return "Ollama (Local)"
 }
 
+// withModel retorna uma cópia do provider usando outro modelo, sem auto-detect.
+func (p *OllamaProvider) withModel(model string) Provider {
+	c := *p
+	c.Model = model
+	c.modelConfigured = true
+	return &c
+}
+
 func (p *OllamaProvider) IsAvailable(ctx context.Context) bool {
 	// If already resolved, just ping
 	if p.BaseURL != "" {
@@ -116,7 +122,8 @@ type ollamaRequest struct {
 	Prompt string `json:"prompt"`
 	System string `json:"system"`
 	Stream bool   `json:"stream"`
-	Format string `json:"format"` // json mode
+	// Format é "json" ou um JSON Schema
+	Format interface{} `json:"format,omitempty"`
 }
 
 type ollamaTagsResponse struct {
@@ -156,65 +163,27 @@ type ollamaResponse struct {
 }
 
 func (p *OllamaProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
-	if !p.modelConfigured {
-		if err := p.resolveModel(ctx); err != nil {
-			return nil, fmt.Errorf("verificação de modelo ollama falhou: %w", err)
-		}
-	}
-
-	reqBody := ollamaRequest{
-		Model:  p.Model,
-		Prompt: fmt.Sprintf("Descrição do Projeto: %s", description),
-		System: prompts.Get("governance.system"),
-		Stream: false,
-		Format: "json",
-	}
-
-	jsonBody, _ := json.Marshal(reqBody)
-	client := http.Client{Timeout: 300 * time.Second} // Generative tasks take time (CPU inference)
-
-	resp, err := client.Post(p.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("falha ao chamar ollama: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIErrorFromResponse("ollama", resp, body)
-	}
-
-	var oResp ollamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta do ollama: %w", err)
-	}
-
-	// Parse the inner JSON generated by LLM
-	var blueprint GovernanceBlueprint
-	// Clean potential markdown fences if model disobeys
-	cleanJSON := strings.TrimPrefix(oResp.Response, "```json")
-	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
+	return GenerateGovernanceBlueprint(ctx, p, description)
+}
 
-	if err := json.Unmarshal([]byte(cleanJSON), &blueprint); err != nil {
-		return nil, fmt.Errorf("falha ao analisar json do blueprint: %w. Bruto: %s", err, oResp.Response)
-	}
+func (p *OllamaProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return p.generate(ctx, ollamaRequest{Prompt: userPrompt, System: systemPrompt})
+}
 
-	return &blueprint, nil
+// CompletionJSON restringe a geração ao schema via format (structured
+// outputs, Ollama v0.5+).
+func (p *OllamaProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	return p.generate(ctx, ollamaRequest{Prompt: userPrompt, System: systemPrompt, Format: schema})
 }
 
-func (p *OllamaProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+// generate chama /api/generate sem streaming, com o modelo resolvido.
+func (p *OllamaProvider) generate(ctx context.Context, reqBody ollamaRequest) (string, error) {
 	if !p.modelConfigured {
 		if err := p.resolveModel(ctx); err != nil {
 			return "", fmt.Errorf("verificação de modelo ollama falhou: %w", err)
 		}
 	}
-
-	reqBody := ollamaRequest{
-		Model:  p.Model,
-		Prompt: userPrompt,
-		System: systemPrompt,
-		Stream: false,
-	}
+	reqBody.Model = p.Model
 
 	jsonBody, _ := json.Marshal(reqBody)
 	client := http.Client{Timeout: 300 * time.Second}
@@ -424,3 +393,94 @@ func (p *OllamaProvider) embedSequential(ctx context.Context, texts []string) ([
 	}
 	return results, nil
 }
+
+type ollamaChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []ollamaChatMessage `json:"messages"`
+	Tools    []openAITool        `json:"tools"`
+	Stream   bool                `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Message struct {
+		Content   string `json:"content"`
+		ToolCalls []struct {
+			Function struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// CompletionWithTools usa /api/chat com tools (formato OpenAI). Modelos sem
+// suporte a tools retornam ErrToolsNotSupported para que o chamador use o
+// protocolo em texto.
+func (p *OllamaProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	if !p.modelConfigured {
+		if err := p.resolveModel(ctx); err != nil {
+			return nil, fmt.Errorf("verificação de modelo ollama falhou: %w", err)
+		}
+	}
+
+	reqBody := ollamaChatRequest{
+		Model: p.Model,
+		Messages: []ollamaChatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream: false,
+	}
+	for _, t := range tools {
+		reqBody.Tools = append(reqBody.Tools, openAITool{
+			Type:     "function",
+			Function: openAIToolFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := http.Client{Timeout: 300 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao chamar ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "does not support tools") {
+			return nil, fmt.Errorf("modelo %s: %w", p.Model, ErrToolsNotSupported)
+		}
+		return nil, NewAPIErrorFromResponse("ollama", resp, body)
+	}
+
+	var oResp ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar resposta do ollama: %w", err)
+	}
+
+	SetUsage(ctx, &UsageMetadata{
+		PromptTokens:     oResp.PromptEvalCount,
+		CompletionTokens: oResp.EvalCount,
+		TotalTokens:      oResp.PromptEvalCount + oResp.EvalCount,
+		Provider:         "ollama",
+		Model:            p.Model,
+		Operation:        "tools",
+	})
+
+	result := &ToolResponse{Content: oResp.Message.Content}
+	for _, tc := range oResp.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return result, nil
+}
@@ -7,9 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 	"strings"
 	"time"
 )
@@ -18,6 +17,14 @@ type OpenAIProvider struct {
 	APIKey  string
 	Model   string
 	BaseURL string
+
+	// Campos opcionais, preenchidos pelo provider openai-compatible.
+	ID             string            // nome em UsageMetadata e erros (padrão "openai")
+	AuthHeader     string            // "Authorization" (Bearer, padrão) ou outro header com a chave crua
+	APIVersion     string            // query param api-version
+	Headers        map[string]string // headers extras em todas as requisições
+	Deployments    map[string]string // modelo -> deployment
+	EmbeddingModel string            // sobrepõe o modelo de embedding padrão
 }
 
 func NewOpenAIProvider() *OpenAIProvider {
@@ -42,10 +49,66 @@ func (p *OpenAIProvider) Name() string {
 	return "OpenAI (Cloud)"
 }
 
+// withModel retorna uma cópia do provider usando outro modelo.
+func (p *OpenAIProvider) withModel(model string) Provider {
+	c := *p
+	c.Model = model
+	return &c
+}
+
 func (p *OpenAIProvider) IsAvailable(ctx context.Context) bool {
 	return p.APIKey != ""
 }
 
+// id retorna o nome do provider usado em UsageMetadata e erros.
+func (p *OpenAIProvider) id() string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return "openai"
+}
+
+// deployment retorna o nome do deployment mapeado para model, ou o próprio model.
+func (p *OpenAIProvider) deployment(model string) string {
+	if d := p.Deployments[model]; d != "" {
+		return d
+	}
+	return model
+}
+
+// requestModel retorna o valor do campo model da requisição. Quando a BaseURL
+// tem o marcador {deployment} o deployment vai na URL e o modelo segue como está.
+func (p *OpenAIProvider) requestModel(model string) string {
+	if strings.Contains(p.BaseURL, "{deployment}") {
+		return model
+	}
+	return p.deployment(model)
+}
+
+// newRequest monta a requisição POST para path com a autenticação, os headers
+// extras e o api-version configurados.
+func (p *OpenAIProvider) newRequest(ctx context.Context, model, path string, body interface{}) *http.Request {
+	endpoint := strings.ReplaceAll(p.BaseURL, "{deployment}", url.PathEscape(p.deployment(model))) + path
+	if p.APIVersion != "" {
+		endpoint += "?api-version=" + url.QueryEscape(p.APIVersion)
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		if p.AuthHeader == "" || strings.EqualFold(p.AuthHeader, "Authorization") {
+			req.Header.Set("Authorization", "Bearer "+p.APIKey)
+		} else {
+			req.Header.Set(p.AuthHeader, p.APIKey)
+		}
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
 type openAIMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -73,66 +136,12 @@ type openAIResponse struct {
 }
 
 func (p *OpenAIProvider) GenerateGovernance(ctx context.Context, description string) (*GovernanceBlueprint, error) {
-	reqBody := openAIRequest{
-		Model: p.Model,
-		Messages: []openAIMessage{
-			{Role: "system", Content: prompts.Get("governance.system")},
-			{Role: "user", Content: fmt.Sprintf("Descrição do Projeto: %s", description)},
-		},
-	}
-	reqBody.ResponseFormat.Type = "json_object"
-
-	jsonBody, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
-
-	client := http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("falha ao chamar openai: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIErrorFromResponse("openai", resp, body)
-	}
-
-	var oResp openAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta da openai: %w", err)
-	}
-
-	SetUsage(ctx, &UsageMetadata{
-		PromptTokens:     oResp.Usage.PromptTokens,
-		CompletionTokens: oResp.Usage.CompletionTokens,
-		TotalTokens:      oResp.Usage.TotalTokens,
-		Provider:         "openai",
-		Model:            p.Model,
-		Operation:        "governance",
-	})
-
-	if len(oResp.Choices) == 0 {
-		return nil, fmt.Errorf("resposta vazia do openai")
-	}
-
-	cleanJSON := oResp.Choices[0].Message.Content
-	// OpenAI with json_object usually returns clean JSON, but safeguard
-	cleanJSON = strings.TrimPrefix(cleanJSON, "```json")
-	cleanJSON = strings.TrimSuffix(cleanJSON, "```")
-
-	var blueprint GovernanceBlueprint
-	if err := json.Unmarshal([]byte(cleanJSON), &blueprint); err != nil {
-		return nil, fmt.Errorf("falha ao analisar json do blueprint: %w", err)
-	}
-
-	return &blueprint, nil
+	return GenerateGovernanceBlueprint(ctx, p, description)
 }
 
 func (p *OpenAIProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	reqBody := openAIRequest{
-		Model: p.Model,
+		Model: p.requestModel(p.Model),
 		Messages: []openAIMessage{
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
@@ -140,39 +149,36 @@ func (p *OpenAIProvider) Completion(ctx context.Context, systemPrompt, userPromp
 	}
 	// No JSON format constraint for general completion
 
-	jsonBody, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	req := p.newRequest(ctx, p.Model, "/chat/completions", reqBody)
 
 	client := http.Client{Timeout: 60 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("falha ao chamar openai: %w", err)
+		return "", fmt.Errorf("falha ao chamar %s: %w", p.id(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", NewAPIErrorFromResponse("openai", resp, body)
+		return "", NewAPIErrorFromResponse(p.id(), resp, body)
 	}
 
 	var oResp openAIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
-		return "", fmt.Errorf("falha ao decodificar resposta da openai: %w", err)
+		return "", fmt.Errorf("falha ao decodificar resposta de %s: %w", p.id(), err)
 	}
 
 	SetUsage(ctx, &UsageMetadata{
 		PromptTokens:     oResp.Usage.PromptTokens,
 		CompletionTokens: oResp.Usage.CompletionTokens,
 		TotalTokens:      oResp.Usage.TotalTokens,
-		Provider:         "openai",
+		Provider:         p.id(),
 		Model:            p.Model,
 		Operation:        "completion",
 	})
 
 	if len(oResp.Choices) == 0 {
-		return "", fmt.Errorf("resposta vazia da openai")
+		return "", fmt.Errorf("resposta vazia de %s", p.id())
 	}
 
 	return oResp.Choices[0].Message.Content, nil
@@ -187,7 +193,7 @@ func (p *OpenAIProvider) StreamCompletion(ctx context.Context, systemPrompt, use
 	}
 
 	reqStruct := streamRequest{
-		Model: p.Model,
+		Model: p.requestModel(p.Model),
 		Messages: []openAIMessage{
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
@@ -195,21 +201,18 @@ func (p *OpenAIProvider) StreamCompletion(ctx context.Context, systemPrompt, use
 		Stream: true,
 	}
 
-	jsonBody, _ := json.Marshal(reqStruct)
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	req := p.newRequest(ctx, p.Model, "/chat/completions", reqStruct)
 
 	client := http.Client{Timeout: 5 * time.Minute}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("falha ao chamar openai stream: %w", err)
+		return fmt.Errorf("falha ao chamar %s stream: %w", p.id(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return NewAPIErrorFromResponse("openai", resp, body)
+		return NewAPIErrorFromResponse(p.id(), resp, body)
 	}
 
 	// Simple SSE Parser
@@ -281,27 +284,28 @@ func getEmbeddingModelForOpenAI() string {
 }
 
 func (p *OpenAIProvider) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	model := p.EmbeddingModel
+	if model == "" {
+		model = getEmbeddingModelForOpenAI()
+	}
 	reqBody := openAIEmbeddingRequest{
 		Input:          texts,
-		Model:          getEmbeddingModelForOpenAI(),
+		Model:          p.requestModel(model),
 		EncodingFormat: "float",
 	}
 
-	jsonBody, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/embeddings", bytes.NewBuffer(jsonBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	req := p.newRequest(ctx, model, "/embeddings", reqBody)
 
 	client := http.Client{Timeout: 60 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao chamar openai embeddings: %w", err)
+		return nil, fmt.Errorf("falha ao chamar %s embeddings: %w", p.id(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, NewAPIErrorFromResponse("openai", resp, body)
+		return nil, NewAPIErrorFromResponse(p.id(), resp, body)
 	}
 
 	var oResp openAIEmbeddingResponse
@@ -318,3 +322,167 @@ func (p *OpenAIProvider) EmbedDocuments(ctx context.Context, texts []string) ([]
 	}
 	return results, nil
 }
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Parameters  ToolSchema `json:"parameters"`
+}
+
+type openAIToolRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools"`
+}
+
+type openAIToolResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// CompletionWithTools usa o tool calling nativo do chat/completions.
+func (p *OpenAIProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	reqBody := openAIToolRequest{
+		Model: p.requestModel(p.Model),
+		Messages: []openAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	for _, t := range tools {
+		reqBody.Tools = append(reqBody.Tools, openAITool{
+			Type:     "function",
+			Function: openAIToolFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+
+	req := p.newRequest(ctx, p.Model, "/chat/completions", reqBody)
+
+	client := http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao chamar %s: %w", p.id(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, NewAPIErrorFromResponse(p.id(), resp, body)
+	}
+
+	var oResp openAIToolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar resposta de %s: %w", p.id(), err)
+	}
+
+	SetUsage(ctx, &UsageMetadata{
+		PromptTokens:     oResp.Usage.PromptTokens,
+		CompletionTokens: oResp.Usage.CompletionTokens,
+		TotalTokens:      oResp.Usage.TotalTokens,
+		Provider:         p.id(),
+		Model:            p.Model,
+		Operation:        "tools",
+	})
+
+	if len(oResp.Choices) == 0 {
+		return nil, fmt.Errorf("resposta vazia de %s", p.id())
+	}
+
+	msg := oResp.Choices[0].Message
+	result := &ToolResponse{Content: msg.Content}
+	for _, tc := range msg.ToolCalls {
+		var args map[string]interface{}
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("argumentos invalidos na chamada de %s: %w", tc.Function.Name, err)
+			}
+		}
+		result.ToolCalls = append(result.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: args})
+	}
+	return result, nil
+}
+
+type openAIJSONSchemaFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string                 `json:"name"`
+		Schema map[string]interface{} `json:"schema"`
+	} `json:"json_schema"`
+}
+
+// CompletionJSON usa response_format json_schema. A OpenAI exige objeto na
+// raiz; outros schemas usam o caminho em texto.
+func (p *OpenAIProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	if schema["type"] != "object" {
+		return "", ErrJSONModeNotSupported
+	}
+
+	format := openAIJSONSchemaFormat{Type: "json_schema"}
+	format.JSONSchema.Name = "response"
+	format.JSONSchema.Schema = schema
+	reqBody := struct {
+		Model          string                 `json:"model"`
+		Messages       []openAIMessage        `json:"messages"`
+		ResponseFormat openAIJSONSchemaFormat `json:"response_format"`
+	}{
+		Model: p.requestModel(p.Model),
+		Messages: []openAIMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		ResponseFormat: format,
+	}
+
+	req := p.newRequest(ctx, p.Model, "/chat/completions", reqBody)
+
+	client := http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("falha ao chamar %s: %w", p.id(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", NewAPIErrorFromResponse(p.id(), resp, body)
+	}
+
+	var oResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&oResp); err != nil {
+		return "", fmt.Errorf("falha ao decodificar resposta de %s: %w", p.id(), err)
+	}
+
+	SetUsage(ctx, &UsageMetadata{
+		PromptTokens:     oResp.Usage.PromptTokens,
+		CompletionTokens: oResp.Usage.CompletionTokens,
+		TotalTokens:      oResp.Usage.TotalTokens,
+		Provider:         p.id(),
+		Model:            p.Model,
+		Operation:        "json",
+	})
+
+	if len(oResp.Choices) == 0 {
+		return "", fmt.Errorf("resposta vazia de %s", p.id())
+	}
+	return oResp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/config"
+)
+
+// openAICompatibleName é o nome do provider em ai.provider, ai.priority,
+// ai.models e ai.embedding.
+const openAICompatibleName = "openai-compatible"
+
+// defaultOpenAICompatibleKeyEnv é a variável de ambiente padrão da chave.
+const defaultOpenAICompatibleKeyEnv = "OPENAI_COMPATIBLE_API_KEY"
+
+func init() {
+	registerProvider(openAICompatibleName, func(ctx context.Context) Provider {
+		p := NewOpenAICompatibleProvider()
+		if p != nil && p.IsAvailable(ctx) {
+			return wrapWithBudget(openAICompatibleName, p, p.Model)
+		}
+		return nil
+	})
+}
+
+// OpenAICompatibleProvider usa a API da OpenAI contra endpoints próprios
+// (vLLM, LM Studio, LocalAI, Azure OpenAI), configurados em ai.openai_compatible.
+type OpenAICompatibleProvider struct {
+	*OpenAIProvider
+}
+
+// NewOpenAICompatibleProvider cria o provider a partir de ai.openai_compatible.
+// Retorna nil se base_url não estiver configurada.
+func NewOpenAICompatibleProvider() *OpenAICompatibleProvider {
+	cfg, err := config.Load()
+	if err != nil || cfg.AI.OpenAICompatible.BaseURL == "" {
+		return nil
+	}
+	oc := cfg.AI.OpenAICompatible
+
+	keyEnv := oc.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = defaultOpenAICompatibleKeyEnv
+	}
+
+	return &OpenAICompatibleProvider{&OpenAIProvider{
+		APIKey:         os.Getenv(keyEnv),
+		Model:          getConfiguredModel(openAICompatibleName),
+		BaseURL:        strings.TrimSuffix(oc.BaseURL, "/"),
+		ID:             openAICompatibleName,
+		AuthHeader:     oc.AuthHeader,
+		APIVersion:     oc.APIVersion,
+		Headers:        oc.Headers,
+		Deployments:    oc.Deployments,
+		EmbeddingModel: GetEmbeddingModel(openAICompatibleName),
+	}}
+}
+
+func (p *OpenAICompatibleProvider) Name() string {
+	return "OpenAI-compatible (" + p.BaseURL + ")"
+}
+
+// IsAvailable exige um modelo configurado; a chave é opcional, já que
+// endpoints locais costumam dispensá-la. Sem chave, o endpoint precisa
+// responder em /models.
+func (p *OpenAICompatibleProvider) IsAvailable(ctx context.Context) bool {
+	if p.Model == "" {
+		return false
+	}
+	if p.APIKey != "" {
+		return true
+	}
+	if strings.Contains(p.BaseURL, "{deployment}") {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/models", nil)
+	if err != nil {
+		return false
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// withModel retorna uma cópia do provider usando outro modelo.
+func (p *OpenAICompatibleProvider) withModel(model string) Provider {
+	c := *p.OpenAIProvider
+	c.Model = model
+	return &OpenAICompatibleProvider{&c}
+}
@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAIConfig grava ~/.yby/config.yaml em um HOME temporário.
+func writeAIConfig(t *testing.T, content string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".yby"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".yby", "config.yaml"), []byte(content), 0644))
+}
+
+func TestOpenAICompatible_NaoConfigurado(t *testing.T) {
+	writeAIConfig(t, "ai:\n  language: pt-BR\n")
+	assert.Nil(t, NewOpenAICompatibleProvider())
+	assert.Nil(t, GetProvider(context.Background(), "openai-compatible"))
+}
+
+func TestOpenAICompatible_Azure(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuth, gotTenant string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		gotTenant = r.Header.Get("X-Tenant")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		resp := openAISuccessResponse("ola do azure")
+		resp.Usage.PromptTokens = 3
+		resp.Usage.TotalTokens = 5
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	writeAIConfig(t, `ai:
+  models:
+    openai-compatible: gpt-4o
+  openai_compatible:
+    base_url: `+server.URL+`/openai/deployments/{deployment}/
+    api_key_env: AZURE_OPENAI_API_KEY
+    auth_header: api-key
+    api_version: 2024-06-01
+    headers:
+      X-Tenant: time-a
+    deployments:
+      gpt-4o: prod-gpt4o
+`)
+	t.Setenv("AZURE_OPENAI_API_KEY", "azure-key")
+
+	p := NewOpenAICompatibleProvider()
+	require.NotNil(t, p)
+	assert.True(t, p.IsAvailable(context.Background()))
+
+	ctx, usage := WithUsage(context.Background())
+	result, err := p.Completion(ctx, "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "ola do azure", result)
+
+	assert.Equal(t, "/openai/deployments/prod-gpt4o/chat/completions", gotPath)
+	assert.Equal(t, "2024-06-01", gotVersion)
+	assert.Equal(t, "azure-key", gotKey)
+	assert.Empty(t, gotAuth)
+	assert.Equal(t, "time-a", gotTenant)
+	assert.Equal(t, "gpt-4o", body["model"])
+	assert.Equal(t, "openai-compatible", usage.Provider)
+	assert.Equal(t, 5, usage.TotalTokens)
+}
+
+func TestOpenAICompatible_VLLMSemChave(t *testing.T) {
+	var chatModel, embedModel, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/v1/models":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+		case "/v1/chat/completions":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			chatModel, _ = req["model"].(string)
+			json.NewEncoder(w).Encode(openAISuccessResponse("ok"))
+		case "/v1/embeddings":
+			var req openAIEmbeddingRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			embedModel = req.Model
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []map[string]interface{}{{"index": 0, "embedding": []float32{0.1, 0.2}}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	writeAIConfig(t, `ai:
+  models:
+    openai-compatible: llama3
+  embedding:
+    openai-compatible: bge-small
+  openai_compatible:
+    base_url: `+server.URL+`/v1
+    deployments:
+      llama3: meta-llama/Meta-Llama-3-8B-Instruct
+`)
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+
+	p := NewOpenAICompatibleProvider()
+	require.NotNil(t, p)
+	assert.True(t, p.IsAvailable(context.Background()))
+
+	_, err := p.Completion(context.Background(), "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, "meta-llama/Meta-Llama-3-8B-Instruct", chatModel)
+	assert.Empty(t, gotAuth, "sem chave não deveria enviar Authorization")
+
+	vectors, err := p.EmbedDocuments(context.Background(), []string{"texto"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}}, vectors)
+	assert.Equal(t, "bge-small", embedModel)
+
+	// Via factory, com os decorators e capacidades nativas preservadas
+	wrapped := GetProvider(context.Background(), "openai-compatible")
+	require.NotNil(t, wrapped)
+	assert.True(t, SupportsTools(wrapped))
+	_, isCompat := innermost(wrapped).(*OpenAICompatibleProvider)
+	assert.True(t, isCompat)
+}
+
+func TestOpenAICompatible_Indisponivel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	writeAIConfig(t, "ai:\n  openai_compatible:\n    base_url: "+server.URL+"\n")
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+	p := NewOpenAICompatibleProvider()
+	require.NotNil(t, p)
+	assert.False(t, p.IsAvailable(context.Background()), "sem modelo configurado")
+
+	p.Model = "llama3"
+	assert.False(t, p.IsAvailable(context.Background()), "endpoint sem chave respondeu 401")
+}
+
+func TestOpenAICompatible_BaseURLPorEnv(t *testing.T) {
+	writeAIConfig(t, "ai:\n  model: qwen2\n")
+	t.Setenv("YBY_AI_OPENAI_COMPATIBLE_BASE_URL", "http://localhost:1234/v1")
+
+	p := NewOpenAICompatibleProvider()
+	require.NotNil(t, p)
+	assert.Equal(t, "http://localhost:1234/v1", p.BaseURL)
+	assert.Equal(t, "qwen2", p.Model)
+	assert.Equal(t, "OpenAI-compatible (http://localhost:1234/v1)", p.Name())
+}
+
+func TestOpenAICompatible_WithModel(t *testing.T) {
+	p := &OpenAICompatibleProvider{&OpenAIProvider{Model: "grande", ID: openAICompatibleName}}
+	cheaper, ok := p.withModel("pequeno").(*OpenAICompatibleProvider)
+	require.True(t, ok)
+	assert.Equal(t, "pequeno", cheaper.Model)
+	assert.Equal(t, "grande", p.Model)
+}
@@ -8,15 +8,20 @@ import (
 
 // defaultPrompts mapeia nomes padronizados aos prompts default.
 var defaultPrompts = map[string]string{
-	"bard.system":          BardSystem,
-	"bard.classify":        BardClassify,
-	"sentinel.investigate": SentinelInvestigate,
-	"sentinel.scan":        SentinelScan,
-	"synapstor.capture":    SynapsotorCapture,
-	"synapstor.study":      SynapsotorStudy,
-	"synapstor.tagger":     SynapsotorTagger,
-	"atlas.refine":         AtlasRefine,
-	"governance.system":    GovernanceSystem,
+	"bard.agent":                    BardAgent,
+	"bard.compact":                  BardCompact,
+	"bard.session_title":            BardSessionTitle,
+	"bard.system":                   BardSystem,
+	"bard.classify":                 BardClassify,
+	"bard.tools":                    BardTools,
+	"sentinel.investigate":          SentinelInvestigate,
+	"sentinel.investigate.workload": SentinelInvestigateWorkload,
+	"sentinel.scan":                 SentinelScan,
+	"synapstor.capture":             SynapsotorCapture,
+	"synapstor.study":               SynapsotorStudy,
+	"synapstor.tagger":              SynapsotorTagger,
+	"atlas.refine":                  AtlasRefine,
+	"governance.system":             GovernanceSystem,
 }
 
 // Get retorna o prompt pelo nome, aplicando overrides na ordem:
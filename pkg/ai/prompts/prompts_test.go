@@ -52,16 +52,21 @@ func TestGetWithVars(t *testing.T) {
 func TestList(t *testing.T) {
 	names := List()
 
-	if len(names) != 9 {
-		t.Errorf("esperava 9 prompts, obteve %d: %v", len(names), names)
+	if len(names) != 14 {
+		t.Errorf("esperava 14 prompts, obteve %d: %v", len(names), names)
 	}
 
 	expected := []string{
 		"atlas.refine",
+		"bard.agent",
 		"bard.classify",
+		"bard.compact",
+		"bard.session_title",
 		"bard.system",
+		"bard.tools",
 		"governance.system",
 		"sentinel.investigate",
+		"sentinel.investigate.workload",
 		"sentinel.scan",
 		"synapstor.capture",
 		"synapstor.study",
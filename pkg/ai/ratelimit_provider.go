@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"sync"
@@ -168,6 +169,37 @@ func (r *RateLimitProvider) GenerateGovernance(ctx context.Context, description
 	return result, err
 }
 
+func (r *RateLimitProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	if !SupportsTools(r.inner) {
+		return nil, ErrToolsNotSupported
+	}
+	if err := r.waitAndCheck(ctx); err != nil {
+		return nil, err
+	}
+	result, err := innerCompletionWithTools(ctx, r.inner, systemPrompt, userPrompt, tools)
+	r.recordResult(err)
+	return result, err
+}
+
+func (r *RateLimitProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	if _, ok := innermost(r.inner).(JSONCompleter); !ok {
+		return "", ErrJSONModeNotSupported
+	}
+	if err := r.waitAndCheck(ctx); err != nil {
+		return "", err
+	}
+	result, err := innerCompletionJSON(ctx, r.inner, systemPrompt, userPrompt, schema)
+	if errors.Is(err, ErrJSONModeNotSupported) {
+		// Não conta como falha: o chamador repete a chamada em texto
+		return "", err
+	}
+	r.recordResult(err)
+	return result, err
+}
+
+// Unwrap retorna o provider envolvido.
+func (r *RateLimitProvider) Unwrap() Provider { return r.inner }
+
 // waitAndCheck aguarda o rate limiter e verifica o circuit breaker.
 func (r *RateLimitProvider) waitAndCheck(ctx context.Context) error {
 	if !r.cb.allow() {
@@ -99,10 +99,44 @@ func (r *RetryProvider) GenerateGovernance(ctx context.Context, description stri
 	return result, err
 }
 
+// CompletionWithTools executa o tool calling nativo com retry automatico.
+func (r *RetryProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	var result *ToolResponse
+	err := retry.Do(ctx, r.opts, func() error {
+		res, err := innerCompletionWithTools(ctx, r.inner, systemPrompt, userPrompt, tools)
+		if err != nil {
+			return r.classifyError(err)
+		}
+		result = res
+		return nil
+	})
+	return result, err
+}
+
+// CompletionJSON executa a chamada em modo JSON com retry automatico.
+func (r *RetryProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	var result string
+	err := retry.Do(ctx, r.opts, func() error {
+		res, err := innerCompletionJSON(ctx, r.inner, systemPrompt, userPrompt, schema)
+		if err != nil {
+			return r.classifyError(err)
+		}
+		result = res
+		return nil
+	})
+	return result, err
+}
+
+// Unwrap retorna o provider envolvido.
+func (r *RetryProvider) Unwrap() Provider { return r.inner }
+
 // classifyError determina se o erro e retentavel baseado no status HTTP.
 // Erros que nao sao APIError ou cujo status nao esta na lista sao tratados
 // como permanentes (nao retentaveis).
 func (r *RetryProvider) classifyError(err error) error {
+	if errors.Is(err, ErrToolsNotSupported) || errors.Is(err, ErrJSONModeNotSupported) {
+		return backoff.Permanent(err)
+	}
 	var apiErr *APIError
 	if errors.As(err, &apiErr) {
 		if !r.retryableStatuses[apiErr.StatusCode] {
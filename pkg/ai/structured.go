@@ -0,0 +1,320 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultJSONRetries é o número padrão de novas tentativas de CompleteJSON
+// quando a resposta não passa na validação.
+const DefaultJSONRetries = 2
+
+// ErrJSONModeNotSupported indica que o provider não tem modo JSON nativo para
+// o schema pedido. CompleteJSON usa Completion com instruções nesse caso.
+var ErrJSONModeNotSupported = errors.New("provider nao suporta modo JSON nativo")
+
+// JSONCompleter é a capacidade opcional de saída estruturada nativa de um
+// Provider (response_format da OpenAI, responseMimeType do Gemini, format do Ollama).
+type JSONCompleter interface {
+	CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error)
+}
+
+// JSONOptions configura CompleteJSON.
+type JSONOptions struct {
+	// Schema é o JSON Schema esperado. Se nil, é derivado do tipo de out.
+	Schema map[string]interface{}
+	// MaxRetries é o número de novas tentativas com o erro de validação
+	// devolvido ao modelo. Zero usa DefaultJSONRetries; negativo desativa.
+	MaxRetries int
+	// Validate aplica regras além do schema sobre o valor já decodificado.
+	Validate func() error
+}
+
+// JSONValidationError é retornado quando nenhuma tentativa produziu JSON válido.
+type JSONValidationError struct {
+	Attempts int
+	// Raw é a última resposta bruta do modelo.
+	Raw string
+	Err error
+}
+
+func (e *JSONValidationError) Error() string {
+	return fmt.Sprintf("resposta da IA invalida apos %d tentativa(s): %v", e.Attempts, e.Err)
+}
+
+func (e *JSONValidationError) Unwrap() error { return e.Err }
+
+// CompleteJSON pede ao modelo uma resposta JSON, valida contra o schema (e
+// opts.Validate) e decodifica em out. Usa o modo JSON nativo do provider quando
+// disponível. Respostas inválidas são reenviadas ao modelo com o erro de
+// validação, até opts.MaxRetries vezes.
+func CompleteJSON(ctx context.Context, p Provider, systemPrompt, userPrompt string, out interface{}, opts JSONOptions) error {
+	schema := opts.Schema
+	if schema == nil {
+		schema = SchemaFor(out)
+	}
+	retries := opts.MaxRetries
+	if retries == 0 {
+		retries = DefaultJSONRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	schemaJSON, _ := json.MarshalIndent(schema, "", "  ")
+	system := fmt.Sprintf("%s\n\nResponda APENAS com um JSON valido, sem texto adicional, seguindo este JSON Schema:\n%s", systemPrompt, schemaJSON)
+
+	prompt := userPrompt
+	var raw string
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		var err error
+		raw, err = completeJSONOnce(ctx, p, system, prompt, schema)
+		if err != nil {
+			return err
+		}
+
+		if lastErr = decodeAndValidate(raw, schema, out, opts.Validate); lastErr == nil {
+			return nil
+		}
+		prompt = fmt.Sprintf("%s\n\n---\nSua resposta anterior e invalida: %v\nResposta anterior:\n%s\n\nResponda novamente apenas com o JSON corrigido.", userPrompt, lastErr, raw)
+	}
+	return &JSONValidationError{Attempts: retries + 1, Raw: raw, Err: lastErr}
+}
+
+func completeJSONOnce(ctx context.Context, p Provider, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	if jc, ok := p.(JSONCompleter); ok {
+		raw, err := jc.CompletionJSON(ctx, systemPrompt, userPrompt, schema)
+		if !errors.Is(err, ErrJSONModeNotSupported) {
+			return raw, err
+		}
+	}
+	return p.Completion(ctx, systemPrompt, userPrompt)
+}
+
+// innerCompletionJSON é usado pelos decorators para repassar a chamada ao
+// provider envolvido.
+func innerCompletionJSON(ctx context.Context, inner Provider, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	jc, ok := inner.(JSONCompleter)
+	if !ok {
+		return "", ErrJSONModeNotSupported
+	}
+	return jc.CompletionJSON(ctx, systemPrompt, userPrompt, schema)
+}
+
+func decodeAndValidate(raw string, schema map[string]interface{}, out interface{}, validate func() error) error {
+	data := ExtractJSON(raw)
+	if data == "" {
+		return errors.New("nenhum json encontrado na resposta")
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal([]byte(data), &generic); err != nil {
+		return fmt.Errorf("JSON malformado: %w", err)
+	}
+	if err := ValidateJSONSchema(schema, generic); err != nil {
+		return err
+	}
+
+	// Zerar out entre tentativas para não misturar campos de respostas anteriores
+	if rv := reflect.ValueOf(out); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	}
+	if err := json.Unmarshal([]byte(data), out); err != nil {
+		return fmt.Errorf("JSON incompativel com o formato esperado: %w", err)
+	}
+	if validate != nil {
+		return validate()
+	}
+	return nil
+}
+
+// ExtractJSON retorna o primeiro objeto ou array JSON do texto, ignorando
+// code fences e texto ao redor. Retorna vazio se não houver JSON.
+func ExtractJSON(text string) string {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text[i:]))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == nil {
+			return string(raw)
+		}
+	}
+	return ""
+}
+
+// ValidateJSONSchema valida um valor decodificado (interface{}) contra o
+// subconjunto de JSON Schema gerado por SchemaFor: type, properties, required,
+// items, additionalProperties, enum, minItems e maxItems.
+func ValidateJSONSchema(schema map[string]interface{}, value interface{}) error {
+	return validateSchemaAt(schema, value, "$")
+}
+
+func validateSchemaAt(schema map[string]interface{}, value interface{}, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if t, _ := schema["type"].(string); t != "" && !matchesSchemaType(t, value) {
+		return fmt.Errorf("%s deveria ser %s", path, t)
+	}
+	if enum := schemaStrings(schema["enum"]); len(enum) > 0 {
+		s, _ := value.(string)
+		if !containsString(enum, s) {
+			return fmt.Errorf("%s deve ser um de: %s", path, strings.Join(enum, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schemaStrings(schema["required"]) {
+			if val, ok := v[name]; !ok || val == nil {
+				return fmt.Errorf("campo obrigatorio %s.%s ausente", path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		extra, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, val := range v {
+			if val == nil {
+				continue
+			}
+			sub, ok := props[name].(map[string]interface{})
+			if !ok {
+				sub = extra
+			}
+			if err := validateSchemaAt(sub, val, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if min, ok := schemaInt(schema["minItems"]); ok && len(v) < min {
+			return fmt.Errorf("%s deve ter ao menos %d item(s)", path, min)
+		}
+		if max, ok := schemaInt(schema["maxItems"]); ok && len(v) > max {
+			return fmt.Errorf("%s deve ter no maximo %d item(s)", path, max)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range v {
+			if err := validateSchemaAt(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func schemaStrings(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// SchemaFor deriva um JSON Schema do tipo de v a partir das tags json. Campos
+// sem omitempty e que não são ponteiros são obrigatórios.
+func SchemaFor(v interface{}) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	return schemaForType(t)
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		var required []string
+		addStructFields(t, props, &required)
+		schema := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+func addStructFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addStructFields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaForType(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
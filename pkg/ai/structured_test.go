@@ -0,0 +1,274 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonCompleterMock adiciona CompletionJSON ao mockProvider.
+type jsonCompleterMock struct {
+	mockProvider
+	jsonFunc func(ctx context.Context, sys, usr string, schema map[string]interface{}) (string, error)
+}
+
+func (m *jsonCompleterMock) CompletionJSON(ctx context.Context, sys, usr string, schema map[string]interface{}) (string, error) {
+	return m.jsonFunc(ctx, sys, usr, schema)
+}
+
+type structuredSample struct {
+	Name    string            `json:"name"`
+	Score   int               `json:"score"`
+	Tags    []string          `json:"tags,omitempty"`
+	Patch   *string           `json:"patch"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Ignored string            `json:"-"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor(&structuredSample{})
+
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []string{"name", "score"}, schema["required"])
+
+	props := schema["properties"].(map[string]interface{})
+	assert.Len(t, props, 5)
+	assert.Equal(t, "string", props["name"].(map[string]interface{})["type"])
+	assert.Equal(t, "integer", props["score"].(map[string]interface{})["type"])
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, props["tags"])
+	assert.Equal(t, map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}, props["labels"])
+	assert.NotContains(t, props, "Ignored")
+
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, SchemaFor([]string{}))
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := SchemaFor(structuredSample{})
+	schema["properties"].(map[string]interface{})["tags"].(map[string]interface{})["minItems"] = 1
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"valido", `{"name":"a","score":1,"patch":null}`, ""},
+		{"campo obrigatorio ausente", `{"name":"a"}`, "$.score"},
+		{"tipo errado", `{"name":"a","score":"alto"}`, "$.score deveria ser integer"},
+		{"inteiro com fracao", `{"name":"a","score":1.5}`, "$.score deveria ser integer"},
+		{"item de array", `{"name":"a","score":1,"tags":["x",2]}`, "$.tags[1] deveria ser string"},
+		{"minItems", `{"name":"a","score":1,"tags":[]}`, "ao menos 1"},
+		{"additionalProperties", `{"name":"a","score":1,"labels":{"env":3}}`, "$.labels.env deveria ser string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &value))
+			err := ValidateJSONSchema(schema, value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	enum := map[string]interface{}{"type": "string", "enum": []interface{}{"low", "high"}}
+	assert.NoError(t, ValidateJSONSchema(enum, "low"))
+	assert.Error(t, ValidateJSONSchema(enum, "medium"))
+}
+
+func TestExtractJSON(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, ExtractJSON("```json\n{\"a\":1}\n```"))
+	assert.Equal(t, `["x","y"]`, ExtractJSON(`As tags sao: ["x","y"]. Pronto.`))
+	assert.Equal(t, `{"a":{"b":"}"}}`, ExtractJSON(`texto {quebrado {"a":{"b":"}"}}`))
+	assert.Empty(t, ExtractJSON("sem json aqui"))
+}
+
+func TestCompleteJSON_SucessoViaCompletion(t *testing.T) {
+	var gotSystem string
+	p := &mockProvider{completionFunc: func(_ context.Context, sys, _ string) (string, error) {
+		gotSystem = sys
+		return "```json\n{\"name\":\"api\",\"score\":7,\"patch\":null}\n```", nil
+	}}
+
+	var out structuredSample
+	err := CompleteJSON(context.Background(), p, "system base", "user", &out, JSONOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "api", out.Name)
+	assert.Equal(t, 7, out.Score)
+	assert.Contains(t, gotSystem, "system base")
+	assert.Contains(t, gotSystem, `"required"`)
+}
+
+func TestCompleteJSON_RetryComErroDeValidacao(t *testing.T) {
+	responses := []string{
+		`{"name":"api"}`,
+		`{"name":"","score":3}`,
+		`{"name":"api","score":3}`,
+	}
+	var prompts []string
+	p := &mockProvider{completionFunc: func(_ context.Context, _, usr string) (string, error) {
+		prompts = append(prompts, usr)
+		resp := responses[0]
+		responses = responses[1:]
+		return resp, nil
+	}}
+
+	var out structuredSample
+	err := CompleteJSON(context.Background(), p, "sys", "pergunta", &out, JSONOptions{
+		Validate: func() error {
+			if out.Name == "" {
+				return errors.New("name vazio")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, out.Score)
+	require.Len(t, prompts, 3)
+	assert.Equal(t, "pergunta", prompts[0])
+	assert.Contains(t, prompts[1], "pergunta")
+	assert.Contains(t, prompts[1], "$.score")
+	assert.Contains(t, prompts[1], `{"name":"api"}`)
+	assert.Contains(t, prompts[2], "name vazio")
+}
+
+func TestCompleteJSON_EsgotaTentativas(t *testing.T) {
+	calls := 0
+	p := &mockProvider{completionFunc: func(_ context.Context, _, _ string) (string, error) {
+		calls++
+		return "nao sei", nil
+	}}
+
+	var out structuredSample
+	err := CompleteJSON(context.Background(), p, "sys", "usr", &out, JSONOptions{MaxRetries: 1})
+	var invalid *JSONValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, 2, invalid.Attempts)
+	assert.Equal(t, "nao sei", invalid.Raw)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = CompleteJSON(context.Background(), p, "sys", "usr", &out, JSONOptions{MaxRetries: -1})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCompleteJSON_ErroDoProviderNaoRetenta(t *testing.T) {
+	calls := 0
+	p := &mockProvider{completionFunc: func(_ context.Context, _, _ string) (string, error) {
+		calls++
+		return "", fmt.Errorf("quota")
+	}}
+
+	var out structuredSample
+	err := CompleteJSON(context.Background(), p, "sys", "usr", &out, JSONOptions{})
+	assert.EqualError(t, err, "quota")
+	assert.Equal(t, 1, calls)
+}
+
+func TestCompleteJSON_ModoNativo(t *testing.T) {
+	var gotSchema map[string]interface{}
+	p := &jsonCompleterMock{
+		mockProvider: mockProvider{completionFunc: func(_ context.Context, _, _ string) (string, error) {
+			t.Fatal("Completion nao deveria ser usado com modo JSON nativo")
+			return "", nil
+		}},
+		jsonFunc: func(_ context.Context, _, _ string, schema map[string]interface{}) (string, error) {
+			gotSchema = schema
+			return `{"name":"nativo","score":1}`, nil
+		},
+	}
+
+	schema := map[string]interface{}{"type": "object", "required": []interface{}{"name"}}
+	var out structuredSample
+	require.NoError(t, CompleteJSON(context.Background(), p, "sys", "usr", &out, JSONOptions{Schema: schema}))
+	assert.Equal(t, "nativo", out.Name)
+	assert.Equal(t, schema, gotSchema)
+}
+
+func TestCompleteJSON_FallbackQuandoModoNativoNaoSuportado(t *testing.T) {
+	p := &jsonCompleterMock{
+		mockProvider: mockProvider{completionFunc: func(_ context.Context, _, _ string) (string, error) {
+			return `["a","b"]`, nil
+		}},
+		jsonFunc: func(_ context.Context, _, _ string, _ map[string]interface{}) (string, error) {
+			return "", ErrJSONModeNotSupported
+		},
+	}
+
+	var tags []string
+	require.NoError(t, CompleteJSON(context.Background(), p, "sys", "usr", &tags, JSONOptions{}))
+	assert.Equal(t, []string{"a", "b"}, tags)
+}
+
+func TestCompleteJSON_AtravesDosDecorators(t *testing.T) {
+	native := false
+	inner := &jsonCompleterMock{
+		jsonFunc: func(_ context.Context, _, _ string, _ map[string]interface{}) (string, error) {
+			native = true
+			return `{"name":"x","score":1}`, nil
+		},
+	}
+	wrapped := NewRetryProvider(NewCostTrackingProvider(inner, "mock"), fastRetryOpts(), nil)
+
+	var out structuredSample
+	require.NoError(t, CompleteJSON(context.Background(), wrapped, "sys", "usr", &out, JSONOptions{}))
+	assert.True(t, native)
+}
+
+func TestOpenAICompletionJSON_EnviaJSONSchema(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]interface{}{"content": `{"name":"x","score":2}`}}},
+		})
+	}))
+	defer server.Close()
+
+	p := &OpenAIProvider{APIKey: "key", BaseURL: server.URL, Model: "gpt-4o-mini"}
+	var out structuredSample
+	require.NoError(t, CompleteJSON(context.Background(), p, "sys", "usr", &out, JSONOptions{}))
+	assert.Equal(t, 2, out.Score)
+
+	format := body["response_format"].(map[string]interface{})
+	assert.Equal(t, "json_schema", format["type"])
+	jsonSchema := format["json_schema"].(map[string]interface{})
+	assert.Equal(t, "response", jsonSchema["name"])
+	assert.Equal(t, "object", jsonSchema["schema"].(map[string]interface{})["type"])
+
+	_, err := p.CompletionJSON(context.Background(), "sys", "usr", map[string]interface{}{"type": "array"})
+	assert.ErrorIs(t, err, ErrJSONModeNotSupported)
+}
+
+func TestOllamaCompletionJSON_EnviaFormat(t *testing.T) {
+	var req map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"models": []map[string]interface{}{{"name": "llama3"}},
+			})
+		case "/api/generate":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			json.NewEncoder(w).Encode(ollamaResponse{Response: `["k8s"]`})
+		}
+	}))
+	defer server.Close()
+
+	p := &OllamaProvider{BaseURL: server.URL, Model: "llama3"}
+	var tags []string
+	require.NoError(t, CompleteJSON(context.Background(), p, "sys", "usr", &tags, JSONOptions{}))
+	assert.Equal(t, []string{"k8s"}, tags)
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, req["format"])
+	assert.Equal(t, "llama3", req["model"])
+}
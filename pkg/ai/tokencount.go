@@ -103,6 +103,23 @@ func (t *TokenAwareProvider) EmbedDocuments(ctx context.Context, texts []string)
 	return t.inner.EmbedDocuments(ctx, texts)
 }
 
+func (t *TokenAwareProvider) CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	if err := t.checkTokens(systemPrompt + userPrompt); err != nil {
+		return nil, err
+	}
+	return innerCompletionWithTools(ctx, t.inner, systemPrompt, userPrompt, tools)
+}
+
+func (t *TokenAwareProvider) CompletionJSON(ctx context.Context, systemPrompt, userPrompt string, schema map[string]interface{}) (string, error) {
+	if err := t.checkTokens(systemPrompt + userPrompt); err != nil {
+		return "", err
+	}
+	return innerCompletionJSON(ctx, t.inner, systemPrompt, userPrompt, schema)
+}
+
+// Unwrap retorna o provider envolvido.
+func (t *TokenAwareProvider) Unwrap() Provider { return t.inner }
+
 // checkTokens valida o tamanho estimado do input contra a context window do modelo.
 func (t *TokenAwareProvider) checkTokens(text string) error {
 	meta := GetModelMetadata(t.model)
@@ -0,0 +1,287 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// ErrToolsNotSupported indica que o provider (ou o modelo) não suporta tool
+// calling nativo. CompleteWithTools usa o protocolo em texto nesse caso.
+var ErrToolsNotSupported = errors.New("provider nao suporta tool calling nativo")
+
+// ToolDefinition descreve uma ferramenta oferecida ao modelo.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  ToolSchema
+}
+
+// ToolSchema é o JSON Schema (type object) dos argumentos de uma ferramenta.
+type ToolSchema struct {
+	Type       string                  `json:"type"`
+	Properties map[string]ToolProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+// ToolProperty descreve um argumento: string, number, integer, boolean, array ou object.
+type ToolProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ToolCall é uma invocação de ferramenta retornada pelo modelo.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// ToolResponse é a resposta de uma completion com ferramentas: texto livre,
+// chamadas de ferramenta ou ambos.
+type ToolResponse struct {
+	Content   string
+	ToolCalls []ToolCall
+	// Native indica que as chamadas vieram da API de tool calling do provider
+	// e não do protocolo em texto.
+	Native bool
+}
+
+// ToolCaller é a capacidade opcional de tool calling nativo de um Provider
+// (OpenAI, Gemini, Bedrock Converse e Ollama). Providers de CLI não a implementam.
+type ToolCaller interface {
+	CompletionWithTools(ctx context.Context, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error)
+}
+
+// SupportsTools informa se o provider (através dos decorators) tem tool calling nativo.
+func SupportsTools(p Provider) bool {
+	_, ok := innermost(p).(ToolCaller)
+	return ok
+}
+
+// innermost retorna o provider concreto por trás dos decorators.
+func innermost(p Provider) Provider {
+	for p != nil {
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	return p
+}
+
+// innerCompletionWithTools é usado pelos decorators para repassar a chamada
+// ao provider envolvido.
+func innerCompletionWithTools(ctx context.Context, inner Provider, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	tc, ok := inner.(ToolCaller)
+	if !ok {
+		return nil, ErrToolsNotSupported
+	}
+	return tc.CompletionWithTools(ctx, systemPrompt, userPrompt, tools)
+}
+
+// CompleteWithTools oferece as ferramentas ao modelo e retorna apenas chamadas
+// válidas segundo o schema de cada ferramenta. Usa tool calling nativo quando
+// o provider suporta; caso contrário descreve as ferramentas no system prompt
+// e extrai as chamadas do texto.
+func CompleteWithTools(ctx context.Context, p Provider, systemPrompt, userPrompt string, tools []ToolDefinition) (*ToolResponse, error) {
+	var resp *ToolResponse
+	if tc, ok := p.(ToolCaller); ok {
+		native, err := tc.CompletionWithTools(ctx, systemPrompt, userPrompt, tools)
+		if err != nil && !errors.Is(err, ErrToolsNotSupported) {
+			return nil, err
+		}
+		if err == nil {
+			native.Native = true
+			resp = native
+		}
+	}
+
+	if resp == nil {
+		text, err := p.Completion(ctx, systemPrompt+"\n\n"+FormatToolsPrompt(tools), userPrompt)
+		if err != nil {
+			return nil, err
+		}
+		calls, remaining := ParseTextToolCalls(text)
+		resp = &ToolResponse{Content: remaining, ToolCalls: calls}
+	}
+
+	valid := resp.ToolCalls[:0]
+	for _, call := range resp.ToolCalls {
+		if err := ValidateToolCall(tools, call); err != nil {
+			slog.Warn("tool call invalida descartada", "tool", call.Name, "erro", err)
+			continue
+		}
+		valid = append(valid, call)
+	}
+	resp.ToolCalls = valid
+	return resp, nil
+}
+
+// ValidateToolCall verifica se a chamada referencia uma ferramenta oferecida,
+// traz os argumentos obrigatórios e respeita os tipos e enums do schema.
+func ValidateToolCall(tools []ToolDefinition, call ToolCall) error {
+	var def *ToolDefinition
+	for i := range tools {
+		if tools[i].Name == call.Name {
+			def = &tools[i]
+			break
+		}
+	}
+	if def == nil {
+		return fmt.Errorf("ferramenta '%s' desconhecida", call.Name)
+	}
+
+	for _, name := range def.Parameters.Required {
+		v, ok := call.Arguments[name]
+		if !ok || v == nil || v == "" {
+			return fmt.Errorf("argumento obrigatorio '%s' ausente em %s", name, call.Name)
+		}
+	}
+
+	for name, value := range call.Arguments {
+		prop, ok := def.Parameters.Properties[name]
+		if !ok {
+			return fmt.Errorf("argumento '%s' nao existe em %s", name, call.Name)
+		}
+		if !matchesSchemaType(prop.Type, value) {
+			return fmt.Errorf("argumento '%s' de %s deveria ser %s", name, call.Name, prop.Type)
+		}
+		if len(prop.Enum) > 0 {
+			s, _ := value.(string)
+			if !containsString(prop.Enum, s) {
+				return fmt.Errorf("argumento '%s' de %s deve ser um de: %s", name, call.Name, strings.Join(prop.Enum, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func matchesSchemaType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StringArgs retorna os argumentos como strings, para ferramentas que recebem
+// map[string]string.
+func (c ToolCall) StringArgs() map[string]string {
+	args := make(map[string]string, len(c.Arguments))
+	for k, v := range c.Arguments {
+		switch val := v.(type) {
+		case string:
+			args[k] = val
+		case nil:
+		default:
+			data, _ := json.Marshal(val)
+			args[k] = string(data)
+		}
+	}
+	return args
+}
+
+// FormatToolsPrompt descreve as ferramentas para o protocolo em texto, usado
+// com providers sem tool calling nativo.
+func FormatToolsPrompt(tools []ToolDefinition) string {
+	var sb strings.Builder
+	sb.WriteString("## Ferramentas Disponíveis\n\n")
+	sb.WriteString("Para executar uma ferramenta, responda com um bloco JSON:\n")
+	sb.WriteString("```json\n{\"tool\": \"nome_da_ferramenta\", \"params\": {\"chave\": \"valor\"}}\n```\n\n")
+	sb.WriteString("Só use ferramentas quando necessário. Responda diretamente quando possível.\n\n")
+
+	for _, tool := range tools {
+		sb.WriteString(fmt.Sprintf("### %s\n%s\n", tool.Name, tool.Description))
+		if len(tool.Parameters.Properties) > 0 {
+			sb.WriteString("Parâmetros:\n")
+			names := make([]string, 0, len(tool.Parameters.Properties))
+			for name := range tool.Parameters.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				prop := tool.Parameters.Properties[name]
+				req := "opcional"
+				if containsString(tool.Parameters.Required, name) {
+					req = "obrigatório"
+				}
+				sb.WriteString(fmt.Sprintf("- `%s` (%s, %s): %s\n", name, prop.Type, req, prop.Description))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ParseTextToolCalls extrai chamadas {"tool": ..., "params": {...}} do texto,
+// dentro ou fora de code fences. Retorna as chamadas e o texto restante.
+func ParseTextToolCalls(text string) ([]ToolCall, string) {
+	var calls []ToolCall
+	var remaining strings.Builder
+
+	for i := 0; i < len(text); {
+		start := strings.IndexByte(text[i:], '{')
+		if start < 0 {
+			remaining.WriteString(text[i:])
+			break
+		}
+		start += i
+
+		dec := json.NewDecoder(strings.NewReader(text[start:]))
+		var obj struct {
+			Tool   string                 `json:"tool"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := dec.Decode(&obj); err != nil || obj.Tool == "" {
+			remaining.WriteString(text[i : start+1])
+			i = start + 1
+			continue
+		}
+
+		remaining.WriteString(text[i:start])
+		calls = append(calls, ToolCall{Name: obj.Tool, Arguments: obj.Params})
+		i = start + int(dec.InputOffset())
+	}
+
+	return calls, strings.TrimSpace(stripEmptyFences(remaining.String()))
+}
+
+// stripEmptyFences remove code fences que ficaram vazios após extrair as chamadas.
+func stripEmptyFences(text string) string {
+	for _, fence := range []string{"```json\n\n```", "```json\n```", "```\n\n```", "```\n```", "```json```", "``````"} {
+		text = strings.ReplaceAll(text, fence, "")
+	}
+	return text
+}
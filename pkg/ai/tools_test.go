@@ -0,0 +1,275 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToolDefs retorna as ferramentas usadas nos testes de tool calling.
+func testToolDefs() []ToolDefinition {
+	return []ToolDefinition{
+		{
+			Name:        "kubectl_logs",
+			Description: "Busca logs de um pod",
+			Parameters: ToolSchema{
+				Type: "object",
+				Properties: map[string]ToolProperty{
+					"pod":       {Type: "string", Description: "Nome do pod"},
+					"namespace": {Type: "string"},
+					"tail":      {Type: "integer"},
+					"level":     {Type: "string", Enum: []string{"info", "error"}},
+				},
+				Required: []string{"pod"},
+			},
+		},
+		{Name: "cluster_status", Description: "Resumo do cluster", Parameters: ToolSchema{Type: "object"}},
+	}
+}
+
+// toolCallerMock é um Provider com tool calling nativo.
+type toolCallerMock struct {
+	mockProvider
+	toolsFunc func(ctx context.Context, sys, usr string, tools []ToolDefinition) (*ToolResponse, error)
+}
+
+func (m *toolCallerMock) CompletionWithTools(ctx context.Context, sys, usr string, tools []ToolDefinition) (*ToolResponse, error) {
+	return m.toolsFunc(ctx, sys, usr, tools)
+}
+
+// ─── Validação ──────────────────────────────────────────────────────────────
+
+func TestValidateToolCall(t *testing.T) {
+	defs := testToolDefs()
+	tests := []struct {
+		name    string
+		call    ToolCall
+		wantErr string
+	}{
+		{"valida", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "tail": float64(50), "level": "error"}}, ""},
+		{"sem argumentos", ToolCall{Name: "cluster_status"}, ""},
+		{"ferramenta desconhecida", ToolCall{Name: "rm_rf"}, "desconhecida"},
+		{"obrigatorio ausente", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"namespace": "prod"}}, "obrigatorio 'pod'"},
+		{"argumento extra", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "force": true}}, "'force' nao existe"},
+		{"tipo errado", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "tail": "50"}}, "deveria ser integer"},
+		{"inteiro fracionado", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "tail": 1.5}}, "deveria ser integer"},
+		{"fora do enum", ToolCall{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "level": "debug"}}, "deve ser um de"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateToolCall(defs, tt.call)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestToolCall_StringArgs(t *testing.T) {
+	call := ToolCall{Arguments: map[string]interface{}{"pod": "api", "tail": float64(50), "follow": true, "empty": nil}}
+	assert.Equal(t, map[string]string{"pod": "api", "tail": "50", "follow": "true"}, call.StringArgs())
+}
+
+// ─── Protocolo em texto ─────────────────────────────────────────────────────
+
+func TestParseTextToolCalls(t *testing.T) {
+	text := "Vou verificar os logs.\n```json\n{\"tool\": \"kubectl_logs\", \"params\": {\"pod\": \"api\", \"tail\": 20}}\n```\n" +
+		`E o cluster: {"tool":"cluster_status","params":{}} pronto. {"outro": "json"}`
+
+	calls, remaining := ParseTextToolCalls(text)
+	require.Len(t, calls, 2)
+	assert.Equal(t, "kubectl_logs", calls[0].Name)
+	assert.Equal(t, map[string]interface{}{"pod": "api", "tail": float64(20)}, calls[0].Arguments)
+	assert.Equal(t, "cluster_status", calls[1].Name)
+	assert.Equal(t, "Vou verificar os logs.\n\nE o cluster:  pronto. {\"outro\": \"json\"}", remaining)
+}
+
+func TestParseTextToolCalls_SemChamadas(t *testing.T) {
+	calls, remaining := ParseTextToolCalls("  O pod esta saudavel {sem json valido  ")
+	assert.Empty(t, calls)
+	assert.Equal(t, "O pod esta saudavel {sem json valido", remaining)
+}
+
+func TestFormatToolsPrompt(t *testing.T) {
+	prompt := FormatToolsPrompt(testToolDefs())
+	assert.Contains(t, prompt, `{"tool": "nome_da_ferramenta"`)
+	assert.Contains(t, prompt, "### kubectl_logs")
+	assert.Contains(t, prompt, "- `pod` (string, obrigatório): Nome do pod")
+	assert.Contains(t, prompt, "- `tail` (integer, opcional)")
+}
+
+// ─── CompleteWithTools ──────────────────────────────────────────────────────
+
+func TestCompleteWithTools_Nativo(t *testing.T) {
+	p := &toolCallerMock{toolsFunc: func(_ context.Context, sys, usr string, tools []ToolDefinition) (*ToolResponse, error) {
+		assert.Equal(t, "system", sys)
+		assert.Len(t, tools, 2)
+		return &ToolResponse{ToolCalls: []ToolCall{
+			{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api"}},
+			{Name: "kubectl_logs", Arguments: map[string]interface{}{}}, // inválida: sem pod
+		}}, nil
+	}}
+
+	resp, err := CompleteWithTools(context.Background(), p, "system", "logs do api", testToolDefs())
+	require.NoError(t, err)
+	assert.True(t, resp.Native)
+	require.Len(t, resp.ToolCalls, 1, "chamadas inválidas devem ser descartadas")
+	assert.Equal(t, "api", resp.ToolCalls[0].Arguments["pod"])
+}
+
+func TestCompleteWithTools_FallbackTexto(t *testing.T) {
+	var gotSystem string
+	p := &mockProvider{completionFunc: func(_ context.Context, sys, _ string) (string, error) {
+		gotSystem = sys
+		return `{"tool": "kubectl_logs", "params": {"pod": "api"}}`, nil
+	}}
+
+	resp, err := CompleteWithTools(context.Background(), p, "system", "logs do api", testToolDefs())
+	require.NoError(t, err)
+	assert.False(t, resp.Native)
+	assert.True(t, strings.HasPrefix(gotSystem, "system\n\n## Ferramentas Disponíveis"))
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "kubectl_logs", resp.ToolCalls[0].Name)
+}
+
+func TestCompleteWithTools_DecoratorSemSuporte(t *testing.T) {
+	// Provider sem tool calling nativo envolvido pelos decorators cai no texto
+	p := wrapProvider(&mockProvider{name: "cli", completionFunc: func(_ context.Context, _, _ string) (string, error) {
+		return "Sem ferramenta.", nil
+	}}, "test-model")
+
+	assert.False(t, SupportsTools(p))
+	resp, err := CompleteWithTools(context.Background(), p, "system", "oi", testToolDefs())
+	require.NoError(t, err)
+	assert.False(t, resp.Native)
+	assert.Equal(t, "Sem ferramenta.", resp.Content)
+	assert.Empty(t, resp.ToolCalls)
+}
+
+func TestCompleteWithTools_ErroNativo(t *testing.T) {
+	p := &toolCallerMock{toolsFunc: func(_ context.Context, _, _ string, _ []ToolDefinition) (*ToolResponse, error) {
+		return nil, &APIError{Provider: "openai", StatusCode: 401, Body: "invalid key"}
+	}}
+	_, err := CompleteWithTools(context.Background(), p, "system", "oi", testToolDefs())
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr), "erros que não são de capacidade não caem no texto")
+}
+
+func TestSupportsTools(t *testing.T) {
+	assert.True(t, SupportsTools(wrapProvider(&OpenAIProvider{APIKey: "k", Model: "gpt-4o-mini"}, "gpt-4o-mini")))
+	assert.True(t, SupportsTools(&OllamaProvider{}))
+	assert.False(t, SupportsTools(NewClaudeCLIProvider()))
+	assert.False(t, SupportsTools(nil))
+}
+
+// ─── Providers ──────────────────────────────────────────────────────────────
+
+func TestOpenAIProvider_CompletionWithTools(t *testing.T) {
+	server, p := newOpenAITestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		tools := req["tools"].([]interface{})
+		require.Len(t, tools, 2)
+		fn := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+		assert.Equal(t, "kubectl_logs", fn["name"])
+		assert.Equal(t, "object", fn["parameters"].(map[string]interface{})["type"])
+
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"kubectl_logs","arguments":"{\"pod\":\"api\",\"tail\":10}"}}]}}]}`))
+	})
+	defer server.Close()
+
+	resp, err := CompleteWithTools(context.Background(), wrapProvider(p, p.Model), "system", "logs", testToolDefs())
+	require.NoError(t, err)
+	assert.True(t, resp.Native)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "call_1", resp.ToolCalls[0].ID)
+	assert.Equal(t, float64(10), resp.ToolCalls[0].Arguments["tail"])
+}
+
+func TestOpenAIProvider_CompletionWithTools_ArgumentosInvalidos(t *testing.T) {
+	server, p := newOpenAITestServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"tool_calls":[{"id":"c","function":{"name":"kubectl_logs","arguments":"{pod:"}}]}}]}`))
+	})
+	defer server.Close()
+
+	_, err := p.CompletionWithTools(context.Background(), "s", "u", testToolDefs())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "argumentos invalidos")
+}
+
+func TestGeminiProvider_CompletionWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, ":generateContent")
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		decls := req["tools"].([]interface{})[0].(map[string]interface{})["functionDeclarations"].([]interface{})
+		require.Len(t, decls, 2)
+		_, hasParams := decls[1].(map[string]interface{})["parameters"]
+		assert.False(t, hasParams, "ferramenta sem argumentos não envia schema vazio")
+		assert.NotNil(t, req["systemInstruction"])
+
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"Verificando. "},{"functionCall":{"name":"kubectl_logs","args":{"pod":"api"}}}]}}]}`))
+	}))
+	defer server.Close()
+
+	p := &GeminiProvider{APIKey: "k", Model: "gemini-2.5-flash", BaseURL: server.URL}
+	resp, err := p.CompletionWithTools(context.Background(), "s", "u", testToolDefs())
+	require.NoError(t, err)
+	assert.Equal(t, "Verificando. ", resp.Content)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "kubectl_logs", resp.ToolCalls[0].Name)
+	assert.Equal(t, "api", resp.ToolCalls[0].Arguments["pod"])
+}
+
+func TestOllamaProvider_CompletionWithTools(t *testing.T) {
+	server, p := newOllamaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		var req ollamaChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.False(t, req.Stream)
+		require.Len(t, req.Tools, 2)
+
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"cluster_status","arguments":{}}}]},"prompt_eval_count":12,"eval_count":3}`))
+	})
+	defer server.Close()
+	p.modelConfigured = true
+
+	resp, err := p.CompletionWithTools(context.Background(), "s", "u", testToolDefs())
+	require.NoError(t, err)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "cluster_status", resp.ToolCalls[0].Name)
+}
+
+func TestOllamaProvider_CompletionWithTools_ModeloSemSuporte(t *testing.T) {
+	server, p := newOllamaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"registry.ollama.ai/library/gemma:latest does not support tools"}`))
+		case "/api/generate":
+			_, _ = w.Write([]byte(`{"response":"{\"tool\": \"cluster_status\", \"params\": {}}"}`))
+		}
+	})
+	defer server.Close()
+	p.modelConfigured = true
+
+	_, err := p.CompletionWithTools(context.Background(), "s", "u", testToolDefs())
+	assert.ErrorIs(t, err, ErrToolsNotSupported)
+
+	// Através dos decorators, cai no protocolo em texto sem retentar
+	resp, err := CompleteWithTools(context.Background(), wrapProvider(p, p.Model), "s", "u", testToolDefs())
+	require.NoError(t, err)
+	assert.False(t, resp.Native)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "cluster_status", resp.ToolCalls[0].Name)
+}
@@ -0,0 +1,208 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UsageRecord é uma linha do ledger de uso: uma chamada de IA com tokens e
+// custo estimado.
+type UsageRecord struct {
+	Time             time.Time `json:"time"`
+	Plugin           string    `json:"plugin"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Operation        string    `json:"operation"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	// CacheHit marca respostas do cache de completions; SavedUSD é o custo
+	// que a chamada teria sem o cache.
+	CacheHit bool    `json:"cache_hit,omitempty"`
+	SavedUSD float64 `json:"saved_usd,omitempty"`
+}
+
+// UsageLedger persiste o uso de IA em arquivos JSONL mensais (AAAA-MM.jsonl).
+type UsageLedger struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// DefaultUsageLedger retorna o ledger em ~/.yby/ai/usage, ou no diretório de
+// YBY_AI_USAGE_DIR. Retorna nil se o diretório home não puder ser resolvido.
+func DefaultUsageLedger() *UsageLedger {
+	if dir := os.Getenv("YBY_AI_USAGE_DIR"); dir != "" {
+		return &UsageLedger{Dir: dir}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return &UsageLedger{Dir: filepath.Join(home, ".yby", "ai", "usage")}
+}
+
+func (l *UsageLedger) monthFile(t time.Time) string {
+	return filepath.Join(l.Dir, t.Format("2006-01")+".jsonl")
+}
+
+// Record adiciona um registro ao arquivo do mês correspondente.
+func (l *UsageLedger) Record(rec UsageRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório do ledger: %w", err)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar registro de uso: %w", err)
+	}
+	f, err := os.OpenFile(l.monthFile(rec.Time), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("falha ao abrir ledger: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("falha ao gravar ledger: %w", err)
+	}
+	return nil
+}
+
+// Load retorna os registros com Time em [from, to), em ordem cronológica.
+// Linhas corrompidas são ignoradas.
+func (l *UsageLedger) Load(from, to time.Time) ([]UsageRecord, error) {
+	var records []UsageRecord
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+	for ; month.Before(to); month = month.AddDate(0, 1, 0) {
+		f, err := os.Open(l.monthFile(month))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler ledger: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r UsageRecord
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				continue
+			}
+			if !r.Time.Before(from) && r.Time.Before(to) {
+				records = append(records, r)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("falha ao ler ledger: %w", err)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// Spent retorna o custo acumulado no dia e no mês de now.
+func (l *UsageLedger) Spent(now time.Time) (day, month float64, err error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	records, err := l.Load(monthStart, now.Add(time.Second))
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, r := range records {
+		month += r.CostUSD
+		if !r.Time.Before(dayStart) {
+			day += r.CostUSD
+		}
+	}
+	return day, month, nil
+}
+
+// UsageSummary agrega registros do ledger por uma chave (dia, plugin, modelo...).
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CacheHits        int     `json:"cache_hits"`
+	SavedUSD         float64 `json:"saved_usd"`
+}
+
+// UsageGroupings são os agrupamentos aceitos por SummarizeUsage.
+var UsageGroupings = []string{"day", "plugin", "model", "provider", "operation"}
+
+// SummarizeUsage agrupa os registros por "day", "plugin", "model", "provider"
+// ou "operation". Dias saem em ordem cronológica; as demais chaves por custo.
+func SummarizeUsage(records []UsageRecord, by string) ([]UsageSummary, error) {
+	keyOf := map[string]func(UsageRecord) string{
+		"day":       func(r UsageRecord) string { return r.Time.Local().Format("2006-01-02") },
+		"plugin":    func(r UsageRecord) string { return r.Plugin },
+		"model":     func(r UsageRecord) string { return r.Model },
+		"provider":  func(r UsageRecord) string { return r.Provider },
+		"operation": func(r UsageRecord) string { return r.Operation },
+	}[by]
+	if keyOf == nil {
+		return nil, fmt.Errorf("agrupamento '%s' invalido (valores aceitos: %s)", by, strings.Join(UsageGroupings, ", "))
+	}
+
+	index := map[string]*UsageSummary{}
+	var out []*UsageSummary
+	for _, r := range records {
+		key := keyOf(r)
+		if key == "" {
+			key = "-"
+		}
+		s, ok := index[key]
+		if !ok {
+			s = &UsageSummary{Key: key}
+			index[key] = s
+			out = append(out, s)
+		}
+		s.Calls++
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.TotalTokens += r.TotalTokens
+		s.CostUSD += r.CostUSD
+		s.SavedUSD += r.SavedUSD
+		if r.CacheHit {
+			s.CacheHits++
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if by == "day" {
+			return out[i].Key < out[j].Key
+		}
+		if out[i].CostUSD != out[j].CostUSD {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].TotalTokens > out[j].TotalTokens
+	})
+	summaries := make([]UsageSummary, len(out))
+	for i, s := range out {
+		summaries[i] = *s
+	}
+	return summaries, nil
+}
+
+// usagePlugin identifica quem fez a chamada: YBY_AI_PLUGIN, o nome do plugin
+// (binário yby-plugin-<nome>) ou "yby" para o CLI principal.
+func usagePlugin() string {
+	if name := os.Getenv("YBY_AI_PLUGIN"); name != "" {
+		return name
+	}
+	base := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	if name, ok := strings.CutPrefix(base, "yby-plugin-"); ok {
+		return name
+	}
+	return "yby"
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain isola o ledger de uso e o cache de completions para que testes que
+// passam por wrapProvider não gravem em ~/.yby/ai.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "yby-ai-usage-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("YBY_AI_USAGE_DIR", filepath.Join(dir, "usage"))
+	os.Setenv("YBY_AI_CACHE_DIR", filepath.Join(dir, "cache"))
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestUsageLedger_RecordELoad(t *testing.T) {
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	base := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+
+	require.NoError(t, ledger.Record(UsageRecord{Time: base, Plugin: "bard", Model: "gpt-4o", CostUSD: 0.5}))
+	require.NoError(t, ledger.Record(UsageRecord{Time: base.Add(2 * time.Hour), Plugin: "sentinel", Model: "gpt-4o-mini", CostUSD: 0.1}))
+
+	_, err := os.Stat(filepath.Join(ledger.Dir, "2026-03.jsonl"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(ledger.Dir, "2026-04.jsonl"))
+	require.NoError(t, err)
+
+	// Linhas corrompidas são ignoradas
+	f, err := os.OpenFile(filepath.Join(ledger.Dir, "2026-04.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	f.WriteString("{quebrado\n")
+	f.Close()
+
+	records, err := ledger.Load(base.AddDate(0, 0, -1), base.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "bard", records[0].Plugin)
+	assert.Equal(t, "sentinel", records[1].Plugin)
+
+	records, err = ledger.Load(base.Add(time.Hour), base.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestUsageLedger_LoadSemArquivos(t *testing.T) {
+	ledger := &UsageLedger{Dir: filepath.Join(t.TempDir(), "inexistente")}
+	records, err := ledger.Load(time.Now().AddDate(0, -2, 0), time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestUsageLedger_Spent(t *testing.T) {
+	ledger := &UsageLedger{Dir: t.TempDir()}
+	now := time.Date(2026, 5, 10, 15, 0, 0, 0, time.UTC)
+
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.AddDate(0, -1, 0), CostUSD: 9}))
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.AddDate(0, 0, -3), CostUSD: 2}))
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.Add(-time.Hour), CostUSD: 0.25}))
+	require.NoError(t, ledger.Record(UsageRecord{Time: now.Add(-2 * time.Hour), CostUSD: 0.25}))
+
+	day, month, err := ledger.Spent(now)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, day, 1e-9)
+	assert.InDelta(t, 2.5, month, 1e-9)
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	d1 := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	d2 := d1.AddDate(0, 0, 1)
+	records := []UsageRecord{
+		{Time: d2, Plugin: "bard", Model: "gpt-4o", TotalTokens: 100, CostUSD: 1},
+		{Time: d1, Plugin: "sentinel", Model: "gpt-4o-mini", TotalTokens: 50, CostUSD: 0.1},
+		{Time: d2, Plugin: "bard", Model: "gpt-4o-mini", TotalTokens: 10, CostUSD: 0.2},
+		{Time: d1, Model: "llama3", TotalTokens: 500},
+	}
+
+	byDay, err := SummarizeUsage(records, "day")
+	require.NoError(t, err)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2026-05-01", byDay[0].Key)
+	assert.Equal(t, 2, byDay[0].Calls)
+	assert.Equal(t, 550, byDay[0].TotalTokens)
+	assert.InDelta(t, 1.2, byDay[1].CostUSD, 1e-9)
+
+	byModel, err := SummarizeUsage(records, "model")
+	require.NoError(t, err)
+	require.Len(t, byModel, 3)
+	assert.Equal(t, "gpt-4o", byModel[0].Key)
+	assert.Equal(t, "gpt-4o-mini", byModel[1].Key)
+	assert.Equal(t, "llama3", byModel[2].Key)
+
+	byPlugin, err := SummarizeUsage(records, "plugin")
+	require.NoError(t, err)
+	assert.Equal(t, "bard", byPlugin[0].Key)
+	assert.Equal(t, "-", byPlugin[2].Key)
+
+	_, err = SummarizeUsage(records, "semana")
+	assert.ErrorContains(t, err, "invalido")
+}
+
+func TestUsagePlugin(t *testing.T) {
+	t.Setenv("YBY_AI_PLUGIN", "synapstor")
+	assert.Equal(t, "synapstor", usagePlugin())
+
+	t.Setenv("YBY_AI_PLUGIN", "")
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"/usr/local/bin/yby-plugin-bard"}
+	assert.Equal(t, "bard", usagePlugin())
+	os.Args = []string{"yby"}
+	assert.Equal(t, "yby", usagePlugin())
+}
@@ -7,9 +7,13 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	ybyerrors "github.com/casheiro/yby-cli/pkg/errors"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -18,6 +22,46 @@ type RateLimitConfig struct {
 	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
 }
 
+// BudgetConfig armazena limites de gasto com IA, em USD, apurados no ledger
+// de uso (~/.yby/ai/usage). Limite zero desativa o período.
+type BudgetConfig struct {
+	DailyUSD   float64 `mapstructure:"daily_usd"`
+	MonthlyUSD float64 `mapstructure:"monthly_usd"`
+	// OnExceed define a ação ao estourar o limite: "stop" (padrão) interrompe
+	// as chamadas; "downgrade" troca para o modelo de Downgrade do provider.
+	OnExceed string `mapstructure:"on_exceed"`
+	// Downgrade mapeia provider -> modelo mais barato (ex: openai: gpt-4o-mini).
+	Downgrade map[string]string `mapstructure:"downgrade"`
+}
+
+// CacheConfig configura o cache em disco de respostas de IA (~/.yby/ai/cache).
+// Desativado por padrão; o TTL aceita durações como "24h" ou "30m".
+type CacheConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	TTL       time.Duration `mapstructure:"ttl"`
+	MaxSizeMB int           `mapstructure:"max_size_mb"`
+}
+
+// OpenAICompatibleConfig configura o provider openai-compatible, usado com
+// endpoints que implementam a API da OpenAI (vLLM, LM Studio, LocalAI, Azure OpenAI).
+// Modelo e embedding vêm de ai.models e ai.embedding com a chave "openai-compatible".
+type OpenAICompatibleConfig struct {
+	// BaseURL do endpoint (ex: http://vllm:8000/v1). No Azure, use
+	// https://<recurso>.openai.azure.com/openai/deployments/{deployment}.
+	BaseURL string `mapstructure:"base_url"`
+	// APIKeyEnv é a variável de ambiente com a chave (padrão OPENAI_COMPATIBLE_API_KEY).
+	// Endpoints locais podem dispensar chave.
+	APIKeyEnv string `mapstructure:"api_key_env"`
+	// AuthHeader é o header da chave: "Authorization" (Bearer, padrão) ou "api-key" (Azure).
+	AuthHeader string `mapstructure:"auth_header"`
+	// APIVersion é enviado como query param api-version (obrigatório no Azure).
+	APIVersion string            `mapstructure:"api_version"`
+	Headers    map[string]string `mapstructure:"headers"`
+	// Deployments mapeia modelo -> nome do deployment. O nome substitui
+	// {deployment} na BaseURL ou, sem o marcador, o campo model da requisição.
+	Deployments map[string]string `mapstructure:"deployments"`
+}
+
 // AIConfig armazena configuração do subsistema de IA.
 type AIConfig struct {
 	Provider  string            `mapstructure:"provider"`
@@ -27,6 +71,10 @@ type AIConfig struct {
 	Language  string            `mapstructure:"language"`
 	Priority  []string          `mapstructure:"priority"`
 	RateLimit RateLimitConfig   `mapstructure:"rate_limit"`
+	Budget    BudgetConfig      `mapstructure:"budget"`
+	Cache     CacheConfig       `mapstructure:"cache"`
+
+	OpenAICompatible OpenAICompatibleConfig `mapstructure:"openai_compatible"`
 }
 
 // LogConfig armazena configuração de logging.
@@ -72,6 +120,8 @@ func bindEnvVars(v *viper.Viper) {
 	_ = v.BindEnv("ai.model", "YBY_AI_MODEL")
 	_ = v.BindEnv("ai.language", "YBY_AI_LANGUAGE")
 	_ = v.BindEnv("ai.priority", "YBY_AI_PRIORITY")
+	_ = v.BindEnv("ai.cache.enabled", "YBY_AI_CACHE")
+	_ = v.BindEnv("ai.openai_compatible.base_url", "YBY_AI_OPENAI_COMPATIBLE_BASE_URL")
 	_ = v.BindEnv("log.level", "YBY_LOG_LEVEL")
 	_ = v.BindEnv("log.format", "YBY_LOG_FORMAT")
 	_ = v.BindEnv("telemetry.enabled", "YBY_TELEMETRY_ENABLED")
@@ -104,7 +154,11 @@ func Load() (*Config, error) {
 	}
 
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		dateToStringHook,
+	))); err != nil {
 		return nil, ybyerrors.Wrap(err, ybyerrors.ErrCodeConfig, "falha ao deserializar configuração")
 	}
 
@@ -115,15 +169,24 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// dateToStringHook preserva como texto datas YAML sem aspas em campos string
+// (ex: api_version: 2024-06-01 do Azure OpenAI), que o parser entrega como time.Time.
+func dateToStringHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if t, ok := data.(time.Time); ok && to.Kind() == reflect.String {
+		return t.Format("2006-01-02"), nil
+	}
+	return data, nil
+}
+
 // Validate verifica se os valores de configuração são válidos.
 func (c *Config) Validate() error {
 	validProviders := map[string]bool{
 		"": true, "auto": true, "ollama": true, "gemini": true, "openai": true,
-		"claude-cli": true, "gemini-cli": true, "bedrock": true,
+		"claude-cli": true, "gemini-cli": true, "bedrock": true, "openai-compatible": true,
 	}
 	if !validProviders[c.AI.Provider] {
 		return ybyerrors.New(ybyerrors.ErrCodeConfig,
-			fmt.Sprintf("ai.provider inválido: %q (valores aceitos: ollama, gemini, openai, claude-cli, gemini-cli, bedrock)", c.AI.Provider))
+			fmt.Sprintf("ai.provider inválido: %q (valores aceitos: ollama, gemini, openai, openai-compatible, claude-cli, gemini-cli, bedrock)", c.AI.Provider))
 	}
 
 	// Validar cada item da lista de prioridade
@@ -133,10 +196,29 @@ func (c *Config) Validate() error {
 		}
 		if !validProviders[p] {
 			return ybyerrors.New(ybyerrors.ErrCodeConfig,
-				fmt.Sprintf("ai.priority contém provider inválido: %q (valores aceitos: ollama, gemini, openai, claude-cli, gemini-cli, bedrock)", p))
+				fmt.Sprintf("ai.priority contém provider inválido: %q (valores aceitos: ollama, gemini, openai, openai-compatible, claude-cli, gemini-cli, bedrock)", p))
 		}
 	}
 
+	if c.AI.Budget.DailyUSD < 0 || c.AI.Budget.MonthlyUSD < 0 {
+		return ybyerrors.New(ybyerrors.ErrCodeConfig, "ai.budget: limites não podem ser negativos")
+	}
+	validActions := map[string]bool{"": true, "stop": true, "downgrade": true}
+	if !validActions[c.AI.Budget.OnExceed] {
+		return ybyerrors.New(ybyerrors.ErrCodeConfig,
+			fmt.Sprintf("ai.budget.on_exceed inválido: %q (valores aceitos: stop, downgrade)", c.AI.Budget.OnExceed))
+	}
+
+	if c.AI.Cache.TTL < 0 || c.AI.Cache.MaxSizeMB < 0 {
+		return ybyerrors.New(ybyerrors.ErrCodeConfig, "ai.cache: ttl e max_size_mb não podem ser negativos")
+	}
+
+	if oc := c.AI.OpenAICompatible; oc.BaseURL != "" &&
+		!strings.HasPrefix(oc.BaseURL, "http://") && !strings.HasPrefix(oc.BaseURL, "https://") {
+		return ybyerrors.New(ybyerrors.ErrCodeConfig,
+			fmt.Sprintf("ai.openai_compatible.base_url inválido: %q (deve começar com http:// ou https://)", oc.BaseURL))
+	}
+
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLevels[c.Log.Level] {
 		return ybyerrors.New(ybyerrors.ErrCodeConfig,
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoad_DefaultValues(t *testing.T) {
@@ -268,6 +269,52 @@ func TestValidate_LogFormatInvalido(t *testing.T) {
 	}
 }
 
+func TestValidate_BudgetInvalido(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AI.Budget.OnExceed = "ignore"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() deveria falhar para ai.budget.on_exceed inválido")
+	}
+
+	cfg = DefaultConfig()
+	cfg.AI.Budget.DailyUSD = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() deveria falhar para limite negativo")
+	}
+}
+
+func TestLoad_BudgetDoArquivo(t *testing.T) {
+	ResetGlobal()
+	tmpHome := t.TempDir()
+	configDir := filepath.Join(tmpHome, ".yby")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `
+ai:
+  budget:
+    daily_usd: 2.5
+    monthly_usd: 40
+    on_exceed: downgrade
+    downgrade:
+      openai: gpt-4o-mini
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() retornou erro inesperado: %v", err)
+	}
+	b := cfg.AI.Budget
+	if b.DailyUSD != 2.5 || b.MonthlyUSD != 40 || b.OnExceed != "downgrade" || b.Downgrade["openai"] != "gpt-4o-mini" {
+		t.Errorf("ai.budget inesperado: %+v", b)
+	}
+}
+
 func TestLoad_ConfigInvalida(t *testing.T) {
 	ResetGlobal()
 
@@ -337,3 +384,86 @@ func TestGet_RetornaConfig(t *testing.T) {
 		t.Error("Get() deveria retornar o mesmo ponteiro (singleton)")
 	}
 }
+
+func TestValidate_OpenAICompatible(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AI.Provider = "openai-compatible"
+	cfg.AI.OpenAICompatible.BaseURL = "http://vllm:8000/v1"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() retornou erro inesperado: %v", err)
+	}
+
+	cfg.AI.OpenAICompatible.BaseURL = "vllm:8000/v1"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() deveria falhar para base_url sem esquema")
+	}
+}
+
+func TestLoad_OpenAICompatibleDoArquivo(t *testing.T) {
+	ResetGlobal()
+	tmpHome := t.TempDir()
+	configDir := filepath.Join(tmpHome, ".yby")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// api_version sem aspas é lido pelo YAML como data
+	configContent := `
+ai:
+  openai_compatible:
+    base_url: https://recurso.openai.azure.com/openai/deployments/{deployment}
+    auth_header: api-key
+    api_version: 2024-06-01
+    deployments:
+      gpt-4o: prod-gpt4o
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() retornou erro inesperado: %v", err)
+	}
+	oc := cfg.AI.OpenAICompatible
+	if oc.APIVersion != "2024-06-01" || oc.AuthHeader != "api-key" || oc.Deployments["gpt-4o"] != "prod-gpt4o" {
+		t.Errorf("ai.openai_compatible inesperado: %+v", oc)
+	}
+}
+
+func TestLoad_CacheDoArquivo(t *testing.T) {
+	ResetGlobal()
+	tmpHome := t.TempDir()
+	configDir := filepath.Join(tmpHome, ".yby")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `
+ai:
+  cache:
+    enabled: true
+    ttl: 12h
+    max_size_mb: 50
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(configContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() retornou erro inesperado: %v", err)
+	}
+	c := cfg.AI.Cache
+	if !c.Enabled || c.TTL != 12*time.Hour || c.MaxSizeMB != 50 {
+		t.Errorf("ai.cache inesperado: %+v", c)
+	}
+
+	bad := DefaultConfig()
+	bad.AI.Cache.MaxSizeMB = -1
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() deveria falhar para max_size_mb negativo")
+	}
+}
@@ -0,0 +1,125 @@
+package kubemetrics
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PressureThreshold é o percentual do limit a partir do qual o uso é destacado
+// (risco de OOMKilled para memória, throttling para CPU).
+const PressureThreshold = 90
+
+// UsageRow compara o uso de um container com seus requests e limits.
+// Percentuais ficam nil quando o request/limit não está definido ou não há métrica.
+type UsageRow struct {
+	Container            string   `json:"container"`
+	CPUUsage             string   `json:"cpu_usage"`
+	CPURequest           string   `json:"cpu_request,omitempty"`
+	CPULimit             string   `json:"cpu_limit,omitempty"`
+	CPURequestPercent    *int     `json:"cpu_request_pct,omitempty"`
+	CPULimitPercent      *int     `json:"cpu_limit_pct,omitempty"`
+	MemoryUsage          string   `json:"memory_usage"`
+	MemoryRequest        string   `json:"memory_request,omitempty"`
+	MemoryLimit          string   `json:"memory_limit,omitempty"`
+	MemoryRequestPercent *int     `json:"memory_request_pct,omitempty"`
+	MemoryLimitPercent   *int     `json:"memory_limit_pct,omitempty"`
+	Notes                []string `json:"notes,omitempty"`
+}
+
+// CompareUsage monta a tabela de uso vs requests/limits dos containers do pod.
+// Com usage nil (metrics-server indisponível), a tabela traz apenas requests e limits.
+func CompareUsage(pod *corev1.Pod, usage *PodUsage) []UsageRow {
+	rows := make([]UsageRow, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		row := UsageRow{Container: c.Name, CPUUsage: "N/A", MemoryUsage: "N/A"}
+		cpuReq, hasCPUReq := c.Resources.Requests[corev1.ResourceCPU]
+		cpuLim, hasCPULim := c.Resources.Limits[corev1.ResourceCPU]
+		memReq, hasMemReq := c.Resources.Requests[corev1.ResourceMemory]
+		memLim, hasMemLim := c.Resources.Limits[corev1.ResourceMemory]
+		if hasCPUReq {
+			row.CPURequest = FormatCPU(cpuReq)
+		}
+		if hasCPULim {
+			row.CPULimit = FormatCPU(cpuLim)
+		}
+		if hasMemReq {
+			row.MemoryRequest = FormatMemory(memReq)
+		}
+		if hasMemLim {
+			row.MemoryLimit = FormatMemory(memLim)
+		} else {
+			row.Notes = append(row.Notes, "sem limit de memoria")
+		}
+
+		var cu ContainerUsage
+		found := false
+		if usage != nil {
+			cu, found = usage.Container(c.Name)
+		}
+		if found {
+			row.CPUUsage = FormatCPU(cu.CPU)
+			row.MemoryUsage = FormatMemory(cu.Memory)
+			if hasCPUReq {
+				row.CPURequestPercent = percent(cu.CPU.MilliValue(), cpuReq.MilliValue())
+			}
+			if hasCPULim {
+				row.CPULimitPercent = percent(cu.CPU.MilliValue(), cpuLim.MilliValue())
+			}
+			if hasMemReq {
+				row.MemoryRequestPercent = percent(cu.Memory.Value(), memReq.Value())
+			}
+			if hasMemLim {
+				row.MemoryLimitPercent = percent(cu.Memory.Value(), memLim.Value())
+			}
+			if p := row.MemoryLimitPercent; p != nil && *p >= PressureThreshold {
+				row.Notes = append(row.Notes, fmt.Sprintf("memoria a %d%% do limit (risco de OOMKilled)", *p))
+			}
+			if p := row.CPULimitPercent; p != nil && *p >= PressureThreshold {
+				row.Notes = append(row.Notes, fmt.Sprintf("CPU a %d%% do limit (provavel throttling)", *p))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// FormatUsageTable renderiza a tabela de uso vs limits em texto alinhado.
+func FormatUsageTable(rows []UsageRow) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER\tCPU USO\tCPU REQ\tCPU LIMIT\t%LIMIT\tMEM USO\tMEM REQ\tMEM LIMIT\t%LIMIT\tOBS")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Container,
+			r.CPUUsage, orDash(r.CPURequest), orDash(r.CPULimit), formatPercent(r.CPULimitPercent),
+			r.MemoryUsage, orDash(r.MemoryRequest), orDash(r.MemoryLimit), formatPercent(r.MemoryLimitPercent),
+			strings.Join(r.Notes, "; "))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+func percent(value, total int64) *int {
+	if total <= 0 {
+		return nil
+	}
+	p := int(value * 100 / total)
+	return &p
+}
+
+func formatPercent(p *int) string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", *p)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
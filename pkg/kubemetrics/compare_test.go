@@ -0,0 +1,71 @@
+package kubemetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func limitedPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			},
+			{Name: "sidecar"},
+		}},
+	}
+}
+
+func TestCompareUsage(t *testing.T) {
+	usage := &PodUsage{Containers: []ContainerUsage{
+		{Name: "app", CPU: resource.MustParse("190m"), Memory: resource.MustParse("240Mi")},
+		{Name: "sidecar", CPU: resource.MustParse("5m"), Memory: resource.MustParse("16Mi")},
+	}}
+
+	rows := CompareUsage(limitedPod(), usage)
+	require.Len(t, rows, 2)
+
+	app := rows[0]
+	assert.Equal(t, "190m", app.CPUUsage)
+	assert.Equal(t, "200m", app.CPULimit)
+	require.NotNil(t, app.CPULimitPercent)
+	assert.Equal(t, 95, *app.CPULimitPercent)
+	assert.Equal(t, 190, *app.CPURequestPercent)
+	assert.Equal(t, 93, *app.MemoryLimitPercent)
+	assert.Len(t, app.Notes, 2, "memória e CPU acima de %d%% do limit", PressureThreshold)
+
+	sidecar := rows[1]
+	assert.Nil(t, sidecar.CPULimitPercent)
+	assert.Nil(t, sidecar.MemoryLimitPercent)
+	assert.Equal(t, []string{"sem limit de memoria"}, sidecar.Notes)
+}
+
+func TestCompareUsage_SemMetricas(t *testing.T) {
+	rows := CompareUsage(limitedPod(), nil)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "N/A", rows[0].CPUUsage)
+	assert.Equal(t, "256Mi", rows[0].MemoryLimit)
+	assert.Nil(t, rows[0].MemoryLimitPercent)
+}
+
+func TestFormatUsageTable(t *testing.T) {
+	usage := &PodUsage{Containers: []ContainerUsage{{Name: "app", CPU: resource.MustParse("50m"), Memory: resource.MustParse("64Mi")}}}
+	table := FormatUsageTable(CompareUsage(limitedPod(), usage))
+
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "CONTAINER"))
+	assert.Contains(t, lines[1], "25%")
+	assert.Contains(t, lines[2], "sem limit de memoria")
+}
@@ -0,0 +1,128 @@
+// Package kubemetrics lê o uso de CPU e memória de pods e containers da API
+// metrics.k8s.io (metrics-server) e o compara com os requests e limits dos
+// containers.
+package kubemetrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Client lê métricas de uso da API metrics.k8s.io.
+type Client struct {
+	metrics metricsclient.Interface
+}
+
+// New cria o client a partir de um clientset de métricas (permite fakes em testes).
+func New(metrics metricsclient.Interface) *Client {
+	return &Client{metrics: metrics}
+}
+
+// NewForConfig cria o client a partir da mesma configuração REST do clientset Kubernetes.
+func NewForConfig(config *rest.Config) (*Client, error) {
+	mc, err := metricsclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar client de metricas: %w", err)
+	}
+	return New(mc), nil
+}
+
+// ContainerUsage é o uso instantâneo de um container.
+type ContainerUsage struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// PodUsage é o uso de um pod, por container, na janela de coleta do metrics-server.
+type PodUsage struct {
+	Namespace  string
+	Name       string
+	Timestamp  time.Time
+	Window     time.Duration
+	Containers []ContainerUsage
+}
+
+// Total soma o uso de todos os containers do pod.
+func (p PodUsage) Total() (cpu, memory resource.Quantity) {
+	for _, c := range p.Containers {
+		cpu.Add(c.CPU)
+		memory.Add(c.Memory)
+	}
+	return cpu, memory
+}
+
+// Container retorna o uso do container pelo nome.
+func (p PodUsage) Container(name string) (ContainerUsage, bool) {
+	for _, c := range p.Containers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ContainerUsage{}, false
+}
+
+// PodUsage retorna o uso do pod. Falha quando o metrics-server não está
+// instalado ou ainda não coletou o pod.
+func (c *Client) PodUsage(ctx context.Context, namespace, name string) (*PodUsage, error) {
+	m, err := c.metrics.MetricsV1beta1().PodMetricses(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter metricas do pod %s/%s: %w", namespace, name, err)
+	}
+	usage := fromPodMetrics(m)
+	return &usage, nil
+}
+
+// ListPodUsage retorna o uso dos pods do namespace (vazio = todos), ordenados por namespace e nome.
+func (c *Client) ListPodUsage(ctx context.Context, namespace string, opts metav1.ListOptions) ([]PodUsage, error) {
+	list, err := c.metrics.MetricsV1beta1().PodMetricses(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar metricas de pods: %w", err)
+	}
+	result := make([]PodUsage, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, fromPodMetrics(&list.Items[i]))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func fromPodMetrics(m *metricsv1beta1.PodMetrics) PodUsage {
+	usage := PodUsage{
+		Namespace: m.Namespace,
+		Name:      m.Name,
+		Timestamp: m.Timestamp.Time,
+		Window:    m.Window.Duration,
+	}
+	for _, c := range m.Containers {
+		usage.Containers = append(usage.Containers, ContainerUsage{
+			Name:   c.Name,
+			CPU:    c.Usage.Cpu().DeepCopy(),
+			Memory: c.Usage.Memory().DeepCopy(),
+		})
+	}
+	return usage
+}
+
+// FormatCPU formata CPU em millicores (ex: 250m).
+func FormatCPU(q resource.Quantity) string {
+	return fmt.Sprintf("%dm", q.MilliValue())
+}
+
+// FormatMemory formata memória em MiB (ex: 128Mi).
+func FormatMemory(q resource.Quantity) string {
+	return fmt.Sprintf("%dMi", q.Value()/(1024*1024))
+}
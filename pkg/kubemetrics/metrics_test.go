@@ -0,0 +1,109 @@
+package kubemetrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func podMetrics(namespace, name string, containers map[string][2]string) metricsv1beta1.PodMetrics {
+	m := metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Timestamp:  metav1.NewTime(time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)),
+		Window:     metav1.Duration{Duration: 30 * time.Second},
+	}
+	for cname, usage := range containers {
+		m.Containers = append(m.Containers, metricsv1beta1.ContainerMetrics{
+			Name: cname,
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(usage[0]),
+				corev1.ResourceMemory: resource.MustParse(usage[1]),
+			},
+		})
+	}
+	return m
+}
+
+// fakeMetrics responde às chamadas da API de métricas com os itens informados.
+// O tracker do fake não resolve o recurso "pods" do grupo metrics.k8s.io, então
+// Get e List são atendidos por reactors.
+func fakeMetrics(items ...metricsv1beta1.PodMetrics) *fake.Clientset {
+	client := &fake.Clientset{}
+	client.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ns := action.GetNamespace()
+		list := &metricsv1beta1.PodMetricsList{}
+		for _, item := range items {
+			if ns == "" || item.Namespace == ns {
+				list.Items = append(list.Items, item)
+			}
+		}
+		return true, list, nil
+	})
+	client.AddReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get := action.(k8stesting.GetAction)
+		for _, item := range items {
+			if item.Namespace == get.GetNamespace() && item.Name == get.GetName() {
+				return true, item.DeepCopy(), nil
+			}
+		}
+		return true, nil, errors.New("not found")
+	})
+	return client
+}
+
+func TestClient_PodUsage(t *testing.T) {
+	client := New(fakeMetrics(podMetrics("prod", "api", map[string][2]string{"app": {"250m", "200Mi"}, "sidecar": {"50m", "56Mi"}})))
+
+	usage, err := client.PodUsage(context.Background(), "prod", "api")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, usage.Window)
+
+	cpu, mem := usage.Total()
+	assert.Equal(t, "300m", FormatCPU(cpu))
+	assert.Equal(t, "256Mi", FormatMemory(mem))
+
+	app, ok := usage.Container("app")
+	require.True(t, ok)
+	assert.Equal(t, "250m", FormatCPU(app.CPU))
+
+	_, err = client.PodUsage(context.Background(), "prod", "inexistente")
+	assert.Error(t, err)
+}
+
+func TestClient_ListPodUsage(t *testing.T) {
+	client := New(fakeMetrics(
+		podMetrics("prod", "b", map[string][2]string{"app": {"10m", "10Mi"}}),
+		podMetrics("dev", "a", map[string][2]string{"app": {"10m", "10Mi"}}),
+		podMetrics("prod", "a", map[string][2]string{"app": {"10m", "10Mi"}}),
+	))
+
+	all, err := client.ListPodUsage(context.Background(), "", metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "dev", all[0].Namespace)
+	assert.Equal(t, "a", all[1].Name)
+
+	prod, err := client.ListPodUsage(context.Background(), "prod", metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, prod, 2)
+}
+
+func TestClient_ListPodUsage_Indisponivel(t *testing.T) {
+	mc := &fake.Clientset{}
+	mc.AddReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("the server could not find the requested resource")
+	})
+	_, err := New(mc).ListPodUsage(context.Background(), "", metav1.ListOptions{})
+	assert.ErrorContains(t, err, "falha ao listar metricas")
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/casheiro/yby-cli/pkg/cloud"
 	"github.com/casheiro/yby-cli/pkg/services/shared"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -18,6 +19,16 @@ import (
 // ou o kubeconfig padrão (~/.kube/config / KUBECONFIG) como fallback.
 // Quando há configuração cloud, injeta token generator com auto-refresh.
 func GetKubeClient() (*kubernetes.Clientset, error) {
+	config, err := GetRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// GetRESTConfig retorna a configuração REST usada pelo GetKubeClient, para
+// clients de outras APIs (ex: metrics.k8s.io) com as mesmas credenciais.
+func GetRESTConfig() (*rest.Config, error) {
 	var kubeConfigPath, kubeContext string
 
 	if currentContext != nil {
@@ -64,5 +75,5 @@ func GetKubeClient() (*kubernetes.Clientset, error) {
 		}
 	}
 
-	return kubernetes.NewForConfig(config)
+	return config, nil
 }
@@ -7,3 +7,7 @@ import "fmt"
 func GetKubeClient() (interface{}, error) {
 	return nil, fmt.Errorf("kubernetes client not available: build without 'k8s' tag")
 }
+
+func GetRESTConfig() (interface{}, error) {
+	return nil, fmt.Errorf("kubernetes client not available: build without 'k8s' tag")
+}
@@ -17,6 +17,12 @@ func TestGetKubeClient_SemBuildTag(t *testing.T) {
 	assert.Contains(t, err.Error(), "kubernetes client not available")
 }
 
+func TestGetRESTConfig_SemBuildTag(t *testing.T) {
+	config, err := GetRESTConfig()
+	assert.Nil(t, config)
+	assert.Error(t, err)
+}
+
 func TestGetValues_ComContexto(t *testing.T) {
 	// Configura contexto global para testar GetValues com contexto não-nil
 	oldCtx := currentContext
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
+)
+
+// agentConfig aplica ao loop de ferramentas o orçamento de tokens que sobra
+// após o system prompt (que já inclui o histórico).
+func agentConfig(cfg BardConfig, systemPrompt string) agent.Config {
+	c := cfg.Agent
+	c.MaxTokens = TokenBudget{
+		MaxTokens:    cfg.MaxTokens,
+		SystemPrompt: EstimateTokens(systemPrompt),
+	}.Available()
+	return c
+}
+
+// runAgentSteps executa o loop de ferramentas exibindo o plano e cada passo
+// em trace. A resposta final fica para run.Finish, que pode ser repetida em
+// caso de falha sem reexecutar as ferramentas.
+func runAgentSteps(ctx context.Context, a *agent.Agent, question, directInput string, trace io.Writer) (*agent.Run, error) {
+	run := a.Start(question, directInput)
+	shown := 0
+	for !run.Done {
+		if err := run.Next(ctx); err != nil {
+			return nil, err
+		}
+		if run.Plan != "" && shown == 0 && len(run.Steps) > 0 {
+			fmt.Fprintf(trace, "Plano:\n%s\n", run.Plan)
+		}
+		for ; shown < len(run.Steps); shown++ {
+			fmt.Fprintf(trace, "[%d] %s\n", shown+1, run.Steps[shown].Trace())
+		}
+	}
+	if run.Reason == agent.ReasonMaxSteps || run.Reason == agent.ReasonTokenBudget {
+		fmt.Fprintf(trace, "Limite da investigacao atingido (%s).\n", strings.ReplaceAll(run.Reason, "_", " "))
+	}
+	return run, nil
+}
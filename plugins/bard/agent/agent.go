@@ -0,0 +1,321 @@
+// Package agent implementa o loop de ferramentas do Bard: o modelo encadeia
+// várias chamadas de ferramenta, recebendo o resultado de cada uma como
+// observação, até responder ou esgotar o orçamento de passos e tokens.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// DefaultMaxSteps é o limite padrão de ferramentas executadas por pergunta.
+const DefaultMaxSteps = 5
+
+// Motivos de encerramento do loop, em Run.Reason.
+const (
+	ReasonAnswered    = "answered"
+	ReasonMaxSteps    = "max_steps"
+	ReasonTokenBudget = "token_budget"
+)
+
+// Config limita o loop do agente.
+type Config struct {
+	// MaxSteps é o número máximo de ferramentas executadas. Zero usa DefaultMaxSteps.
+	MaxSteps int `yaml:"max_steps"`
+	// MaxTokens é o orçamento de tokens para a pergunta e as observações,
+	// descontados o system prompt e o histórico (ver TokenBudget no Bard).
+	// Zero desabilita o limite.
+	MaxTokens int `yaml:"-"`
+}
+
+// Agent conduz o loop de ferramentas com um provider.
+type Agent struct {
+	Provider ai.Provider
+	// SystemPrompt é usado na resposta final; as decisões de ferramenta usam
+	// o prompt bard.agent.
+	SystemPrompt string
+	Config       Config
+
+	// Environment, Mutations e Audit são repassados a tools.PrepareMutation
+	// quando o modelo escolhe uma ferramenta de escrita.
+	Environment string
+	Mutations   tools.MutationPolicy
+	Audit       *tools.AuditLog
+	// Confirm decide sobre uma operação de escrita já simulada. Quando nil,
+	// o loop pausa com Run.Pending preenchido até Resume ser chamado.
+	Confirm func(pending *tools.PendingMutation) bool
+}
+
+// Step é uma ferramenta executada pelo agente.
+type Step struct {
+	Tool   string
+	Params map[string]string
+	// Thought é o texto que o modelo escreveu junto com a chamada.
+	Thought string
+	Result  tools.ToolResult
+}
+
+// Run é uma execução do loop para uma pergunta.
+type Run struct {
+	agent       *Agent
+	question    string
+	directInput string
+
+	// Plan é o plano declarado pelo modelo na primeira decisão.
+	Plan  string
+	Steps []Step
+	// Pending é a operação de escrita aguardando confirmação (ver Resume).
+	Pending *tools.PendingMutation
+	// Done indica que não há mais ferramentas a executar; falta Finish.
+	Done   bool
+	Reason string
+}
+
+// Start inicia uma execução. directInput é o input da resposta final quando
+// nenhuma ferramenta for usada (a pergunta enriquecida com contexto RAG).
+func (a *Agent) Start(question, directInput string) *Run {
+	if directInput == "" {
+		directInput = question
+	}
+	return &Run{agent: a, question: question, directInput: directInput}
+}
+
+// Run executa o loop completo e escreve a resposta final em out.
+func (a *Agent) Run(ctx context.Context, question, directInput string, out io.Writer) (*Run, error) {
+	r := a.Start(question, directInput)
+	for !r.Done {
+		if err := r.Next(ctx); err != nil {
+			return r, err
+		}
+		if r.Pending != nil {
+			return r, fmt.Errorf("operação %s aguardando confirmação", r.Pending.Tool.Name)
+		}
+	}
+	return r, r.Finish(ctx, out)
+}
+
+func (a *Agent) maxSteps() int {
+	if a.Config.MaxSteps > 0 {
+		return a.Config.MaxSteps
+	}
+	return DefaultMaxSteps
+}
+
+// Next pede ao modelo a próxima decisão e executa as ferramentas escolhidas.
+// Marca Done quando o modelo não chama ferramentas ou o orçamento acaba.
+func (r *Run) Next(ctx context.Context) error {
+	if r.Done || r.Pending != nil {
+		return nil
+	}
+	if len(r.Steps) >= r.agent.maxSteps() {
+		r.stop(ReasonMaxSteps)
+		return nil
+	}
+	if r.overBudget() {
+		r.stop(ReasonTokenBudget)
+		return nil
+	}
+
+	resp, err := ai.CompleteWithTools(ctx, r.agent.Provider, prompts.Get("bard.agent"), r.transcript(), tools.Definitions())
+	if err != nil {
+		return fmt.Errorf("falha ao decidir o próximo passo: %w", err)
+	}
+	thought := strings.TrimSpace(resp.Content)
+	if len(resp.ToolCalls) == 0 {
+		r.stop(ReasonAnswered)
+		return nil
+	}
+	if r.Plan == "" && len(r.Steps) == 0 {
+		r.Plan = thought
+	}
+
+	for _, call := range resp.ToolCalls {
+		if len(r.Steps) >= r.agent.maxSteps() {
+			r.stop(ReasonMaxSteps)
+			return nil
+		}
+		r.execute(ctx, call, thought)
+		thought = ""
+		if r.Pending != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// execute roda uma chamada e registra a observação. Ferramentas de escrita
+// passam por PrepareMutation e pela confirmação.
+func (r *Run) execute(ctx context.Context, call ai.ToolCall, thought string) {
+	params := call.StringArgs()
+	step := Step{Tool: call.Name, Params: params, Thought: thought, Result: tools.ToolResult{ToolName: call.Name}}
+
+	tool := tools.Get(call.Name)
+	switch {
+	case tool == nil:
+		step.Result.Error = fmt.Sprintf("ferramenta %s não encontrada", call.Name)
+	case tool.Mutating:
+		pending, err := tools.PrepareMutation(ctx, tool, params, r.agent.Environment, r.agent.Mutations, r.agent.Audit)
+		if err != nil {
+			step.Result.Error = err.Error()
+			break
+		}
+		if r.agent.Confirm == nil {
+			r.Pending = pending
+			r.Steps = append(r.Steps, step)
+			return
+		}
+		step.Result = decide(ctx, pending, r.agent.Confirm(pending))
+	default:
+		output, err := tool.Execute(ctx, params)
+		step.Result.Output = output
+		if err != nil {
+			step.Result.Error = err.Error()
+		}
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// Resume aplica (approved) ou cancela a operação pendente e registra o
+// resultado como observação do último passo.
+func (r *Run) Resume(ctx context.Context, approved bool) {
+	if r.Pending == nil {
+		return
+	}
+	r.Steps[len(r.Steps)-1].Result = decide(ctx, r.Pending, approved)
+	r.Pending = nil
+}
+
+func decide(ctx context.Context, pending *tools.PendingMutation, approved bool) tools.ToolResult {
+	result := tools.ToolResult{ToolName: pending.Tool.Name}
+	if !approved {
+		pending.Reject()
+		result.Error = "operação cancelada pelo usuário; nada foi alterado no cluster"
+		return result
+	}
+	output, err := pending.Approve(ctx)
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Finish gera a resposta final em out, com as observações coletadas.
+func (r *Run) Finish(ctx context.Context, out io.Writer) error {
+	input := r.directInput
+	if len(r.Steps) > 0 {
+		input = fmt.Sprintf("Pergunta do usuario: %s\n\n%s\nAnalise os resultados e responda ao usuario de forma clara e acionavel.", r.question, r.observations())
+		if r.Reason == ReasonMaxSteps || r.Reason == ReasonTokenBudget {
+			input += " O limite de passos da investigacao foi atingido: responda com o que foi coletado e indique o que falta verificar."
+		}
+	}
+	return r.agent.Provider.StreamCompletion(ctx, r.agent.SystemPrompt, input, out)
+}
+
+func (r *Run) stop(reason string) {
+	r.Done = true
+	r.Reason = reason
+}
+
+// transcript monta o input da decisão: a pergunta e as observações até aqui.
+func (r *Run) transcript() string {
+	if len(r.Steps) == 0 {
+		return r.question
+	}
+	remaining := r.agent.maxSteps() - len(r.Steps)
+	return fmt.Sprintf("Pergunta do usuario: %s\n\n%s\nPassos restantes: %d. Chame a proxima ferramenta necessaria ou, se ja tiver o suficiente, responda sem chamar ferramentas.",
+		r.question, r.observations(), remaining)
+}
+
+// observations formata os resultados das ferramentas, cortando cada saída
+// para que todos os passos caibam no orçamento de tokens.
+func (r *Run) observations() string {
+	limit := r.observationLimit()
+	var sb strings.Builder
+	for i, s := range r.Steps {
+		params, _ := json.Marshal(s.Params)
+		sb.WriteString(fmt.Sprintf("### Passo %d: %s %s\n", i+1, s.Tool, params))
+		if s.Result.Error != "" {
+			sb.WriteString("Erro: " + s.Result.Error + "\n")
+		}
+		if s.Result.Output != "" {
+			sb.WriteString(truncate(s.Result.Output, limit) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// observationLimit divide o orçamento que sobra após a pergunta entre os
+// passos possíveis. Zero indica sem limite.
+func (r *Run) observationLimit() int {
+	if r.agent.Config.MaxTokens <= 0 {
+		return 0
+	}
+	available := r.agent.Config.MaxTokens - ai.EstimateTokens(r.question)
+	limit := available / r.agent.maxSteps()
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
+
+// overBudget indica que a pergunta e as observações já ocupam o orçamento.
+func (r *Run) overBudget() bool {
+	if r.agent.Config.MaxTokens <= 0 {
+		return false
+	}
+	return ai.EstimateTokens(r.transcript()) >= r.agent.Config.MaxTokens
+}
+
+// truncate mantém o final da saída (o mais recente em logs e eventos) dentro
+// do limite de tokens.
+func truncate(text string, maxTokens int) string {
+	if maxTokens <= 0 || ai.EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	maxChars := maxTokens * 4
+	return "[... saida truncada ...]\n" + text[len(text)-maxChars:]
+}
+
+// Trace descreve o passo em uma linha, para exibição do progresso.
+func (s Step) Trace() string {
+	var params []string
+	for _, k := range sortedKeys(s.Params) {
+		if k == "manifest" || k == "patch" {
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s=%s", k, s.Params[k]))
+	}
+	line := strings.TrimSpace(s.Tool + " " + strings.Join(params, " "))
+	switch {
+	case s.Result.Error != "":
+		return line + " -> erro: " + firstLine(s.Result.Error)
+	case s.Result.Output != "":
+		return fmt.Sprintf("%s -> ok (%d linhas)", line, strings.Count(strings.TrimRight(s.Result.Output, "\n"), "\n")+1)
+	default:
+		return line
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,287 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// scriptedProvider responde às decisões com uma sequência fixa de chamadas
+// e registra os inputs recebidos.
+type scriptedProvider struct {
+	responses [][]ai.ToolCall
+	decisions []string
+	final     string
+}
+
+func (p *scriptedProvider) Name() string                       { return "scripted" }
+func (p *scriptedProvider) IsAvailable(_ context.Context) bool { return true }
+func (p *scriptedProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (p *scriptedProvider) Completion(_ context.Context, _, _ string) (string, error) {
+	return "", nil
+}
+func (p *scriptedProvider) StreamCompletion(_ context.Context, _, userPrompt string, out io.Writer) error {
+	p.final = userPrompt
+	_, err := io.WriteString(out, "resposta final")
+	return err
+}
+func (p *scriptedProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+func (p *scriptedProvider) CompletionWithTools(_ context.Context, _, userPrompt string, _ []ai.ToolDefinition) (*ai.ToolResponse, error) {
+	p.decisions = append(p.decisions, userPrompt)
+	i := len(p.decisions) - 1
+	if i >= len(p.responses) {
+		return &ai.ToolResponse{Content: "pronto"}, nil
+	}
+	content := ""
+	if i == 0 {
+		content = "1. listar pods\n2. ler logs"
+	}
+	return &ai.ToolResponse{Content: content, ToolCalls: p.responses[i]}, nil
+}
+
+func call(name string, args map[string]interface{}) []ai.ToolCall {
+	return []ai.ToolCall{{Name: name, Arguments: args}}
+}
+
+// registerAgentTestTools substitui o registro por ferramentas de teste e
+// restaura as ferramentas originais ao final.
+func registerAgentTestTools(t *testing.T, executed *int) {
+	t.Helper()
+	saved := tools.All()
+	tools.Reset()
+	t.Cleanup(func() {
+		tools.Reset()
+		for _, tool := range saved {
+			tools.Register(tool)
+		}
+	})
+
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_get",
+		Intents:    []string{"list_resources"},
+		Parameters: []tools.ToolParam{{Name: "resource", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return "checkout-abc   1/1   Running   5 restarts", nil
+		},
+	})
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_logs",
+		Intents:    []string{"logs"},
+		Parameters: []tools.ToolParam{{Name: "pod", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return "timeout ao conectar no banco\n" + strings.Repeat("x", 4000), nil
+		},
+	})
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_rollout_restart",
+		Intents:    []string{"restart_workload"},
+		Mutating:   true,
+		Parameters: []tools.ToolParam{{Name: "resource", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			*executed++
+			return "deployment.apps/checkout restarted", nil
+		},
+		DryRun: func(ctx context.Context, params map[string]string) (string, error) {
+			return "+  restartedAt: agora", nil
+		},
+	})
+}
+
+// TestAgent_EncadeiaFerramentas verifica que cada resultado volta como observação.
+func TestAgent_EncadeiaFerramentas(t *testing.T) {
+	registerAgentTestTools(t, new(int))
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		call("kubectl_get", map[string]interface{}{"resource": "pods"}),
+		call("kubectl_logs", map[string]interface{}{"pod": "checkout-abc"}),
+	}}
+	a := &Agent{Provider: provider}
+
+	var out strings.Builder
+	run, err := a.Run(context.Background(), "por que o checkout esta lento?", "", &out)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if len(run.Steps) != 2 || run.Steps[0].Tool != "kubectl_get" || run.Steps[1].Tool != "kubectl_logs" {
+		t.Fatalf("passos inesperados: %+v", run.Steps)
+	}
+	if run.Reason != ReasonAnswered {
+		t.Errorf("motivo esperado answered, obtido %s", run.Reason)
+	}
+	if run.Plan != "1. listar pods\n2. ler logs" {
+		t.Errorf("plano inesperado: %q", run.Plan)
+	}
+	if len(provider.decisions) != 3 {
+		t.Fatalf("esperava 3 decisões, obteve %d", len(provider.decisions))
+	}
+	if !strings.Contains(provider.decisions[1], "checkout-abc   1/1   Running") {
+		t.Errorf("a segunda decisão deveria receber a observação do primeiro passo:\n%s", provider.decisions[1])
+	}
+	if !strings.Contains(provider.final, "### Passo 2: kubectl_logs") || !strings.Contains(provider.final, "timeout ao conectar") {
+		t.Errorf("resposta final deveria receber todas as observações:\n%s", provider.final)
+	}
+	if out.String() != "resposta final" {
+		t.Errorf("saída inesperada: %s", out.String())
+	}
+}
+
+// TestAgent_RespostaDireta verifica que sem ferramentas a resposta usa o input direto.
+func TestAgent_RespostaDireta(t *testing.T) {
+	registerAgentTestTools(t, new(int))
+	provider := &scriptedProvider{}
+	a := &Agent{Provider: provider}
+
+	run, err := a.Run(context.Background(), "o que e um pod?", "Contexto: docs\n\nPergunta: o que e um pod?", io.Discard)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(run.Steps) != 0 || run.Plan != "" {
+		t.Errorf("não esperava passos: %+v", run.Steps)
+	}
+	if provider.final != "Contexto: docs\n\nPergunta: o que e um pod?" {
+		t.Errorf("input final inesperado: %s", provider.final)
+	}
+}
+
+// TestAgent_MaxSteps verifica o limite de passos.
+func TestAgent_MaxSteps(t *testing.T) {
+	registerAgentTestTools(t, new(int))
+	var responses [][]ai.ToolCall
+	for i := 0; i < 10; i++ {
+		responses = append(responses, call("kubectl_get", map[string]interface{}{"resource": fmt.Sprintf("pods-%d", i)}))
+	}
+	provider := &scriptedProvider{responses: responses}
+	a := &Agent{Provider: provider, Config: Config{MaxSteps: 2}}
+
+	run, err := a.Run(context.Background(), "investiga tudo", "", io.Discard)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(run.Steps) != 2 || run.Reason != ReasonMaxSteps {
+		t.Errorf("esperava 2 passos e max_steps, obteve %d e %s", len(run.Steps), run.Reason)
+	}
+	if !strings.Contains(provider.final, "limite de passos") {
+		t.Errorf("resposta final deveria mencionar o limite:\n%s", provider.final)
+	}
+}
+
+// TestAgent_OrcamentoDeTokens verifica que as observações respeitam o orçamento.
+func TestAgent_OrcamentoDeTokens(t *testing.T) {
+	registerAgentTestTools(t, new(int))
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		call("kubectl_logs", map[string]interface{}{"pod": "checkout-abc"}),
+	}}
+	a := &Agent{Provider: provider, Config: Config{MaxSteps: 2, MaxTokens: 200}}
+
+	run, err := a.Run(context.Background(), "logs do checkout", "", io.Discard)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(run.Steps) != 1 {
+		t.Fatalf("esperava 1 passo, obteve %d", len(run.Steps))
+	}
+	if !strings.Contains(provider.final, "saida truncada") {
+		t.Error("observação acima do orçamento deveria ser truncada")
+	}
+	if ai.EstimateTokens(provider.final) > 400 {
+		t.Errorf("input final excede o orçamento: %d tokens", ai.EstimateTokens(provider.final))
+	}
+}
+
+// TestAgent_FerramentaInexistente verifica que o erro vira observação.
+func TestAgent_FerramentaInexistente(t *testing.T) {
+	registerAgentTestTools(t, new(int))
+	r := (&Agent{Provider: &scriptedProvider{}}).Start("q", "")
+	r.execute(context.Background(), ai.ToolCall{Name: "nao_existe"}, "")
+
+	if len(r.Steps) != 1 || !strings.Contains(r.Steps[0].Result.Error, "não encontrada") {
+		t.Errorf("passo inesperado: %+v", r.Steps)
+	}
+}
+
+// TestAgent_EscritaAguardaConfirmacao verifica a pausa e a retomada do loop.
+func TestAgent_EscritaAguardaConfirmacao(t *testing.T) {
+	var executed int
+	registerAgentTestTools(t, &executed)
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		call("kubectl_rollout_restart", map[string]interface{}{"resource": "deployment/checkout"}),
+	}}
+	a := &Agent{Provider: provider, Environment: "dev", Mutations: tools.MutationPolicy{Enabled: true}}
+
+	run := a.Start("reinicia o checkout", "")
+	if err := run.Next(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if run.Pending == nil || executed != 0 {
+		t.Fatal("operação de escrita deveria aguardar confirmação sem executar")
+	}
+	if !strings.Contains(run.Pending.Diff, "restartedAt") {
+		t.Errorf("diff inesperado: %s", run.Pending.Diff)
+	}
+
+	run.Resume(context.Background(), true)
+	if run.Pending != nil || executed != 1 {
+		t.Fatal("confirmação deveria executar a operação")
+	}
+	if run.Steps[0].Result.Output != "deployment.apps/checkout restarted" {
+		t.Errorf("observação inesperada: %+v", run.Steps[0].Result)
+	}
+
+	if err := run.Next(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !run.Done {
+		t.Error("loop deveria terminar após a resposta do modelo")
+	}
+}
+
+// TestAgent_EscritaRecusada verifica a confirmação síncrona recusada.
+func TestAgent_EscritaRecusada(t *testing.T) {
+	var executed int
+	registerAgentTestTools(t, &executed)
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		call("kubectl_rollout_restart", map[string]interface{}{"resource": "deployment/checkout"}),
+	}}
+	var asked int
+	a := &Agent{
+		Provider:    provider,
+		Environment: "dev",
+		Mutations:   tools.MutationPolicy{Enabled: true},
+		Confirm: func(p *tools.PendingMutation) bool {
+			asked++
+			return false
+		},
+	}
+
+	run, err := a.Run(context.Background(), "reinicia o checkout", "", io.Discard)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if asked != 1 || executed != 0 {
+		t.Errorf("esperava 1 confirmação e nenhuma execução, obteve %d e %d", asked, executed)
+	}
+	if !strings.Contains(run.Steps[0].Result.Error, "cancelada") {
+		t.Errorf("observação inesperada: %+v", run.Steps[0].Result)
+	}
+}
+
+// TestStep_Trace verifica a linha de progresso do passo.
+func TestStep_Trace(t *testing.T) {
+	s := Step{Tool: "kubectl_get", Params: map[string]string{"resource": "pods", "namespace": "app"}, Result: tools.ToolResult{Output: "a\nb\n"}}
+	if got := s.Trace(); got != "kubectl_get namespace=app resource=pods -> ok (2 linhas)" {
+		t.Errorf("trace inesperado: %s", got)
+	}
+	s.Result = tools.ToolResult{Error: "falhou\ndetalhes"}
+	if got := s.Trace(); got != "kubectl_get namespace=app resource=pods -> erro: falhou" {
+		t.Errorf("trace inesperado: %s", got)
+	}
+}
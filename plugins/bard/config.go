@@ -4,6 +4,9 @@ import (
 	"os"
 
 	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
+	"github.com/casheiro/yby-cli/plugins/bard/mcp"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,6 +16,13 @@ type BardConfig struct {
 	RelevanceThreshold float64 `yaml:"relevance_threshold"`
 	SystemPromptExtra  string  `yaml:"system_prompt_extra"`
 	MaxTokens          int     `yaml:"max_tokens"`
+
+	// Mutations controla as ferramentas que alteram o cluster.
+	Mutations tools.MutationPolicy `yaml:"mutations"`
+	// Agent limita o loop de ferramentas (agent.max_steps).
+	Agent agent.Config `yaml:"agent"`
+	// MCPServers são servidores MCP locais cujas ferramentas o Bard usa.
+	MCPServers []mcp.ServerConfig `yaml:"mcp_servers"`
 }
 
 // loadBardConfig carrega a configuração do Bard a partir de .yby/bard.yaml.
@@ -22,6 +32,7 @@ func loadBardConfig() BardConfig {
 		TopK:               5,
 		RelevanceThreshold: 0.6,
 		MaxTokens:          32000,
+		Agent:              agent.Config{MaxSteps: agent.DefaultMaxSteps},
 	}
 
 	data, err := os.ReadFile(".yby/bard.yaml")
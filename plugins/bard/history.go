@@ -33,7 +33,7 @@ var secretPatterns = []*regexp.Regexp{
 
 // HistoryEntry representa uma entrada no histórico de conversas.
 type HistoryEntry struct {
-	Role      string `json:"role"` // "user" ou "assistant"
+	Role      string `json:"role"` // "user", "assistant" ou "summary" (resumo da sessão compactada)
 	Content   string `json:"content"`
 	Timestamp string `json:"timestamp"`
 	SessionID string `json:"session_id,omitempty"` // identificador da sessão
@@ -269,13 +269,15 @@ func loadAllEntriesRaw() ([]HistoryEntry, error) {
 	return entries, nil
 }
 
-// clearHistory remove o arquivo de histórico.
+// clearHistory remove o arquivo de histórico e os metadados das sessões.
 func clearHistory() {
 	os.Remove(historyFile)
+	os.Remove(sessionsFile)
 }
 
 // formatHistoryContext formata as entradas de histórico como texto
-// para injeção no system prompt.
+// para injeção no system prompt. Entradas com role "summary" são o resumo
+// das mensagens compactadas da sessão.
 func formatHistoryContext(entries []HistoryEntry) string {
 	if len(entries) == 0 {
 		return ""
@@ -283,6 +285,10 @@ func formatHistoryContext(entries []HistoryEntry) string {
 
 	result := "## Histórico de Conversas Anteriores\n"
 	for _, e := range entries {
+		if e.Role == "summary" {
+			result += fmt.Sprintf("\n**Resumo das mensagens anteriores:**\n%s\n", e.Content)
+			continue
+		}
 		label := "Usuário"
 		if e.Role == "assistant" {
 			label = "Assistente"
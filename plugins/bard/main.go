@@ -18,12 +18,16 @@ import (
 	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 	"github.com/casheiro/yby-cli/pkg/plugin"
 	"github.com/casheiro/yby-cli/pkg/retry"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
 	"github.com/casheiro/yby-cli/plugins/bard/tools"
 	"github.com/casheiro/yby-cli/plugins/bard/tui"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
 )
 
+// bardVersion é a versão do plugin, no manifesto e no servidor MCP.
+const bardVersion = "1.0.0"
+
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "erro: %v\n", err)
@@ -42,6 +46,11 @@ func run() error {
 		return handlePluginRequest(req)
 	}
 
+	// Binário invocado diretamente como servidor MCP: o stdin é do protocolo
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		return handleMCPCommand(os.Args[2:])
+	}
+
 	// 2. Check for Stdin Protocol (Legacy/Automation)
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -61,12 +70,21 @@ func handlePluginRequest(req plugin.PluginRequest) error {
 	case "manifest":
 		respond(plugin.PluginManifest{
 			Name:        "bard",
-			Version:     "1.0.0",
+			Version:     bardVersion,
 			Description: "Assistente IA interativo com tool calling, TUI e integracao cross-plugin",
 			Hooks:       []string{"command"},
 		})
 		return nil
 	case "command":
+		if len(req.Args) > 0 {
+			switch req.Args[0] {
+			case "mcp":
+				return handleMCPCommand(req.Args[1:])
+			case "serve":
+				return runServe(req.Context, req.Args[1:])
+			}
+		}
+
 		// Parsear flags
 		var promptMsg string
 		for i, arg := range req.Args {
@@ -92,33 +110,16 @@ func handlePluginRequest(req plugin.PluginRequest) error {
 
 func runOneShot(ctxData map[string]interface{}, prompt string) error {
 	tools.LoadExternalTools()
+	bardCfg := loadBardConfig()
+	environment := bardEnvironment(ctxData)
+	applyMutationPolicy(bardCfg.Mutations, environment)
 
 	ctx := context.Background()
 	provider := ai.GetProvider(ctx, "auto")
 	if provider == nil {
 		return fmt.Errorf("nenhum provedor de IA disponivel")
 	}
-
-	// Classificar intenção
-	intent := ClassifyIntent(ctx, provider, prompt)
-
-	var toolOutput string
-	var toolName string
-
-	if intent != nil && !intent.Direct && intent.Intent != "direct" {
-		tool := tools.FindByIntent(intent.Intent)
-		if tool != nil {
-			toolName = tool.Name
-			fmt.Fprintf(os.Stderr, "Executando %s...\n", toolName)
-
-			output, execErr := tool.Execute(ctx, intent.Params)
-			if execErr != nil {
-				toolOutput = fmt.Sprintf("Erro ao executar %s: %v", toolName, execErr)
-			} else {
-				toolOutput = output
-			}
-		}
-	}
+	defer connectMCPServers(ctx, bardCfg.MCPServers, os.Stderr)()
 
 	// Busca semântica no Synapstor pra enriquecer contexto
 	var ukiContext string
@@ -141,26 +142,39 @@ func runOneShot(ctxData map[string]interface{}, prompt string) error {
 		}
 	}
 
-	// Construir input
-	finalInput := prompt
+	// Construir input para resposta sem ferramentas
+	directInput := prompt
 	if ukiContext != "" {
-		finalInput = fmt.Sprintf("Contexto do projeto (documentacao):\n%s\n\nPergunta: %s", ukiContext, prompt)
-	}
-	if toolOutput != "" {
-		finalInput = fmt.Sprintf("Pergunta: %s\n\nResultado da ferramenta %s:\n%s\n\nAnalise e responda de forma clara.", prompt, toolName, toolOutput)
-		if ukiContext != "" {
-			finalInput = fmt.Sprintf("Contexto do projeto:\n%s\n\nPergunta: %s\n\nResultado da ferramenta %s:\n%s\n\nAnalise e responda de forma clara.", ukiContext, prompt, toolName, toolOutput)
-		}
+		directInput = fmt.Sprintf("Contexto do projeto (documentacao):\n%s\n\nPergunta: %s", ukiContext, prompt)
 	}
 
-	// Responder
 	systemPrompt := prompts.GetWithVars("bard.system", map[string]string{
 		"blueprint_json_summary": "",
 		"tools_prompt":           tools.FormatToolsPrompt(),
 		"cluster_context":        "",
 	})
 
-	return provider.StreamCompletion(ctx, systemPrompt, finalInput, os.Stdout)
+	// Sem terminal não há como confirmar ferramentas de escrita: são recusadas
+	var in *bufio.Scanner
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		in = bufio.NewScanner(os.Stdin)
+	}
+	a := &agent.Agent{
+		Provider:     provider,
+		SystemPrompt: systemPrompt,
+		Config:       agentConfig(bardCfg, systemPrompt),
+		Environment:  environment,
+		Mutations:    bardCfg.Mutations,
+		Audit:        tools.NewAuditLog(),
+		Confirm:      confirmMutation(in, os.Stderr),
+	}
+
+	// Responder (plano e passos no stderr, resposta no stdout)
+	run, err := runAgentSteps(ctx, a, prompt, directInput, os.Stderr)
+	if err != nil {
+		return err
+	}
+	return run.Finish(ctx, os.Stdout)
 }
 
 func printBardHelp() {
@@ -168,25 +182,46 @@ func printBardHelp() {
 	fmt.Println()
 	fmt.Println("Uso: yby bard [flags]")
 	fmt.Println()
-	fmt.Println("O Bard detecta automaticamente a intencao e executa ferramentas do Yby")
-	fmt.Println("(sentinel, atlas, kubectl) quando necessario. A IA interpreta os resultados.")
+	fmt.Println("O Bard encadeia ferramentas do Yby (sentinel, atlas, kubectl) quando necessario,")
+	fmt.Println("usando o resultado de cada uma para decidir a proxima (ate agent.max_steps em")
+	fmt.Println(".yby/bard.yaml, padrao 5). A IA interpreta os resultados e exibe o plano.")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -p, --prompt \"msg\"    Pergunta one-shot (responde e sai)")
 	fmt.Println()
+	fmt.Println("Subcomandos:")
+	fmt.Println("  mcp serve             Expoe as ferramentas via MCP (stdio) para editores e agentes")
+	fmt.Println("  serve [--addr 127.0.0.1:8088]")
+	fmt.Println("                        Chat via HTTP: API com streaming (SSE) e interface web")
+	fmt.Println("                        (YBY_BARD_TOKEN exige autenticacao; obrigatorio fora do loopback)")
+	fmt.Println()
 	fmt.Println("Modos de uso:")
 	fmt.Println("  yby bard                          Chat interativo (TUI)")
 	fmt.Println("  yby bard -p \"lista os pods\"        One-shot (responde e sai)")
 	fmt.Println("  echo \"pergunta\" | yby bard         Batch via pipe")
+	fmt.Println("  yby bard serve                    Interface web em http://127.0.0.1:8088")
+	fmt.Println()
+	fmt.Println("Comandos do chat:")
+	fmt.Println("  /sessions                         Lista as sessoes com nome e titulo")
+	fmt.Println("  /resume [sessao]                  Retoma uma sessao (padrao: a mais recente)")
+	fmt.Println("  /name <nome>                      Da um nome a sessao atual")
+	fmt.Println("  /search <termos>                  Busca nas mensagens de todas as sessoes")
+	fmt.Println("  /export [sessao] [arquivo.md]     Exporta a sessao para markdown")
 	fmt.Println()
 	fmt.Println("Ferramentas integradas:")
 	fmt.Println("  sentinel scan/investigate          Scan de seguranca e investigacao de pods")
 	fmt.Println("  kubectl get/logs/events/describe   Consultas read-only ao cluster")
+	fmt.Println("  kubectl scale/rollout restart/     Alteracoes no cluster, com dry-run, diff e")
+	fmt.Println("    patch/apply                      confirmacao explicita (mutations em .yby/bard.yaml)")
 	fmt.Println("  atlas blueprint                    Topologia da infraestrutura")
 	fmt.Println()
 	fmt.Println("Tools externas (YAML):")
 	fmt.Println("  ~/.yby/tools/*.yaml                Tools globais do usuario")
 	fmt.Println("  .yby/tools/*.yaml                  Tools do projeto")
+	fmt.Println()
+	fmt.Println("Servidores MCP (mcp_servers em .yby/bard.yaml):")
+	fmt.Println("  - name: docs                       Ferramentas de servidores MCP locais (stdio),")
+	fmt.Println("    command: mcp-docs-server         registradas como <name>_<ferramenta>")
 }
 
 func startChat(ctxData map[string]interface{}) error {
@@ -210,8 +245,11 @@ func startChat(ctxData map[string]interface{}) error {
 		slog.Debug("memoria semantica indisponivel", "erro", err)
 	}
 
-	// 2. Carregar configuração do Bard
+	// 2. Carregar configuração do Bard e a política de escrita do ambiente
 	bardCfg := loadBardConfig()
+	environment := bardEnvironment(ctxData)
+	applyMutationPolicy(bardCfg.Mutations, environment)
+	audit := tools.NewAuditLog()
 
 	// 3. Detectar modo batch (non-TTY)
 	isTTY := term.IsTerminal(int(os.Stdin.Fd()))
@@ -219,24 +257,36 @@ func startChat(ctxData map[string]interface{}) error {
 		return runBatchMode(ctx, provider, vectorStore, bardCfg, ctxData)
 	}
 
-	// 4. Enriquecer contexto do cluster (non-fatal)
+	// 4. Ferramentas de servidores MCP locais (mcp_servers em .yby/bard.yaml)
+	defer connectMCPServers(ctx, bardCfg.MCPServers, os.Stderr)()
+
+	// 5. Enriquecer contexto do cluster (non-fatal)
 	clusterCtx := EnrichContext(ctx)
 
-	// 5. Gerar SessionID para esta sessão interativa
+	// 6. Gerar SessionID para esta sessão interativa
 	sessionID := time.Now().Format("20060102-150405")
 
-	// 6. Carregar histórico de sessões anteriores
-	history := loadHistory()
-
-	// 7. Construir system prompt enriquecido
-	systemPrompt := buildSystemPrompt(ctxData, bardCfg, history, clusterCtx)
+	// 7. Sessões: histórico por sessão (compactado quando longo), títulos
+	// gerados pela IA e os comandos /sessions, /resume, /name, /search e /export
+	sessions := newSessionStore(provider)
+	defer sessions.wait()
 
 	// 8. Usar Rich TUI (Bubbletea) por padrão, legacy UI via env var
 	if os.Getenv("YBY_BARD_LEGACY_UI") == "" {
 		tuiConfig := tui.Config{
-			SystemPrompt: systemPrompt,
-			SessionID:    sessionID,
-			SaveMessage:  saveMessage,
+			SessionID:   sessionID,
+			SaveMessage: sessions.save,
+			Environment: environment,
+			Mutations:   bardCfg.Mutations,
+			Audit:       audit,
+			Prompt: func(id string) (string, agent.Config) {
+				systemPrompt := buildSystemPrompt(ctxData, bardCfg, sessions.history(ctx, id), clusterCtx)
+				return systemPrompt, agentConfig(bardCfg, systemPrompt)
+			},
+			Command: func(input, id string) (tui.CommandResult, bool) {
+				output, next, ok := sessions.command(input, id)
+				return tui.CommandResult{Output: output, SessionID: next}, ok
+			},
 		}
 		if clusterCtx != nil {
 			tuiConfig.Namespace = clusterCtx.Namespace
@@ -245,11 +295,9 @@ func startChat(ctxData map[string]interface{}) error {
 		return tui.Run(provider, vectorStore, tuiConfig)
 	}
 
-	historyCtx := formatHistoryContext(history)
-
 	// Configuração da UI (legacy)
 	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true).Render("🤖 Yby Bard"))
-	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Digite 'exit' para sair. '/clear' para limpar histórico. '/sessions' para listar sessões."))
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Digite 'exit' para sair. '/clear' para limpar histórico. '/sessions', '/resume', '/name', '/search' e '/export' gerenciam sessões."))
 
 	if vectorStore != nil {
 		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("🧠 Memória Semântica Ativa."))
@@ -279,36 +327,20 @@ func startChat(ctxData map[string]interface{}) error {
 			continue
 		}
 
-		// Comando /sessions para listar sessões
-		if input == "/sessions" {
-			handleSessionsList(sessionID)
+		// Comandos de sessão (/sessions, /resume, /name, /search, /export)
+		if output, id, ok := sessions.command(input, sessionID); ok {
+			sessionID = id
+			fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(output))
 			continue
 		}
 
-		// Comando /session <id> para carregar sessão específica
-		if strings.HasPrefix(input, "/session ") {
-			targetID := strings.TrimSpace(strings.TrimPrefix(input, "/session "))
-			entries, loadErr := loadAllEntries()
-			if loadErr != nil {
-				fmt.Printf("Erro ao carregar sessões: %v\n", loadErr)
-				continue
-			}
-			sessionEntries := loadSessionHistory(entries, targetID, maxHistoryEntries)
-			if len(sessionEntries) == 0 {
-				fmt.Printf(lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render("⚠️  Sessão '%s' não encontrada ou vazia.\n"), targetID)
-				continue
-			}
-			// Atualizar contexto de histórico com a sessão carregada
-			historyCtx = formatHistoryContext(sessionEntries)
-			systemPrompt = buildSystemPrompt(ctxData, bardCfg, sessionEntries, clusterCtx)
-			fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Render(
-				fmt.Sprintf("Carregada sessão %s (%d mensagens)", targetID, len(sessionEntries)),
-			))
-			continue
-		}
+		// Histórico da sessão e system prompt, montados antes de salvar a pergunta
+		history := sessions.history(ctx, sessionID)
+		historyCtx := formatHistoryContext(history)
+		systemPrompt := buildSystemPrompt(ctxData, bardCfg, history, clusterCtx)
 
 		// Salvar mensagem do usuário antes da chamada IA
-		saveMessage("user", input, sessionID)
+		sessions.save("user", input, sessionID)
 
 		fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("Bard > "))
 
@@ -354,32 +386,20 @@ func startChat(ctxData map[string]interface{}) error {
 			runInput = fmt.Sprintf("Contexto Adicional Recuperado (Memória Semântica):\n%s\n\nPergunta do Usuário: %s", truncatedRAG, input)
 		}
 
-		// Classificar intenção via IA (chamada rápida e focada)
-		intent := ClassifyIntent(ctx, provider, input)
-
-		var toolOutput string
-		var toolName string
-
-		if intent != nil && !intent.Direct && intent.Intent != "direct" {
-			// Resolver e executar tool programaticamente
-			tool := tools.FindByIntent(intent.Intent)
-			if tool != nil {
-				toolName = tool.Name
-				fmt.Printf("Executando %s...\n", toolName)
-
-				output, execErr := tool.Execute(ctx, intent.Params)
-				if execErr != nil {
-					toolOutput = fmt.Sprintf("Erro ao executar %s: %v", toolName, execErr)
-				} else {
-					toolOutput = output
-				}
-			}
+		// Loop de ferramentas: o modelo encadeia ferramentas até ter o suficiente
+		a := &agent.Agent{
+			Provider:     provider,
+			SystemPrompt: systemPrompt,
+			Config:       agentConfig(bardCfg, systemPrompt),
+			Environment:  environment,
+			Mutations:    bardCfg.Mutations,
+			Audit:        audit,
+			Confirm:      confirmMutation(scanner, os.Stdout),
 		}
-
-		// Construir input pra IA — com ou sem resultado de tool
-		finalInput := runInput
-		if toolOutput != "" {
-			finalInput = fmt.Sprintf("Pergunta do usuario: %s\n\nResultado da ferramenta %s:\n%s\n\nAnalise o resultado e responda ao usuario de forma clara e acionavel.", input, toolName, toolOutput)
+		run, err := runAgentSteps(ctx, a, input, runInput, os.Stdout)
+		if err != nil {
+			fmt.Printf("\nErro: %v\n", err)
+			continue
 		}
 
 		// Stream da resposta final
@@ -395,13 +415,13 @@ func startChat(ctxData map[string]interface{}) error {
 		}
 
 		attempt := 0
-		err := retry.Do(ctx, retryOpts, func() error {
+		err = retry.Do(ctx, retryOpts, func() error {
 			attempt++
 			if attempt > 1 {
 				fmt.Print("\nTentando novamente...")
 				responseBuf.Reset()
 			}
-			return provider.StreamCompletion(ctx, systemPrompt, finalInput, writer)
+			return run.Finish(ctx, writer)
 		})
 
 		if err != nil {
@@ -409,7 +429,7 @@ func startChat(ctxData map[string]interface{}) error {
 		} else {
 			response := responseBuf.String()
 			if response != "" {
-				saveMessage("assistant", response, sessionID)
+				sessions.save("assistant", response, sessionID)
 			}
 		}
 		fmt.Println()
@@ -464,32 +484,6 @@ func buildSystemPrompt(ctxData map[string]interface{}, bardCfg BardConfig, histo
 	return systemPrompt
 }
 
-// handleSessionsList exibe a lista de sessões disponíveis.
-func handleSessionsList(currentSessionID string) {
-	entries, err := loadAllEntries()
-	if err != nil {
-		fmt.Printf("Erro ao carregar sessões: %v\n", err)
-		return
-	}
-
-	if len(entries) == 0 {
-		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Nenhuma sessão encontrada."))
-		return
-	}
-
-	sessions := listSessions(entries)
-	fmt.Println(lipgloss.NewStyle().Bold(true).Render("Sessões disponíveis:"))
-	for _, s := range sessions {
-		marker := "  "
-		suffix := ""
-		if s.SessionID == currentSessionID {
-			marker = "* "
-			suffix = " — atual"
-		}
-		fmt.Printf("  %s%s (%d mensagens)%s\n", marker, s.SessionID, s.MessageCount, suffix)
-	}
-}
-
 // runBatchMode executa o Bard em modo não-interativo (pipe/batch).
 // Processa uma pergunta por linha do stdin, sem styling nem histórico.
 func runBatchMode(ctx context.Context, provider ai.Provider, vectorStore *ai.VectorStore, bardCfg BardConfig, ctxData map[string]interface{}) error {
@@ -511,20 +505,7 @@ func runBatchMode(ctx context.Context, provider ai.Provider, vectorStore *ai.Vec
 		first = false
 
 		// Busca RAG (se vector store disponível)
-		runInput := question
-		if vectorStore != nil {
-			results, searchErr := vectorStore.Search(ctx, question, bardCfg.TopK)
-			if searchErr == nil && len(results) > 0 {
-				filtered := filterByThreshold(results, bardCfg.RelevanceThreshold)
-				if len(filtered) > 0 {
-					var sb strings.Builder
-					for _, res := range filtered {
-						sb.WriteString(fmt.Sprintf("\n--- Contexto: %s ---\n%s\n", res.Metadata["title"], res.Content))
-					}
-					runInput = fmt.Sprintf("Contexto Adicional Recuperado (Memória Semântica):\n%s\n\nPergunta do Usuário: %s", sb.String(), question)
-				}
-			}
-		}
+		runInput := ragInput(ctx, vectorStore, bardCfg, question)
 
 		err := provider.StreamCompletion(ctx, systemPrompt, runInput, os.Stdout)
 		if err != nil {
@@ -373,6 +373,9 @@ func TestLoadBardConfig_Defaults(t *testing.T) {
 	if cfg.SystemPromptExtra != "" {
 		t.Errorf("SystemPromptExtra padrão esperado vazio, obtido '%s'", cfg.SystemPromptExtra)
 	}
+	if cfg.Agent.MaxSteps != 5 {
+		t.Errorf("agent.max_steps padrão esperado 5, obtido %d", cfg.Agent.MaxSteps)
+	}
 }
 
 // TestLoadBardConfig_ComArquivo verifica que valores custom são carregados.
@@ -405,6 +408,36 @@ system_prompt_extra: "Responda sempre em formato de lista."
 	if cfg.SystemPromptExtra != "Responda sempre em formato de lista." {
 		t.Errorf("SystemPromptExtra inesperado: '%s'", cfg.SystemPromptExtra)
 	}
+	if cfg.Mutations.Enabled {
+		t.Error("ferramentas de escrita deveriam vir desabilitadas por padrão")
+	}
+}
+
+// TestLoadBardConfig_Mutations verifica a leitura da política de escrita.
+func TestLoadBardConfig_Mutations(t *testing.T) {
+	tmpDir := t.TempDir()
+	restore := chdir(t, tmpDir)
+	defer restore()
+
+	if err := os.MkdirAll(".yby", 0755); err != nil {
+		t.Fatalf("falha ao criar diretório: %v", err)
+	}
+	content := `mutations:
+  enabled: true
+  deny_environments: [prod, staging]
+`
+	if err := os.WriteFile(".yby/bard.yaml", []byte(content), 0644); err != nil {
+		t.Fatalf("falha ao criar arquivo de configuração: %v", err)
+	}
+
+	cfg := loadBardConfig()
+
+	if !cfg.Mutations.Enabled {
+		t.Error("mutations.enabled deveria ser true")
+	}
+	if len(cfg.Mutations.DenyEnvironments) != 2 || cfg.Mutations.DenyEnvironments[1] != "staging" {
+		t.Errorf("deny_environments inesperado: %v", cfg.Mutations.DenyEnvironments)
+	}
 }
 
 // --- Testes de Filtro por Threshold ---
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/casheiro/yby-cli/plugins/bard/mcp"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// handleMCPCommand trata `yby bard mcp <subcomando>`.
+func handleMCPCommand(args []string) error {
+	if len(args) > 0 && args[0] == "serve" {
+		return runMCPServe(context.Background(), os.Stdin, os.Stdout)
+	}
+	fmt.Println("Uso: yby bard mcp serve")
+	fmt.Println()
+	fmt.Println("Expoe as ferramentas do Bard (e as tools YAML de .yby/tools) via")
+	fmt.Println("Model Context Protocol no stdio, para editores e outros agentes.")
+	if len(args) == 0 {
+		return nil
+	}
+	return fmt.Errorf("subcomando mcp desconhecido: %s", args[0])
+}
+
+// runMCPServe atende clientes MCP até o fim do stdin. Ferramentas de escrita
+// não são expostas (ver mcp.Server).
+func runMCPServe(ctx context.Context, in io.Reader, out io.Writer) error {
+	tools.LoadExternalTools()
+	srv := &mcp.Server{Name: "yby-bard", Version: bardVersion}
+	return srv.Serve(ctx, in, out)
+}
+
+// connectMCPServers inicia os servidores MCP configurados e registra suas
+// ferramentas. Falhas são avisadas em warn e não impedem o Bard de seguir.
+// Retorna a função que encerra os servidores.
+func connectMCPServers(ctx context.Context, servers []mcp.ServerConfig, warn io.Writer) func() {
+	var clients []*mcp.Client
+	for _, cfg := range servers {
+		client, err := mcp.Start(ctx, cfg)
+		if err != nil {
+			fmt.Fprintf(warn, "aviso: servidor MCP %s indisponivel: %v\n", cfg.Name, err)
+			continue
+		}
+		if _, err := mcp.RegisterTools(ctx, client); err != nil {
+			fmt.Fprintf(warn, "aviso: %v\n", err)
+			_ = client.Close()
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return func() {
+		for _, c := range clients {
+			_ = c.Close()
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// handshakeTimeout limita a inicialização de um servidor MCP.
+const handshakeTimeout = 10 * time.Second
+
+// ServerConfig configura um servidor MCP local, iniciado como subprocesso
+// (mcp_servers em .yby/bard.yaml).
+type ServerConfig struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env"`
+}
+
+// ErrClosed indica que a conexão com o servidor MCP foi encerrada.
+var ErrClosed = errors.New("conexão MCP encerrada")
+
+// Client é um cliente MCP sobre stdio.
+type Client struct {
+	Name string
+
+	conn  *conn
+	stdin io.Closer
+	cmd   *exec.Cmd
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan message
+	done    chan struct{}
+}
+
+// Start inicia o servidor configurado e executa o handshake do MCP.
+func Start(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	if cfg.Name == "" || cfg.Command == "" {
+		return nil, fmt.Errorf("servidor MCP exige name e command")
+	}
+
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+os.ExpandEnv(v))
+	}
+	cmd.Stderr = io.Discard
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar stdin do servidor MCP %s: %w", cfg.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar stdout do servidor MCP %s: %w", cfg.Name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("falha ao iniciar servidor MCP %s: %w", cfg.Name, err)
+	}
+
+	c := NewClient(cfg.Name, stdout, stdin)
+	c.stdin = stdin
+	c.cmd = cmd
+
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	if err := c.Initialize(ctx); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient cria um cliente sobre uma conexão já aberta (usado em testes).
+// Chame Initialize antes das demais operações.
+func NewClient(name string, in io.Reader, out io.Writer) *Client {
+	c := &Client{
+		Name:    name,
+		conn:    newConn(in, out),
+		pending: make(map[int64]chan message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Initialize negocia a versão do protocolo e confirma a inicialização.
+func (c *Client) Initialize(ctx context.Context) error {
+	var result initializeResult
+	err := c.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: LatestProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      implementation{Name: "yby-bard", Version: "1.0.0"},
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("falha ao inicializar servidor MCP %s: %w", c.Name, err)
+	}
+	return c.conn.write(message{Method: "notifications/initialized"})
+}
+
+// ListTools retorna todas as ferramentas do servidor, seguindo a paginação.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var all []Tool
+	cursor := ""
+	for {
+		params := map[string]string{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var result listToolsResult
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("falha ao listar ferramentas de %s: %w", c.Name, err)
+		}
+		all = append(all, result.Tools...)
+		if result.NextCursor == "" {
+			return all, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool executa uma ferramenta do servidor.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallResult, error) {
+	var result CallResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, fmt.Errorf("falha ao chamar %s em %s: %w", name, c.Name, err)
+	}
+	return &result, nil
+}
+
+// Close encerra a conexão e o subprocesso do servidor.
+func (c *Client) Close() error {
+	if c.stdin != nil {
+		_ = c.stdin.Close()
+	}
+	if c.cmd == nil {
+		return nil
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- c.cmd.Wait() }()
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		_ = c.cmd.Process.Kill()
+		<-exited
+	}
+	return nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar parâmetros: %w", err)
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	raw := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.conn.write(message{ID: &raw, Method: method, Params: data}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("resposta MCP inválida: %w", err)
+		}
+		return nil
+	}
+}
+
+// readLoop entrega as respostas às chamadas pendentes e responde às
+// requisições do servidor.
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		line, err := c.conn.read()
+		if err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil || msg.ID == nil {
+			continue
+		}
+
+		if msg.Method != "" {
+			// requisições do servidor: só ping é suportado
+			reply := message{ID: msg.ID, Result: json.RawMessage("{}")}
+			if msg.Method != "ping" {
+				reply = message{ID: msg.ID, Error: &rpcError{Code: codeMethodNotFound, Message: "método não suportado: " + msg.Method}}
+			}
+			_ = c.conn.write(reply)
+			continue
+		}
+
+		id, err := strconv.ParseInt(string(*msg.ID), 10, 64)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		ch := c.pending[id]
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- msg
+		}
+	}
+}
+
+// invalidToolChars são os caracteres não aceitos em nomes de ferramenta
+// pelas APIs de tool calling.
+var invalidToolChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolName retorna o nome da ferramenta do servidor no registry do Bard.
+func ToolName(server, tool string) string {
+	name := invalidToolChars.ReplaceAllString(server+"_"+tool, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// RegisterTools registra no registry do Bard as ferramentas do cliente, com o
+// nome prefixado pelo servidor. Ferramentas existentes não são sobrescritas.
+// Retorna os nomes registrados.
+func RegisterTools(ctx context.Context, c *Client) ([]string, error) {
+	list, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered []string
+	for _, t := range list {
+		name := ToolName(c.Name, t.Name)
+		if tools.Get(name) != nil {
+			continue
+		}
+		remote, schema := t.Name, t.InputSchema
+
+		var params []tools.ToolParam
+		for _, p := range sortedProperties(schema) {
+			params = append(params, tools.ToolParam{
+				Name:        p,
+				Description: schema.Properties[p].Description,
+				Required:    contains(schema.Required, p),
+			})
+		}
+
+		tools.Register(&tools.Tool{
+			Name:        name,
+			Description: fmt.Sprintf("[MCP %s] %s", c.Name, t.Description),
+			Intents:     []string{name},
+			Parameters:  params,
+			Execute: func(ctx context.Context, params map[string]string) (string, error) {
+				result, err := c.CallTool(ctx, remote, typedArgs(schema, params))
+				if err != nil {
+					return "", err
+				}
+				if result.IsError {
+					return result.Text(), fmt.Errorf("ferramenta %s retornou erro: %s", remote, result.Text())
+				}
+				return result.Text(), nil
+			},
+		})
+		registered = append(registered, name)
+	}
+	return registered, nil
+}
+
+// typedArgs converte os parâmetros (sempre strings no Bard) para os tipos
+// declarados no schema da ferramenta remota.
+func typedArgs(schema ai.ToolSchema, params map[string]string) map[string]interface{} {
+	args := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		args[k] = v
+		switch schema.Properties[k].Type {
+		case "integer":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				args[k] = n
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				args[k] = f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(v); err == nil {
+				args[k] = b
+			}
+		case "array", "object":
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+				args[k] = decoded
+			} else if schema.Properties[k].Type == "array" {
+				args[k] = strings.Split(v, ",")
+			}
+		}
+	}
+	return args
+}
+
+func sortedProperties(schema ai.ToolSchema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/testutil"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// TestHelperProcess é o servidor MCP iniciado pelo teste de Start.
+func TestHelperProcess(t *testing.T) {
+	if !testutil.HelperProcessVerifier() {
+		return
+	}
+	tools.Reset()
+	tools.Register(&tools.Tool{
+		Name:       "echo",
+		Parameters: []tools.ToolParam{{Name: "text", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return os.Getenv("ECHO_PREFIX") + params["text"], nil
+		},
+	})
+	srv := &Server{Name: "helper", Version: "test"}
+	_ = srv.Serve(context.Background(), os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+// pipeClient conecta um Client ao Server do pacote por pipes em memória.
+func pipeClient(t *testing.T, name string) *Client {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	srv := &Server{Name: "yby-bard", Version: "test"}
+	go func() {
+		_ = srv.Serve(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+	}()
+	t.Cleanup(func() { clientOut.Close() })
+
+	c := NewClient(name, clientIn, clientOut)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize falhou: %v", err)
+	}
+	return c
+}
+
+func TestClient_ListAndCall(t *testing.T) {
+	registerTestTools(t)
+	c := pipeClient(t, "bard")
+	ctx := context.Background()
+
+	list, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools falhou: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("esperava 2 ferramentas, obteve %d", len(list))
+	}
+
+	result, err := c.CallTool(ctx, "kubectl_get", map[string]interface{}{"resource": "pods"})
+	if err != nil {
+		t.Fatalf("CallTool falhou: %v", err)
+	}
+	if result.IsError || result.Text() != "get pods -n " {
+		t.Errorf("resultado = %+v", result)
+	}
+
+	_, err = c.CallTool(ctx, "kubectl_scale", nil)
+	var rerr *rpcError
+	if !errors.As(err, &rerr) || rerr.Code != codeInvalidParams {
+		t.Errorf("esperava erro invalid params, obteve %v", err)
+	}
+}
+
+func TestClient_ConexaoEncerrada(t *testing.T) {
+	clientIn, serverOut := io.Pipe()
+	c := NewClient("x", clientIn, io.Discard)
+	serverOut.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.ListTools(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("esperava ErrClosed, obteve %v", err)
+	}
+}
+
+func TestRegisterTools(t *testing.T) {
+	registerTestTools(t)
+	c := pipeClient(t, "remoto")
+	ctx := context.Background()
+
+	// já existente no registro: não deve ser sobrescrita. É de escrita para
+	// não ser listada pelo próprio servidor de teste, que usa o mesmo registro.
+	tools.Register(&tools.Tool{Name: "remoto_falha", Description: "local", Mutating: true})
+
+	names, err := RegisterTools(ctx, c)
+	if err != nil {
+		t.Fatalf("RegisterTools falhou: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"remoto_kubectl_get"}) {
+		t.Fatalf("registradas = %v", names)
+	}
+	if tools.Get("remoto_falha").Description != "local" {
+		t.Error("ferramenta existente foi sobrescrita")
+	}
+
+	tool := tools.Get("remoto_kubectl_get")
+	if !strings.HasPrefix(tool.Description, "[MCP remoto] ") {
+		t.Errorf("descrição = %q", tool.Description)
+	}
+	if len(tool.Intents) != 1 || tool.Intents[0] != "remoto_kubectl_get" {
+		t.Errorf("intents = %v", tool.Intents)
+	}
+	if len(tool.Parameters) != 2 || tool.Parameters[0].Name != "namespace" || !tool.Parameters[1].Required {
+		t.Errorf("parâmetros = %+v", tool.Parameters)
+	}
+
+	output, err := tool.Execute(ctx, map[string]string{"resource": "svc", "namespace": "app"})
+	if err != nil || output != "get svc -n app" {
+		t.Errorf("Execute = %q, %v", output, err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	c, err := Start(context.Background(), ServerConfig{
+		Name:    "helper",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestHelperProcess", "--"},
+		Env:     map[string]string{"GO_WANT_HELPER_PROCESS": "1", "ECHO_PREFIX": "eco: "},
+	})
+	if err != nil {
+		t.Fatalf("Start falhou: %v", err)
+	}
+	defer c.Close()
+
+	result, err := c.CallTool(context.Background(), "echo", map[string]interface{}{"text": "ola"})
+	if err != nil {
+		t.Fatalf("CallTool falhou: %v", err)
+	}
+	if result.Text() != "eco: ola" {
+		t.Errorf("resultado = %q", result.Text())
+	}
+}
+
+func TestStart_ConfigInvalida(t *testing.T) {
+	if _, err := Start(context.Background(), ServerConfig{Name: "x"}); err == nil {
+		t.Error("esperava erro sem command")
+	}
+	if _, err := Start(context.Background(), ServerConfig{Name: "x", Command: "/nao/existe/mcp"}); err == nil {
+		t.Error("esperava erro com comando inexistente")
+	}
+}
+
+func TestToolName(t *testing.T) {
+	tests := []struct {
+		server, tool, expected string
+	}{
+		{"github", "list_issues", "github_list_issues"},
+		{"meu server", "busca.docs", "meu_server_busca_docs"},
+		{"s", strings.Repeat("a", 80), "s_" + strings.Repeat("a", 62)},
+	}
+	for _, tt := range tests {
+		if got := ToolName(tt.server, tt.tool); got != tt.expected {
+			t.Errorf("ToolName(%q, %q) = %q, esperado %q", tt.server, tt.tool, got, tt.expected)
+		}
+	}
+}
+
+func TestTypedArgs(t *testing.T) {
+	schema := ai.ToolSchema{Properties: map[string]ai.ToolProperty{
+		"limit":  {Type: "integer"},
+		"ratio":  {Type: "number"},
+		"all":    {Type: "boolean"},
+		"labels": {Type: "array"},
+		"tags":   {Type: "array"},
+		"filter": {Type: "object"},
+		"name":   {Type: "string"},
+	}}
+	args := typedArgs(schema, map[string]string{
+		"limit":  "10",
+		"ratio":  "0.5",
+		"all":    "true",
+		"labels": `["a","b"]`,
+		"tags":   "x,y",
+		"filter": `{"app":"api"}`,
+		"name":   "42",
+		"extra":  "livre",
+	})
+	expected := map[string]interface{}{
+		"limit":  int64(10),
+		"ratio":  0.5,
+		"all":    true,
+		"labels": []interface{}{"a", "b"},
+		"tags":   []string{"x", "y"},
+		"filter": map[string]interface{}{"app": "api"},
+		"name":   "42",
+		"extra":  "livre",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("typedArgs = %#v\nesperado %#v", args, expected)
+	}
+}
@@ -0,0 +1,162 @@
+// Package mcp implementa o subconjunto do Model Context Protocol usado pelo
+// Bard: servidor stdio que expõe o registry de ferramentas e cliente stdio
+// que registra ferramentas de servidores MCP locais.
+//
+// O transporte é JSON-RPC 2.0 com uma mensagem JSON por linha.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+)
+
+// LatestProtocolVersion é a versão do MCP anunciada quando o outro lado pede
+// uma versão não suportada.
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedVersions são as versões do MCP cujo subconjunto de ferramentas
+// (initialize, tools/list, tools/call) é idêntico.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// Códigos de erro do JSON-RPC.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// message é uma mensagem JSON-RPC: requisição, notificação (sem ID) ou resposta.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// rpcError é o erro de uma resposta JSON-RPC.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("erro MCP %d: %s", e.Code, e.Message)
+}
+
+// Tool descreve uma ferramenta em tools/list.
+type Tool struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	InputSchema ai.ToolSchema `json:"inputSchema"`
+}
+
+// Content é um bloco de conteúdo de tools/call. Só o tipo text é usado.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// CallResult é o resultado de tools/call. Falhas da ferramenta vêm com
+// IsError, e não como erro do protocolo.
+type CallResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text concatena os blocos de texto do resultado.
+func (r CallResult) Text() string {
+	var text string
+	for _, c := range r.Content {
+		if c.Type != "text" {
+			continue
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += c.Text
+	}
+	return text
+}
+
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      implementation         `json:"clientInfo"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      implementation         `json:"serverInfo"`
+}
+
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// conn lê e escreve mensagens JSON-RPC delimitadas por linha.
+type conn struct {
+	scanner *bufio.Scanner
+	mu      sync.Mutex
+	out     io.Writer
+}
+
+func newConn(in io.Reader, out io.Writer) *conn {
+	scanner := bufio.NewScanner(in)
+	// respostas de ferramentas podem ser grandes (logs, manifestos)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &conn{scanner: scanner, out: out}
+}
+
+// read retorna a próxima linha não vazia, ou io.EOF.
+func (c *conn) read() ([]byte, error) {
+	for c.scanner.Scan() {
+		if line := c.scanner.Bytes(); len(line) > 0 {
+			return line, nil
+		}
+	}
+	if err := c.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (c *conn) write(msg message) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar mensagem MCP: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.out.Write(append(data, '\n'))
+	return err
+}
+
+func negotiateVersion(requested string) string {
+	for _, v := range supportedVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return LatestProtocolVersion
+}
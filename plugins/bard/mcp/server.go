@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// Server expõe o registry de ferramentas do Bard via MCP.
+//
+// Ferramentas de escrita (tools.Tool.Mutating) não são expostas: elas exigem
+// a confirmação do usuário no chat, que não existe neste transporte.
+type Server struct {
+	Name    string
+	Version string
+}
+
+// Serve atende requisições MCP de in até EOF ou cancelamento do contexto.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	c := newConn(in, out)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := c.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("falha ao ler requisição MCP: %w", err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			null := json.RawMessage("null")
+			if werr := c.write(message{ID: &null, Error: &rpcError{Code: codeParseError, Message: err.Error()}}); werr != nil {
+				return werr
+			}
+			continue
+		}
+		// notificações (initialized, cancelled) e respostas não têm resposta
+		if msg.ID == nil || msg.Method == "" {
+			continue
+		}
+
+		result, rerr := s.handle(ctx, msg)
+		reply := message{ID: msg.ID, Error: rerr}
+		if rerr == nil {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("falha ao serializar resposta MCP: %w", err)
+			}
+			reply.Result = data
+		}
+		if err := c.write(reply); err != nil {
+			return fmt.Errorf("falha ao escrever resposta MCP: %w", err)
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, msg message) (interface{}, *rpcError) {
+	if msg.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "jsonrpc deve ser \"2.0\""}
+	}
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return initializeResult{
+			ProtocolVersion: negotiateVersion(params.ProtocolVersion),
+			Capabilities:    map[string]interface{}{"tools": map[string]interface{}{}},
+			ServerInfo:      implementation{Name: s.Name, Version: s.Version},
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return listToolsResult{Tools: servedTools()}, nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		tool := tools.Get(params.Name)
+		if tool == nil || tool.Mutating {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("ferramenta desconhecida: %s", params.Name)}
+		}
+		return callTool(ctx, tool, params.Arguments), nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("método não suportado: %s", msg.Method)}
+	}
+}
+
+// servedTools retorna as ferramentas expostas pelo servidor, ordenadas por nome.
+func servedTools() []Tool {
+	var served []Tool
+	for _, t := range tools.All() {
+		if t.Mutating {
+			continue
+		}
+		schema := ai.ToolSchema{Type: "object", Properties: map[string]ai.ToolProperty{}}
+		for _, p := range t.Parameters {
+			schema.Properties[p.Name] = ai.ToolProperty{Type: "string", Description: p.Description}
+			if p.Required {
+				schema.Required = append(schema.Required, p.Name)
+			}
+		}
+		served = append(served, Tool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	sort.Slice(served, func(i, j int) bool { return served[i].Name < served[j].Name })
+	return served
+}
+
+// callTool executa a ferramenta; erros de execução voltam no resultado.
+func callTool(ctx context.Context, tool *tools.Tool, args map[string]interface{}) CallResult {
+	for _, p := range tool.Parameters {
+		if _, ok := args[p.Name]; p.Required && !ok {
+			return CallResult{IsError: true, Content: []Content{{Type: "text", Text: fmt.Sprintf("parâmetro obrigatório ausente: %s", p.Name)}}}
+		}
+	}
+	output, err := tool.Execute(ctx, ai.ToolCall{Arguments: args}.StringArgs())
+	if err != nil {
+		text := err.Error()
+		if output != "" {
+			text = output + "\n" + text
+		}
+		return CallResult{IsError: true, Content: []Content{{Type: "text", Text: text}}}
+	}
+	return CallResult{Content: []Content{{Type: "text", Text: output}}}
+}
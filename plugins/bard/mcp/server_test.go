@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// registerTestTools substitui o registro por ferramentas de teste e restaura
+// as ferramentas originais ao final.
+func registerTestTools(t *testing.T) {
+	t.Helper()
+	saved := tools.All()
+	tools.Reset()
+	t.Cleanup(func() {
+		tools.Reset()
+		for _, tool := range saved {
+			tools.Register(tool)
+		}
+	})
+
+	tools.Register(&tools.Tool{
+		Name:        "kubectl_get",
+		Description: "Lista recursos",
+		Intents:     []string{"list_resources"},
+		Parameters: []tools.ToolParam{
+			{Name: "resource", Description: "Tipo do recurso", Required: true},
+			{Name: "namespace", Description: "Namespace"},
+		},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return "get " + params["resource"] + " -n " + params["namespace"], nil
+		},
+	})
+	tools.Register(&tools.Tool{
+		Name: "falha",
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return "saida parcial", errors.New("comando falhou")
+		},
+	})
+	tools.Register(&tools.Tool{
+		Name:     "kubectl_scale",
+		Mutating: true,
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			t.Error("ferramenta de escrita não deveria ser executada via MCP")
+			return "", nil
+		},
+	})
+}
+
+// serve envia as linhas ao servidor e retorna as respostas decodificadas.
+func serve(t *testing.T, lines ...string) []message {
+	t.Helper()
+	var out bytes.Buffer
+	srv := &Server{Name: "yby-bard", Version: "test"}
+	if err := srv.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve retornou erro: %v", err)
+	}
+	var replies []message
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("resposta inválida %q: %v", line, err)
+		}
+		if msg.JSONRPC != "2.0" {
+			t.Errorf("jsonrpc = %q, esperado 2.0", msg.JSONRPC)
+		}
+		replies = append(replies, msg)
+	}
+	return replies
+}
+
+func decodeResult(t *testing.T, msg message, v interface{}) {
+	t.Helper()
+	if msg.Error != nil {
+		t.Fatalf("resposta com erro: %v", msg.Error)
+	}
+	if err := json.Unmarshal(msg.Result, v); err != nil {
+		t.Fatalf("resultado inválido: %v", err)
+	}
+}
+
+func TestServe_Initialize(t *testing.T) {
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"1999-01-01", LatestProtocolVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			replies := serve(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+tt.requested+`","capabilities":{},"clientInfo":{"name":"editor","version":"1"}}}`)
+			if len(replies) != 1 {
+				t.Fatalf("esperava 1 resposta, obteve %d", len(replies))
+			}
+			var result initializeResult
+			decodeResult(t, replies[0], &result)
+			if result.ProtocolVersion != tt.expected {
+				t.Errorf("protocolVersion = %q, esperado %q", result.ProtocolVersion, tt.expected)
+			}
+			if result.ServerInfo.Name != "yby-bard" {
+				t.Errorf("serverInfo.name = %q", result.ServerInfo.Name)
+			}
+			if _, ok := result.Capabilities["tools"]; !ok {
+				t.Error("capabilities deveria anunciar tools")
+			}
+		})
+	}
+}
+
+func TestServe_ListTools(t *testing.T) {
+	registerTestTools(t)
+
+	replies := serve(t, `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`)
+	if len(replies) != 1 {
+		t.Fatalf("esperava 1 resposta, obteve %d", len(replies))
+	}
+	if string(*replies[0].ID) != `"a"` {
+		t.Errorf("id = %s, esperado \"a\"", *replies[0].ID)
+	}
+	var result listToolsResult
+	decodeResult(t, replies[0], &result)
+
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "falha,kubectl_get" {
+		t.Fatalf("ferramentas = %v, esperado [falha kubectl_get] sem a de escrita", names)
+	}
+
+	get := result.Tools[1]
+	if get.InputSchema.Type != "object" {
+		t.Errorf("inputSchema.type = %q", get.InputSchema.Type)
+	}
+	if get.InputSchema.Properties["resource"].Type != "string" {
+		t.Errorf("resource deveria ser string: %+v", get.InputSchema.Properties)
+	}
+	if len(get.InputSchema.Required) != 1 || get.InputSchema.Required[0] != "resource" {
+		t.Errorf("required = %v", get.InputSchema.Required)
+	}
+}
+
+func TestServe_CallTool(t *testing.T) {
+	registerTestTools(t)
+
+	replies := serve(t,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"kubectl_get","arguments":{"resource":"pods","namespace":"app"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"falha"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"kubectl_get","arguments":{}}}`,
+	)
+	if len(replies) != 3 {
+		t.Fatalf("esperava 3 respostas, obteve %d", len(replies))
+	}
+
+	var ok CallResult
+	decodeResult(t, replies[0], &ok)
+	if ok.IsError || ok.Text() != "get pods -n app" {
+		t.Errorf("resultado = %+v", ok)
+	}
+
+	var failed CallResult
+	decodeResult(t, replies[1], &failed)
+	if !failed.IsError || !strings.Contains(failed.Text(), "saida parcial") || !strings.Contains(failed.Text(), "comando falhou") {
+		t.Errorf("erro da ferramenta deveria vir no resultado com isError: %+v", failed)
+	}
+
+	var missing CallResult
+	decodeResult(t, replies[2], &missing)
+	if !missing.IsError || !strings.Contains(missing.Text(), "resource") {
+		t.Errorf("parâmetro obrigatório ausente deveria ser reportado: %+v", missing)
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	registerTestTools(t)
+
+	replies := serve(t,
+		`{nao e json`,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"kubectl_scale","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"inexistente"}}`,
+		`{"jsonrpc":"1.0","id":4,"method":"ping"}`,
+	)
+	expected := []int{codeParseError, codeMethodNotFound, codeInvalidParams, codeInvalidParams, codeInvalidRequest}
+	if len(replies) != len(expected) {
+		t.Fatalf("esperava %d respostas, obteve %d", len(expected), len(replies))
+	}
+	for i, code := range expected {
+		if replies[i].Error == nil || replies[i].Error.Code != code {
+			t.Errorf("resposta %d: erro = %v, esperado código %d", i, replies[i].Error, code)
+		}
+	}
+	if replies[0].ID != nil {
+		t.Errorf("erro de parse deveria ter id null, obteve %s", *replies[0].ID)
+	}
+}
+
+func TestServe_NotificacoesSemResposta(t *testing.T) {
+	replies := serve(t,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`,
+	)
+	if len(replies) != 1 {
+		t.Fatalf("esperava só a resposta do ping, obteve %d", len(replies))
+	}
+	if string(*replies[0].ID) != "7" || replies[0].Error != nil {
+		t.Errorf("resposta do ping = %+v", replies[0])
+	}
+}
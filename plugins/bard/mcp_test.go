@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/bard/mcp"
+)
+
+// TestHandleMCPCommand_Desconhecido verifica o erro para subcomandos inválidos.
+func TestHandleMCPCommand_Desconhecido(t *testing.T) {
+	if err := handleMCPCommand(nil); err != nil {
+		t.Errorf("sem subcomando deveria só exibir o uso, obteve %v", err)
+	}
+	if err := handleMCPCommand([]string{"connect"}); err == nil {
+		t.Error("esperava erro para subcomando desconhecido")
+	}
+}
+
+// TestRunMCPServe verifica que as ferramentas nativas são expostas, exceto
+// as de escrita.
+func TestRunMCPServe(t *testing.T) {
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	}, "\n"))
+	var out bytes.Buffer
+	if err := runMCPServe(context.Background(), in, &out); err != nil {
+		t.Fatalf("runMCPServe falhou: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("esperava 2 respostas, obteve %d: %s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"name":"yby-bard"`) {
+		t.Errorf("initialize deveria identificar o servidor: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"name":"kubectl_get"`) {
+		t.Errorf("tools/list deveria conter kubectl_get: %s", lines[1])
+	}
+	if strings.Contains(lines[1], `"name":"kubectl_scale"`) {
+		t.Errorf("tools/list não deveria expor ferramentas de escrita: %s", lines[1])
+	}
+}
+
+// TestConnectMCPServers_Falha verifica que um servidor indisponível só gera aviso.
+func TestConnectMCPServers_Falha(t *testing.T) {
+	var warn bytes.Buffer
+	closeAll := connectMCPServers(context.Background(), []mcp.ServerConfig{
+		{Name: "quebrado", Command: "/nao/existe/servidor-mcp"},
+	}, &warn)
+	closeAll()
+
+	if !strings.Contains(warn.String(), "servidor MCP quebrado indisponivel") {
+		t.Errorf("esperava aviso, obteve %q", warn.String())
+	}
+}
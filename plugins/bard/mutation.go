@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// bardEnvironment retorna o ambiente do projeto: o do contexto do plugin ou,
+// na falta dele, o de YBY_ENV.
+func bardEnvironment(ctxData map[string]interface{}) string {
+	if env, ok := ctxData["environment"].(string); ok && env != "" && env != "unknown" {
+		return env
+	}
+	return os.Getenv("YBY_ENV")
+}
+
+// applyMutationPolicy remove as ferramentas de escrita do registry quando a
+// política não as permite no ambiente, para que a IA nem as considere.
+func applyMutationPolicy(policy tools.MutationPolicy, environment string) {
+	if policy.Allows(environment) != nil {
+		tools.DisableMutating()
+	}
+}
+
+// confirmMutation retorna a confirmação de escrita do modo texto: exibe o
+// diff do dry-run e lê a resposta em in. Sem in (terminal não interativo),
+// sempre recusa. Recebe o scanner do chat para não competir com ele pela
+// leitura do stdin.
+func confirmMutation(in *bufio.Scanner, out io.Writer) func(pending *tools.PendingMutation) bool {
+	return func(pending *tools.PendingMutation) bool {
+		fmt.Fprintf(out, "%s (dry-run no servidor)\n%s\n", pending.Summary(), pending.Diff)
+		if in == nil {
+			fmt.Fprintln(out, "Operacao nao executada: confirmacao exige terminal interativo.")
+			return false
+		}
+
+		fmt.Fprint(out, "Aplicar esta alteracao no cluster? [s/N]: ")
+		answer := ""
+		if in.Scan() {
+			answer = in.Text()
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "s", "sim", "y", "yes":
+			return true
+		}
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// newFakeMutatingTool cria uma ferramenta de escrita que conta execuções.
+func newFakeMutatingTool(executed *int) *tools.Tool {
+	return &tools.Tool{
+		Name:       "kubectl_rollout_restart",
+		Mutating:   true,
+		Parameters: []tools.ToolParam{{Name: "resource"}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			*executed++
+			return "deployment.apps/api restarted", nil
+		},
+		DryRun: func(ctx context.Context, params map[string]string) (string, error) {
+			return "+      restartedAt: agora", nil
+		},
+	}
+}
+
+// TestBardEnvironment verifica a origem do ambiente.
+func TestBardEnvironment(t *testing.T) {
+	t.Setenv("YBY_ENV", "staging")
+
+	if env := bardEnvironment(map[string]interface{}{"environment": "prod"}); env != "prod" {
+		t.Errorf("esperava ambiente do contexto, obteve %s", env)
+	}
+	if env := bardEnvironment(map[string]interface{}{"environment": "unknown"}); env != "staging" {
+		t.Errorf("esperava YBY_ENV para ambiente desconhecido, obteve %s", env)
+	}
+	if env := bardEnvironment(nil); env != "staging" {
+		t.Errorf("esperava YBY_ENV sem contexto, obteve %s", env)
+	}
+}
+
+// TestConfirmMutation verifica a confirmação em modo texto.
+func TestConfirmMutation(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       *bufio.Scanner
+		approved bool
+		contains string
+	}{
+		{"confirmado", bufio.NewScanner(strings.NewReader("s\n")), true, "[s/N]"},
+		{"confirmado em ingles", bufio.NewScanner(strings.NewReader("yes\n")), true, "[s/N]"},
+		{"recusado", bufio.NewScanner(strings.NewReader("n\n")), false, "[s/N]"},
+		{"sem resposta", bufio.NewScanner(strings.NewReader("")), false, "[s/N]"},
+		{"sem terminal", nil, false, "terminal interativo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executed int
+			pending, err := tools.PrepareMutation(context.Background(), newFakeMutatingTool(&executed),
+				map[string]string{"resource": "deployment/api"}, "dev", tools.MutationPolicy{Enabled: true}, nil)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			var out bytes.Buffer
+			if got := confirmMutation(tt.in, &out)(pending); got != tt.approved {
+				t.Errorf("esperava %v, obteve %v", tt.approved, got)
+			}
+			if !strings.Contains(out.String(), "restartedAt") || !strings.Contains(out.String(), tt.contains) {
+				t.Errorf("saída inesperada: %s", out.String())
+			}
+			if executed != 0 {
+				t.Error("a confirmação não deve executar a operação")
+			}
+		})
+	}
+}
+
+// TestApplyMutationPolicy verifica a remoção das ferramentas de escrita em
+// ambientes negados.
+func TestApplyMutationPolicy(t *testing.T) {
+	saved := tools.All()
+	t.Cleanup(func() {
+		tools.Reset()
+		for _, tool := range saved {
+			tools.Register(tool)
+		}
+	})
+
+	applyMutationPolicy(tools.MutationPolicy{Enabled: true}, "dev")
+	if tools.Get("kubectl_scale") == nil {
+		t.Fatal("ferramentas de escrita deveriam continuar disponíveis em dev")
+	}
+
+	applyMutationPolicy(tools.MutationPolicy{Enabled: true}, "prod")
+	if tools.Get("kubectl_scale") != nil {
+		t.Error("ferramentas de escrita deveriam ser removidas em prod")
+	}
+	if tools.Get("kubectl_get") == nil {
+		t.Error("ferramentas de leitura não deveriam ser removidas")
+	}
+}
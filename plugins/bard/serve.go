@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+	"github.com/casheiro/yby-cli/plugins/bard/web"
+)
+
+// defaultServeAddr é o endereço padrão de `yby bard serve`: só a máquina
+// local alcança o servidor.
+const defaultServeAddr = "127.0.0.1:8088"
+
+// parseServeArgs lê as flags de `yby bard serve`.
+func parseServeArgs(args []string) (string, error) {
+	addr := defaultServeAddr
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--addr" && i+1 < len(args):
+			addr = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--addr="):
+			addr = strings.TrimPrefix(arg, "--addr=")
+		default:
+			return "", fmt.Errorf("flag desconhecida para serve: %s", arg)
+		}
+	}
+	if addr == "" {
+		return "", fmt.Errorf("--addr nao pode ser vazio")
+	}
+	return addr, nil
+}
+
+// checkServeAddr recusa expor o Bard fora da máquina local sem token: a API
+// executa ferramentas com as credenciais do usuário.
+func checkServeAddr(addr, token string) error {
+	if token != "" || isLoopbackAddr(addr) {
+		return nil
+	}
+	return fmt.Errorf("endereco %s aceita conexoes de outras maquinas; defina YBY_BARD_TOKEN ou use --addr %s", addr, defaultServeAddr)
+}
+
+// isLoopbackAddr informa se o endereço de escuta é restrito à interface de
+// loopback. Host vazio (":8088") escuta em todas as interfaces.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// runServe inicia o Bard como servidor HTTP: API de chat com SSE e interface
+// web, com o mesmo system prompt, RAG, ferramentas e histórico do terminal.
+func runServe(ctxData map[string]interface{}, args []string) error {
+	addr, err := parseServeArgs(args)
+	if err != nil {
+		return err
+	}
+	token := os.Getenv("YBY_BARD_TOKEN")
+	if err := checkServeAddr(addr, token); err != nil {
+		return err
+	}
+
+	tools.LoadExternalTools()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	provider := ai.GetProvider(ctx, "auto")
+	if provider == nil {
+		return fmt.Errorf("nenhum provedor de IA disponível. Defina OLLAMA_HOST ou OPENAI_API_KEY")
+	}
+
+	cwd, _ := os.Getwd()
+	vectorStore, err := ai.NewVectorStore(ctx, filepath.Join(cwd, ".synapstor", ".index"), ai.GetEmbeddingProvider(ctx))
+	if err != nil {
+		slog.Debug("memoria semantica indisponivel", "erro", err)
+		vectorStore = nil
+	}
+
+	bardCfg := loadBardConfig()
+	environment := bardEnvironment(ctxData)
+	applyMutationPolicy(bardCfg.Mutations, environment)
+	defer connectMCPServers(ctx, bardCfg.MCPServers, os.Stderr)()
+	clusterCtx := EnrichContext(ctx)
+
+	sessions := newSessionStore(provider)
+	defer sessions.wait()
+
+	srv := web.New(provider, web.Config{
+		SystemPrompt: func(sessionID string) string {
+			return buildSystemPrompt(ctxData, bardCfg, sessions.history(ctx, sessionID), clusterCtx)
+		},
+		Input: func(ctx context.Context, question string) string {
+			return ragInput(ctx, vectorStore, bardCfg, question)
+		},
+		History: func(sessionID string) []web.Message {
+			var messages []web.Message
+			for _, e := range sessionEntries(sessionID) {
+				messages = append(messages, web.Message{Role: e.Role, Content: e.Content})
+			}
+			return messages
+		},
+		SaveMessage: sessions.save,
+		Agent: func(systemPrompt string) agent.Config {
+			return agentConfig(bardCfg, systemPrompt)
+		},
+		Environment: environment,
+		Mutations:   bardCfg.Mutations,
+		Audit:       tools.NewAuditLog(),
+		Token:       token,
+	})
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Bard disponivel em %s (Ctrl+C para encerrar)\n", serveURL(addr))
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "aviso: YBY_BARD_TOKEN nao definido, a API aceita qualquer processo desta maquina")
+	}
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("falha ao iniciar servidor em %s: %w", addr, err)
+	}
+	return nil
+}
+
+// serveURL retorna a URL de acesso ao endereço de escuta.
+func serveURL(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "http://localhost" + addr
+	}
+	return "http://" + addr
+}
+
+// ragInput enriquece a pergunta com o contexto do Synapstor acima do
+// threshold de relevância. Sem vector store ou resultados, retorna a pergunta.
+func ragInput(ctx context.Context, vectorStore *ai.VectorStore, bardCfg BardConfig, question string) string {
+	if vectorStore == nil {
+		return question
+	}
+	results, err := vectorStore.Search(ctx, question, bardCfg.TopK)
+	if err != nil || len(results) == 0 {
+		return question
+	}
+	filtered := filterByThreshold(results, bardCfg.RelevanceThreshold)
+	if len(filtered) == 0 {
+		return question
+	}
+	var sb strings.Builder
+	for _, res := range filtered {
+		sb.WriteString(fmt.Sprintf("\n--- Contexto: %s ---\n%s\n", res.Metadata["title"], res.Content))
+	}
+	return fmt.Sprintf("Contexto Adicional Recuperado (Memória Semântica):\n%s\n\nPergunta do Usuário: %s", sb.String(), question)
+}
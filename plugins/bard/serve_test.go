@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestParseServeArgs verifica as flags de `yby bard serve`.
+func TestParseServeArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{nil, "127.0.0.1:8088", false},
+		{[]string{"--addr", "127.0.0.1:9000"}, "127.0.0.1:9000", false},
+		{[]string{"--addr=:9090"}, ":9090", false},
+		{[]string{"--addr="}, "", true},
+		{[]string{"--porta", "80"}, "", true},
+	}
+	for _, tt := range tests {
+		addr, err := parseServeArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseServeArgs(%v) erro = %v, esperava erro: %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if addr != tt.expected {
+			t.Errorf("parseServeArgs(%v) = %q, esperado %q", tt.args, addr, tt.expected)
+		}
+	}
+}
+
+// TestCheckServeAddr verifica que endereços fora do loopback exigem token.
+func TestCheckServeAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		token   string
+		wantErr bool
+	}{
+		{"127.0.0.1:8088", "", false},
+		{"localhost:8088", "", false},
+		{"[::1]:8088", "", false},
+		{":8088", "", true},
+		{"0.0.0.0:8088", "", true},
+		{"10.0.0.5:8088", "", true},
+		{"bard.interno:8088", "", true},
+		{":8088", "segredo", false},
+		{"0.0.0.0:8088", "segredo", false},
+	}
+	for _, tt := range tests {
+		if err := checkServeAddr(tt.addr, tt.token); (err != nil) != tt.wantErr {
+			t.Errorf("checkServeAddr(%q, %q) erro = %v, esperava erro: %v", tt.addr, tt.token, err, tt.wantErr)
+		}
+	}
+}
+
+// TestServeURL verifica a URL exibida para o endereço de escuta.
+func TestServeURL(t *testing.T) {
+	if got := serveURL(":8088"); got != "http://localhost:8088" {
+		t.Errorf("serveURL(:8088) = %q", got)
+	}
+	if got := serveURL("10.0.0.5:8088"); got != "http://10.0.0.5:8088" {
+		t.Errorf("serveURL(10.0.0.5:8088) = %q", got)
+	}
+}
+
+// TestRagInput_SemVectorStore verifica que a pergunta segue sem contexto.
+func TestRagInput_SemVectorStore(t *testing.T) {
+	if got := ragInput(context.Background(), nil, loadBardConfig(), "pergunta"); got != "pergunta" {
+		t.Errorf("ragInput = %q", got)
+	}
+}
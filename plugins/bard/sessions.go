@@ -0,0 +1,543 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+)
+
+// sessionsFile guarda os metadados das sessões (nome, título e resumo). As
+// mensagens continuam em historyFile.
+const sessionsFile = ".yby/.bard_sessions.json"
+
+// compactKeepEntries é o número de mensagens recentes mantidas na íntegra
+// quando uma sessão longa é compactada.
+const compactKeepEntries = maxHistoryEntries / 2
+
+// maxSearchResults limita os resultados de /search.
+const maxSearchResults = 20
+
+// maxTitleLength limita o título gerado, em caracteres.
+const maxTitleLength = 60
+
+// SessionMeta contém os metadados de uma sessão.
+type SessionMeta struct {
+	Name  string `json:"name,omitempty"`
+	Title string `json:"title,omitempty"`
+	// Summary resume as mensagens da sessão até CompactedUntil, que deixam
+	// de ser enviadas na íntegra ao modelo.
+	Summary string `json:"summary,omitempty"`
+	// CompactedUntil é o timestamp da última mensagem resumida. Um índice
+	// deslocaria quando mensagens antigas expiram por maxHistoryAge.
+	CompactedUntil string `json:"compacted_until,omitempty"`
+	// CompactedTies é quantas mensagens com timestamp igual a CompactedUntil
+	// foram resumidas: o timestamp tem resolução de segundos.
+	CompactedTies int `json:"compacted_ties,omitempty"`
+}
+
+// SearchResult é uma mensagem encontrada por /search.
+type SearchResult struct {
+	SessionID string
+	Entry     HistoryEntry
+	Snippet   string
+}
+
+// sessionsMu serializa as escritas em sessionsFile (títulos são gerados em
+// segundo plano).
+var sessionsMu sync.Mutex
+
+// loadSessionMeta lê os metadados de todas as sessões.
+func loadSessionMeta() map[string]SessionMeta {
+	meta := make(map[string]SessionMeta)
+	data, err := os.ReadFile(sessionsFile)
+	if err != nil {
+		return meta
+	}
+	_ = json.Unmarshal(data, &meta)
+	return meta
+}
+
+// updateSessionMeta aplica update aos metadados da sessão e os persiste.
+func updateSessionMeta(sessionID string, update func(*SessionMeta)) error {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	meta := loadSessionMeta()
+	m := meta[sessionID]
+	update(&m)
+	meta[sessionID] = m
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("falha ao serializar sessões: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(sessionsFile), 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório de sessões: %w", err)
+	}
+	if err := os.WriteFile(sessionsFile, data, 0600); err != nil {
+		return fmt.Errorf("falha ao salvar sessões: %w", err)
+	}
+	return nil
+}
+
+// sessionStore gerencia as sessões do chat: persistência das mensagens,
+// títulos gerados pela IA, compactação de sessões longas e os comandos de
+// sessão (/sessions, /resume, /name, /search, /export).
+type sessionStore struct {
+	provider ai.Provider
+	wg       sync.WaitGroup
+}
+
+func newSessionStore(provider ai.Provider) *sessionStore {
+	return &sessionStore{provider: provider}
+}
+
+// save persiste a mensagem. Após a primeira resposta de uma sessão sem
+// título, gera o título em segundo plano.
+func (s *sessionStore) save(role, content, sessionID string) {
+	saveMessage(role, content, sessionID)
+	if role != "assistant" || s.provider == nil || loadSessionMeta()[sessionID].Title != "" {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.generateTitle(context.Background(), sessionID); err != nil {
+			slog.Debug("titulo da sessao nao gerado", "sessao", sessionID, "erro", err)
+		}
+	}()
+}
+
+// wait aguarda os títulos em geração antes de o Bard encerrar.
+func (s *sessionStore) wait() {
+	s.wg.Wait()
+}
+
+// generateTitle gera o título da sessão a partir das primeiras mensagens.
+func (s *sessionStore) generateTitle(ctx context.Context, sessionID string) error {
+	entries := sessionEntries(sessionID)
+	if len(entries) > 4 {
+		entries = entries[:4]
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%s: %s\n\n", roleLabel(e.Role), truncateRunes(e.Content, 500)))
+	}
+
+	resp, err := s.provider.Completion(ctx, prompts.Get("bard.session_title"), sb.String())
+	if err != nil {
+		return fmt.Errorf("falha ao gerar título: %w", err)
+	}
+	title := cleanTitle(resp)
+	if title == "" {
+		return errors.New("título vazio")
+	}
+	return updateSessionMeta(sessionID, func(m *SessionMeta) {
+		if m.Title == "" {
+			m.Title = title
+		}
+	})
+}
+
+// history retorna o histórico da sessão para o system prompt. Quando as
+// mensagens ainda não resumidas passam de maxHistoryEntries, as mais antigas
+// são compactadas em um resumo gerado pela IA e só as compactKeepEntries
+// mais recentes seguem na íntegra. Se o resumo falhar, mantém as
+// maxHistoryEntries mais recentes.
+func (s *sessionStore) history(ctx context.Context, sessionID string) []HistoryEntry {
+	meta := loadSessionMeta()[sessionID]
+	recent := uncompacted(sessionEntries(sessionID), meta)
+	if len(recent) > maxHistoryEntries {
+		if err := s.compact(ctx, sessionID, recent, &meta); err != nil {
+			slog.Debug("sessao nao compactada", "sessao", sessionID, "erro", err)
+			recent = recent[len(recent)-maxHistoryEntries:]
+		} else {
+			recent = recent[len(recent)-compactKeepEntries:]
+		}
+	}
+
+	var history []HistoryEntry
+	if meta.Summary != "" {
+		history = append(history, HistoryEntry{Role: "summary", Content: meta.Summary})
+	}
+	return append(history, recent...)
+}
+
+// uncompacted retorna as mensagens da sessão posteriores às já resumidas.
+// As mensagens estão em ordem de gravação; com timestamp inválido, o resumo
+// é ignorado a partir daquela mensagem.
+func uncompacted(session []HistoryEntry, meta SessionMeta) []HistoryEntry {
+	if meta.CompactedUntil == "" {
+		return session
+	}
+	until, err := time.Parse(time.RFC3339, meta.CompactedUntil)
+	if err != nil {
+		return session
+	}
+	ties := meta.CompactedTies
+	for i, e := range session {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		switch {
+		case err != nil:
+			return session[i:]
+		case ts.Before(until):
+		case ts.Equal(until) && ties > 0:
+			ties--
+		default:
+			return session[i:]
+		}
+	}
+	return nil
+}
+
+// compact resume as mensagens não resumidas mais antigas junto com o resumo
+// anterior e atualiza meta. recent são as mensagens posteriores ao resumo.
+func (s *sessionStore) compact(ctx context.Context, sessionID string, recent []HistoryEntry, meta *SessionMeta) error {
+	if s.provider == nil {
+		return errors.New("nenhum provedor de IA para resumir a sessão")
+	}
+	old := recent[:len(recent)-compactKeepEntries]
+	input := formatHistoryContext(old)
+	if meta.Summary != "" {
+		input = "## Resumo anterior\n" + meta.Summary + "\n\n" + input
+	}
+
+	summary, err := s.provider.Completion(ctx, prompts.Get("bard.compact"), input)
+	if err != nil {
+		return fmt.Errorf("falha ao resumir sessão: %w", err)
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return errors.New("resumo vazio")
+	}
+
+	until := old[len(old)-1].Timestamp
+	ties := 0
+	if meta.CompactedUntil == until {
+		ties = meta.CompactedTies
+	}
+	for _, e := range old {
+		if e.Timestamp == until {
+			ties++
+		}
+	}
+	if err := updateSessionMeta(sessionID, func(m *SessionMeta) {
+		m.Summary, m.CompactedUntil, m.CompactedTies = summary, until, ties
+	}); err != nil {
+		return err
+	}
+	meta.Summary, meta.CompactedUntil, meta.CompactedTies = summary, until, ties
+	return nil
+}
+
+// command trata os comandos de sessão do chat. Retorna o texto a exibir, a
+// sessão ativa após o comando e false quando input não é um comando de sessão.
+func (s *sessionStore) command(input, current string) (string, string, bool) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return "", current, false
+	}
+	args := fields[1:]
+
+	switch fields[0] {
+	case "/sessions":
+		return formatSessions(current), current, true
+	case "/resume", "/session":
+		output, sessionID := resumeSession(args, current)
+		return output, sessionID, true
+	case "/name":
+		return nameSession(args, current), current, true
+	case "/search":
+		return formatSearch(strings.Join(args, " ")), current, true
+	case "/export":
+		return exportSession(args, current), current, true
+	}
+	return "", current, false
+}
+
+// sessionEntries retorna todas as mensagens da sessão.
+func sessionEntries(sessionID string) []HistoryEntry {
+	entries, _ := loadAllEntries()
+	return loadSessionHistory(entries, sessionID, len(entries))
+}
+
+// resolveSession encontra a sessão pelo ID ou pelo nome.
+func resolveSession(entries []HistoryEntry, meta map[string]SessionMeta, ref string) (string, bool) {
+	for _, e := range entries {
+		if e.SessionID == ref {
+			return ref, true
+		}
+	}
+	for id, m := range meta {
+		if m.Name != "" && strings.EqualFold(m.Name, ref) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// sessionLabel descreve a sessão com nome e título, quando existirem.
+func sessionLabel(sessionID string, m SessionMeta) string {
+	label := sessionID
+	if m.Name != "" {
+		label += " [" + m.Name + "]"
+	}
+	if m.Title != "" {
+		label += " " + m.Title
+	}
+	return label
+}
+
+func formatSessions(current string) string {
+	entries, err := loadAllEntries()
+	if err != nil {
+		return fmt.Sprintf("Erro ao carregar sessões: %v", err)
+	}
+	if len(entries) == 0 {
+		return "Nenhuma sessão encontrada."
+	}
+
+	meta := loadSessionMeta()
+	var sb strings.Builder
+	sb.WriteString("Sessões disponíveis:")
+	for _, s := range listSessions(entries) {
+		marker, suffix := "  ", ""
+		if s.SessionID == current {
+			marker, suffix = "* ", " — atual"
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s%s (%d mensagens)%s", marker, sessionLabel(s.SessionID, meta[s.SessionID]), s.MessageCount, suffix))
+	}
+	return sb.String()
+}
+
+// resumeSession troca a sessão ativa. Sem argumentos, retoma a sessão mais
+// recente além da atual.
+func resumeSession(args []string, current string) (string, string) {
+	entries, err := loadAllEntries()
+	if err != nil {
+		return fmt.Sprintf("Erro ao carregar sessões: %v", err), current
+	}
+	meta := loadSessionMeta()
+
+	var target string
+	if len(args) == 0 {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].SessionID != current {
+				target = entries[i].SessionID
+				break
+			}
+		}
+		if target == "" {
+			return "Nenhuma sessão anterior para retomar.", current
+		}
+	} else {
+		id, ok := resolveSession(entries, meta, args[0])
+		if !ok {
+			return fmt.Sprintf("Sessão '%s' não encontrada.", args[0]), current
+		}
+		target = id
+	}
+
+	count := len(loadSessionHistory(entries, target, len(entries)))
+	return fmt.Sprintf("Sessão retomada: %s (%d mensagens)", sessionLabel(target, meta[target]), count), target
+}
+
+// nameSession dá um nome à sessão atual, usado em /resume e /export.
+func nameSession(args []string, current string) string {
+	name := strings.Join(args, "-")
+	if name == "" {
+		return "Uso: /name <nome>"
+	}
+	for id, m := range loadSessionMeta() {
+		if id != current && strings.EqualFold(m.Name, name) {
+			return fmt.Sprintf("O nome '%s' já é usado pela sessão %s.", name, id)
+		}
+	}
+	if err := updateSessionMeta(current, func(m *SessionMeta) { m.Name = name }); err != nil {
+		return fmt.Sprintf("Erro ao nomear sessão: %v", err)
+	}
+	return fmt.Sprintf("Sessão %s agora se chama '%s'.", current, name)
+}
+
+// searchSessions busca mensagens que contenham todos os termos da consulta,
+// sem diferenciar maiúsculas. As mais recentes vêm primeiro.
+func searchSessions(entries []HistoryEntry, query string) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []SearchResult
+	for i := len(entries) - 1; i >= 0 && len(results) < maxSearchResults; i-- {
+		content := strings.ToLower(entries[i].Content)
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(content, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, SearchResult{
+				SessionID: entries[i].SessionID,
+				Entry:     entries[i],
+				Snippet:   snippet(entries[i].Content, terms[0]),
+			})
+		}
+	}
+	return results
+}
+
+func formatSearch(query string) string {
+	if strings.TrimSpace(query) == "" {
+		return "Uso: /search <termos>"
+	}
+	entries, err := loadAllEntries()
+	if err != nil {
+		return fmt.Sprintf("Erro ao carregar sessões: %v", err)
+	}
+	results := searchSessions(entries, query)
+	if len(results) == 0 {
+		return fmt.Sprintf("Nenhuma mensagem encontrada para '%s'.", query)
+	}
+
+	meta := loadSessionMeta()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Resultados para '%s':", query))
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("\n  %s (%s) %s: %s", sessionLabel(r.SessionID, meta[r.SessionID]), r.Entry.Timestamp, roleLabel(r.Entry.Role), r.Snippet))
+	}
+	return sb.String()
+}
+
+// snippet retorna o trecho da mensagem em volta da primeira ocorrência do
+// termo, em uma linha.
+func snippet(content, term string) string {
+	const radius = 60
+	text := strings.Join(strings.Fields(content), " ")
+	idx := strings.Index(strings.ToLower(text), term)
+	if idx < 0 {
+		return truncateRunes(text, 2*radius)
+	}
+
+	start, end := idx-radius, idx+len(term)+radius
+	prefix, suffix := "...", "..."
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	}
+	// não cortar caracteres multibyte ao meio
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	return prefix + text[start:end] + suffix
+}
+
+// exportSession exporta uma sessão para markdown: /export [sessão] [arquivo.md].
+// Sem sessão, exporta a atual; sem arquivo, grava bard-<sessão>.md.
+func exportSession(args []string, current string) string {
+	entries, err := loadAllEntries()
+	if err != nil {
+		return fmt.Sprintf("Erro ao carregar sessões: %v", err)
+	}
+	meta := loadSessionMeta()
+
+	target := current
+	if len(args) > 0 && !strings.HasSuffix(args[0], ".md") {
+		id, ok := resolveSession(entries, meta, args[0])
+		if !ok {
+			return fmt.Sprintf("Sessão '%s' não encontrada.", args[0])
+		}
+		target, args = id, args[1:]
+	}
+	session := loadSessionHistory(entries, target, len(entries))
+	if len(session) == 0 {
+		return fmt.Sprintf("Sessão %s sem mensagens para exportar.", target)
+	}
+
+	path := fmt.Sprintf("bard-%s.md", target)
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if err := os.WriteFile(path, []byte(sessionMarkdown(target, meta[target], session)), 0644); err != nil {
+		return fmt.Sprintf("Erro ao exportar sessão: %v", err)
+	}
+	return fmt.Sprintf("Sessão exportada para %s (%d mensagens).", path, len(session))
+}
+
+// sessionMarkdown formata a sessão como markdown.
+func sessionMarkdown(sessionID string, m SessionMeta, entries []HistoryEntry) string {
+	title := m.Title
+	if title == "" {
+		title = m.Name
+	}
+	if title == "" {
+		title = "Sessão " + sessionID
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# " + title + "\n\n")
+	sb.WriteString(fmt.Sprintf("- Sessão: `%s`\n", sessionID))
+	if m.Name != "" {
+		sb.WriteString(fmt.Sprintf("- Nome: %s\n", m.Name))
+	}
+	sb.WriteString(fmt.Sprintf("- Mensagens: %d\n", len(entries)))
+	sb.WriteString(fmt.Sprintf("- Período: %s a %s\n", entries[0].Timestamp, entries[len(entries)-1].Timestamp))
+	if m.Summary != "" {
+		sb.WriteString("\n## Resumo\n\n" + m.Summary + "\n")
+	}
+	sb.WriteString("\n## Conversa\n")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("\n### %s (%s)\n\n%s\n", roleLabel(e.Role), e.Timestamp, strings.TrimSpace(e.Content)))
+	}
+	return sb.String()
+}
+
+func roleLabel(role string) string {
+	if role == "assistant" {
+		return "Assistente"
+	}
+	return "Usuário"
+}
+
+// cleanTitle extrai o título da resposta do modelo: primeira linha, sem
+// aspas ou marcadores de markdown, limitada a maxTitleLength.
+func cleanTitle(resp string) string {
+	title := firstNonEmptyLine(resp)
+	title = strings.TrimPrefix(title, "Título:")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*#. ")
+	return truncateRunes(title, maxTitleLength)
+}
+
+func firstNonEmptyLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncateRunes corta s em max caracteres, indicando o corte com "...".
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-3]) + "..."
+}
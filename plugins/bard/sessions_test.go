@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+)
+
+// completionProvider responde às chamadas de Completion com respond e
+// registra os inputs recebidos.
+type completionProvider struct {
+	mu      sync.Mutex
+	respond func(userPrompt string) (string, error)
+	inputs  []string
+}
+
+func (p *completionProvider) Name() string                       { return "fake" }
+func (p *completionProvider) IsAvailable(_ context.Context) bool { return true }
+func (p *completionProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (p *completionProvider) Completion(_ context.Context, _, userPrompt string) (string, error) {
+	p.mu.Lock()
+	p.inputs = append(p.inputs, userPrompt)
+	p.mu.Unlock()
+	return p.respond(userPrompt)
+}
+func (p *completionProvider) StreamCompletion(_ context.Context, _, _ string, _ io.Writer) error {
+	return nil
+}
+func (p *completionProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+
+func (p *completionProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.inputs)
+}
+
+// saveConversation grava n mensagens alternando usuário e assistente.
+func saveConversation(sessionID string, n int) {
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		saveMessage(role, fmt.Sprintf("mensagem %d", i), sessionID)
+	}
+}
+
+// TestSessionStore_GeraTitulo verifica que o título é gerado após a primeira
+// resposta e não é gerado de novo.
+func TestSessionStore_GeraTitulo(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	provider := &completionProvider{respond: func(string) (string, error) {
+		return "\"Pods em CrashLoop no namespace app.\"\n", nil
+	}}
+	store := newSessionStore(provider)
+
+	store.save("user", "por que o checkout reinicia?", "s1")
+	store.wait()
+	if provider.calls() != 0 {
+		t.Fatal("título não deve ser gerado antes da resposta")
+	}
+
+	store.save("assistant", "o pod checkout está em CrashLoopBackOff", "s1")
+	store.wait()
+	if got := loadSessionMeta()["s1"].Title; got != "Pods em CrashLoop no namespace app" {
+		t.Errorf("título = %q", got)
+	}
+	if !strings.Contains(provider.inputs[0], "Usuário: por que o checkout reinicia?") {
+		t.Errorf("input do título = %q", provider.inputs[0])
+	}
+
+	store.save("assistant", "mais uma resposta", "s1")
+	store.wait()
+	if provider.calls() != 1 {
+		t.Errorf("título não deveria ser regerado, chamadas = %d", provider.calls())
+	}
+}
+
+// TestSessionStore_HistoryCompacta verifica que sessões longas viram resumo
+// + mensagens recentes e que o resumo anterior entra na próxima compactação.
+func TestSessionStore_HistoryCompacta(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	summaries := 0
+	provider := &completionProvider{respond: func(string) (string, error) {
+		summaries++
+		return fmt.Sprintf("resumo %d", summaries), nil
+	}}
+	store := newSessionStore(provider)
+	saveConversation("outra", 3)
+	saveConversation("s1", maxHistoryEntries+5)
+
+	history := store.history(context.Background(), "s1")
+	if len(history) != compactKeepEntries+1 {
+		t.Fatalf("esperava resumo + %d mensagens, obteve %d", compactKeepEntries, len(history))
+	}
+	if history[0].Role != "summary" || history[0].Content != "resumo 1" {
+		t.Errorf("primeira entrada deveria ser o resumo: %+v", history[0])
+	}
+	if history[1].Content != fmt.Sprintf("mensagem %d", maxHistoryEntries+5-compactKeepEntries) {
+		t.Errorf("primeira mensagem mantida = %q", history[1].Content)
+	}
+	if !strings.Contains(provider.inputs[0], "mensagem 0") || strings.Contains(provider.inputs[0], "resumo") {
+		t.Errorf("input da compactação = %q", provider.inputs[0])
+	}
+	entries := sessionEntries("s1")
+	meta := loadSessionMeta()["s1"]
+	if meta.CompactedUntil != entries[maxHistoryEntries+5-compactKeepEntries-1].Timestamp {
+		t.Errorf("compacted_until = %q", meta.CompactedUntil)
+	}
+	if rest := uncompacted(entries, meta); len(rest) != compactKeepEntries {
+		t.Errorf("esperava %d mensagens após o resumo, obteve %d", compactKeepEntries, len(rest))
+	}
+
+	// sem mensagens novas suficientes, usa o resumo salvo
+	store.history(context.Background(), "s1")
+	if provider.calls() != 1 {
+		t.Errorf("não deveria compactar de novo, chamadas = %d", provider.calls())
+	}
+
+	saveConversation("s1", maxHistoryEntries)
+	history = store.history(context.Background(), "s1")
+	if provider.calls() != 2 || history[0].Content != "resumo 2" {
+		t.Fatalf("esperava segunda compactação: chamadas = %d, %+v", provider.calls(), history[0])
+	}
+	if !strings.Contains(provider.inputs[1], "## Resumo anterior\nresumo 1") {
+		t.Errorf("resumo anterior deveria entrar na compactação: %q", provider.inputs[1])
+	}
+}
+
+// TestSessionStore_HistoryComMensagensExpiradas verifica que mensagens
+// descartadas por maxHistoryAge não deslocam o ponto do resumo.
+func TestSessionStore_HistoryComMensagensExpiradas(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	now := time.Now().Truncate(time.Second)
+	expired := now.Add(-maxHistoryAge - time.Hour).Format(time.RFC3339)
+	compacted := now.Add(-time.Hour).Format(time.RFC3339)
+	recent := now.Format(time.RFC3339)
+	var lines []string
+	add := func(ts, content string) {
+		data, _ := json.Marshal(HistoryEntry{Role: "user", Content: content, Timestamp: ts, SessionID: "s1"})
+		lines = append(lines, string(data))
+	}
+	for i := 0; i < 3; i++ {
+		add(expired, fmt.Sprintf("expirada %d", i))
+	}
+	add(compacted, "resumida 1")
+	add(compacted, "resumida 2")
+	add(compacted, "nova 1")
+	add(recent, "nova 2")
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(historyFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateSessionMeta("s1", func(m *SessionMeta) {
+		m.Summary, m.CompactedUntil, m.CompactedTies = "resumo", compacted, 2
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	history := newSessionStore(nil).history(context.Background(), "s1")
+	var contents []string
+	for _, e := range history {
+		contents = append(contents, e.Content)
+	}
+	if got := strings.Join(contents, ", "); got != "resumo, nova 1, nova 2" {
+		t.Errorf("histórico = %s", got)
+	}
+}
+
+// TestSessionStore_HistoryFalhaNaCompactacao verifica o fallback para as
+// mensagens mais recentes.
+func TestSessionStore_HistoryFalhaNaCompactacao(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	store := newSessionStore(&completionProvider{respond: func(string) (string, error) {
+		return "", errors.New("provider indisponível")
+	}})
+	saveConversation("s1", maxHistoryEntries+3)
+
+	history := store.history(context.Background(), "s1")
+	if len(history) != maxHistoryEntries || history[0].Content != "mensagem 3" {
+		t.Errorf("esperava as %d mais recentes, obteve %d começando em %+v", maxHistoryEntries, len(history), history[0])
+	}
+	if _, err := os.Stat(sessionsFile); !os.IsNotExist(err) {
+		t.Error("metadados não deveriam ser gravados sem resumo")
+	}
+}
+
+// TestSessionCommands verifica /name, /resume, /sessions e comandos desconhecidos.
+func TestSessionCommands(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	store := newSessionStore(nil)
+	saveConversation("antiga", 2)
+	saveConversation("recente", 3)
+
+	if _, _, ok := store.command("o que é um pod?", "atual"); ok {
+		t.Error("texto comum não é comando")
+	}
+	if _, _, ok := store.command("/desconhecido", "atual"); ok {
+		t.Error("comando desconhecido não deveria ser tratado")
+	}
+
+	output, current, _ := store.command("/name incidente checkout", "antiga")
+	if current != "antiga" || !strings.Contains(output, "incidente-checkout") {
+		t.Errorf("/name = %q, %q", output, current)
+	}
+	if output, _, _ := store.command("/name INCIDENTE-checkout", "recente"); !strings.Contains(output, "já é usado") {
+		t.Errorf("nome repetido deveria ser recusado: %q", output)
+	}
+
+	// sem argumento: a mais recente além da atual
+	output, current, _ = store.command("/resume", "atual")
+	if current != "recente" || !strings.Contains(output, "recente (3 mensagens)") {
+		t.Errorf("/resume = %q, %q", output, current)
+	}
+	// pelo nome
+	output, current, _ = store.command("/resume incidente-checkout", "atual")
+	if current != "antiga" || !strings.Contains(output, "antiga [incidente-checkout]") {
+		t.Errorf("/resume <nome> = %q, %q", output, current)
+	}
+	// /session continua funcionando
+	if _, current, _ = store.command("/session recente", "atual"); current != "recente" {
+		t.Errorf("/session <id> = %q", current)
+	}
+	output, current, _ = store.command("/resume inexistente", "atual")
+	if current != "atual" || !strings.Contains(output, "não encontrada") {
+		t.Errorf("/resume inexistente = %q, %q", output, current)
+	}
+
+	output, _, _ = store.command("/sessions", "recente")
+	if !strings.Contains(output, "antiga [incidente-checkout] (2 mensagens)") || !strings.Contains(output, "* recente (3 mensagens) — atual") {
+		t.Errorf("/sessions = %q", output)
+	}
+}
+
+// TestSessionCommand_Search verifica a busca em todas as sessões.
+func TestSessionCommand_Search(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	saveMessage("user", "O pod Checkout está em CrashLoopBackOff desde ontem", "s1")
+	saveMessage("assistant", "Verifique os logs do checkout", "s1")
+	saveMessage("user", "crashloop no worker", "s2")
+
+	output, _, _ := newSessionStore(nil).command("/search crashloop checkout", "s3")
+	if !strings.Contains(output, "s1") || !strings.Contains(output, "Usuário: O pod Checkout está em CrashLoopBackOff") {
+		t.Errorf("/search = %q", output)
+	}
+	if strings.Contains(output, "s2") || strings.Contains(output, "Assistente") {
+		t.Errorf("só mensagens com todos os termos deveriam ser retornadas: %q", output)
+	}
+
+	output, _, _ = newSessionStore(nil).command("/search inexistente", "s3")
+	if !strings.Contains(output, "Nenhuma mensagem") {
+		t.Errorf("/search sem resultados = %q", output)
+	}
+}
+
+// TestSessionCommand_Export verifica a exportação para markdown.
+func TestSessionCommand_Export(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	saveMessage("user", "lista os pods", "s1")
+	saveMessage("assistant", "## Pods\n- api", "s1")
+	if err := updateSessionMeta("s1", func(m *SessionMeta) {
+		m.Name, m.Title, m.Summary = "pods", "Listagem de pods", "usuario listou pods"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store := newSessionStore(nil)
+
+	output, _, _ := store.command("/export", "s1")
+	if !strings.Contains(output, "bard-s1.md") {
+		t.Fatalf("/export = %q", output)
+	}
+	data, err := os.ReadFile("bard-s1.md")
+	if err != nil {
+		t.Fatalf("arquivo não exportado: %v", err)
+	}
+	md := string(data)
+	for _, expected := range []string{"# Listagem de pods", "- Nome: pods", "## Resumo\n\nusuario listou pods", "### Usuário (", "lista os pods", "### Assistente (", "## Pods\n- api"} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("markdown sem %q:\n%s", expected, md)
+		}
+	}
+
+	// outra sessão, pelo nome, em arquivo escolhido
+	if output, _, _ := store.command("/export pods saida.md", "s2"); !strings.Contains(output, "saida.md") {
+		t.Errorf("/export <sessao> <arquivo> = %q", output)
+	}
+	if _, err := os.Stat("saida.md"); err != nil {
+		t.Errorf("arquivo escolhido não foi criado: %v", err)
+	}
+
+	if output, _, _ := store.command("/export", "vazia"); !strings.Contains(output, "sem mensagens") {
+		t.Errorf("/export de sessão vazia = %q", output)
+	}
+}
+
+// TestFormatHistoryContext_Resumo verifica a formatação do resumo da sessão.
+func TestFormatHistoryContext_Resumo(t *testing.T) {
+	result := formatHistoryContext([]HistoryEntry{
+		{Role: "summary", Content: "usuario investigou o checkout"},
+		{Role: "user", Content: "e agora?", Timestamp: "2025-01-01T10:00:00Z"},
+	})
+	if !strings.Contains(result, "**Resumo das mensagens anteriores:**\nusuario investigou o checkout") {
+		t.Errorf("resumo ausente: %q", result)
+	}
+	if !strings.Contains(result, "**Usuário** (2025-01-01T10:00:00Z):\ne agora?") {
+		t.Errorf("mensagem ausente: %q", result)
+	}
+}
+
+// TestClearHistory_RemoveSessoes verifica que /clear também remove os metadados.
+func TestClearHistory_RemoveSessoes(t *testing.T) {
+	restore := chdir(t, t.TempDir())
+	defer restore()
+
+	saveMessage("user", "oi", "s1")
+	if err := updateSessionMeta("s1", func(m *SessionMeta) { m.Name = "x" }); err != nil {
+		t.Fatal(err)
+	}
+	clearHistory()
+	if len(loadSessionMeta()) != 0 {
+		t.Error("metadados das sessões deveriam ser removidos")
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a ", 50) + "alvo encontrado" + strings.Repeat(" b", 50)
+	got := snippet(long, "alvo")
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") || !strings.Contains(got, "alvo encontrado") {
+		t.Errorf("snippet = %q", got)
+	}
+	if got := snippet("linha um\nlinha   dois", "dois"); got != "linha um linha dois" {
+		t.Errorf("snippet curto = %q", got)
+	}
+	if got := snippet(strings.Repeat("ç", 100)+"x", "x"); !strings.HasPrefix(got, "...ç") {
+		t.Errorf("snippet não deveria cortar caracteres multibyte: %q", got)
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := map[string]string{
+		"Título: Deploy da API\n":          "Deploy da API",
+		"\n  **Erro no ingress**  \nextra": "Erro no ingress",
+		strings.Repeat("palavra ", 20):     strings.Repeat("palavra ", 7) + "p...",
+	}
+	for input, expected := range tests {
+		if got := cleanTitle(input); got != expected {
+			t.Errorf("cleanTitle(%q) = %q, esperado %q", input, got, expected)
+		}
+	}
+}
@@ -18,6 +18,16 @@ type TokenBudget struct {
 	RAGCtx       int
 }
 
+// Available retorna os tokens que sobram para a pergunta e as observações
+// das ferramentas após os componentes já alocados.
+func (b TokenBudget) Available() int {
+	available := b.MaxTokens - b.SystemPrompt - b.UserInput - b.HistoryCtx - b.RAGCtx
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
 // TruncateToFit ajusta os componentes do prompt para caber no orçamento de tokens.
 // Prioridade de truncamento: histórico primeiro, depois RAG.
 // O system prompt e user input nunca são truncados.
@@ -141,3 +141,29 @@ func TestTruncateToTokens_Zero(t *testing.T) {
 		t.Errorf("esperado vazio, obtido %q", got)
 	}
 }
+
+// TestTokenBudget_Available verifica o saldo após os componentes alocados.
+func TestTokenBudget_Available(t *testing.T) {
+	b := TokenBudget{MaxTokens: 1000, SystemPrompt: 300, UserInput: 50, HistoryCtx: 100, RAGCtx: 50}
+	if got := b.Available(); got != 500 {
+		t.Errorf("esperado 500, obtido %d", got)
+	}
+	b.SystemPrompt = 2000
+	if got := b.Available(); got != 0 {
+		t.Errorf("orçamento estourado deveria retornar 0, obtido %d", got)
+	}
+}
+
+// TestAgentConfig verifica que o loop recebe o orçamento após o system prompt.
+func TestAgentConfig(t *testing.T) {
+	cfg := BardConfig{MaxTokens: 1000}
+	cfg.Agent.MaxSteps = 3
+
+	got := agentConfig(cfg, strings.Repeat("a", 400))
+	if got.MaxSteps != 3 {
+		t.Errorf("MaxSteps esperado 3, obtido %d", got.MaxSteps)
+	}
+	if got.MaxTokens != 900 {
+		t.Errorf("MaxTokens esperado 900, obtido %d", got.MaxTokens)
+	}
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+)
+
+// ClassifyText classifica a intenção pedindo ao modelo um JSON com intent e
+// params (prompt bard.classify). Usado quando o provider não tem tool calling
+// nativo. A resposta passa por ai.CompleteJSON, que restringe intent às
+// intenções registradas e pede correção de respostas inválidas.
+func ClassifyText(ctx context.Context, provider ai.Provider, input string) (*IntentResult, error) {
+	intents := []interface{}{"direct"}
+	var intentList strings.Builder
+	for _, t := range All() {
+		if len(t.Intents) > 0 {
+			fmt.Fprintf(&intentList, "- %s: %s\n", t.Intents[0], t.Description)
+			intents = append(intents, t.Intents[0])
+		}
+	}
+	intentList.WriteString("- direct: responder diretamente sem executar ferramenta\n")
+
+	classifyPrompt := prompts.Get("bard.classify")
+	if classifyPrompt == "" {
+		classifyPrompt = `Classifique a intencao. Responda APENAS JSON:
+{"intent":"nome","params":{"chave":"valor"},"direct":false}
+Se nao precisa de ferramenta: {"intent":"direct","params":{},"direct":true}`
+	}
+
+	userPrompt := fmt.Sprintf("Intencoes:\n%s\nUsuario: %s", intentList.String(), input)
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"intent": map[string]interface{}{"type": "string", "enum": intents},
+			"params": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			"direct": map[string]interface{}{"type": "boolean"},
+		},
+		"required": []interface{}{"intent"},
+	}
+
+	var intent IntentResult
+	if err := ai.CompleteJSON(ctx, provider, classifyPrompt, userPrompt, &intent, ai.JSONOptions{Schema: schema}); err != nil {
+		return nil, err
+	}
+	if intent.Intent == "direct" {
+		intent.Direct = true
+	}
+	if intent.Params == nil {
+		intent.Params = map[string]string{}
+	}
+	return &intent, nil
+}
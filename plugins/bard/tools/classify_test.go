@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider responde Completion com uma sequência fixa de respostas.
+type scriptedProvider struct {
+	textProvider
+	responses []string
+	prompts   []string
+	err       error
+}
+
+func (p *scriptedProvider) Completion(_ context.Context, _, userPrompt string) (string, error) {
+	p.prompts = append(p.prompts, userPrompt)
+	if p.err != nil {
+		return "", p.err
+	}
+	resp := p.responses[0]
+	if len(p.responses) > 1 {
+		p.responses = p.responses[1:]
+	}
+	return resp, nil
+}
+
+// TestClassifyText verifica a classificação em texto com resposta válida.
+func TestClassifyText(t *testing.T) {
+	registerNativeTestTools(t)
+
+	p := &scriptedProvider{responses: []string{"```json\n{\"intent\":\"logs\",\"params\":{\"pod\":\"api\"},\"direct\":false}\n```"}}
+	intent, err := ClassifyText(context.Background(), p, "logs do api")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if intent.Direct || intent.Intent != "logs" || intent.Params["pod"] != "api" {
+		t.Errorf("intenção inesperada: %+v", intent)
+	}
+	if !strings.Contains(p.prompts[0], "- logs: Logs de um pod") {
+		t.Errorf("prompt deveria listar as intenções: %q", p.prompts[0])
+	}
+}
+
+// TestClassifyText_IntencaoDesconhecida verifica que intenções fora da lista
+// são devolvidas ao modelo para correção.
+func TestClassifyText_IntencaoDesconhecida(t *testing.T) {
+	registerNativeTestTools(t)
+
+	p := &scriptedProvider{responses: []string{
+		`{"intent":"deletar_cluster","params":{}}`,
+		`{"intent":"direct"}`,
+	}}
+	intent, err := ClassifyText(context.Background(), p, "oi")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !intent.Direct || intent.Params == nil {
+		t.Errorf("esperava resposta direta após correção: %+v", intent)
+	}
+	if len(p.prompts) != 2 || !strings.Contains(p.prompts[1], "deletar_cluster") {
+		t.Errorf("a correção deveria trazer a resposta anterior: %q", p.prompts)
+	}
+}
+
+// TestClassifyText_Erro verifica a propagação de erros do provider.
+func TestClassifyText_Erro(t *testing.T) {
+	registerNativeTestTools(t)
+
+	if _, err := ClassifyText(context.Background(), &scriptedProvider{err: errors.New("quota")}, "oi"); err == nil {
+		t.Error("erro do provider deveria ser propagado")
+	}
+}
@@ -90,6 +90,14 @@ func buildCheckString(call ToolCall) string {
 		parts = append(parts, "logs")
 	case "kubectl_events":
 		parts = append(parts, "events")
+	case "kubectl_scale":
+		return fmt.Sprintf("scale %s replicas=%s", call.Params["resource"], call.Params["replicas"])
+	case "kubectl_rollout_restart":
+		parts = append(parts, "rollout restart")
+	case "kubectl_patch":
+		parts = append(parts, "patch")
+	case "kubectl_apply":
+		parts = append(parts, "apply")
 	}
 
 	for _, v := range call.Params {
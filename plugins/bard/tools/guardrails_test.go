@@ -119,3 +119,16 @@ func TestValidateToolCall_CaseInsensitive(t *testing.T) {
 		t.Error("esperava bloqueio independente de case")
 	}
 }
+
+// TestValidateToolCall_ScaleParaZero verifica bloqueio do kubectl_scale com 0 réplicas.
+func TestValidateToolCall_ScaleParaZero(t *testing.T) {
+	call := ToolCall{Name: "kubectl_scale", Params: map[string]string{"resource": "deployment/api", "replicas": "0"}}
+	if err := ValidateToolCall(call); err == nil {
+		t.Error("esperava bloqueio para scale com 0 réplicas")
+	}
+
+	call.Params["replicas"] = "3"
+	if err := ValidateToolCall(call); err != nil {
+		t.Errorf("scale para 3 réplicas não deveria ser bloqueado: %v", err)
+	}
+}
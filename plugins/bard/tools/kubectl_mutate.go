@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+)
+
+// kubectlRunner executa o kubectl com stdin opcional. Substituído nos testes.
+var kubectlRunner = func(ctx context.Context, stdin string, args ...string) (string, error) {
+	slog.Debug("executando kubectl", "args", strings.Join(args, " "))
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("kubectl %s falhou: %w\n%s", args[0], err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func init() {
+	Register(&Tool{
+		Name:        "kubectl_scale",
+		Description: "Altera o número de réplicas de um Deployment ou StatefulSet (requer confirmação)",
+		Intents:     []string{"scale_workload"},
+		Parameters: []ToolParam{
+			{Name: "resource", Description: "Recurso no formato tipo/nome (ex: deployment/api)", Required: true},
+			{Name: "replicas", Description: "Número de réplicas desejado", Required: true},
+			{Name: "namespace", Description: "Namespace alvo", Required: false},
+		},
+		Mutating: true,
+		Execute:  runMutation(scaleArgs),
+		DryRun:   dryRunMutation(scaleArgs),
+	})
+	Register(&Tool{
+		Name:        "kubectl_rollout_restart",
+		Description: "Reinicia os pods de um Deployment, StatefulSet ou DaemonSet via rollout restart (requer confirmação)",
+		Intents:     []string{"restart_workload"},
+		Parameters: []ToolParam{
+			{Name: "resource", Description: "Recurso no formato tipo/nome (ex: deployment/api)", Required: true},
+			{Name: "namespace", Description: "Namespace alvo", Required: false},
+		},
+		Mutating: true,
+		Execute:  runMutation(restartArgs),
+		DryRun:   dryRunMutation(restartArgs),
+	})
+	Register(&Tool{
+		Name:        "kubectl_patch",
+		Description: "Aplica um patch em um recurso Kubernetes (requer confirmação)",
+		Intents:     []string{"patch_resource"},
+		Parameters: []ToolParam{
+			{Name: "resource", Description: "Recurso no formato tipo/nome (ex: deployment/api)", Required: true},
+			{Name: "patch", Description: "Conteúdo do patch em JSON", Required: true},
+			{Name: "patch_type", Description: "Tipo do patch: strategic, merge ou json. Padrão: strategic", Required: false},
+			{Name: "namespace", Description: "Namespace alvo", Required: false},
+		},
+		Mutating: true,
+		Execute:  runMutation(patchArgs),
+		DryRun:   dryRunMutation(patchArgs),
+	})
+	Register(&Tool{
+		Name:        "kubectl_apply",
+		Description: "Aplica um manifesto YAML gerado no cluster (requer confirmação)",
+		Intents:     []string{"apply_manifest"},
+		Parameters: []ToolParam{
+			{Name: "manifest", Description: "Manifesto YAML completo a ser aplicado", Required: true},
+			{Name: "namespace", Description: "Namespace alvo", Required: false},
+		},
+		Mutating: true,
+		Execute:  runMutation(applyArgs),
+		DryRun:   dryRunMutation(applyArgs),
+	})
+}
+
+// kubectlMutation descreve uma operação de escrita: o comando, o kubectl get
+// que lê o estado atual do(s) objeto(s) afetado(s) e o stdin de ambos.
+type kubectlMutation struct {
+	args  []string
+	get   []string
+	stdin string
+}
+
+// mutationArgs monta a operação a partir dos parâmetros da ferramenta.
+type mutationArgs func(params map[string]string) (*kubectlMutation, error)
+
+func scaleArgs(params map[string]string) (*kubectlMutation, error) {
+	resource := params["resource"]
+	if resource == "" {
+		return nil, fmt.Errorf("parâmetro 'resource' é obrigatório")
+	}
+	replicas, err := strconv.Atoi(params["replicas"])
+	if err != nil || replicas < 0 {
+		return nil, fmt.Errorf("parâmetro 'replicas' inválido: %q", params["replicas"])
+	}
+	return &kubectlMutation{
+		args: withNamespace([]string{"scale", resource, "--replicas=" + strconv.Itoa(replicas)}, params),
+		get:  withNamespace([]string{"get", resource}, params),
+	}, nil
+}
+
+func restartArgs(params map[string]string) (*kubectlMutation, error) {
+	resource := params["resource"]
+	if resource == "" {
+		return nil, fmt.Errorf("parâmetro 'resource' é obrigatório")
+	}
+	return &kubectlMutation{
+		args: withNamespace([]string{"rollout", "restart", resource}, params),
+		get:  withNamespace([]string{"get", resource}, params),
+	}, nil
+}
+
+func patchArgs(params map[string]string) (*kubectlMutation, error) {
+	resource := params["resource"]
+	if resource == "" {
+		return nil, fmt.Errorf("parâmetro 'resource' é obrigatório")
+	}
+	if params["patch"] == "" {
+		return nil, fmt.Errorf("parâmetro 'patch' é obrigatório")
+	}
+	patchType := params["patch_type"]
+	if patchType == "" {
+		patchType = "strategic"
+	}
+	switch patchType {
+	case "strategic", "merge", "json":
+	default:
+		return nil, fmt.Errorf("parâmetro 'patch_type' inválido: %q", patchType)
+	}
+	return &kubectlMutation{
+		args: withNamespace([]string{"patch", resource, "--type=" + patchType, "-p", params["patch"]}, params),
+		get:  withNamespace([]string{"get", resource}, params),
+	}, nil
+}
+
+func applyArgs(params map[string]string) (*kubectlMutation, error) {
+	if strings.TrimSpace(params["manifest"]) == "" {
+		return nil, fmt.Errorf("parâmetro 'manifest' é obrigatório")
+	}
+	return &kubectlMutation{
+		args:  withNamespace([]string{"apply", "-f", "-"}, params),
+		get:   withNamespace([]string{"get", "-f", "-"}, params),
+		stdin: params["manifest"],
+	}, nil
+}
+
+func withNamespace(args []string, params map[string]string) []string {
+	if ns := params["namespace"]; ns != "" {
+		args = append(args, "-n", ns)
+	}
+	return args
+}
+
+// runMutation retorna o Execute de uma ferramenta de escrita.
+func runMutation(build mutationArgs) func(ctx context.Context, params map[string]string) (string, error) {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		m, err := build(params)
+		if err != nil {
+			return "", err
+		}
+		return kubectlRunner(ctx, m.stdin, m.args...)
+	}
+}
+
+// dryRunMutation retorna o DryRun de uma ferramenta de escrita: simula a
+// operação com --dry-run=server e gera o diff unificado entre o estado atual
+// e o resultado simulado.
+func dryRunMutation(build mutationArgs) func(ctx context.Context, params map[string]string) (string, error) {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		m, err := build(params)
+		if err != nil {
+			return "", err
+		}
+
+		current, err := kubectlRunner(ctx, m.stdin, append(m.get, "-o", "yaml")...)
+		if err != nil {
+			// apply pode criar recursos novos: ausência de objetos é um estado
+			// válido e a saída traz os que já existem
+			if m.args[0] != "apply" || !strings.Contains(err.Error(), "NotFound") {
+				return "", err
+			}
+		}
+
+		simulated, err := kubectlRunner(ctx, m.stdin, append(m.args, "--dry-run=server", "-o", "yaml")...)
+		if err != nil {
+			return "", err
+		}
+
+		return diffObjects(current, simulated)
+	}
+}
+
+// volatileMetadata são campos que mudam a cada escrita e só poluem o diff.
+var volatileMetadata = []string{"resourceVersion", "generation", "managedFields", "uid", "creationTimestamp"}
+
+// diffObjects gera o diff unificado entre os objetos atuais e os simulados,
+// ignorando status e metadados voláteis. Com vários objetos (manifesto com
+// mais de um documento), cada um é comparado com o seu estado atual e os
+// ausentes em before aparecem como criação.
+func diffObjects(before, after string) (string, error) {
+	current, err := parseObjects(before)
+	if err != nil {
+		return "", err
+	}
+	simulated, err := parseObjects(after)
+	if err != nil {
+		return "", err
+	}
+	existing := make(map[string]string, len(current))
+	for _, obj := range current {
+		existing[objectKey(obj)] = obj.yaml
+	}
+
+	var diffs []string
+	for _, obj := range simulated {
+		key := objectKey(obj)
+		a := existing[key]
+		if a == obj.yaml {
+			continue
+		}
+		from, to := "atual", "após aplicar"
+		if len(simulated) > 1 {
+			from, to = from+" "+key, to+" "+key
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(a),
+			B:        splitLines(obj.yaml),
+			FromFile: from,
+			ToFile:   to,
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+		diffs = append(diffs, diff)
+	}
+	if len(diffs) == 0 {
+		return "(nenhuma alteração)", nil
+	}
+	return strings.Join(diffs, ""), nil
+}
+
+// kubeObject é um objeto da saída do kubectl, já normalizado.
+type kubeObject struct {
+	kind, namespace, name string
+	yaml                  string
+}
+
+func objectKey(obj kubeObject) string {
+	if obj.namespace == "" {
+		return obj.kind + "/" + obj.name
+	}
+	return obj.kind + "/" + obj.namespace + "/" + obj.name
+}
+
+// parseObjects lê a saída YAML do kubectl, que pode ter vários documentos ou
+// um List, e normaliza cada objeto. Vazio representa nenhum objeto.
+func parseObjects(data string) ([]kubeObject, error) {
+	var objects []kubeObject
+	dec := yaml.NewDecoder(strings.NewReader(data))
+	for {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("falha ao interpretar saída do kubectl: %w", err)
+		}
+		if obj == nil {
+			continue
+		}
+		if items, ok := obj["items"].([]interface{}); ok && strings.HasSuffix(fmt.Sprint(obj["kind"]), "List") {
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					o, err := normalizeObject(m)
+					if err != nil {
+						return nil, err
+					}
+					objects = append(objects, o)
+				}
+			}
+			continue
+		}
+		o, err := normalizeObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+}
+
+func normalizeObject(obj map[string]interface{}) (kubeObject, error) {
+	o := kubeObject{kind: fmt.Sprint(obj["kind"])}
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+		o.name, _ = meta["name"].(string)
+		o.namespace, _ = meta["namespace"].(string)
+	}
+	cleanObject(obj)
+
+	// indentação de 2 espaços, como a saída do kubectl
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(obj); err != nil {
+		return kubeObject{}, fmt.Errorf("falha ao serializar objeto: %w", err)
+	}
+	o.yaml = buf.String()
+	return o, nil
+}
+
+// splitLines separa o YAML em linhas para o diff; vazio representa um objeto
+// inexistente e não gera linhas.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(s, "\n"))
+}
+
+func cleanObject(obj map[string]interface{}) {
+	delete(obj, "status")
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range volatileMetadata {
+			delete(meta, field)
+		}
+		if len(meta) == 0 {
+			delete(obj, "metadata")
+		}
+	}
+	if items, ok := obj["items"].([]interface{}); ok {
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				cleanObject(m)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// stubKubectl substitui o kubectlRunner e registra os comandos executados.
+func stubKubectl(t *testing.T, fn func(stdin string, args []string) (string, error)) *[]string {
+	t.Helper()
+	var calls []string
+	orig := kubectlRunner
+	kubectlRunner = func(ctx context.Context, stdin string, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return fn(stdin, args)
+	}
+	t.Cleanup(func() { kubectlRunner = orig })
+	return &calls
+}
+
+const deploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: default
+  resourceVersion: "%s"
+  generation: %d
+spec:
+  replicas: %d
+status:
+  readyReplicas: 2
+`
+
+// TestKubectlMutatingTools_Registradas verifica o registro das ferramentas de escrita.
+func TestKubectlMutatingTools_Registradas(t *testing.T) {
+	for _, name := range []string{"kubectl_scale", "kubectl_rollout_restart", "kubectl_patch", "kubectl_apply"} {
+		tool := Get(name)
+		if tool == nil {
+			t.Errorf("ferramenta '%s' não encontrada no registry", name)
+			continue
+		}
+		if !tool.Mutating || tool.DryRun == nil || tool.Execute == nil {
+			t.Errorf("ferramenta '%s' deveria ser de escrita com DryRun e Execute", name)
+		}
+	}
+}
+
+// TestKubectlScale_DryRunDiff verifica o diff entre o estado atual e o simulado.
+func TestKubectlScale_DryRunDiff(t *testing.T) {
+	calls := stubKubectl(t, func(stdin string, args []string) (string, error) {
+		if args[0] == "get" {
+			return fmt.Sprintf(deploymentYAML, "100", 3, 2), nil
+		}
+		return fmt.Sprintf(deploymentYAML, "101", 4, 5), nil
+	})
+
+	diff, err := Get("kubectl_scale").DryRun(context.Background(), map[string]string{
+		"resource": "deployment/api", "replicas": "5", "namespace": "default",
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	want := []string{
+		"get deployment/api -n default -o yaml",
+		"scale deployment/api --replicas=5 -n default --dry-run=server -o yaml",
+	}
+	if strings.Join(*calls, "|") != strings.Join(want, "|") {
+		t.Errorf("comandos inesperados: %v", *calls)
+	}
+	if !strings.Contains(diff, "-  replicas: 2") || !strings.Contains(diff, "+  replicas: 5") {
+		t.Errorf("diff sem a alteração de réplicas:\n%s", diff)
+	}
+	if strings.Contains(diff, "resourceVersion") || strings.Contains(diff, "readyReplicas") {
+		t.Errorf("diff não deveria conter metadados voláteis ou status:\n%s", diff)
+	}
+}
+
+// TestKubectlScale_ReplicasInvalidas verifica a validação de réplicas.
+func TestKubectlScale_ReplicasInvalidas(t *testing.T) {
+	stubKubectl(t, func(stdin string, args []string) (string, error) {
+		t.Fatal("kubectl não deveria ser executado")
+		return "", nil
+	})
+	for _, replicas := range []string{"", "abc", "-1"} {
+		_, err := Get("kubectl_scale").DryRun(context.Background(), map[string]string{"resource": "deployment/api", "replicas": replicas})
+		if err == nil {
+			t.Errorf("esperava erro para replicas=%q", replicas)
+		}
+	}
+}
+
+// TestKubectlApply_RecursoNovo verifica que um recurso inexistente gera diff de criação.
+func TestKubectlApply_RecursoNovo(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  key: value\n"
+	var stdins []string
+	stubKubectl(t, func(stdin string, args []string) (string, error) {
+		stdins = append(stdins, stdin)
+		if args[0] == "get" {
+			return "", fmt.Errorf("kubectl get falhou: exit status 1\nError from server (NotFound): configmaps \"cfg\" not found")
+		}
+		return manifest, nil
+	})
+
+	diff, err := Get("kubectl_apply").DryRun(context.Background(), map[string]string{"manifest": manifest})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(diff, "+  key: value") {
+		t.Errorf("diff de criação inesperado:\n%s", diff)
+	}
+	for _, s := range stdins {
+		if s != manifest {
+			t.Errorf("manifesto deveria ser enviado via stdin, obteve %q", s)
+		}
+	}
+}
+
+// TestKubectlApply_ManifestoComObjetosExistentesENovos verifica que, quando
+// o get falha por um objeto novo, os já existentes aparecem como alteração e
+// só o novo aparece como criação.
+func TestKubectlApply_ManifestoComObjetosExistentesENovos(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  key: novo\n---\n" +
+		"apiVersion: v1\nkind: Secret\nmetadata:\n  name: token\ntype: Opaque\n"
+	stubKubectl(t, func(stdin string, args []string) (string, error) {
+		if args[0] == "get" {
+			// kubectl imprime os objetos encontrados e falha pelo ausente
+			return "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\n  namespace: default\n  resourceVersion: \"7\"\ndata:\n  key: antigo\n",
+				fmt.Errorf("kubectl get falhou: exit status 1\nError from server (NotFound): secrets \"token\" not found")
+		}
+		return `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cfg
+    namespace: default
+    resourceVersion: "8"
+  data:
+    key: novo
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: token
+    namespace: default
+  type: Opaque
+`, nil
+	})
+
+	diff, err := Get("kubectl_apply").DryRun(context.Background(), map[string]string{"manifest": manifest})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	for _, want := range []string{
+		"--- atual ConfigMap/default/cfg",
+		"-  key: antigo\n+  key: novo",
+		"--- atual Secret/default/token",
+		"+type: Opaque",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff sem %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "+kind: ConfigMap") {
+		t.Errorf("ConfigMap existente não deveria aparecer como criação:\n%s", diff)
+	}
+}
+
+// TestKubectlPatch_SemAlteracao verifica o retorno quando o patch não muda nada.
+func TestKubectlPatch_SemAlteracao(t *testing.T) {
+	stubKubectl(t, func(stdin string, args []string) (string, error) {
+		return fmt.Sprintf(deploymentYAML, "100", 3, 2), nil
+	})
+
+	diff, err := Get("kubectl_patch").DryRun(context.Background(), map[string]string{
+		"resource": "deployment/api", "patch": `{"spec":{"replicas":2}}`,
+	})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if diff != "(nenhuma alteração)" {
+		t.Errorf("esperava nenhuma alteração, obteve:\n%s", diff)
+	}
+}
+
+// TestKubectlPatch_TipoInvalido verifica a validação do tipo de patch.
+func TestKubectlPatch_TipoInvalido(t *testing.T) {
+	_, err := Get("kubectl_patch").Execute(context.Background(), map[string]string{
+		"resource": "deployment/api", "patch": "{}", "patch_type": "yaml",
+	})
+	if err == nil {
+		t.Error("esperava erro para patch_type inválido")
+	}
+}
+
+// TestKubectlRolloutRestart_Execute verifica o comando executado após a confirmação.
+func TestKubectlRolloutRestart_Execute(t *testing.T) {
+	calls := stubKubectl(t, func(stdin string, args []string) (string, error) {
+		return "deployment.apps/api restarted", nil
+	})
+
+	out, err := Get("kubectl_rollout_restart").Execute(context.Background(), map[string]string{"resource": "deployment/api", "namespace": "app"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if out != "deployment.apps/api restarted" {
+		t.Errorf("saída inesperada: %s", out)
+	}
+	if (*calls)[0] != "rollout restart deployment/api -n app" {
+		t.Errorf("comando inesperado: %s", (*calls)[0])
+	}
+}
@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMutationDenied indica que a política do ambiente não permite ferramentas
+// que alteram o cluster.
+var ErrMutationDenied = errors.New("ferramentas de escrita desabilitadas neste ambiente")
+
+// defaultDeniedEnvironments são os ambientes em que ferramentas de escrita
+// ficam desabilitadas quando a política não define deny_environments.
+var defaultDeniedEnvironments = []string{"prod", "production"}
+
+// MutationPolicy controla as ferramentas de escrita, configurada na seção
+// mutations de .yby/bard.yaml.
+type MutationPolicy struct {
+	// Enabled habilita as ferramentas de escrita. Desabilitadas por padrão.
+	Enabled bool `yaml:"enabled"`
+	// DenyEnvironments lista ambientes em que a escrita nunca é permitida.
+	// Vazio usa o padrão (prod, production).
+	DenyEnvironments []string `yaml:"deny_environments"`
+}
+
+// Allows retorna nil se a política permite escrita no ambiente informado.
+func (p MutationPolicy) Allows(environment string) error {
+	if !p.Enabled {
+		return fmt.Errorf("%w (habilite mutations.enabled em .yby/bard.yaml)", ErrMutationDenied)
+	}
+	denied := p.DenyEnvironments
+	if len(denied) == 0 {
+		denied = defaultDeniedEnvironments
+	}
+	for _, env := range denied {
+		if strings.EqualFold(env, environment) {
+			return fmt.Errorf("%w: '%s' está em mutations.deny_environments", ErrMutationDenied, environment)
+		}
+	}
+	return nil
+}
+
+// DisableMutating remove do registry as ferramentas de escrita, para que não
+// sejam oferecidas à IA quando a política não as permite.
+func DisableMutating() {
+	mu.Lock()
+	defer mu.Unlock()
+	for name, tool := range registry {
+		if tool.Mutating {
+			delete(registry, name)
+		}
+	}
+}
+
+// AuditEntry registra uma decisão sobre uma ferramenta de escrita.
+type AuditEntry struct {
+	Timestamp   time.Time         `json:"timestamp"`
+	Tool        string            `json:"tool"`
+	Params      map[string]string `json:"params"`
+	Environment string            `json:"environment,omitempty"`
+	// Decision é "denied" (política ou guardrail), "rejected" (usuário
+	// recusou) ou "approved" (executada).
+	Decision string `json:"decision"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+// AuditLog persiste as decisões sobre ferramentas de escrita em JSONL.
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAuditLog cria um AuditLog em ~/.yby/bard/audit.jsonl.
+func NewAuditLog() *AuditLog {
+	home, _ := os.UserHomeDir()
+	return &AuditLog{path: filepath.Join(home, ".yby", "bard", "audit.jsonl")}
+}
+
+// NewAuditLogWithPath cria um AuditLog com caminho customizado (usado em testes).
+func NewAuditLogWithPath(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Path retorna o caminho do arquivo de auditoria.
+func (a *AuditLog) Path() string { return a.path }
+
+// Record adiciona a entrada ao arquivo de auditoria.
+func (a *AuditLog) Record(entry AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório de auditoria: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("falha ao abrir log de auditoria: %w", err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar entrada de auditoria: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// PendingMutation é uma operação de escrita já simulada, aguardando a
+// confirmação do usuário.
+type PendingMutation struct {
+	Tool        *Tool
+	Params      map[string]string
+	Environment string
+	// Diff é o resultado do dry-run no servidor.
+	Diff string
+
+	audit *AuditLog
+}
+
+// PrepareMutation verifica a política do ambiente e os guardrails e executa o
+// dry-run da ferramenta. Operações negadas são registradas na auditoria.
+func PrepareMutation(ctx context.Context, tool *Tool, params map[string]string, environment string, policy MutationPolicy, audit *AuditLog) (*PendingMutation, error) {
+	if !tool.Mutating || tool.DryRun == nil {
+		return nil, fmt.Errorf("ferramenta %s não é de escrita", tool.Name)
+	}
+	pending := &PendingMutation{Tool: tool, Params: params, Environment: environment, audit: audit}
+
+	if err := policy.Allows(environment); err != nil {
+		pending.record("denied", "", err)
+		return nil, err
+	}
+	if err := ValidateToolCall(ToolCall{Name: tool.Name, Params: params}); err != nil {
+		pending.record("denied", "", err)
+		return nil, err
+	}
+
+	diff, err := tool.DryRun(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("dry-run de %s falhou: %w", tool.Name, err)
+	}
+	pending.Diff = diff
+	return pending, nil
+}
+
+// Summary descreve a operação em uma linha, para a confirmação.
+func (p *PendingMutation) Summary() string {
+	var parts []string
+	for _, param := range p.Tool.Parameters {
+		if v := p.Params[param.Name]; v != "" && param.Name != "manifest" && param.Name != "patch" {
+			parts = append(parts, fmt.Sprintf("%s=%s", param.Name, v))
+		}
+	}
+	return fmt.Sprintf("%s %s", p.Tool.Name, strings.Join(parts, " "))
+}
+
+// Approve executa a operação e registra o resultado na auditoria.
+func (p *PendingMutation) Approve(ctx context.Context) (string, error) {
+	output, err := p.Tool.Execute(ctx, p.Params)
+	p.record("approved", output, err)
+	return output, err
+}
+
+// Reject registra que o usuário recusou a operação.
+func (p *PendingMutation) Reject() {
+	p.record("rejected", "", nil)
+}
+
+func (p *PendingMutation) record(decision, output string, err error) {
+	if p.audit == nil {
+		return
+	}
+	entry := AuditEntry{
+		Timestamp:   time.Now(),
+		Tool:        p.Tool.Name,
+		Params:      p.Params,
+		Environment: p.Environment,
+		Decision:    decision,
+		Success:     decision == "approved" && err == nil,
+		Output:      strings.TrimSpace(output),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	_ = p.audit.Record(entry)
+}
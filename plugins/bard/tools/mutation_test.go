@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readAudit lê as entradas gravadas no log de auditoria.
+func readAudit(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatalf("falha ao ler auditoria: %v", err)
+	}
+	var entries []AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("linha de auditoria inválida: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// fakeMutatingTool cria uma ferramenta de escrita que registra as execuções.
+func fakeMutatingTool(executed *int) *Tool {
+	return &Tool{
+		Name:     "kubectl_scale",
+		Intents:  []string{"scale_workload"},
+		Mutating: true,
+		Parameters: []ToolParam{
+			{Name: "resource", Required: true},
+			{Name: "replicas", Required: true},
+		},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			*executed++
+			return "deployment.apps/api scaled", nil
+		},
+		DryRun: func(ctx context.Context, params map[string]string) (string, error) {
+			return "-  replicas: 2\n+  replicas: 5\n", nil
+		},
+	}
+}
+
+// TestMutationPolicy_Allows verifica a política padrão e a customizada.
+func TestMutationPolicy_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  MutationPolicy
+		env     string
+		allowed bool
+	}{
+		{"desabilitada por padrão", MutationPolicy{}, "dev", false},
+		{"habilitada em dev", MutationPolicy{Enabled: true}, "dev", true},
+		{"prod negado por padrão", MutationPolicy{Enabled: true}, "prod", false},
+		{"production negado por padrão", MutationPolicy{Enabled: true}, "Production", false},
+		{"lista customizada", MutationPolicy{Enabled: true, DenyEnvironments: []string{"staging"}}, "staging", false},
+		{"lista customizada substitui o padrão", MutationPolicy{Enabled: true, DenyEnvironments: []string{"staging"}}, "prod", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Allows(tt.env)
+			if tt.allowed && err != nil {
+				t.Errorf("esperava permitido, obteve %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrMutationDenied) {
+				t.Errorf("esperava ErrMutationDenied, obteve %v", err)
+			}
+		})
+	}
+}
+
+// TestDisableMutating verifica que apenas as ferramentas de escrita são removidas.
+func TestDisableMutating(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	var executed int
+	Register(fakeMutatingTool(&executed))
+	Register(&Tool{Name: "kubectl_get", Intents: []string{"list_resources"}})
+
+	DisableMutating()
+
+	if Get("kubectl_scale") != nil {
+		t.Error("ferramenta de escrita deveria ter sido removida")
+	}
+	if Get("kubectl_get") == nil {
+		t.Error("ferramenta de leitura não deveria ser removida")
+	}
+}
+
+// TestPrepareMutation_Aprovada verifica dry-run, execução e auditoria.
+func TestPrepareMutation_Aprovada(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit := NewAuditLogWithPath(auditPath)
+	var executed int
+	tool := fakeMutatingTool(&executed)
+	params := map[string]string{"resource": "deployment/api", "replicas": "5"}
+
+	pending, err := PrepareMutation(context.Background(), tool, params, "dev", MutationPolicy{Enabled: true}, audit)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if executed != 0 {
+		t.Fatal("a ferramenta não deve executar antes da confirmação")
+	}
+	if !strings.Contains(pending.Diff, "+  replicas: 5") {
+		t.Errorf("diff inesperado: %s", pending.Diff)
+	}
+	if pending.Summary() != "kubectl_scale resource=deployment/api replicas=5" {
+		t.Errorf("resumo inesperado: %s", pending.Summary())
+	}
+
+	if _, err := pending.Approve(context.Background()); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if executed != 1 {
+		t.Errorf("esperava 1 execução, obteve %d", executed)
+	}
+
+	entries := readAudit(t, auditPath)
+	if len(entries) != 1 {
+		t.Fatalf("esperava 1 entrada de auditoria, obteve %d", len(entries))
+	}
+	if entries[0].Decision != "approved" || !entries[0].Success || entries[0].Environment != "dev" {
+		t.Errorf("entrada inesperada: %+v", entries[0])
+	}
+}
+
+// TestPrepareMutation_Rejeitada verifica que a recusa não executa e é auditada.
+func TestPrepareMutation_Rejeitada(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	var executed int
+	tool := fakeMutatingTool(&executed)
+
+	pending, err := PrepareMutation(context.Background(), tool, map[string]string{"resource": "deployment/api", "replicas": "5"}, "dev", MutationPolicy{Enabled: true}, NewAuditLogWithPath(auditPath))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	pending.Reject()
+
+	if executed != 0 {
+		t.Error("operação recusada não deve executar")
+	}
+	entries := readAudit(t, auditPath)
+	if len(entries) != 1 || entries[0].Decision != "rejected" || entries[0].Success {
+		t.Errorf("auditoria inesperada: %+v", entries)
+	}
+}
+
+// TestPrepareMutation_NegadaPelaPolitica verifica que o ambiente negado não
+// chega ao dry-run e fica registrado.
+func TestPrepareMutation_NegadaPelaPolitica(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	var executed int
+	tool := fakeMutatingTool(&executed)
+	tool.DryRun = func(ctx context.Context, params map[string]string) (string, error) {
+		t.Fatal("dry-run não deveria ser executado")
+		return "", nil
+	}
+
+	_, err := PrepareMutation(context.Background(), tool, map[string]string{"resource": "deployment/api", "replicas": "5"}, "prod", MutationPolicy{Enabled: true}, NewAuditLogWithPath(auditPath))
+	if !errors.Is(err, ErrMutationDenied) {
+		t.Fatalf("esperava ErrMutationDenied, obteve %v", err)
+	}
+	entries := readAudit(t, auditPath)
+	if len(entries) != 1 || entries[0].Decision != "denied" {
+		t.Errorf("auditoria inesperada: %+v", entries)
+	}
+}
+
+// TestPrepareMutation_Guardrail verifica que scale para 0 é bloqueado.
+func TestPrepareMutation_Guardrail(t *testing.T) {
+	var executed int
+	_, err := PrepareMutation(context.Background(), fakeMutatingTool(&executed), map[string]string{"resource": "deployment/api", "replicas": "0"}, "dev", MutationPolicy{Enabled: true}, nil)
+	if err == nil || !strings.Contains(err.Error(), "operação bloqueada") {
+		t.Errorf("esperava bloqueio do guardrail, obteve %v", err)
+	}
+}
+
+// TestPrepareMutation_FerramentaDeLeitura verifica que ferramentas comuns são recusadas.
+func TestPrepareMutation_FerramentaDeLeitura(t *testing.T) {
+	tool := &Tool{Name: "kubectl_get"}
+	if _, err := PrepareMutation(context.Background(), tool, nil, "dev", MutationPolicy{Enabled: true}, nil); err == nil {
+		t.Error("esperava erro para ferramenta que não é de escrita")
+	}
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+)
+
+// Definitions converte as ferramentas com intents em definições de tool calling
+// nativo. Todos os parâmetros das ferramentas do Bard são strings.
+func Definitions() []ai.ToolDefinition {
+	var defs []ai.ToolDefinition
+	for _, t := range All() {
+		if len(t.Intents) == 0 {
+			continue
+		}
+		schema := ai.ToolSchema{Type: "object", Properties: map[string]ai.ToolProperty{}}
+		for _, p := range t.Parameters {
+			schema.Properties[p.Name] = ai.ToolProperty{Type: "string", Description: p.Description}
+			if p.Required {
+				schema.Required = append(schema.Required, p.Name)
+			}
+		}
+		defs = append(defs, ai.ToolDefinition{Name: t.Name, Description: t.Description, Parameters: schema})
+	}
+	return defs
+}
+
+// ClassifyNative escolhe a ferramenta via tool calling nativo do provider.
+// Retorna ai.ErrToolsNotSupported quando o provider não suporta, para que o
+// chamador use a classificação em texto (prompt bard.classify).
+func ClassifyNative(ctx context.Context, provider ai.Provider, input string) (*IntentResult, error) {
+	tc, ok := provider.(ai.ToolCaller)
+	if !ok || !ai.SupportsTools(provider) {
+		return nil, ai.ErrToolsNotSupported
+	}
+	defs := Definitions()
+	resp, err := tc.CompletionWithTools(ctx, prompts.Get("bard.tools"), input, defs)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := &IntentResult{Intent: "direct", Params: map[string]string{}, Direct: true}
+	if len(resp.ToolCalls) == 0 {
+		return direct, nil
+	}
+	call := resp.ToolCalls[0]
+	if err := ai.ValidateToolCall(defs, call); err != nil {
+		return direct, nil
+	}
+	tool := Get(call.Name)
+	if tool == nil {
+		return direct, nil
+	}
+	return &IntentResult{Intent: tool.Intents[0], Params: call.StringArgs()}, nil
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+)
+
+// nativeProvider é um provider com tool calling nativo que retorna chamadas fixas.
+type nativeProvider struct {
+	calls      []ai.ToolCall
+	err        error
+	gotDefs    []ai.ToolDefinition
+	completion bool
+}
+
+func (p *nativeProvider) Name() string                       { return "native" }
+func (p *nativeProvider) IsAvailable(_ context.Context) bool { return true }
+func (p *nativeProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (p *nativeProvider) Completion(_ context.Context, _, _ string) (string, error) {
+	p.completion = true
+	return "", nil
+}
+func (p *nativeProvider) StreamCompletion(_ context.Context, _, _ string, _ io.Writer) error {
+	return nil
+}
+func (p *nativeProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+func (p *nativeProvider) CompletionWithTools(_ context.Context, _, _ string, tools []ai.ToolDefinition) (*ai.ToolResponse, error) {
+	p.gotDefs = tools
+	return &ai.ToolResponse{ToolCalls: p.calls}, p.err
+}
+
+// textProvider expõe apenas ai.Provider, sem ai.ToolCaller.
+type textProvider struct{ ai.Provider }
+
+// registerNativeTestTools substitui o registro pelas ferramentas de teste e
+// restaura as ferramentas registradas no init ao final do teste.
+func registerNativeTestTools(t *testing.T) {
+	saved := All()
+	Reset()
+	t.Cleanup(func() {
+		Reset()
+		for _, tool := range saved {
+			Register(tool)
+		}
+	})
+
+	Register(&Tool{
+		Name:        "kubectl_logs",
+		Description: "Logs de um pod",
+		Intents:     []string{"logs"},
+		Parameters: []ToolParam{
+			{Name: "pod", Description: "Nome do pod", Required: true},
+			{Name: "namespace", Description: "Namespace"},
+		},
+	})
+	Register(&Tool{Name: "interna", Description: "Sem intents"})
+}
+
+// TestDefinitions verifica a conversão das ferramentas em schemas.
+func TestDefinitions(t *testing.T) {
+	registerNativeTestTools(t)
+
+	defs := Definitions()
+	if len(defs) != 1 || defs[0].Name != "kubectl_logs" {
+		t.Fatalf("apenas ferramentas com intents deveriam ser oferecidas: %+v", defs)
+	}
+	schema := defs[0].Parameters
+	if schema.Type != "object" || schema.Properties["pod"].Type != "string" || len(schema.Required) != 1 || schema.Required[0] != "pod" {
+		t.Errorf("schema inesperado: %+v", schema)
+	}
+}
+
+// TestClassifyNative verifica o mapeamento da chamada para a intenção.
+func TestClassifyNative(t *testing.T) {
+	registerNativeTestTools(t)
+
+	p := &nativeProvider{calls: []ai.ToolCall{{Name: "kubectl_logs", Arguments: map[string]interface{}{"pod": "api", "namespace": "prod"}}}}
+	intent, err := ClassifyNative(context.Background(), p, "logs do api em prod")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if intent.Direct || intent.Intent != "logs" || intent.Params["pod"] != "api" || intent.Params["namespace"] != "prod" {
+		t.Errorf("intenção inesperada: %+v", intent)
+	}
+	if len(p.gotDefs) != 1 {
+		t.Errorf("provider deveria receber as definições: %+v", p.gotDefs)
+	}
+}
+
+// TestClassifyNative_Direta verifica respostas sem chamada ou com chamada inválida.
+func TestClassifyNative_Direta(t *testing.T) {
+	registerNativeTestTools(t)
+
+	for _, calls := range [][]ai.ToolCall{
+		nil,
+		{{Name: "kubectl_logs", Arguments: map[string]interface{}{"namespace": "prod"}}}, // sem pod
+		{{Name: "interna"}}, // não oferecida
+	} {
+		intent, err := ClassifyNative(context.Background(), &nativeProvider{calls: calls}, "oi")
+		if err != nil || !intent.Direct {
+			t.Errorf("esperava resposta direta para %+v, obteve %+v (%v)", calls, intent, err)
+		}
+	}
+}
+
+// TestClassifyNative_SemSuporte verifica o erro que leva ao protocolo em texto.
+func TestClassifyNative_SemSuporte(t *testing.T) {
+	inner := &nativeProvider{}
+	if _, err := ClassifyNative(context.Background(), textProvider{inner}, "oi"); !errors.Is(err, ai.ErrToolsNotSupported) {
+		t.Errorf("esperava ErrToolsNotSupported, obteve %v", err)
+	}
+	if inner.completion || inner.gotDefs != nil {
+		t.Error("não deveria chamar a IA quando não há suporte nativo")
+	}
+
+	failing := &nativeProvider{err: errors.New("quota")}
+	if _, err := ClassifyNative(context.Background(), failing, "oi"); err == nil {
+		t.Error("erro do provider deveria ser propagado")
+	}
+}
@@ -9,6 +9,14 @@ type Tool struct {
 	Intents     []string // palavras-chave/padrões que ativam esta ferramenta
 	Parameters  []ToolParam
 	Execute     func(ctx context.Context, params map[string]string) (string, error)
+
+	// Mutating marca ferramentas que alteram o cluster. Elas passam por
+	// PrepareMutation (política, guardrails e dry-run) e só executam após
+	// confirmação explícita do usuário.
+	Mutating bool
+	// DryRun simula a operação no servidor e retorna o diff esperado.
+	// Obrigatório em ferramentas Mutating.
+	DryRun func(ctx context.Context, params map[string]string) (string, error)
 }
 
 // IntentResult é o resultado da classificação de intenção pela IA.
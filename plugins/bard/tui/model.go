@@ -7,10 +7,8 @@ import (
 	"io"
 	"strings"
 
-	"encoding/json"
-
 	"github.com/casheiro/yby-cli/pkg/ai"
-	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
 	"github.com/casheiro/yby-cli/plugins/bard/tools"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -19,15 +17,14 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// maxToolIterations limita o número de iterações de tool calling.
-const maxToolIterations = 5
-
 // state representa o estado atual da TUI.
 type state int
 
 const (
 	stateIdle state = iota
 	stateStreaming
+	// stateConfirm aguarda a confirmação de uma ferramenta de escrita.
+	stateConfirm
 )
 
 // Config contém a configuração passada para a TUI.
@@ -38,6 +35,31 @@ type Config struct {
 	Cluster      string
 	AIModel      string
 	SaveMessage  func(role, content, sessionID string)
+
+	// Environment é o ambiente do projeto, usado pela política de escrita.
+	Environment string
+	// Mutations é a política das ferramentas de escrita (.yby/bard.yaml).
+	Mutations tools.MutationPolicy
+	// Audit registra as decisões sobre ferramentas de escrita.
+	Audit *tools.AuditLog
+	// Agent limita o loop de ferramentas (passos e tokens).
+	Agent agent.Config
+
+	// Prompt, quando definido, monta a cada mensagem o system prompt e os
+	// limites do loop com o histórico da sessão, no lugar de SystemPrompt e
+	// Agent.
+	Prompt func(sessionID string) (string, agent.Config)
+	// Command trata os comandos iniciados por "/" (ex: /resume, /export).
+	// Retorna false para comandos desconhecidos.
+	Command func(input, sessionID string) (CommandResult, bool)
+}
+
+// CommandResult é o resultado de um comando do chat.
+type CommandResult struct {
+	// Output é exibido no chat.
+	Output string
+	// SessionID, quando preenchido, passa a ser a sessão do chat.
+	SessionID string
 }
 
 // responseMsg é enviada quando o streaming da IA termina.
@@ -46,6 +68,12 @@ type responseMsg struct {
 	err     error
 }
 
+// stepMsg é enviada a cada passo do loop de ferramentas.
+type stepMsg struct {
+	run *agent.Run
+	err error
+}
+
 // Model é o modelo principal do Bubbletea para o Bard.
 type Model struct {
 	viewport    viewport.Model
@@ -59,10 +87,16 @@ type Model struct {
 	height      int
 	renderer    *glamour.TermRenderer
 	err         error
+
+	// run é o loop de ferramentas em andamento; planShown e shownSteps
+	// indicam o que dele já foi exibido no trace.
+	run        *agent.Run
+	planShown  bool
+	shownSteps int
 }
 
 type chatMessage struct {
-	role    string // "user", "assistant", "tool", "error"
+	role    string // "user", "assistant", "tool", "error", "info"
 	content string
 }
 
@@ -105,6 +139,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.state == stateConfirm {
+			return m.handleConfirmKey(msg)
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
@@ -122,6 +159,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if input == "exit" || input == "quit" {
 				return m, tea.Quit
 			}
+			if strings.HasPrefix(input, "/") && m.config.Command != nil {
+				m.textarea.Reset()
+				return m.runCommand(input), nil
+			}
 
 			// Adicionar mensagem do usuário
 			m.messages = append(m.messages, chatMessage{role: "user", content: input})
@@ -129,11 +170,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = stateStreaming
 			m.updateViewport()
 
-			// Salvar mensagem do usuário
-			if m.config.SaveMessage != nil {
-				m.config.SaveMessage("user", input, m.config.SessionID)
-			}
-
 			return m, m.sendMessage(input)
 		}
 
@@ -157,8 +193,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateViewport()
 		return m, nil
 
+	case stepMsg:
+		return m.handleStep(msg)
+
 	case responseMsg:
 		m.state = stateIdle
+		m.run = nil
 		if msg.err != nil {
 			m.messages = append(m.messages, chatMessage{role: "error", content: msg.err.Error()})
 		} else {
@@ -196,7 +236,11 @@ func (m Model) View() string {
 	// Cabeçalho
 	b.WriteString(headerStyle.Render("Yby Bard"))
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  Digite 'exit' para sair"))
+	help := "  Digite 'exit' para sair"
+	if m.config.Command != nil {
+		help += " | /sessions /resume /name /search /export"
+	}
+	b.WriteString(dimStyle.Render(help))
 	b.WriteString("\n")
 
 	// Viewport com mensagens
@@ -208,20 +252,47 @@ func (m Model) View() string {
 	b.WriteString("\n")
 
 	// Input
-	if m.state == stateStreaming {
+	switch m.state {
+	case stateStreaming:
 		b.WriteString(thinkingStyle.Render("  Processando..."))
-	} else {
+	case stateConfirm:
+		b.WriteString(confirmStyle.Render("  Aplicar esta alteração no cluster? [s/N]"))
+	default:
 		b.WriteString(m.textarea.View())
 	}
 
 	return b.String()
 }
 
+// runCommand executa um comando do chat e exibe o resultado.
+func (m Model) runCommand(input string) Model {
+	result, ok := m.config.Command(input, m.config.SessionID)
+	if !ok {
+		m.messages = append(m.messages, chatMessage{role: "error", content: "comando desconhecido: " + strings.Fields(input)[0]})
+	} else {
+		if result.SessionID != "" {
+			m.config.SessionID = result.SessionID
+		}
+		m.messages = append(m.messages, chatMessage{role: "info", content: result.Output})
+	}
+	m.updateViewport()
+	return m
+}
+
 // sendMessage envia a mensagem para a IA e retorna o resultado.
 func (m Model) sendMessage(input string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
+		// o prompt é montado antes de salvar a pergunta, que ainda não é histórico
+		systemPrompt, agentConfig := m.config.SystemPrompt, m.config.Agent
+		if m.config.Prompt != nil {
+			systemPrompt, agentConfig = m.config.Prompt(m.config.SessionID)
+		}
+		if m.config.SaveMessage != nil {
+			m.config.SaveMessage("user", input, m.config.SessionID)
+		}
+
 		// Busca RAG
 		runInput := input
 		if m.vectorStore != nil {
@@ -239,31 +310,112 @@ func (m Model) sendMessage(input string) tea.Cmd {
 			}
 		}
 
-		// Classificar intenção via IA (chamada rápida)
-		intent := classifyIntent(ctx, m.provider, input)
-
-		finalInput := runInput
-		if intent != nil && !intent.Direct && intent.Intent != "direct" {
-			tool := tools.FindByIntent(intent.Intent)
-			if tool != nil {
-				output, execErr := tool.Execute(ctx, intent.Params)
-				toolResult := output
-				if execErr != nil {
-					toolResult = fmt.Sprintf("Erro: %v", execErr)
-				}
-				finalInput = fmt.Sprintf("Pergunta do usuario: %s\n\nResultado da ferramenta %s:\n%s\n\nAnalise o resultado e responda ao usuario de forma clara e acionavel.", input, tool.Name, toolResult)
-			}
+		a := &agent.Agent{
+			Provider:     m.provider,
+			SystemPrompt: systemPrompt,
+			Config:       agentConfig,
+			Environment:  m.config.Environment,
+			Mutations:    m.config.Mutations,
+			Audit:        m.config.Audit,
+		}
+		run := a.Start(input, runInput)
+		return stepMsg{run: run, err: run.Next(ctx)}
+	}
+}
+
+// handleStep exibe o plano e os novos passos do loop e decide a continuação:
+// próximo passo, confirmação de escrita ou resposta final.
+func (m Model) handleStep(msg stepMsg) (tea.Model, tea.Cmd) {
+	run := msg.run
+	if m.run != run {
+		m.run, m.planShown, m.shownSteps = run, false, 0
+	}
+	if msg.err != nil {
+		m.state = stateIdle
+		m.run = nil
+		m.messages = append(m.messages, chatMessage{role: "error", content: msg.err.Error()})
+		m.updateViewport()
+		return m, nil
+	}
+
+	if !m.planShown && run.Plan != "" && len(run.Steps) > 0 {
+		m.messages = append(m.messages, chatMessage{role: "tool", content: "Plano:\n" + run.Plan})
+		m.planShown = true
+	}
+	shown := len(run.Steps)
+	if run.Pending != nil {
+		// o passo pendente só entra no trace depois da decisão do usuário
+		shown--
+	}
+	for ; m.shownSteps < shown; m.shownSteps++ {
+		m.messages = append(m.messages, chatMessage{role: "tool", content: fmt.Sprintf("[%d] %s", m.shownSteps+1, run.Steps[m.shownSteps].Trace())})
+	}
+
+	switch {
+	case run.Pending != nil:
+		m.state = stateConfirm
+		m.messages = append(m.messages, chatMessage{
+			role:    "tool",
+			content: fmt.Sprintf("%s (dry-run no servidor)\n%s", run.Pending.Summary(), run.Pending.Diff),
+		})
+		m.updateViewport()
+		return m, nil
+	case run.Done:
+		if run.Reason == agent.ReasonMaxSteps || run.Reason == agent.ReasonTokenBudget {
+			m.messages = append(m.messages, chatMessage{role: "tool", content: "Limite da investigação atingido (" + strings.ReplaceAll(run.Reason, "_", " ") + ")."})
 		}
+		m.updateViewport()
+		return m, m.finish(run)
+	}
+	m.updateViewport()
+	return m, m.next(run)
+}
 
+// next executa o próximo passo do loop.
+func (m Model) next(run *agent.Run) tea.Cmd {
+	return func() tea.Msg {
+		return stepMsg{run: run, err: run.Next(context.Background())}
+	}
+}
+
+// finish gera a resposta final com as observações coletadas.
+func (m Model) finish(run *agent.Run) tea.Cmd {
+	return func() tea.Msg {
 		var responseBuf bytes.Buffer
-		err := m.provider.StreamCompletion(ctx, m.config.SystemPrompt, finalInput, io.Writer(&responseBuf))
-		if err != nil {
+		if err := run.Finish(context.Background(), io.Writer(&responseBuf)); err != nil {
 			return responseMsg{err: err}
 		}
 		return responseMsg{content: responseBuf.String()}
 	}
 }
 
+// handleConfirmKey trata a resposta do usuário à confirmação de escrita:
+// 's' ou 'y' aplica a operação, qualquer outra tecla a cancela. Em ambos os
+// casos o loop continua com o resultado como observação.
+func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	run := m.run
+	approved := false
+	switch msg.String() {
+	case "s", "S", "y", "Y":
+		approved = true
+	}
+
+	if msg.Type == tea.KeyCtrlC {
+		run.Resume(context.Background(), false)
+		return m, tea.Quit
+	}
+
+	m.state = stateStreaming
+	if !approved {
+		m.messages = append(m.messages, chatMessage{role: "tool", content: "Operação cancelada. Nada foi alterado no cluster."})
+	}
+	m.updateViewport()
+	return m, func() tea.Msg {
+		run.Resume(context.Background(), approved)
+		return stepMsg{run: run}
+	}
+}
+
 // updateViewport atualiza o conteúdo do viewport com as mensagens.
 func (m *Model) updateViewport() {
 	var content strings.Builder
@@ -296,6 +448,9 @@ func (m *Model) updateViewport() {
 			content.WriteString(errorMsgStyle.Render("Erro: "))
 			content.WriteString(msg.content)
 			content.WriteString("\n\n")
+		case "info":
+			content.WriteString(dimStyle.Render(msg.content))
+			content.WriteString("\n\n")
 		}
 	}
 
@@ -326,8 +481,11 @@ func (m Model) renderStatusBar() string {
 	}
 
 	stateStr := "idle"
-	if m.state == stateStreaming {
+	switch m.state {
+	case stateStreaming:
 		stateStr = "streaming"
+	case stateConfirm:
+		stateStr = "aguardando confirmação"
 	}
 	items = append(items, statusKeyStyle.Render("Status: ")+statusValueStyle.Render(stateStr))
 
@@ -342,42 +500,3 @@ func Run(provider ai.Provider, vectorStore *ai.VectorStore, config Config) error
 	_, err := p.Run()
 	return err
 }
-
-// classifyIntent classifica a intenção do usuário via IA.
-func classifyIntent(ctx context.Context, provider ai.Provider, input string) *tools.IntentResult {
-	allTools := tools.All()
-	var intentList strings.Builder
-	for _, t := range allTools {
-		if len(t.Intents) > 0 {
-			fmt.Fprintf(&intentList, "- %s: %s\n", t.Intents[0], t.Description)
-		}
-	}
-	intentList.WriteString("- direct: responder diretamente sem executar ferramenta\n")
-
-	classifyPrompt := prompts.Get("bard.classify")
-	if classifyPrompt == "" {
-		classifyPrompt = `Classifique a intencao. Responda APENAS JSON:
-{"intent":"nome","params":{"chave":"valor"},"direct":false}
-Se nao precisa de ferramenta: {"intent":"direct","params":{},"direct":true}`
-	}
-
-	userPrompt := fmt.Sprintf("Intencoes:\n%s\nUsuario: %s", intentList.String(), input)
-
-	result, err := provider.Completion(ctx, classifyPrompt, userPrompt)
-	if err != nil {
-		return &tools.IntentResult{Direct: true}
-	}
-
-	var intent tools.IntentResult
-	clean := strings.TrimSpace(result)
-	clean = strings.TrimPrefix(clean, "```json")
-	clean = strings.TrimPrefix(clean, "```")
-	clean = strings.TrimSuffix(clean, "```")
-	clean = strings.TrimSpace(clean)
-
-	if err := json.Unmarshal([]byte(clean), &intent); err != nil {
-		return &tools.IntentResult{Direct: true}
-	}
-
-	return &intent
-}
@@ -1,10 +1,17 @@
 package tui
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -136,3 +143,171 @@ func TestView_SemTamanho(t *testing.T) {
 		t.Error("view sem tamanho deveria mostrar 'Carregando'")
 	}
 }
+
+// mutatingProvider pede uma ferramenta de escrita na primeira decisão e
+// responde diretamente nas seguintes.
+type mutatingProvider struct{ decisions int }
+
+func (p *mutatingProvider) Name() string                       { return "fake" }
+func (p *mutatingProvider) IsAvailable(_ context.Context) bool { return true }
+func (p *mutatingProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (p *mutatingProvider) Completion(_ context.Context, _, _ string) (string, error) { return "", nil }
+func (p *mutatingProvider) StreamCompletion(_ context.Context, _, _ string, out io.Writer) error {
+	_, err := io.WriteString(out, "feito")
+	return err
+}
+func (p *mutatingProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+func (p *mutatingProvider) CompletionWithTools(_ context.Context, _, _ string, _ []ai.ToolDefinition) (*ai.ToolResponse, error) {
+	p.decisions++
+	if p.decisions > 1 {
+		return &ai.ToolResponse{}, nil
+	}
+	return &ai.ToolResponse{
+		Content:   "1. escalar a api",
+		ToolCalls: []ai.ToolCall{{Name: "kubectl_scale", Arguments: map[string]interface{}{"resource": "deployment/api", "replicas": "5"}}},
+	}, nil
+}
+
+// pendingStep registra uma ferramenta de escrita falsa e executa o primeiro
+// passo do loop, que fica aguardando confirmação.
+func pendingStep(t *testing.T, executed *int) (Model, stepMsg, string) {
+	t.Helper()
+	saved := tools.All()
+	tools.Reset()
+	t.Cleanup(func() {
+		tools.Reset()
+		for _, tool := range saved {
+			tools.Register(tool)
+		}
+	})
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_scale",
+		Intents:    []string{"scale_workload"},
+		Mutating:   true,
+		Parameters: []tools.ToolParam{{Name: "resource", Required: true}, {Name: "replicas", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			*executed++
+			return "scaled", nil
+		},
+		DryRun: func(ctx context.Context, params map[string]string) (string, error) {
+			return "-  replicas: 2\n+  replicas: 5", nil
+		},
+	})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	config := Config{
+		Environment: "dev",
+		Mutations:   tools.MutationPolicy{Enabled: true},
+		Audit:       tools.NewAuditLogWithPath(auditPath),
+	}
+	model := New(&mutatingProvider{}, nil, config)
+	model.state = stateStreaming
+	model.width = 80
+
+	msg := model.sendMessage("escala a api para 5")().(stepMsg)
+	if msg.err != nil {
+		t.Fatalf("erro inesperado: %v", msg.err)
+	}
+	return model, msg, auditPath
+}
+
+// TestStepMsg_Confirmacao verifica que o diff é exibido e a TUI aguarda confirmação.
+func TestStepMsg_Confirmacao(t *testing.T) {
+	var executed int
+	model, msg, _ := pendingStep(t, &executed)
+
+	updated, cmd := model.Update(msg)
+	m := updated.(Model)
+
+	if m.state != stateConfirm {
+		t.Fatalf("estado esperado confirm, obtido %d", m.state)
+	}
+	if cmd != nil {
+		t.Error("não deveria continuar o loop antes da confirmação")
+	}
+	if len(m.messages) != 2 || !strings.Contains(m.messages[0].content, "Plano:") || !strings.Contains(m.messages[1].content, "+  replicas: 5") {
+		t.Errorf("plano e diff deveriam ser exibidos: %+v", m.messages)
+	}
+	if !strings.Contains(m.View(), "[s/N]") {
+		t.Error("view deveria pedir confirmação")
+	}
+	if executed != 0 {
+		t.Error("operação não deve executar antes da confirmação")
+	}
+}
+
+// TestConfirmKey_Recusa verifica que qualquer tecla diferente de s/y cancela.
+func TestConfirmKey_Recusa(t *testing.T) {
+	var executed int
+	model, msg, auditPath := pendingStep(t, &executed)
+	updated, _ := model.Update(msg)
+
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m := updated.(Model)
+	if m.state != stateStreaming || cmd == nil {
+		t.Fatal("recusa deveria continuar o loop com a observação")
+	}
+
+	next := cmd().(stepMsg)
+	if executed != 0 {
+		t.Error("operação recusada não deve executar")
+	}
+	updated, _ = m.Update(next)
+	m = updated.(Model)
+	last := m.messages[len(m.messages)-1].content
+	if !strings.Contains(last, "[1] kubectl_scale") || !strings.Contains(last, "cancelada") {
+		t.Errorf("trace deveria registrar o cancelamento: %s", last)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("auditoria não gravada: %v", err)
+	}
+	var entry tools.AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Decision != "rejected" {
+		t.Errorf("auditoria inesperada: %s", data)
+	}
+}
+
+// TestConfirmKey_Aprova verifica que 's' executa a operação e segue para a resposta.
+func TestConfirmKey_Aprova(t *testing.T) {
+	var executed int
+	model, msg, _ := pendingStep(t, &executed)
+	updated, _ := model.Update(msg)
+
+	updated, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m := updated.(Model)
+	if m.state != stateStreaming || cmd == nil {
+		t.Fatal("aprovação deveria retornar o comando de execução")
+	}
+	if executed != 0 {
+		t.Error("a execução deve ocorrer no comando, fora do Update")
+	}
+
+	updated, cmd = m.Update(cmd())
+	m = updated.(Model)
+	if executed != 1 {
+		t.Errorf("esperava 1 execução, obteve %d", executed)
+	}
+	plans := 0
+	for _, msg := range m.messages {
+		if strings.HasPrefix(msg.content, "Plano:") {
+			plans++
+		}
+	}
+	if plans != 1 {
+		t.Errorf("o plano deveria ser exibido uma vez, obteve %d", plans)
+	}
+
+	// próximo passo: o modelo responde sem ferramentas e a resposta final é gerada
+	updated, cmd = m.Update(cmd())
+	m = updated.(Model)
+	resp := cmd().(responseMsg)
+	if resp.content != "feito" {
+		t.Errorf("resposta inesperada: %+v", resp)
+	}
+}
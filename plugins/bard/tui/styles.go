@@ -44,6 +44,11 @@ var (
 			Foreground(lipgloss.Color("#FFD700")).
 			Italic(true)
 
+	// confirmStyle estiliza o pedido de confirmação de escrita
+	confirmStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F05D5E")).
+			Bold(true)
+
 	// headerStyle estiliza o cabeçalho da TUI
 	headerStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#DA70D6")).
@@ -0,0 +1,434 @@
+// Package web implementa o modo servidor do Bard: API de chat com streaming
+// via Server-Sent Events e uma interface web mínima embutida.
+//
+// Cada pergunta é enviada em POST /api/chat e respondida como um stream SSE
+// com o plano, os passos do loop de ferramentas e a resposta final. Quando o
+// modelo escolhe uma ferramenta de escrita, o stream termina com o evento
+// confirm e a decisão do usuário segue em POST /api/confirm.
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+//go:embed static/index.html
+var static embed.FS
+
+// maxRequestBody limita o corpo das requisições da API.
+const maxRequestBody = 64 * 1024
+
+// validSessionID restringe os IDs de sessão aceitos dos clientes, que são
+// gravados no histórico.
+var validSessionID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Config contém a configuração do servidor. As funções vêm do Bard para que
+// o servidor use o mesmo system prompt, RAG e histórico do chat no terminal.
+type Config struct {
+	// SystemPrompt monta o system prompt da sessão, com o histórico dela.
+	SystemPrompt func(sessionID string) string
+	// Input monta o input da resposta sem ferramentas: a pergunta com o
+	// contexto recuperado do Synapstor. Quando nil, usa a própria pergunta.
+	Input func(ctx context.Context, question string) string
+	// History retorna as mensagens já trocadas na sessão.
+	History func(sessionID string) []Message
+	// SaveMessage persiste uma mensagem no histórico da sessão.
+	SaveMessage func(role, content, sessionID string)
+	// Agent retorna os limites do loop de ferramentas para o system prompt.
+	Agent func(systemPrompt string) agent.Config
+
+	// Environment é o ambiente do projeto, usado pela política de escrita.
+	Environment string
+	// Mutations é a política das ferramentas de escrita (.yby/bard.yaml).
+	Mutations tools.MutationPolicy
+	// Audit registra as decisões sobre ferramentas de escrita.
+	Audit *tools.AuditLog
+
+	// Token, quando definido, é exigido nas rotas da API como
+	// "Authorization: Bearer <token>". Sem token, a API só atende requisições
+	// endereçadas a localhost ou a um IP de loopback.
+	Token string
+}
+
+// Message é uma mensagem do histórico de uma sessão.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Server atende a API de chat e a interface web.
+type Server struct {
+	provider ai.Provider
+	config   Config
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session guarda o loop em andamento de uma sessão e o que dele já foi
+// enviado. busy serializa as requisições da sessão: uma segunda pergunta
+// simultânea é recusada.
+type session struct {
+	busy    sync.Mutex
+	run     *agent.Run
+	planned bool
+	shown   int
+}
+
+type chatRequest struct {
+	SessionID string `json:"session_id"`
+	Message   string `json:"message"`
+}
+
+type confirmRequest struct {
+	SessionID string `json:"session_id"`
+	Approved  bool   `json:"approved"`
+}
+
+// New cria o servidor.
+func New(provider ai.Provider, config Config) *Server {
+	return &Server{
+		provider: provider,
+		config:   config,
+		sessions: make(map[string]*session),
+	}
+}
+
+// Handler retorna as rotas do servidor.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("POST /api/chat", s.api(requireJSON(s.handleChat)))
+	mux.HandleFunc("POST /api/confirm", s.api(requireJSON(s.handleConfirm)))
+	mux.HandleFunc("GET /api/sessions/{id}/messages", s.api(s.handleMessages))
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	page, err := static.ReadFile("static/index.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+// api aplica às rotas da API as verificações de host, de origem e o token.
+func (s *Server) api(next http.HandlerFunc) http.HandlerFunc {
+	return s.localHost(sameOrigin(s.authorize(next)))
+}
+
+// localHost rejeita, sem token, requisições cujo Host não é a máquina local.
+// Com DNS rebinding, uma página de outro site aponta o próprio domínio para
+// 127.0.0.1 e chega ao servidor com Origin igual ao Host.
+func (s *Server) localHost(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Token == "" && !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, "host nao permitido")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isLoopbackHost informa se o cabeçalho Host (com ou sem porta) é
+// localhost ou um IP de loopback.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sameOrigin rejeita requisições de páginas de outra origem: sem isso,
+// qualquer site aberto no navegador alcançaria o servidor local. Clientes
+// fora do navegador não enviam Origin.
+func sameOrigin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !strings.EqualFold(u.Host, r.Host) {
+				writeError(w, http.StatusForbidden, "origem nao permitida")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requireJSON exige corpo application/json. Formulários e text/plain são
+// enviados por outros sites sem preflight CORS.
+func requireJSON(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type deve ser application/json")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// authorize exige o token configurado, comparado em tempo constante.
+func (s *Server) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "token invalido ou ausente")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := decodeRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	question := strings.TrimSpace(req.Message)
+	if question == "" {
+		writeError(w, http.StatusBadRequest, "mensagem vazia")
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = newSessionID()
+	}
+	if !validSessionID.MatchString(req.SessionID) {
+		writeError(w, http.StatusBadRequest, "session_id invalido")
+		return
+	}
+
+	sess := s.session(req.SessionID, true)
+	if !sess.busy.TryLock() {
+		writeError(w, http.StatusConflict, "sessao ocupada com outra pergunta")
+		return
+	}
+	defer sess.busy.Unlock()
+
+	ctx := r.Context()
+	// uma nova pergunta descarta a operação que aguardava confirmação
+	if sess.run != nil && sess.run.Pending != nil {
+		sess.run.Resume(context.WithoutCancel(ctx), false)
+	}
+	sess.run = nil
+
+	st := newStream(w)
+	st.send("session", map[string]string{"session_id": req.SessionID})
+
+	// o prompt é montado antes de salvar a pergunta, que não é histórico
+	systemPrompt := s.config.SystemPrompt(req.SessionID)
+	s.save("user", question, req.SessionID)
+
+	input := question
+	if s.config.Input != nil {
+		input = s.config.Input(ctx, question)
+	}
+	var cfg agent.Config
+	if s.config.Agent != nil {
+		cfg = s.config.Agent(systemPrompt)
+	}
+	a := &agent.Agent{
+		Provider:     s.provider,
+		SystemPrompt: systemPrompt,
+		Config:       cfg,
+		Environment:  s.config.Environment,
+		Mutations:    s.config.Mutations,
+		Audit:        s.config.Audit,
+	}
+	sess.planned, sess.shown = false, 0
+	s.drive(ctx, st, sess, a.Start(question, input), req.SessionID)
+}
+
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	var req confirmRequest
+	if err := decodeRequest(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sess := s.session(req.SessionID, false)
+	if sess == nil {
+		writeError(w, http.StatusNotFound, "sessao nao encontrada")
+		return
+	}
+	if !sess.busy.TryLock() {
+		writeError(w, http.StatusConflict, "sessao ocupada com outra pergunta")
+		return
+	}
+	defer sess.busy.Unlock()
+
+	run := sess.run
+	if run == nil || run.Pending == nil {
+		writeError(w, http.StatusConflict, "nenhuma operacao aguardando confirmacao")
+		return
+	}
+
+	st := newStream(w)
+	// a operação não é interrompida se o navegador desconectar no meio
+	run.Resume(context.WithoutCancel(r.Context()), req.Approved)
+	sess.emitSteps(st, run)
+	s.drive(r.Context(), st, sess, run, req.SessionID)
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !validSessionID.MatchString(id) {
+		writeError(w, http.StatusBadRequest, "session_id invalido")
+		return
+	}
+	messages := []Message{}
+	if s.config.History != nil {
+		messages = append(messages, s.config.History(id)...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(messages)
+}
+
+// drive executa o loop até pausar em uma confirmação ou terminar com a
+// resposta final, enviando cada passo no stream.
+func (s *Server) drive(ctx context.Context, st *stream, sess *session, run *agent.Run, sessionID string) {
+	for !run.Done {
+		if err := run.Next(ctx); err != nil {
+			sess.run = nil
+			st.send("error", map[string]string{"message": err.Error()})
+			return
+		}
+		sess.emitSteps(st, run)
+		if run.Pending != nil {
+			sess.run = run
+			st.send("confirm", map[string]string{"summary": run.Pending.Summary(), "diff": run.Pending.Diff})
+			return
+		}
+	}
+	sess.run = nil
+	if run.Reason == agent.ReasonMaxSteps || run.Reason == agent.ReasonTokenBudget {
+		st.send("info", map[string]string{"text": "Limite da investigacao atingido (" + strings.ReplaceAll(run.Reason, "_", " ") + ")."})
+	}
+
+	var response bytes.Buffer
+	if err := run.Finish(ctx, io.MultiWriter(&response, tokenWriter{st})); err != nil {
+		st.send("error", map[string]string{"message": err.Error()})
+		return
+	}
+	if response.Len() > 0 {
+		s.save("assistant", response.String(), sessionID)
+	}
+	st.send("done", struct{}{})
+}
+
+// emitSteps envia o plano e os passos ainda não enviados. O passo pendente
+// só é enviado depois da decisão do usuário.
+func (sess *session) emitSteps(st *stream, run *agent.Run) {
+	if !sess.planned && run.Plan != "" && len(run.Steps) > 0 {
+		st.send("plan", map[string]string{"text": run.Plan})
+		sess.planned = true
+	}
+	last := len(run.Steps)
+	if run.Pending != nil {
+		last--
+	}
+	for ; sess.shown < last; sess.shown++ {
+		st.send("step", map[string]interface{}{"index": sess.shown + 1, "trace": run.Steps[sess.shown].Trace()})
+	}
+}
+
+// session retorna a sessão, criando-a quando create é verdadeiro.
+func (s *Server) session(id string, create bool) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := s.sessions[id]
+	if sess == nil && create {
+		sess = &session{}
+		s.sessions[id] = sess
+	}
+	return sess
+}
+
+func (s *Server) save(role, content, sessionID string) {
+	if s.config.SaveMessage != nil {
+		s.config.SaveMessage(role, content, sessionID)
+	}
+}
+
+// newSessionID gera o ID de uma sessão web: o formato das sessões do
+// terminal com um sufixo aleatório, já que várias pessoas usam o servidor.
+func newSessionID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+func decodeRequest(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody)).Decode(v); err != nil {
+		return fmt.Errorf("requisicao invalida: %w", err)
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// stream escreve eventos SSE e os envia imediatamente ao cliente. Falhas de
+// escrita (cliente desconectado) são ignoradas: o contexto da requisição
+// interrompe o loop.
+type stream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func newStream(w http.ResponseWriter) *stream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	return &stream{w: w, rc: http.NewResponseController(w)}
+}
+
+func (st *stream) send(event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(st.w, "event: %s\ndata: %s\n\n", event, payload)
+	_ = st.rc.Flush()
+}
+
+// tokenWriter envia cada trecho da resposta final como evento token.
+type tokenWriter struct{ st *stream }
+
+func (t tokenWriter) Write(p []byte) (int, error) {
+	t.st.send("token", map[string]string{"text": string(p)})
+	return len(p), nil
+}
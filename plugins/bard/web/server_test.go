@@ -0,0 +1,522 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/plugins/bard/agent"
+	"github.com/casheiro/yby-cli/plugins/bard/tools"
+)
+
+// scriptedProvider devolve uma sequência fixa de chamadas de ferramenta e
+// depois responde diretamente, registrando o system prompt recebido.
+type scriptedProvider struct {
+	mu           sync.Mutex
+	responses    [][]ai.ToolCall
+	decisions    int
+	systemPrompt string
+}
+
+func (p *scriptedProvider) Name() string                       { return "scripted" }
+func (p *scriptedProvider) IsAvailable(_ context.Context) bool { return true }
+func (p *scriptedProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (p *scriptedProvider) Completion(_ context.Context, _, _ string) (string, error) { return "", nil }
+func (p *scriptedProvider) StreamCompletion(_ context.Context, systemPrompt, _ string, out io.Writer) error {
+	p.mu.Lock()
+	p.systemPrompt = systemPrompt
+	p.mu.Unlock()
+	if _, err := io.WriteString(out, "resposta "); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "final")
+	return err
+}
+func (p *scriptedProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+func (p *scriptedProvider) CompletionWithTools(_ context.Context, _, _ string, _ []ai.ToolDefinition) (*ai.ToolResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i := p.decisions
+	p.decisions++
+	if i >= len(p.responses) {
+		return &ai.ToolResponse{}, nil
+	}
+	return &ai.ToolResponse{Content: "1. investigar", ToolCalls: p.responses[i]}, nil
+}
+
+type event struct {
+	name string
+	data map[string]interface{}
+}
+
+// postEvents envia o corpo ao servidor e decodifica os eventos SSE.
+func postEvents(t *testing.T, srv *httptest.Server, path, body string) []event {
+	t.Helper()
+	resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("falha na requisição: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status %d: %s", resp.StatusCode, data)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var events []event
+	var current event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data); err != nil {
+				t.Fatalf("data inválido %q: %v", line, err)
+			}
+		case line == "":
+			events = append(events, current)
+			current = event{}
+		}
+	}
+	return events
+}
+
+func names(events []event) string {
+	var n []string
+	for _, e := range events {
+		n = append(n, e.name)
+	}
+	return strings.Join(n, ",")
+}
+
+// tokens concatena o texto dos eventos token.
+func tokens(events []event) string {
+	var sb strings.Builder
+	for _, e := range events {
+		if e.name == "token" {
+			sb.WriteString(e.data["text"].(string))
+		}
+	}
+	return sb.String()
+}
+
+// savedMessages registra as mensagens persistidas pelo servidor.
+type savedMessages struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (s *savedMessages) save(role, content, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list = append(s.list, sessionID+"|"+role+"|"+content)
+}
+
+// registerWebTestTools substitui o registro por ferramentas de teste e
+// restaura as ferramentas originais ao final.
+func registerWebTestTools(t *testing.T, executed *int) {
+	t.Helper()
+	saved := tools.All()
+	tools.Reset()
+	t.Cleanup(func() {
+		tools.Reset()
+		for _, tool := range saved {
+			tools.Register(tool)
+		}
+	})
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_get",
+		Intents:    []string{"list_resources"},
+		Parameters: []tools.ToolParam{{Name: "resource", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			return "api-1   1/1   Running", nil
+		},
+	})
+	tools.Register(&tools.Tool{
+		Name:       "kubectl_scale",
+		Intents:    []string{"scale_workload"},
+		Mutating:   true,
+		Parameters: []tools.ToolParam{{Name: "resource", Required: true}, {Name: "replicas", Required: true}},
+		Execute: func(ctx context.Context, params map[string]string) (string, error) {
+			*executed++
+			return "deployment.apps/api scaled", nil
+		},
+		DryRun: func(ctx context.Context, params map[string]string) (string, error) {
+			return "-  replicas: 2\n+  replicas: 5", nil
+		},
+	})
+}
+
+func newTestServer(t *testing.T, provider ai.Provider, saved *savedMessages, mutate func(*Config)) *httptest.Server {
+	t.Helper()
+	config := Config{
+		SystemPrompt: func(sessionID string) string { return "prompt da sessao " + sessionID },
+		SaveMessage:  saved.save,
+		Agent:        func(string) agent.Config { return agent.Config{MaxSteps: 3} },
+		Environment:  "dev",
+		Mutations:    tools.MutationPolicy{Enabled: true},
+		Audit:        tools.NewAuditLogWithPath(filepath.Join(t.TempDir(), "audit.jsonl")),
+	}
+	if mutate != nil {
+		mutate(&config)
+	}
+	srv := httptest.NewServer(New(provider, config).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestIndex verifica que a interface web é servida na raiz.
+func TestIndex(t *testing.T) {
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("falha na requisição: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Yby Bard") {
+		t.Errorf("status %d, corpo sem a interface", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+// TestChat_RespostaDireta verifica o stream de uma pergunta sem ferramentas
+// e a persistência no histórico da sessão.
+func TestChat_RespostaDireta(t *testing.T) {
+	registerWebTestTools(t, new(int))
+	provider := &scriptedProvider{}
+	saved := &savedMessages{}
+	var input string
+	srv := newTestServer(t, provider, saved, func(c *Config) {
+		c.Input = func(ctx context.Context, question string) string {
+			input = question
+			return "contexto + " + question
+		}
+	})
+
+	events := postEvents(t, srv, "/api/chat", `{"session_id":"s1","message":"  o que e o yby?  "}`)
+
+	if names(events) != "session,token,token,done" {
+		t.Fatalf("eventos = %s", names(events))
+	}
+	if events[0].data["session_id"] != "s1" {
+		t.Errorf("session_id = %v", events[0].data["session_id"])
+	}
+	if tokens(events) != "resposta final" {
+		t.Errorf("resposta = %q", tokens(events))
+	}
+	if input != "o que e o yby?" {
+		t.Errorf("Input recebeu %q", input)
+	}
+	if provider.systemPrompt != "prompt da sessao s1" {
+		t.Errorf("system prompt = %q", provider.systemPrompt)
+	}
+	expected := []string{"s1|user|o que e o yby?", "s1|assistant|resposta final"}
+	if strings.Join(saved.list, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("mensagens salvas = %v", saved.list)
+	}
+}
+
+// TestChat_NovaSessao verifica que uma sessão é criada quando o cliente não
+// informa o ID.
+func TestChat_NovaSessao(t *testing.T) {
+	registerWebTestTools(t, new(int))
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+
+	events := postEvents(t, srv, "/api/chat", `{"message":"oi"}`)
+	id, _ := events[0].data["session_id"].(string)
+	if !validSessionID.MatchString(id) {
+		t.Errorf("session_id gerado inválido: %q", id)
+	}
+}
+
+// TestChat_Ferramentas verifica que o plano e os passos são enviados antes
+// da resposta final.
+func TestChat_Ferramentas(t *testing.T) {
+	registerWebTestTools(t, new(int))
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		{{Name: "kubectl_get", Arguments: map[string]interface{}{"resource": "pods"}}},
+	}}
+	srv := newTestServer(t, provider, &savedMessages{}, nil)
+
+	events := postEvents(t, srv, "/api/chat", `{"session_id":"s1","message":"a api esta rodando?"}`)
+
+	if names(events) != "session,plan,step,token,token,done" {
+		t.Fatalf("eventos = %s", names(events))
+	}
+	if events[1].data["text"] != "1. investigar" {
+		t.Errorf("plano = %v", events[1].data["text"])
+	}
+	if trace := events[2].data["trace"].(string); trace != "kubectl_get resource=pods -> ok (1 linhas)" {
+		t.Errorf("trace = %q", trace)
+	}
+}
+
+// TestChat_Confirmacao verifica que uma ferramenta de escrita pausa o stream
+// com o diff e só executa após a aprovação.
+func TestChat_Confirmacao(t *testing.T) {
+	var executed int
+	registerWebTestTools(t, &executed)
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		{{Name: "kubectl_scale", Arguments: map[string]interface{}{"resource": "deployment/api", "replicas": "5"}}},
+	}}
+	saved := &savedMessages{}
+	srv := newTestServer(t, provider, saved, nil)
+
+	events := postEvents(t, srv, "/api/chat", `{"session_id":"s1","message":"escala a api para 5"}`)
+	if names(events) != "session,plan,confirm" {
+		t.Fatalf("eventos = %s", names(events))
+	}
+	if diff := events[2].data["diff"].(string); !strings.Contains(diff, "+  replicas: 5") {
+		t.Errorf("diff = %q", diff)
+	}
+	if executed != 0 {
+		t.Fatal("operação não deve executar antes da confirmação")
+	}
+
+	events = postEvents(t, srv, "/api/confirm", `{"session_id":"s1","approved":true}`)
+	if names(events) != "step,token,token,done" {
+		t.Fatalf("eventos após confirmação = %s", names(events))
+	}
+	if executed != 1 {
+		t.Errorf("esperava 1 execução, obteve %d", executed)
+	}
+	if trace := events[0].data["trace"].(string); !strings.Contains(trace, "kubectl_scale") || !strings.Contains(trace, "-> ok") {
+		t.Errorf("trace = %q", trace)
+	}
+	if len(saved.list) != 2 {
+		t.Errorf("mensagens salvas = %v", saved.list)
+	}
+
+	// sem operação pendente
+	resp, err := http.Post(srv.URL+"/api/confirm", "application/json", strings.NewReader(`{"session_id":"s1","approved":true}`))
+	if err != nil {
+		t.Fatalf("falha na requisição: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("status = %d, esperado 409", resp.StatusCode)
+	}
+}
+
+// TestChat_Recusa verifica que recusar cancela a operação e o loop continua.
+func TestChat_Recusa(t *testing.T) {
+	var executed int
+	registerWebTestTools(t, &executed)
+	provider := &scriptedProvider{responses: [][]ai.ToolCall{
+		{{Name: "kubectl_scale", Arguments: map[string]interface{}{"resource": "deployment/api", "replicas": "5"}}},
+	}}
+	srv := newTestServer(t, provider, &savedMessages{}, nil)
+
+	postEvents(t, srv, "/api/chat", `{"session_id":"s1","message":"escala a api para 5"}`)
+	events := postEvents(t, srv, "/api/confirm", `{"session_id":"s1","approved":false}`)
+
+	if names(events) != "step,token,token,done" {
+		t.Fatalf("eventos = %s", names(events))
+	}
+	if trace := events[0].data["trace"].(string); !strings.Contains(trace, "cancelada") {
+		t.Errorf("trace deveria indicar o cancelamento: %q", trace)
+	}
+	if executed != 0 {
+		t.Error("operação recusada não deve executar")
+	}
+}
+
+// TestAPI_Erros verifica as validações das requisições.
+func TestAPI_Erros(t *testing.T) {
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"json invalido", "/api/chat", `{`, http.StatusBadRequest},
+		{"mensagem vazia", "/api/chat", `{"message":"   "}`, http.StatusBadRequest},
+		{"sessao invalida", "/api/chat", `{"session_id":"../x","message":"oi"}`, http.StatusBadRequest},
+		{"confirmacao sem sessao", "/api/confirm", `{"session_id":"nenhuma","approved":true}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+tt.path, "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("falha na requisição: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, esperado %d", resp.StatusCode, tt.status)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body["error"] == "" {
+				t.Errorf("resposta de erro sem mensagem: %v", body)
+			}
+		})
+	}
+}
+
+// TestAuth verifica a exigência do token quando configurado.
+func TestAuth(t *testing.T) {
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, func(c *Config) {
+		c.Token = "segredo"
+		c.History = func(sessionID string) []Message {
+			return []Message{{Role: "user", Content: "pergunta de " + sessionID}}
+		}
+	})
+
+	get := func(auth string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/sessions/s1/messages", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("falha na requisição: %v", err)
+		}
+		return resp
+	}
+
+	for _, auth := range []string{"", "Bearer errado"} {
+		resp := get(auth)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, esperado 401", auth, resp.StatusCode)
+		}
+	}
+
+	resp := get("Bearer segredo")
+	defer resp.Body.Close()
+	var messages []Message
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Content != "pergunta de s1" {
+		t.Errorf("mensagens = %+v", messages)
+	}
+
+	// a interface web não exige token: ela o pede ao receber 401
+	page, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("falha na requisição: %v", err)
+	}
+	page.Body.Close()
+	if page.StatusCode != http.StatusOK {
+		t.Errorf("status da interface = %d", page.StatusCode)
+	}
+}
+
+// TestAPI_ContentType verifica que as rotas de escrita exigem JSON.
+func TestAPI_ContentType(t *testing.T) {
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		for _, path := range []string{"/api/chat", "/api/confirm"} {
+			resp, err := http.Post(srv.URL+path, contentType, strings.NewReader(`{"message":"oi"}`))
+			if err != nil {
+				t.Fatalf("falha na requisição: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusUnsupportedMediaType {
+				t.Errorf("%s com Content-Type %q: status = %d, esperado 415", path, contentType, resp.StatusCode)
+			}
+		}
+	}
+
+	events := postEvents(t, srv, "/api/chat", `{"message":"oi"}`)
+	if len(events) == 0 {
+		t.Error("requisição JSON deveria ser aceita")
+	}
+}
+
+// TestAPI_Origem verifica que páginas de outra origem não alcançam a API.
+func TestAPI_Origem(t *testing.T) {
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+
+	do := func(method, path, origin string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(`{"message":"   "}`))
+		req.Header.Set("Content-Type", "application/json")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("falha na requisição: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, origin := range []string{"https://exemplo.com", "http://localhost:1", "null"} {
+		if status := do(http.MethodPost, "/api/chat", origin); status != http.StatusForbidden {
+			t.Errorf("POST com Origin %q: status = %d, esperado 403", origin, status)
+		}
+		if status := do(http.MethodGet, "/api/sessions/s1/messages", origin); status != http.StatusForbidden {
+			t.Errorf("GET com Origin %q: status = %d, esperado 403", origin, status)
+		}
+	}
+
+	// mesma origem e clientes sem Origin chegam à validação da mensagem
+	for _, origin := range []string{srv.URL, ""} {
+		if status := do(http.MethodPost, "/api/chat", origin); status != http.StatusBadRequest {
+			t.Errorf("POST com Origin %q: status = %d, esperado 400", origin, status)
+		}
+	}
+}
+
+// TestAPI_Host verifica que, sem token, a API recusa hosts fora do loopback
+// (DNS rebinding chega com Origin igual ao Host).
+func TestAPI_Host(t *testing.T) {
+	do := func(srv *httptest.Server, host, token string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/confirm", strings.NewReader(`{"session_id":"s1","approved":true}`))
+		req.Host = host
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "http://"+host)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("falha na requisição: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	srv := newTestServer(t, &scriptedProvider{}, &savedMessages{}, nil)
+	for _, host := range []string{"evil.example", "evil.example:8088", "10.0.0.5:8088"} {
+		if status := do(srv, host, ""); status != http.StatusForbidden {
+			t.Errorf("Host %q sem token: status = %d, esperado 403", host, status)
+		}
+	}
+	// hosts locais chegam à validação da sessão
+	for _, host := range []string{"localhost:8088", "127.0.0.1:8088", "[::1]:8088"} {
+		if status := do(srv, host, ""); status != http.StatusNotFound {
+			t.Errorf("Host %q sem token: status = %d, esperado 404", host, status)
+		}
+	}
+
+	// com token, o host não é restrito: o token é a proteção
+	withToken := newTestServer(t, &scriptedProvider{}, &savedMessages{}, func(c *Config) { c.Token = "segredo" })
+	if status := do(withToken, "bard.interno:8088", "segredo"); status != http.StatusNotFound {
+		t.Errorf("Host externo com token: status = %d, esperado 404", status)
+	}
+}
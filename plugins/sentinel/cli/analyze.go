@@ -0,0 +1,146 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+)
+
+// analyzeOptions são as flags de `sentinel analyze`.
+type analyzeOptions struct {
+	Bundle       string
+	Provider     string
+	PromptFile   string
+	OutputFormat string
+	OutputFile   string
+}
+
+// parseAnalyzeArgs interpreta as flags de `sentinel analyze`.
+func parseAnalyzeArgs(args []string) analyzeOptions {
+	opts := analyzeOptions{Provider: "auto"}
+	for i := 0; i < len(args); i++ {
+		next := func() string {
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		switch args[i] {
+		case "--bundle", "-b":
+			opts.Bundle = next()
+		case "--provider":
+			opts.Provider = next()
+		case "--prompt-file":
+			opts.PromptFile = next()
+		case "--output", "-o":
+			opts.OutputFormat = next()
+		case "--file", "-f":
+			opts.OutputFile = next()
+		default:
+			// Aceita o bundle como argumento posicional
+			if !strings.HasPrefix(args[i], "-") && opts.Bundle == "" {
+				opts.Bundle = args[i]
+			}
+		}
+	}
+	return opts
+}
+
+// analyzeBundle reexecuta a análise de IA sobre a evidência do bundle.
+func analyzeBundle(ctx context.Context, b *InvestigationBundle, provider ai.Provider, systemPrompt string) (AnalysisResult, error) {
+	input, err := b.AIContext()
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	result, err := requestAnalysis(ctx, provider, systemPrompt, input)
+	if err != nil {
+		var invalid *ai.JSONValidationError
+		if errors.As(err, &invalid) {
+			return AnalysisResult{}, fmt.Errorf("falha ao parsear resposta da IA: %w\nConteudo bruto:\n%s", err, invalid.Raw)
+		}
+		return AnalysisResult{}, fmt.Errorf("falha na chamada da IA: %w", err)
+	}
+	return result, nil
+}
+
+// bundlePrompt resolve o system prompt da reanálise: arquivo informado em
+// --prompt-file ou a versão atual do prompt usado na coleta.
+func bundlePrompt(b *InvestigationBundle, promptFile string) (string, error) {
+	if promptFile != "" {
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return "", fmt.Errorf("falha ao ler prompt: %w", err)
+		}
+		return string(data), nil
+	}
+	if p := prompts.Get(b.Manifest.PromptName); p != "" {
+		return p, nil
+	}
+	if b.Prompt != "" {
+		return b.Prompt, nil
+	}
+	return "", fmt.Errorf("prompt '%s' nao encontrado", b.Manifest.PromptName)
+}
+
+// runAnalyze executa `sentinel analyze --bundle`: reanalisa offline, sem
+// acesso ao cluster, a evidência gravada por `investigate --save-bundle`.
+func runAnalyze(args []string) bool {
+	opts := parseAnalyzeArgs(args)
+	if opts.Bundle == "" {
+		fmt.Println("❌ Bundle obrigatorio. Uso: yby sentinel analyze --bundle <arquivo.tar.gz> [--provider nome] [--prompt-file arquivo]")
+		return false
+	}
+
+	b, err := readBundle(opts.Bundle)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+
+	width, titleStyle, boxStyle, labelStyle := investigateStyles()
+	target := b.Manifest.Name
+	if b.Manifest.Kind != "Pod" {
+		target = strings.ToLower(b.Manifest.Kind) + "/" + b.Manifest.Name
+	}
+	fmt.Println(titleStyle.Render(fmt.Sprintf("\n🛡️  Sentinel Analyze: %s/%s", b.Manifest.Namespace, target)))
+	fmt.Printf("📦 Evidencia coletada em %s\n", b.Manifest.CollectedAt.Local().Format("2006-01-02 15:04:05"))
+
+	if b.Analysis != nil {
+		source := b.Manifest.Provider
+		if b.Analysis.RuleID != "" {
+			source = "regra " + b.Analysis.RuleID
+		}
+		fmt.Printf("📝 Analise original (%s): %s (confianca %d%%)\n", source, b.Analysis.RootCause, b.Analysis.Confidence)
+	}
+
+	systemPrompt, err := bundlePrompt(b, opts.PromptFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+
+	ctx := context.Background()
+	provider := ai.GetProvider(ctx, opts.Provider)
+	if provider == nil {
+		fmt.Printf("❌ Provedor de IA '%s' indisponivel.\n", opts.Provider)
+		return false
+	}
+
+	fmt.Printf("\nAnalisando com IA (%s)...\n", provider.Name())
+	result, err := analyzeBundle(ctx, b, provider, systemPrompt)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+
+	renderResult(result, target, b.Manifest.Namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+	return true
+}
@@ -0,0 +1,110 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+)
+
+// fakeProvider responde às análises com uma resposta fixa e registra a entrada.
+type fakeProvider struct {
+	response     string
+	err          error
+	systemPrompt string
+	userPrompt   string
+}
+
+func (f *fakeProvider) Name() string                       { return "fake" }
+func (f *fakeProvider) IsAvailable(_ context.Context) bool { return true }
+func (f *fakeProvider) GenerateGovernance(_ context.Context, _ string) (*ai.GovernanceBlueprint, error) {
+	return nil, nil
+}
+func (f *fakeProvider) Completion(_ context.Context, systemPrompt, userPrompt string) (string, error) {
+	f.systemPrompt, f.userPrompt = systemPrompt, userPrompt
+	return f.response, f.err
+}
+func (f *fakeProvider) StreamCompletion(_ context.Context, _, _ string, _ io.Writer) error {
+	return nil
+}
+func (f *fakeProvider) EmbedDocuments(_ context.Context, _ []string) ([][]float32, error) {
+	return nil, nil
+}
+
+func TestParseAnalyzeArgs(t *testing.T) {
+	opts := parseAnalyzeArgs([]string{"--bundle", "out.tar.gz", "--provider", "gemini", "--prompt-file", "v2.txt", "-o", "json", "-f", "r.json"})
+	want := analyzeOptions{Bundle: "out.tar.gz", Provider: "gemini", PromptFile: "v2.txt", OutputFormat: "json", OutputFile: "r.json"}
+	if opts != want {
+		t.Errorf("esperado %+v, obtido %+v", want, opts)
+	}
+
+	opts = parseAnalyzeArgs([]string{"out.tar.gz"})
+	if opts.Bundle != "out.tar.gz" || opts.Provider != "auto" {
+		t.Errorf("bundle posicional e provider padrão esperados: %+v", opts)
+	}
+}
+
+func TestAnalyzeBundle(t *testing.T) {
+	provider := &fakeProvider{response: "```json\n" + `{"root_cause":"Postgres fora do ar","technical_detail":"connection refused","confidence":90,"suggested_fix":"Suba o banco"}` + "\n```"}
+	b := samplePodBundle()
+
+	result, err := analyzeBundle(context.Background(), b, provider, "prompt v2")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if result.RootCause != "Postgres fora do ar" || result.Confidence != 90 {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+	if !strings.HasPrefix(provider.systemPrompt, "prompt v2") || !strings.Contains(provider.userPrompt, "panic: connection refused") {
+		t.Errorf("provider deveria receber o prompt informado e a evidência do bundle: %q / %q", provider.systemPrompt, provider.userPrompt)
+	}
+
+	if _, err := analyzeBundle(context.Background(), b, &fakeProvider{err: errors.New("quota")}, "p"); err == nil {
+		t.Error("esperava erro da IA")
+	}
+	if _, err := analyzeBundle(context.Background(), b, &fakeProvider{response: "nao e json"}, "p"); err == nil || !strings.Contains(err.Error(), "nao e json") {
+		t.Errorf("erro de parse deveria trazer a resposta bruta: %v", err)
+	}
+}
+
+func TestBundlePrompt(t *testing.T) {
+	b := samplePodBundle()
+
+	promptFile := filepath.Join(t.TempDir(), "v2.txt")
+	if err := os.WriteFile(promptFile, []byte("prompt experimental"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := bundlePrompt(b, promptFile); err != nil || p != "prompt experimental" {
+		t.Errorf("esperava prompt do arquivo, obteve %q (%v)", p, err)
+	}
+	if _, err := bundlePrompt(b, filepath.Join(t.TempDir(), "inexistente.txt")); err == nil {
+		t.Error("esperava erro para arquivo de prompt inexistente")
+	}
+
+	// Sem --prompt-file usa a versão atual do prompt da coleta
+	if p, err := bundlePrompt(b, ""); err != nil || !strings.Contains(p, "Root Cause") {
+		t.Errorf("esperava o prompt sentinel.investigate atual, obteve %q (%v)", p, err)
+	}
+
+	// Prompt desconhecido cai no prompt gravado no bundle
+	b.Manifest.PromptName = "prompt.removido"
+	if p, err := bundlePrompt(b, ""); err != nil || p != "system prompt" {
+		t.Errorf("esperava o prompt gravado no bundle, obteve %q (%v)", p, err)
+	}
+}
+
+func TestRunAnalyze_SemBundle(t *testing.T) {
+	if runAnalyze(nil) {
+		t.Error("analyze sem bundle deveria falhar")
+	}
+	if runAnalyze([]string{"--bundle", filepath.Join(t.TempDir(), "inexistente.tar.gz")}) {
+		t.Error("analyze com bundle inexistente deveria falhar")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 
 	"github.com/open-policy-agent/opa/v1/rego"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -237,12 +238,38 @@ violation contains result if {
 `
 
 // OPABackend implementa SecurityBackend usando Open Policy Agent para
-// avaliar políticas Rego embarcadas contra recursos Kubernetes.
-type OPABackend struct{}
+// avaliar políticas Rego embarcadas e políticas do usuário contra recursos Kubernetes.
+type OPABackend struct {
+	policyDirs     []string
+	loadOnce       sync.Once
+	customPolicies []CustomPolicy
+}
 
-// NewOPABackend cria uma nova instância do OPABackend.
+// NewOPABackend cria uma nova instância do OPABackend que, além das políticas
+// embarcadas, carrega políticas do usuário de .yby/policies e ~/.yby/policies.
 func NewOPABackend() *OPABackend {
-	return &OPABackend{}
+	return &OPABackend{policyDirs: DefaultPolicyDirs()}
+}
+
+// NewOPABackendWithPolicyDirs cria um OPABackend que carrega políticas do
+// usuário apenas dos diretórios informados.
+func NewOPABackendWithPolicyDirs(dirs ...string) *OPABackend {
+	return &OPABackend{policyDirs: dirs}
+}
+
+// loadCustomPolicies carrega as políticas do usuário uma única vez por backend.
+// Políticas com erro são reportadas individualmente e ignoradas; as demais
+// continuam ativas.
+func (o *OPABackend) loadCustomPolicies(ctx context.Context) {
+	o.loadOnce.Do(func() {
+		policies, err := LoadCustomPolicies(ctx, o.policyDirs...)
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				slog.Warn("opa: política customizada ignorada", "error", e)
+			}
+		}
+		o.customPolicies = policies
+	})
 }
 
 // Name retorna o identificador do backend.
@@ -291,6 +318,17 @@ func (o *OPABackend) ScanCluster(ctx context.Context, client kubernetes.Interfac
 		findings = append(findings, defaultDenyFindings...)
 	}
 
+	// 5. Avaliar políticas do usuário (.yby/policies, ~/.yby/policies)
+	o.loadCustomPolicies(ctx)
+	if len(o.customPolicies) > 0 {
+		customFindings, err := o.scanCustomPolicies(ctx, client, namespace)
+		if err != nil {
+			slog.Warn("opa: falha ao escanear políticas customizadas", "error", err)
+		} else {
+			findings = append(findings, customFindings...)
+		}
+	}
+
 	return findings, nil
 }
 
@@ -0,0 +1,390 @@
+//go:build k8s
+
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/tester"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// PolicyDirName é o subdiretório de .yby onde ficam as políticas Rego do usuário.
+const PolicyDirName = "policies"
+
+// CustomPolicy representa uma política Rego fornecida pelo usuário.
+// A política deve definir um conjunto `violation` com objetos contendo ao menos
+// `msg`; `severity`, `id`, `category` e `recommendation` são opcionais.
+type CustomPolicy struct {
+	Name    string
+	Path    string
+	Package string
+	query   rego.PreparedEvalQuery
+}
+
+// DefaultPolicyDirs retorna os diretórios de políticas do projeto e do usuário,
+// nessa ordem: .yby/policies e ~/.yby/policies.
+func DefaultPolicyDirs() []string {
+	dirs := []string{filepath.Join(".yby", PolicyDirName)}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".yby", PolicyDirName))
+	}
+	return dirs
+}
+
+// LoadCustomPolicies carrega e compila os arquivos .rego dos diretórios
+// informados, ignorando arquivos de teste (*_test.rego) e diretórios
+// inexistentes. Um arquivo ilegível ou inválido não impede o carregamento dos
+// demais: as políticas válidas são retornadas junto com um erro (errors.Join)
+// com uma entrada por arquivo ou diretório com falha.
+func LoadCustomPolicies(ctx context.Context, dirs ...string) ([]CustomPolicy, error) {
+	var policies []CustomPolicy
+	var errs []error
+	for _, dir := range dirs {
+		files, err := regoFiles(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, path := range files {
+			if strings.HasSuffix(path, "_test.rego") {
+				continue
+			}
+			p, err := compileCustomPolicy(ctx, path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			policies = append(policies, *p)
+		}
+	}
+	return policies, errors.Join(errs...)
+}
+
+// compileCustomPolicy parseia o módulo para descobrir seu package e prepara a
+// query `<package>.violation` para avaliação repetida.
+func compileCustomPolicy(ctx context.Context, path string) (*CustomPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler política %s: %w", path, err)
+	}
+	module, err := ast.ParseModule(path, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("política %s inválida: %w", path, err)
+	}
+
+	pkg := module.Package.Path.String()
+	query, err := rego.New(
+		rego.Query(pkg+".violation"),
+		rego.Module(path, string(data)),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao compilar política %s: %w", path, err)
+	}
+
+	return &CustomPolicy{
+		Name:    strings.TrimSuffix(filepath.Base(path), ".rego"),
+		Path:    path,
+		Package: pkg,
+		query:   query,
+	}, nil
+}
+
+// regoFiles lista os arquivos .rego de um diretório, recursivamente e ordenados.
+func regoFiles(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".rego" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar políticas em %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// scanCustomPolicies avalia as políticas do usuário contra cada objeto que o
+// backend já busca: ClusterRoleBindings, ClusterRoles, Roles e NetworkPolicies.
+// O input é o objeto Kubernetes completo, com kind e apiVersion preenchidos.
+func (o *OPABackend) scanCustomPolicies(ctx context.Context, client kubernetes.Interface, namespace string) ([]Finding, error) {
+	objects, err := fetchPolicyObjects(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, policy := range o.customPolicies {
+		for _, obj := range objects {
+			rs, err := policy.query.Eval(ctx, rego.EvalInput(obj.input))
+			if err != nil {
+				slog.Warn("opa: falha ao avaliar política customizada", "policy", policy.Path, "resource", obj.resource, "error", err)
+				continue
+			}
+			if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+				continue
+			}
+			for _, v := range parseCustomViolations(rs[0].Expressions[0].Value) {
+				id := v.id
+				if id == "" {
+					id = policy.Name
+				}
+				category := v.category
+				if category == "" {
+					category = "config"
+				}
+				findings = append(findings, Finding{
+					ID:             fmt.Sprintf("opa/custom/%s", id),
+					Source:         "opa",
+					Severity:       v.severity,
+					Category:       category,
+					Resource:       obj.resource,
+					Namespace:      obj.namespace,
+					Message:        v.msg,
+					Recommendation: v.recommendation,
+				})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// policyObject é um objeto Kubernetes preparado como input de política.
+type policyObject struct {
+	resource  string
+	namespace string
+	input     map[string]interface{}
+}
+
+// fetchPolicyObjects lista os objetos avaliados pelas políticas customizadas.
+func fetchPolicyObjects(ctx context.Context, client kubernetes.Interface, namespace string) ([]policyObject, error) {
+	var objects []policyObject
+
+	bindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar ClusterRoleBindings: %w", err)
+	}
+	for i := range bindings.Items {
+		b := &bindings.Items[i]
+		objects = appendPolicyObject(objects, b, "ClusterRoleBinding", "rbac.authorization.k8s.io/v1", b.Name, "")
+	}
+
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar ClusterRoles: %w", err)
+	}
+	for i := range clusterRoles.Items {
+		r := &clusterRoles.Items[i]
+		objects = appendPolicyObject(objects, r, "ClusterRole", "rbac.authorization.k8s.io/v1", r.Name, "")
+	}
+
+	roles, err := client.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar Roles: %w", err)
+	}
+	for i := range roles.Items {
+		r := &roles.Items[i]
+		objects = appendPolicyObject(objects, r, "Role", "rbac.authorization.k8s.io/v1", r.Name, namespace)
+	}
+
+	netpols, err := client.NetworkingV1().NetworkPolicies(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar NetworkPolicies: %w", err)
+	}
+	for i := range netpols.Items {
+		np := &netpols.Items[i]
+		objects = appendPolicyObject(objects, np, "NetworkPolicy", "networking.k8s.io/v1", np.Name, namespace)
+	}
+
+	return objects, nil
+}
+
+func appendPolicyObject(objects []policyObject, obj runtime.Object, kind, apiVersion, name, namespace string) []policyObject {
+	input, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		slog.Warn("opa: falha ao converter objeto para input", "kind", kind, "name", name, "error", err)
+		return objects
+	}
+	input["kind"] = kind
+	input["apiVersion"] = apiVersion
+	return append(objects, policyObject{
+		resource:  fmt.Sprintf("%s/%s", kind, name),
+		namespace: namespace,
+		input:     input,
+	})
+}
+
+// customViolation estende opaViolation com os campos opcionais aceitos em políticas do usuário.
+type customViolation struct {
+	opaViolation
+	id             string
+	category       string
+	recommendation string
+}
+
+// parseCustomViolations extrai violações, incluindo campos opcionais, do resultado Rego.
+func parseCustomViolations(value interface{}) []customViolation {
+	var items []map[string]interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				items = append(items, m)
+			}
+		}
+	case map[string]interface{}:
+		items = append(items, v)
+	}
+
+	var violations []customViolation
+	for _, m := range items {
+		base := extractViolation(m)
+		if base == nil {
+			continue
+		}
+		cv := customViolation{opaViolation: *base}
+		cv.id, _ = m["id"].(string)
+		cv.category, _ = m["category"].(string)
+		cv.recommendation, _ = m["recommendation"].(string)
+		violations = append(violations, cv)
+	}
+	return violations
+}
+
+// PolicyTestResult é o resultado de um teste Rego (regra test_*).
+type PolicyTestResult struct {
+	Package string
+	Name    string
+	File    string
+	Passed  bool
+	Skipped bool
+	Error   string
+}
+
+// RunPolicyTests executa os testes Rego (regras test_*) encontrados em dir.
+// Arquivos YAML em dir/fixtures ficam disponíveis como data.fixtures.<nome>,
+// onde <nome> é o nome do arquivo sem extensão; arquivos multi-documento viram listas.
+func RunPolicyTests(ctx context.Context, dir string) ([]PolicyTestResult, error) {
+	files, err := regoFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nenhum arquivo .rego encontrado em %s", dir)
+	}
+
+	modules := make(map[string]*ast.Module, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler %s: %w", path, err)
+		}
+		module, err := ast.ParseModule(path, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("política %s inválida: %w", path, err)
+		}
+		modules[path] = module
+	}
+
+	fixtures, err := loadFixtures(filepath.Join(dir, "fixtures"))
+	if err != nil {
+		return nil, err
+	}
+
+	store := inmem.NewFromObject(map[string]interface{}{"fixtures": fixtures})
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir transação do store: %w", err)
+	}
+	defer store.Abort(ctx, txn)
+
+	ch, err := tester.NewRunner().SetStore(store).SetModules(modules).RunTests(ctx, txn)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao compilar testes: %w", err)
+	}
+
+	var results []PolicyTestResult
+	for r := range ch {
+		res := PolicyTestResult{
+			Package: r.Package,
+			Name:    r.Name,
+			Passed:  r.Pass(),
+			Skipped: r.Skip,
+		}
+		if r.Location != nil {
+			res.File = r.Location.File
+		}
+		if r.Error != nil {
+			res.Error = r.Error.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// loadFixtures lê os arquivos YAML de dir e os converte em documentos JSON-compatíveis.
+func loadFixtures(dir string) (map[string]interface{}, error) {
+	fixtures := make(map[string]interface{})
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return fixtures, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar fixtures em %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler fixture %s: %w", path, err)
+		}
+
+		var docs []interface{}
+		for _, raw := range strings.Split(string(data), "\n---") {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			var doc interface{}
+			if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+				return nil, fmt.Errorf("fixture %s inválida: %w", path, err)
+			}
+			if doc != nil {
+				docs = append(docs, doc)
+			}
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ext)
+		if len(docs) == 1 {
+			fixtures[name] = docs[0]
+		} else {
+			fixtures[name] = docs
+		}
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,207 @@
+//go:build k8s
+
+package backends
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const customTeamLabelPolicy = `package org.rbac
+
+import rego.v1
+
+violation contains result if {
+	input.kind == "ClusterRole"
+	not input.metadata.labels.team
+	result := {
+		"id": "rbac_team_label",
+		"msg": sprintf("ClusterRole '%s' sem label team", [input.metadata.name]),
+		"severity": "low",
+		"category": "rbac",
+		"recommendation": "Adicione a label team com o time responsável",
+	}
+}
+`
+
+const customTeamLabelTest = `package org.rbac_test
+
+import rego.v1
+
+import data.org.rbac
+
+test_sem_label_viola if {
+	count(rbac.violation) == 1 with input as data.fixtures.role_sem_label
+}
+
+test_com_label_passa if {
+	count(rbac.violation) == 0 with input as data.fixtures.role_com_label
+}
+`
+
+func writePolicyFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCustomPolicies_IgnoraTestesEDiretoriosInexistentes(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+	writePolicyFile(t, dir, "team_test.rego", customTeamLabelTest)
+
+	policies, err := LoadCustomPolicies(context.Background(), dir, filepath.Join(dir, "nao-existe"))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("esperava 1 política, obteve %d", len(policies))
+	}
+	if policies[0].Package != "data.org.rbac" || policies[0].Name != "team" {
+		t.Errorf("política carregada inesperada: %+v", policies[0])
+	}
+}
+
+func TestLoadCustomPolicies_PoliticaInvalida(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "a_broken.rego", "package x\n\nviolation contains if {")
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+	writePolicyFile(t, dir, "z_broken.rego", "package y\n\nviolation contains if {")
+
+	policies, err := LoadCustomPolicies(context.Background(), dir)
+	if err == nil {
+		t.Fatal("esperava erro para política inválida")
+	}
+	for _, name := range []string{"a_broken.rego", "z_broken.rego"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("erro deveria citar %s: %v", name, err)
+		}
+	}
+	if len(policies) != 1 || policies[0].Name != "team" {
+		t.Errorf("política válida deveria continuar carregada, obteve %+v", policies)
+	}
+}
+
+func TestOPABackend_PoliticaInvalidaNaoDesativaAsDemais(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "broken.rego", "package x\n\nviolation contains if {")
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+
+	o := NewOPABackendWithPolicyDirs(dir)
+	o.loadCustomPolicies(context.Background())
+	if len(o.customPolicies) != 1 {
+		t.Errorf("esperava a política válida ativa, obteve %d", len(o.customPolicies))
+	}
+}
+
+func TestOPABackend_PoliticaCustomizadaGeraFinding(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+
+	client := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "app-reader"}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "app-writer", Labels: map[string]string{"team": "payments"}}},
+	)
+
+	backend := NewOPABackendWithPolicyDirs(dir)
+	findings, err := backend.ScanCluster(context.Background(), client, "default")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	var custom []Finding
+	for _, f := range findings {
+		if f.ID == "opa/custom/rbac_team_label" {
+			custom = append(custom, f)
+		}
+	}
+	if len(custom) != 1 {
+		t.Fatalf("esperava 1 finding customizado, obteve %d (%+v)", len(custom), findings)
+	}
+	f := custom[0]
+	if f.Resource != "ClusterRole/app-reader" || f.Severity != "low" || f.Category != "rbac" {
+		t.Errorf("finding customizado inesperado: %+v", f)
+	}
+	if f.Recommendation == "" {
+		t.Error("recomendação da política deveria ser propagada")
+	}
+}
+
+func TestParseCustomViolations_DefaultsECamposOpcionais(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{"msg": "x"},
+		map[string]interface{}{"msg": "y", "severity": "high", "id": "abc"},
+		map[string]interface{}{"severity": "high"},
+	}
+	violations := parseCustomViolations(value)
+	if len(violations) != 2 {
+		t.Fatalf("esperava 2 violações, obteve %d", len(violations))
+	}
+	if violations[0].severity != "medium" {
+		t.Errorf("severity padrão deveria ser medium, obteve %s", violations[0].severity)
+	}
+	if violations[1].id != "abc" {
+		t.Errorf("id deveria ser propagado, obteve %s", violations[1].id)
+	}
+}
+
+func TestRunPolicyTests_ComFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+	writePolicyFile(t, dir, "team_test.rego", customTeamLabelTest)
+	writePolicyFile(t, filepath.Join(dir, "fixtures"), "role_sem_label.yaml", "kind: ClusterRole\nmetadata:\n  name: a\n")
+	writePolicyFile(t, filepath.Join(dir, "fixtures"), "role_com_label.yaml", "kind: ClusterRole\nmetadata:\n  name: b\n  labels:\n    team: x\n")
+
+	results, err := RunPolicyTests(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("esperava 2 resultados, obteve %d", len(results))
+	}
+	for _, r := range results {
+		if !r.Passed {
+			t.Errorf("teste %s deveria passar: %+v", r.Name, r)
+		}
+	}
+}
+
+func TestRunPolicyTests_FalhaReportada(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, dir, "team.rego", customTeamLabelPolicy)
+	writePolicyFile(t, dir, "team_test.rego", customTeamLabelTest)
+	// Fixture "com label" sem a label faz o segundo teste falhar
+	writePolicyFile(t, filepath.Join(dir, "fixtures"), "role_sem_label.yaml", "kind: ClusterRole\nmetadata:\n  name: a\n")
+	writePolicyFile(t, filepath.Join(dir, "fixtures"), "role_com_label.yaml", "kind: ClusterRole\nmetadata:\n  name: b\n")
+
+	results, err := RunPolicyTests(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("esperava 1 teste falhando, obteve %d", failed)
+	}
+}
+
+func TestRunPolicyTests_DiretorioVazio(t *testing.T) {
+	if _, err := RunPolicyTests(context.Background(), t.TempDir()); err == nil {
+		t.Error("esperava erro para diretório sem políticas")
+	}
+}
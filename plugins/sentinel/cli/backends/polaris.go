@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
 
 	polarisconfig "github.com/fairwindsops/polaris/pkg/config"
 	"github.com/fairwindsops/polaris/pkg/kube"
@@ -92,17 +93,11 @@ func buildDynamicClient() (dynamic.Interface, error) {
 
 // mapPolarisResults converte os resultados da auditoria Polaris para o formato
 // unificado de findings do Sentinel.
-// Agrupa por check — cada check aparece uma vez com a lista de recursos afetados.
+// Gera um finding por check e recurso afetado, em ordem estável: Resource
+// identifica o finding no baseline e no histórico de scans.
 func mapPolarisResults(auditData validator.AuditData, namespace string) []Finding {
-	// Agrupar: checkID+severity → lista de recursos afetados
-	type checkGroup struct {
-		id             string
-		severity       string
-		message        string
-		recommendation string
-		resources      map[string]bool
-	}
-	groups := make(map[string]*checkGroup) // chave: checkID+severity
+	seen := make(map[string]bool) // chave: checkID|severity|namespace|recurso
+	var findings []Finding
 
 	for _, result := range auditData.Results {
 		resource := fmt.Sprintf("%s/%s", result.Kind, result.Name)
@@ -110,7 +105,6 @@ func mapPolarisResults(auditData validator.AuditData, namespace string) []Findin
 		if resultNamespace == "" {
 			resultNamespace = namespace
 		}
-		_ = resultNamespace
 
 		// Coletar checks falhados de todos os níveis (controlador, pod, container)
 		collectChecks := func(checkID string, msg validator.ResultMessage) {
@@ -121,19 +115,21 @@ func mapPolarisResults(auditData validator.AuditData, namespace string) []Findin
 			if sev == "info" {
 				return
 			}
-			key := fmt.Sprintf("%s|%s", checkID, sev)
-			g, ok := groups[key]
-			if !ok {
-				g = &checkGroup{
-					id:             checkID,
-					severity:       sev,
-					message:        msg.Message,
-					recommendation: formatRecommendation(msg.Details),
-					resources:      make(map[string]bool),
-				}
-				groups[key] = g
+			key := fmt.Sprintf("%s|%s|%s|%s", checkID, sev, resultNamespace, resource)
+			if seen[key] {
+				return
 			}
-			g.resources[resource] = true
+			seen[key] = true
+			findings = append(findings, Finding{
+				ID:             fmt.Sprintf("polaris/%s", checkID),
+				Source:         "polaris",
+				Severity:       sev,
+				Category:       "pod-security",
+				Resource:       resource,
+				Namespace:      resultNamespace,
+				Message:        msg.Message,
+				Recommendation: formatRecommendation(msg.Details),
+			})
 		}
 
 		for checkID, msg := range result.Results {
@@ -151,32 +147,19 @@ func mapPolarisResults(auditData validator.AuditData, namespace string) []Findin
 		}
 	}
 
-	// Converter grupos em findings — 1 finding por check
-	var findings []Finding
-	for _, g := range groups {
-		// Listar recursos afetados
-		var resources []string
-		for r := range g.resources {
-			resources = append(resources, r)
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
 		}
-
-		resourceStr := resources[0]
-		if len(resources) > 1 {
-			resourceStr = fmt.Sprintf("%s (+%d)", resources[0], len(resources)-1)
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
 		}
-
-		findings = append(findings, Finding{
-			ID:             fmt.Sprintf("polaris/%s", g.id),
-			Source:         "polaris",
-			Severity:       g.severity,
-			Category:       "pod-security",
-			Resource:       resourceStr,
-			Namespace:      namespace,
-			Message:        g.message,
-			Recommendation: g.recommendation,
-		})
-	}
-
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Severity < b.Severity
+	})
 	return findings
 }
 
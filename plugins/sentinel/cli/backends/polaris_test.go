@@ -3,9 +3,11 @@
 package backends
 
 import (
+	"reflect"
 	"testing"
 
 	polarisconfig "github.com/fairwindsops/polaris/pkg/config"
+	"github.com/fairwindsops/polaris/pkg/validator"
 )
 
 // TestPolarisBackendName verifica que o nome do backend é "polaris".
@@ -75,3 +77,53 @@ func TestFormatRecommendation(t *testing.T) {
 		})
 	}
 }
+
+// TestMapPolarisResults_UmFindingPorRecurso verifica que um check que falha
+// em vários recursos gera um finding por recurso, na mesma ordem a cada
+// execução: Resource compõe a chave do baseline e do histórico.
+func TestMapPolarisResults_UmFindingPorRecurso(t *testing.T) {
+	root := validator.ResultSet{"runAsRootAllowed": {ID: "runAsRootAllowed", Message: "Deve rodar como não-root", Severity: polarisconfig.SeverityDanger}}
+	workload := func(kind, name, namespace string) validator.Result {
+		return validator.Result{
+			Kind: kind, Name: name, Namespace: namespace,
+			PodResult: &validator.PodResult{
+				Results: root,
+				ContainerResults: []validator.ContainerResult{
+					{Name: "app", Results: root},
+					{Name: "sidecar", Results: validator.ResultSet{
+						"cpuLimitsMissing": {ID: "cpuLimitsMissing", Message: "Defina limit de CPU", Severity: polarisconfig.SeverityWarning},
+						"tagNotSpecified":  {ID: "tagNotSpecified", Success: true, Severity: polarisconfig.SeverityDanger},
+					}},
+				},
+			},
+		}
+	}
+	audit := validator.AuditData{Results: []validator.Result{
+		workload("Deployment", "worker", "prod"),
+		workload("Deployment", "api", "prod"),
+		workload("StatefulSet", "db", ""),
+	}}
+
+	findings := mapPolarisResults(audit, "prod")
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.ID+" "+f.Namespace+" "+f.Resource+" "+f.Severity)
+	}
+	expected := []string{
+		"polaris/cpuLimitsMissing prod Deployment/api high",
+		"polaris/cpuLimitsMissing prod Deployment/worker high",
+		"polaris/cpuLimitsMissing prod StatefulSet/db high",
+		"polaris/runAsRootAllowed prod Deployment/api critical",
+		"polaris/runAsRootAllowed prod Deployment/worker critical",
+		"polaris/runAsRootAllowed prod StatefulSet/db critical",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("findings inesperados:\n%v", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		if again := mapPolarisResults(audit, "prod"); !reflect.DeepEqual(again, findings) {
+			t.Fatalf("resultado mudou entre execuções:\n%v\n%v", findings, again)
+		}
+	}
+}
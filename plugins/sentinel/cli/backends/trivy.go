@@ -0,0 +1,429 @@
+//go:build k8s
+
+package backends
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// TrivyDBEnv aponta para um arquivo de vulnerabilidades local (relatórios
+	// Trivy em JSON), usado no lugar do binário trivy.
+	TrivyDBEnv = "YBY_TRIVY_DB"
+	// trivyCacheTTL é a validade dos resultados em cache por digest de imagem.
+	trivyCacheTTL = 24 * time.Hour
+	// maxAffectedRefs limita os containers listados na mensagem de cada finding.
+	maxAffectedRefs = 5
+)
+
+// TrivyBackend implementa SecurityBackend escaneando as imagens dos containers
+// com o binário trivy local ou com um arquivo de vulnerabilidades local.
+type TrivyBackend struct {
+	// Binary é o executável do trivy (padrão: "trivy" no PATH).
+	Binary string
+	// DBPath é o arquivo local de vulnerabilidades. Quando definido, o binário não é usado.
+	DBPath string
+	// CacheDir guarda resultados por digest entre execuções (vazio desativa o cache).
+	CacheDir string
+
+	execCommand func(name string, args ...string) *exec.Cmd
+	lookPath    func(file string) (string, error)
+	now         func() time.Time
+
+	// O arquivo de vulnerabilidades é carregado uma única vez, mesmo com o
+	// backend compartilhado entre os workers do scan multi-namespace.
+	dbOnce sync.Once
+	db     map[string][]TrivyVulnerability
+	dbErr  error
+}
+
+// NewTrivyBackend cria o backend usando o arquivo de $YBY_TRIVY_DB, se definido,
+// ou o binário trivy do PATH.
+func NewTrivyBackend() *TrivyBackend {
+	cacheDir := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		cacheDir = filepath.Join(home, ".yby", "sentinel", "cache", "trivy")
+	}
+	return &TrivyBackend{
+		Binary:   "trivy",
+		DBPath:   os.Getenv(TrivyDBEnv),
+		CacheDir: cacheDir,
+	}
+}
+
+// NewTrivyBackendWithDB cria o backend a partir de um arquivo de vulnerabilidades local.
+func NewTrivyBackendWithDB(dbPath, cacheDir string) *TrivyBackend {
+	return &TrivyBackend{DBPath: dbPath, CacheDir: cacheDir}
+}
+
+// Name retorna o identificador do backend.
+func (t *TrivyBackend) Name() string {
+	return "trivy"
+}
+
+// IsAvailable verifica se há um arquivo de vulnerabilidades ou binário trivy.
+func (t *TrivyBackend) IsAvailable() bool {
+	if t.DBPath != "" {
+		_, err := os.Stat(t.DBPath)
+		return err == nil
+	}
+	if t.Binary == "" {
+		return false
+	}
+	_, err := t.lookPathFunc()(t.Binary)
+	return err == nil
+}
+
+// TrivyVulnerability é o subconjunto usado de uma vulnerabilidade do relatório JSON do trivy.
+type TrivyVulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Severity         string `json:"Severity"`
+	Title            string `json:"Title"`
+	PrimaryURL       string `json:"PrimaryURL"`
+}
+
+// trivyReport é o formato de `trivy image --format json`.
+type trivyReport struct {
+	ArtifactName string `json:"ArtifactName"`
+	Metadata     struct {
+		ImageID     string   `json:"ImageID"`
+		RepoDigests []string `json:"RepoDigests"`
+	} `json:"Metadata"`
+	Results []struct {
+		Target          string               `json:"Target"`
+		Vulnerabilities []TrivyVulnerability `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// vulnerabilities retorna as vulnerabilidades de todos os targets do relatório.
+func (r trivyReport) vulnerabilities() []TrivyVulnerability {
+	var vulns []TrivyVulnerability
+	for _, res := range r.Results {
+		vulns = append(vulns, res.Vulnerabilities...)
+	}
+	return vulns
+}
+
+// containerImage é uma imagem única do namespace, identificada pelo digest
+// quando o kubelet o informa, e os containers que a utilizam.
+type containerImage struct {
+	Ref    string
+	Digest string
+	Users  []string
+}
+
+// key identifica a imagem para deduplicação e cache.
+func (c containerImage) key() string {
+	if c.Digest != "" {
+		return c.Digest
+	}
+	return c.Ref
+}
+
+// ScanCluster coleta as imagens dos pods do namespace, escaneia cada digest
+// uma única vez e converte as CVEs em findings da categoria supply-chain.
+func (t *TrivyBackend) ScanCluster(ctx context.Context, client kubernetes.Interface, namespace string) ([]Finding, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar pods: %w", err)
+	}
+
+	byKey := make(map[string]*containerImage)
+	var order []string
+	for _, pod := range pods.Items {
+		digests := make(map[string]string)
+		for _, st := range append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...) {
+			digests[st.Name] = imageDigest(st.ImageID)
+		}
+		for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			img := containerImage{Ref: c.Image, Digest: digests[c.Name]}
+			existing, ok := byKey[img.key()]
+			if !ok {
+				existing = &img
+				byKey[img.key()] = existing
+				order = append(order, img.key())
+			}
+			existing.Users = append(existing.Users, fmt.Sprintf("%s/%s", pod.Name, c.Name))
+		}
+	}
+
+	var findings []Finding
+	var scanErrs []string
+	for _, key := range order {
+		img := byKey[key]
+		vulns, err := t.scanImage(*img)
+		if err != nil {
+			scanErrs = append(scanErrs, fmt.Sprintf("%s: %v", img.Ref, err))
+			continue
+		}
+		findings = append(findings, mapTrivyVulnerabilities(*img, namespace, vulns)...)
+	}
+
+	// Falha em todas as imagens indica problema no trivy/base, não ausência de CVEs
+	if len(order) > 0 && len(scanErrs) == len(order) {
+		return nil, fmt.Errorf("falha ao escanear imagens: %s", strings.Join(scanErrs, "; "))
+	}
+	for _, e := range scanErrs {
+		fmt.Fprintf(os.Stderr, "aviso: trivy: %s\n", e)
+	}
+	return findings, nil
+}
+
+// imageDigest extrai o digest sha256 do imageID reportado pelo kubelet
+// (ex: "docker-pullable://nginx@sha256:abc" ou "sha256:abc").
+func imageDigest(imageID string) string {
+	if i := strings.Index(imageID, "sha256:"); i >= 0 {
+		return imageID[i:]
+	}
+	return ""
+}
+
+// scanImage retorna as vulnerabilidades da imagem, consultando o cache,
+// o arquivo local de vulnerabilidades ou o binário trivy, nessa ordem.
+func (t *TrivyBackend) scanImage(img containerImage) ([]TrivyVulnerability, error) {
+	if vulns, ok := t.loadCached(img); ok {
+		return vulns, nil
+	}
+
+	var vulns []TrivyVulnerability
+	var err error
+	if t.DBPath != "" {
+		vulns, err = t.lookupDB(img)
+	} else {
+		vulns, err = t.runTrivy(img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.saveCached(img, vulns)
+	return vulns, nil
+}
+
+// runTrivy executa `trivy image` na imagem, fixando o digest quando conhecido.
+func (t *TrivyBackend) runTrivy(img containerImage) ([]TrivyVulnerability, error) {
+	target := img.Ref
+	if img.Digest != "" {
+		target = pinnedRef(img.Ref, img.Digest)
+	}
+
+	out, err := t.execCommandFunc()(t.Binary, "image", "--quiet", "--format", "json", "--scanners", "vuln", target).Output()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao executar trivy: %w", err)
+	}
+	var report trivyReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("falha ao parsear saída do trivy: %w", err)
+	}
+	return report.vulnerabilities(), nil
+}
+
+// pinnedRef troca a tag (ou digest) da referência pelo digest informado,
+// preservando a porta de registries como "host:5000/app:1.0".
+func pinnedRef(ref, digest string) string {
+	repo := strings.SplitN(ref, "@", 2)[0]
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + "@" + digest
+}
+
+// lookupDB busca a imagem no arquivo local de vulnerabilidades pelo digest
+// ou, na falta dele, pela referência. Imagens ausentes não têm vulnerabilidades conhecidas.
+func (t *TrivyBackend) lookupDB(img containerImage) ([]TrivyVulnerability, error) {
+	t.dbOnce.Do(func() {
+		t.db, t.dbErr = LoadTrivyDB(t.DBPath)
+	})
+	if t.dbErr != nil {
+		return nil, t.dbErr
+	}
+	if img.Digest != "" {
+		if vulns, ok := t.db[img.Digest]; ok {
+			return vulns, nil
+		}
+	}
+	return t.db[img.Ref], nil
+}
+
+// LoadTrivyDB lê um arquivo de vulnerabilidades local: um relatório JSON do
+// trivy ou uma lista deles. Cada relatório é indexado pelo nome do artefato,
+// pelo ImageID e pelos digests dos RepoDigests.
+func LoadTrivyDB(path string) (map[string][]TrivyVulnerability, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler base de vulnerabilidades %s: %w", path, err)
+	}
+
+	var reports []trivyReport
+	if err := json.Unmarshal(data, &reports); err != nil {
+		var single trivyReport
+		if err2 := json.Unmarshal(data, &single); err2 != nil {
+			return nil, fmt.Errorf("falha ao parsear base de vulnerabilidades %s: %w", path, err)
+		}
+		reports = []trivyReport{single}
+	}
+
+	db := make(map[string][]TrivyVulnerability)
+	for _, r := range reports {
+		vulns := r.vulnerabilities()
+		keys := []string{r.ArtifactName, imageDigest(r.Metadata.ImageID)}
+		for _, d := range r.Metadata.RepoDigests {
+			keys = append(keys, imageDigest(d))
+		}
+		for _, k := range keys {
+			if k != "" {
+				db[k] = vulns
+			}
+		}
+	}
+	return db, nil
+}
+
+// trivyCacheEntry é o conteúdo em cache do scan de um digest.
+type trivyCacheEntry struct {
+	Digest          string               `json:"digest"`
+	Timestamp       time.Time            `json:"timestamp"`
+	Vulnerabilities []TrivyVulnerability `json:"vulnerabilities"`
+}
+
+// cachePath retorna o arquivo de cache da imagem. Só imagens com digest são
+// cacheadas, já que tags podem apontar para conteúdos diferentes.
+func (t *TrivyBackend) cachePath(img containerImage) string {
+	if t.CacheDir == "" || img.Digest == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(img.Digest))
+	return filepath.Join(t.CacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func (t *TrivyBackend) loadCached(img containerImage) ([]TrivyVulnerability, bool) {
+	path := t.cachePath(img)
+	if path == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry trivyCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Digest != img.Digest {
+		return nil, false
+	}
+	if t.nowFunc()().Sub(entry.Timestamp) > trivyCacheTTL {
+		return nil, false
+	}
+	return entry.Vulnerabilities, true
+}
+
+func (t *TrivyBackend) saveCached(img containerImage, vulns []TrivyVulnerability) {
+	path := t.cachePath(img)
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(t.CacheDir, 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(trivyCacheEntry{Digest: img.Digest, Timestamp: t.nowFunc()(), Vulnerabilities: vulns})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// mapTrivyVulnerabilities converte as CVEs de uma imagem em findings, uma por
+// CVE+pacote, listando os containers afetados na mensagem.
+func mapTrivyVulnerabilities(img containerImage, namespace string, vulns []TrivyVulnerability) []Finding {
+	users := append([]string{}, img.Users...)
+	sort.Strings(users)
+	affected := strings.Join(users, ", ")
+	if len(users) > maxAffectedRefs {
+		affected = fmt.Sprintf("%s e mais %d", strings.Join(users[:maxAffectedRefs], ", "), len(users)-maxAffectedRefs)
+	}
+
+	seen := make(map[string]bool)
+	var findings []Finding
+	for _, v := range vulns {
+		key := v.VulnerabilityID + "|" + v.PkgName
+		if v.VulnerabilityID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		msg := fmt.Sprintf("%s em %s %s (imagem %s, usada por %s)", v.VulnerabilityID, v.PkgName, v.InstalledVersion, img.Ref, affected)
+		if v.Title != "" {
+			msg = fmt.Sprintf("%s: %s", msg, v.Title)
+		}
+		rec := fmt.Sprintf("Sem correção disponível para %s; avalie trocar a imagem base ou mitigar a exposição.", v.PkgName)
+		if v.FixedVersion != "" {
+			rec = fmt.Sprintf("Atualize %s para %s e reconstrua a imagem.", v.PkgName, v.FixedVersion)
+		}
+		if v.PrimaryURL != "" {
+			rec += " Referência: " + v.PrimaryURL
+		}
+
+		findings = append(findings, Finding{
+			ID:             fmt.Sprintf("trivy/%s", v.VulnerabilityID),
+			Source:         "trivy",
+			Severity:       mapTrivySeverity(v.Severity),
+			Category:       "supply-chain",
+			Resource:       fmt.Sprintf("Image/%s", img.Ref),
+			Namespace:      namespace,
+			Message:        msg,
+			Recommendation: rec,
+		})
+	}
+	return findings
+}
+
+// mapTrivySeverity converte a severidade do trivy para o formato unificado.
+func mapTrivySeverity(s string) string {
+	switch strings.ToUpper(s) {
+	case "CRITICAL":
+		return "critical"
+	case "HIGH":
+		return "high"
+	case "MEDIUM":
+		return "medium"
+	case "LOW":
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+func (t *TrivyBackend) execCommandFunc() func(string, ...string) *exec.Cmd {
+	if t.execCommand != nil {
+		return t.execCommand
+	}
+	return exec.Command
+}
+
+func (t *TrivyBackend) lookPathFunc() func(string) (string, error) {
+	if t.lookPath != nil {
+		return t.lookPath
+	}
+	return exec.LookPath
+}
+
+func (t *TrivyBackend) nowFunc() func() time.Time {
+	if t.now != nil {
+		return t.now
+	}
+	return time.Now
+}
@@ -0,0 +1,230 @@
+//go:build k8s
+
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	fixtureTrivyDB = "testdata/trivy-db.json"
+	nginxDigest    = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+)
+
+func trivyPod(name, image, imageID string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: image}},
+		},
+	}
+	if imageID != "" {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", ImageID: imageID}}
+	}
+	return pod
+}
+
+func TestTrivyBackend_Name(t *testing.T) {
+	if NewTrivyBackendWithDB(fixtureTrivyDB, "").Name() != "trivy" {
+		t.Error("nome do backend deveria ser trivy")
+	}
+}
+
+func TestTrivyBackend_IsAvailable(t *testing.T) {
+	if !NewTrivyBackendWithDB(fixtureTrivyDB, "").IsAvailable() {
+		t.Error("backend com base local existente deveria estar disponível")
+	}
+	if NewTrivyBackendWithDB("testdata/inexistente.json", "").IsAvailable() {
+		t.Error("backend com base local inexistente não deveria estar disponível")
+	}
+	b := &TrivyBackend{Binary: "trivy", lookPath: func(string) (string, error) { return "", errors.New("não encontrado") }}
+	if b.IsAvailable() {
+		t.Error("backend sem binário no PATH não deveria estar disponível")
+	}
+}
+
+func TestLoadTrivyDB_IndexaPorNomeEDigest(t *testing.T) {
+	db, err := LoadTrivyDB(fixtureTrivyDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"nginx:1.25", nginxDigest, "sha256:1111111111111111111111111111111111111111111111111111111111111111", "redis:7"} {
+		if _, ok := db[key]; !ok {
+			t.Errorf("base deveria indexar %s", key)
+		}
+	}
+}
+
+func TestTrivyBackend_ScanCluster_BaseLocal(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		// Mesmo digest com tags diferentes: escaneado uma única vez
+		trivyPod("api-1", "nginx:1.25", "docker-pullable://nginx@"+nginxDigest),
+		trivyPod("api-2", "registry.local/nginx:stable", "docker-pullable://nginx@"+nginxDigest),
+		// Sem status: resolvido pela referência
+		trivyPod("cache", "redis:7", ""),
+		trivyPod("limpa", "busybox:1.36", ""),
+	)
+
+	b := NewTrivyBackendWithDB(fixtureTrivyDB, "")
+	findings, err := b.ScanCluster(context.Background(), client, "prod")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// nginx: CVE-2024-0001 (deduplicada entre targets) + CVE-2024-0002; redis: CVE-2024-0003
+	if len(findings) != 3 {
+		t.Fatalf("esperava 3 findings, obteve %d: %+v", len(findings), findings)
+	}
+	critical := findings[0]
+	if critical.ID != "trivy/CVE-2024-0001" || critical.Severity != "critical" || critical.Category != "supply-chain" || critical.Source != "trivy" {
+		t.Errorf("finding inesperado: %+v", critical)
+	}
+	if !strings.Contains(critical.Message, "api-1/app") || !strings.Contains(critical.Message, "api-2/app") {
+		t.Errorf("mensagem deveria listar os containers afetados: %s", critical.Message)
+	}
+	if !strings.Contains(critical.Recommendation, "3.0.13-1") {
+		t.Errorf("recomendação deveria citar a versão corrigida: %s", critical.Recommendation)
+	}
+	if findings[1].Severity != "low" || !strings.Contains(findings[1].Recommendation, "Sem correção") {
+		t.Errorf("finding sem correção inesperado: %+v", findings[1])
+	}
+	if findings[2].ID != "trivy/CVE-2024-0003" || findings[2].Resource != "Image/redis:7" {
+		t.Errorf("finding do redis inesperado: %+v", findings[2])
+	}
+}
+
+// TestTrivyBackend_ScanCluster_Concorrente cobre o backend compartilhado entre
+// os workers do scan multi-namespace (rode com -race).
+func TestTrivyBackend_ScanCluster_Concorrente(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		trivyPod("api-1", "nginx:1.25", "docker-pullable://nginx@"+nginxDigest),
+		trivyPod("cache", "redis:7", ""),
+	)
+	b := NewTrivyBackendWithDB(fixtureTrivyDB, "")
+
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			findings, err := b.ScanCluster(context.Background(), client, "prod")
+			if err != nil {
+				t.Errorf("erro inesperado: %v", err)
+			}
+			counts[i] = len(findings)
+		}(i)
+	}
+	wg.Wait()
+	for i, n := range counts {
+		if n != 3 {
+			t.Errorf("worker %d: esperava 3 findings, obteve %d", i, n)
+		}
+	}
+}
+
+func TestTrivyBackend_ScanCluster_BinarioECache(t *testing.T) {
+	report, err := os.ReadFile(fixtureTrivyDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// O binário devolve um relatório único: usa o do nginx da fixture
+	var reports []json.RawMessage
+	if err := json.Unmarshal(report, &reports); err != nil {
+		t.Fatal(err)
+	}
+	single := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(single, reports[0], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]string
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	b := &TrivyBackend{
+		Binary:   "trivy",
+		CacheDir: t.TempDir(),
+		execCommand: func(name string, args ...string) *exec.Cmd {
+			calls = append(calls, append([]string{name}, args...))
+			return exec.Command("cat", single)
+		},
+		now: func() time.Time { return now },
+	}
+	client := fake.NewSimpleClientset(trivyPod("api", "registry.local:5000/nginx:1.25", "docker-pullable://nginx@"+nginxDigest))
+
+	findings, err := b.ScanCluster(context.Background(), client, "prod")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("esperava 2 findings, obteve %d", len(findings))
+	}
+	if len(calls) != 1 || calls[0][len(calls[0])-1] != "registry.local:5000/nginx@"+nginxDigest {
+		t.Fatalf("esperava trivy com a imagem fixada no digest, obteve %v", calls)
+	}
+
+	// Segunda execução usa o cache
+	if _, err := b.ScanCluster(context.Background(), client, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("esperava resultado do cache, trivy foi chamado %d vezes", len(calls))
+	}
+
+	// Cache expirado volta a chamar o trivy
+	now = now.Add(trivyCacheTTL + time.Minute)
+	if _, err := b.ScanCluster(context.Background(), client, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("esperava nova chamada ao trivy após expirar o cache, obteve %d", len(calls))
+	}
+}
+
+func TestTrivyBackend_ScanCluster_FalhaTotal(t *testing.T) {
+	b := &TrivyBackend{
+		Binary:      "trivy",
+		execCommand: func(string, ...string) *exec.Cmd { return exec.Command("false") },
+	}
+	client := fake.NewSimpleClientset(trivyPod("api", "nginx:1.25", ""))
+
+	if _, err := b.ScanCluster(context.Background(), client, "prod"); err == nil {
+		t.Error("esperava erro quando nenhuma imagem pôde ser escaneada")
+	}
+}
+
+func TestPinnedRef(t *testing.T) {
+	cases := map[string]string{
+		"nginx:1.25":                    "nginx@sha256:x",
+		"nginx":                         "nginx@sha256:x",
+		"registry.local:5000/app:1.0":   "registry.local:5000/app@sha256:x",
+		"registry.local:5000/app":       "registry.local:5000/app@sha256:x",
+		"ghcr.io/org/app@sha256:antigo": "ghcr.io/org/app@sha256:x",
+	}
+	for ref, want := range cases {
+		if got := pinnedRef(ref, "sha256:x"); got != want {
+			t.Errorf("pinnedRef(%s) = %s, esperava %s", ref, got, want)
+		}
+	}
+}
+
+func TestMapTrivySeverity(t *testing.T) {
+	cases := map[string]string{"CRITICAL": "critical", "HIGH": "high", "MEDIUM": "medium", "LOW": "low", "UNKNOWN": "info"}
+	for in, want := range cases {
+		if got := mapTrivySeverity(in); got != want {
+			t.Errorf("mapTrivySeverity(%s) = %s, esperava %s", in, got, want)
+		}
+	}
+}
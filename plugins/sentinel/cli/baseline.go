@@ -0,0 +1,286 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultBaselineFile = ".yby/sentinel-baseline.yaml"
+	baselineDateLayout  = "2006-01-02"
+	// defaultSuppressionDays é a validade padrão de novas suppressões geradas por `baseline update`.
+	defaultSuppressionDays = 90
+)
+
+// Baseline registra findings aceitos que não devem ser reportados novamente.
+type Baseline struct {
+	Version      int             `yaml:"version" json:"version"`
+	Suppressions []BaselineEntry `yaml:"suppressions" json:"suppressions"`
+}
+
+// BaselineEntry identifica um finding aceito por CheckID+Resource+Namespace.
+type BaselineEntry struct {
+	CheckID       string `yaml:"check_id" json:"check_id"`
+	Resource      string `yaml:"resource" json:"resource"`
+	Namespace     string `yaml:"namespace" json:"namespace"`
+	Justification string `yaml:"justification" json:"justification"`
+	Owner         string `yaml:"owner" json:"owner"`
+	// Expires é a data (YYYY-MM-DD) a partir da qual a suppressão deixa de valer.
+	Expires string `yaml:"expires" json:"expires"`
+}
+
+// key retorna a identidade da entrada, compatível com baselineKey.
+func (e BaselineEntry) key() string {
+	return fmt.Sprintf("%s|%s|%s", e.CheckID, e.Namespace, e.Resource)
+}
+
+// expired indica se a suppressão já venceu em relação a now.
+// Datas inválidas são tratadas como expiradas para não suprimir indefinidamente.
+func (e BaselineEntry) expired(now time.Time) bool {
+	if e.Expires == "" {
+		return false
+	}
+	exp, err := time.Parse(baselineDateLayout, e.Expires)
+	if err != nil {
+		return true
+	}
+	// A suppressão vale até o fim do dia informado
+	return !now.Before(exp.AddDate(0, 0, 1))
+}
+
+// baselineKey retorna a identidade de um finding para comparação com o baseline.
+func baselineKey(f checks.SecurityFinding) string {
+	return fmt.Sprintf("%s|%s|%s", f.CheckID, f.Namespace, f.Resource)
+}
+
+// BaselineResult é o resultado da aplicação do baseline sobre os findings de um scan.
+type BaselineResult struct {
+	Active     []checks.SecurityFinding
+	Suppressed int
+	Expired    []BaselineEntry
+}
+
+// loadBaseline lê o arquivo de baseline. Arquivo inexistente retorna baseline vazio.
+func loadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Baseline{Version: 1}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler baseline %s: %w", path, err)
+	}
+
+	var b Baseline
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("falha ao parsear baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// saveBaseline grava o baseline em disco, criando o diretório se necessário.
+func saveBaseline(path string, b *Baseline) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório do baseline: %w", err)
+	}
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar baseline: %w", err)
+	}
+	header := "# Baseline do Sentinel — findings aceitos. Gerado/atualizado por `yby sentinel baseline update`.\n"
+	return os.WriteFile(path, append([]byte(header), data...), 0644)
+}
+
+// applyBaseline remove dos findings aqueles cobertos por suppressões válidas.
+// Findings cobertos por suppressões expiradas continuam ativos e as entradas
+// expiradas são retornadas para que o relatório as destaque.
+func applyBaseline(findings []checks.SecurityFinding, b *Baseline, now time.Time) BaselineResult {
+	valid := make(map[string]bool)
+	var result BaselineResult
+	for _, e := range b.Suppressions {
+		if e.expired(now) {
+			result.Expired = append(result.Expired, e)
+			continue
+		}
+		valid[e.key()] = true
+	}
+
+	for _, f := range findings {
+		if valid[baselineKey(f)] {
+			result.Suppressed++
+			continue
+		}
+		result.Active = append(result.Active, f)
+	}
+	return result
+}
+
+// baselineScope delimita o que um scan avaliou. Entradas do baseline fora do
+// escopo (outros namespaces, outros paths) não são descartadas por `baseline
+// update` só por não terem finding correspondente no scan.
+type baselineScope struct {
+	// namespaces escaneados.
+	namespaces map[string]bool
+	// objects, quando não nil (scan --path), restringe o escopo aos objetos
+	// carregados dos manifests, identificados por "namespace|nome".
+	objects map[string]bool
+}
+
+// newBaselineScope cria o escopo dos namespaces informados.
+func newBaselineScope(namespaces []string) baselineScope {
+	scope := baselineScope{namespaces: make(map[string]bool, len(namespaces))}
+	for _, ns := range namespaces {
+		scope.namespaces[ns] = true
+	}
+	return scope
+}
+
+// contains indica se a entrada foi avaliada pelo scan. Entradas sem namespace
+// (recursos de cluster) e recursos que não são objetos carregados do path
+// ficam fora do escopo.
+func (s baselineScope) contains(e BaselineEntry) bool {
+	if !s.namespaces[e.Namespace] {
+		return false
+	}
+	if s.objects == nil {
+		return true
+	}
+	return s.objects[e.Namespace+"|"+resourceObjectName(e.Resource)]
+}
+
+// resourceObjectName extrai o nome do objeto de Resource nos formatos usados
+// pelos checks e backends: "pod", "pod/container" e "Kind/nome".
+func resourceObjectName(resource string) string {
+	first, rest, found := strings.Cut(resource, "/")
+	if found && first != "" && unicode.IsUpper(rune(first[0])) {
+		name, _, _ := strings.Cut(rest, "/")
+		return name
+	}
+	return first
+}
+
+// updateBaseline gera um novo baseline a partir dos findings atuais. Entradas
+// existentes mantêm justificativa, owner e validade; findings novos recebem os
+// valores padrão informados; entradas dentro do escopo do scan sem finding
+// correspondente (já corrigidas) são descartadas, e as demais são mantidas
+// sem alteração. Retorna o baseline e a contagem de entradas adicionadas e removidas.
+func updateBaseline(existing *Baseline, findings []checks.SecurityFinding, scope baselineScope, defaults BaselineEntry) (*Baseline, int, int) {
+	previous := make(map[string]BaselineEntry, len(existing.Suppressions))
+	for _, e := range existing.Suppressions {
+		previous[e.key()] = e
+	}
+
+	updated := &Baseline{Version: 1}
+	seen := make(map[string]bool)
+	added := 0
+	for _, f := range findings {
+		key := baselineKey(f)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if e, ok := previous[key]; ok {
+			updated.Suppressions = append(updated.Suppressions, e)
+			continue
+		}
+		entry := defaults
+		entry.CheckID = f.CheckID
+		entry.Resource = f.Resource
+		entry.Namespace = f.Namespace
+		updated.Suppressions = append(updated.Suppressions, entry)
+		added++
+	}
+
+	removed := 0
+	for key, e := range previous {
+		if seen[key] {
+			continue
+		}
+		if scope.contains(e) {
+			removed++
+			continue
+		}
+		seen[key] = true
+		updated.Suppressions = append(updated.Suppressions, e)
+	}
+
+	sort.Slice(updated.Suppressions, func(i, j int) bool {
+		return updated.Suppressions[i].key() < updated.Suppressions[j].key()
+	})
+	return updated, added, removed
+}
+
+// parseBaselineDefaults interpreta --owner, --justification e --expires do
+// subcomando `baseline update`, aplicando os valores padrão.
+func parseBaselineDefaults(args []string, now time.Time) (BaselineEntry, error) {
+	entry := BaselineEntry{
+		Justification: "aceito via baseline update",
+		Expires:       now.AddDate(0, 0, defaultSuppressionDays).Format(baselineDateLayout),
+	}
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			break
+		}
+		switch args[i] {
+		case "--owner":
+			entry.Owner = args[i+1]
+			i++
+		case "--justification":
+			entry.Justification = args[i+1]
+			i++
+		case "--expires":
+			if _, err := time.Parse(baselineDateLayout, args[i+1]); err != nil {
+				return entry, fmt.Errorf("data invalida para --expires (use YYYY-MM-DD): %s", args[i+1])
+			}
+			entry.Expires = args[i+1]
+			i++
+		}
+	}
+	return entry, nil
+}
+
+// runBaselineUpdate executa um scan e gera/atualiza o arquivo de baseline.
+func runBaselineUpdate(args []string) {
+	opts := parseScanArgs(args)
+	defaults, err := parseBaselineDefaults(args, time.Now())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	report, _, err := collectScan(context.Background(), opts)
+	if err != nil {
+		fmt.Printf("Falha no scan: %v\n", err)
+		return
+	}
+
+	path := opts.baselinePath()
+	existing, err := loadBaseline(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	updated, added, removed := updateBaseline(existing, report.Findings, report.scope, defaults)
+	if err := saveBaseline(path, updated); err != nil {
+		fmt.Printf("❌ Falha ao salvar baseline: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Baseline atualizado em %s: %d suppressoes (%d novas, %d removidas)\n", path, len(updated.Suppressions), added, removed)
+	if added > 0 {
+		fmt.Println("Revise owner, justificativa e validade das novas entradas antes de commitar.")
+	}
+}
@@ -0,0 +1,223 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+)
+
+var baselineNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func baselineFindings() []checks.SecurityFinding {
+	return []checks.SecurityFinding{
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app"},
+		{CheckID: "POD_RESOURCE_LIMITS", Namespace: "prod", Resource: "api/app"},
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "worker/app"},
+	}
+}
+
+func TestApplyBaseline_SuprimeEntradasValidas(t *testing.T) {
+	b := &Baseline{Suppressions: []BaselineEntry{
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Expires: "2026-12-31"},
+	}}
+
+	result := applyBaseline(baselineFindings(), b, baselineNow)
+	if result.Suppressed != 1 {
+		t.Errorf("esperava 1 finding suprimido, obteve %d", result.Suppressed)
+	}
+	if len(result.Active) != 2 {
+		t.Errorf("esperava 2 findings ativos, obteve %d", len(result.Active))
+	}
+	if len(result.Expired) != 0 {
+		t.Errorf("não esperava suppressões expiradas, obteve %v", result.Expired)
+	}
+}
+
+func TestApplyBaseline_ExpiradaVoltaASerReportada(t *testing.T) {
+	b := &Baseline{Suppressions: []BaselineEntry{
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Expires: "2026-03-09", Owner: "time-a"},
+	}}
+
+	result := applyBaseline(baselineFindings(), b, baselineNow)
+	if result.Suppressed != 0 {
+		t.Errorf("suppressão expirada não deveria suprimir, obteve %d", result.Suppressed)
+	}
+	if len(result.Active) != 3 {
+		t.Errorf("esperava 3 findings ativos, obteve %d", len(result.Active))
+	}
+	if len(result.Expired) != 1 || result.Expired[0].Owner != "time-a" {
+		t.Errorf("esperava 1 suppressão expirada de time-a, obteve %v", result.Expired)
+	}
+}
+
+func TestBaselineEntry_ValidaAteOFimDoDia(t *testing.T) {
+	e := BaselineEntry{Expires: "2026-03-10"}
+	if e.expired(baselineNow) {
+		t.Error("suppressão deveria valer durante todo o dia de expiração")
+	}
+	if !(BaselineEntry{Expires: "10/03/2026"}).expired(baselineNow) {
+		t.Error("data inválida deveria ser tratada como expirada")
+	}
+	if (BaselineEntry{}).expired(baselineNow) {
+		t.Error("entrada sem data não deveria expirar")
+	}
+}
+
+func TestUpdateBaseline_PreservaExistentesERemoveCorrigidos(t *testing.T) {
+	existing := &Baseline{Suppressions: []BaselineEntry{
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Owner: "time-a", Justification: "legado", Expires: "2026-06-01"},
+		{CheckID: "RBAC_WILDCARD", Namespace: "prod", Resource: "Role/old", Owner: "time-b"},
+	}}
+	defaults := BaselineEntry{Owner: "sec", Justification: "novo", Expires: "2026-09-01"}
+
+	updated, added, removed := updateBaseline(existing, baselineFindings(), newBaselineScope([]string{"prod"}), defaults)
+	if added != 2 || removed != 1 {
+		t.Errorf("esperava 2 adicionadas e 1 removida, obteve %d/%d", added, removed)
+	}
+	if len(updated.Suppressions) != 3 {
+		t.Fatalf("esperava 3 suppressões, obteve %d", len(updated.Suppressions))
+	}
+	for _, e := range updated.Suppressions {
+		if e.Resource == "api/app" && e.CheckID == "POD_PRIVILEGED" && e.Owner != "time-a" {
+			t.Errorf("entrada existente deveria manter owner original, obteve %s", e.Owner)
+		}
+		if e.Resource == "worker/app" && e.Owner != "sec" {
+			t.Errorf("entrada nova deveria usar owner padrão, obteve %s", e.Owner)
+		}
+	}
+}
+
+func TestUpdateBaseline_MantemEntradasForaDoEscopo(t *testing.T) {
+	outro := BaselineEntry{CheckID: "POD_ROOT", Namespace: "team-b", Resource: "db/app", Owner: "time-b", Justification: "legado", Expires: "2026-06-01"}
+	cluster := BaselineEntry{CheckID: "RBAC_WILDCARD", Resource: "ClusterRole/admin", Owner: "plataforma"}
+	existing := &Baseline{Suppressions: []BaselineEntry{outro, cluster}}
+
+	updated, added, removed := updateBaseline(existing, baselineFindings(), newBaselineScope([]string{"prod"}), BaselineEntry{})
+	if added != 3 || removed != 0 {
+		t.Errorf("esperava 3 adicionadas e 0 removidas, obteve %d/%d", added, removed)
+	}
+	kept := make(map[string]BaselineEntry)
+	for _, e := range updated.Suppressions {
+		kept[e.key()] = e
+	}
+	if kept[outro.key()] != outro || kept[cluster.key()] != cluster {
+		t.Errorf("entradas fora do escopo deveriam ser mantidas sem alteracao: %+v", updated.Suppressions)
+	}
+}
+
+func TestUpdateBaseline_EscopoDoPath(t *testing.T) {
+	scope := newBaselineScope([]string{"prod"})
+	scope.objects = map[string]bool{"prod|api": true}
+	existing := &Baseline{Suppressions: []BaselineEntry{
+		{CheckID: "POD_ROOT", Namespace: "prod", Resource: "api/app"},         // carregado do path, corrigido
+		{CheckID: "RBAC_WILDCARD", Namespace: "prod", Resource: "Role/api"},   // carregado do path, corrigido
+		{CheckID: "POD_ROOT", Namespace: "prod", Resource: "billing/app"},     // outro path, mesmo namespace
+		{CheckID: "NETPOL_DEFAULT_DENY", Namespace: "prod", Resource: "prod"}, // nível de namespace
+	}}
+
+	updated, _, removed := updateBaseline(existing, nil, scope, BaselineEntry{})
+	if removed != 2 || len(updated.Suppressions) != 2 {
+		t.Fatalf("esperava 2 removidas e 2 mantidas, obteve %d/%+v", removed, updated.Suppressions)
+	}
+	for _, e := range updated.Suppressions {
+		if e.Resource == "api/app" || e.Resource == "Role/api" {
+			t.Errorf("entrada do path sem finding deveria ser removida: %+v", e)
+		}
+	}
+}
+
+func TestCollectScan_EscopoDoPath(t *testing.T) {
+	report, _, err := collectScan(context.Background(), offlineScanOptions(t, ""))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !report.scope.contains(BaselineEntry{Namespace: "apps", Resource: "api/app"}) {
+		t.Error("objeto carregado do path deveria estar no escopo")
+	}
+	if report.scope.contains(BaselineEntry{Namespace: "apps", Resource: "billing/app"}) {
+		t.Error("objeto de outro path nao deveria estar no escopo")
+	}
+	if report.scope.contains(BaselineEntry{Namespace: "team-b", Resource: "api/app"}) {
+		t.Error("namespace nao escaneado nao deveria estar no escopo")
+	}
+}
+
+func TestResourceObjectName(t *testing.T) {
+	tests := map[string]string{
+		"api":                "api",
+		"api/app":            "api",
+		"Deployment/api":     "api",
+		"ClusterRole/admin":  "admin",
+		"Image/nginx:1.27":   "nginx:1.27",
+		"Deployment/api/app": "api",
+	}
+	for resource, expected := range tests {
+		if got := resourceObjectName(resource); got != expected {
+			t.Errorf("resourceObjectName(%q) = %q, esperado %q", resource, got, expected)
+		}
+	}
+}
+
+func TestSaveLoadBaseline_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".yby", "sentinel-baseline.yaml")
+	b := &Baseline{Version: 1, Suppressions: []BaselineEntry{
+		{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Owner: "time-a", Justification: "legado", Expires: "2026-06-01"},
+	}}
+
+	if err := saveBaseline(path, b); err != nil {
+		t.Fatalf("erro ao salvar: %v", err)
+	}
+	loaded, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("erro ao carregar: %v", err)
+	}
+	if len(loaded.Suppressions) != 1 || loaded.Suppressions[0] != b.Suppressions[0] {
+		t.Errorf("baseline carregado difere do salvo: %+v", loaded)
+	}
+}
+
+func TestLoadBaseline_ArquivoInexistente(t *testing.T) {
+	b, err := loadBaseline(filepath.Join(t.TempDir(), "nao-existe.yaml"))
+	if err != nil {
+		t.Fatalf("arquivo inexistente não deveria gerar erro: %v", err)
+	}
+	if len(b.Suppressions) != 0 {
+		t.Error("esperava baseline vazio")
+	}
+}
+
+func TestParseBaselineDefaults(t *testing.T) {
+	entry, err := parseBaselineDefaults([]string{"-n", "prod", "--owner", "sec", "--expires", "2026-05-01"}, baselineNow)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if entry.Owner != "sec" || entry.Expires != "2026-05-01" {
+		t.Errorf("defaults inesperados: %+v", entry)
+	}
+
+	entry, _ = parseBaselineDefaults(nil, baselineNow)
+	if entry.Expires != "2026-06-08" {
+		t.Errorf("esperava validade padrão de 90 dias (2026-06-08), obteve %s", entry.Expires)
+	}
+
+	if _, err := parseBaselineDefaults([]string{"--expires", "amanha"}, baselineNow); err == nil {
+		t.Error("esperava erro para data inválida")
+	}
+}
+
+func TestExportScanMarkdown_SuppressoesExpiradas(t *testing.T) {
+	report := ScanReport{
+		Namespace:           "prod",
+		ExpiredSuppressions: []BaselineEntry{{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Owner: "time-a", Expires: "2026-01-01"}},
+	}
+	content := exportScanMarkdown(report)
+	if !strings.Contains(content, "## Suppressões Expiradas") || !strings.Contains(content, "time-a") {
+		t.Errorf("markdown deveria listar suppressões expiradas, conteúdo: %s", content)
+	}
+}
@@ -0,0 +1,322 @@
+//go:build k8s
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// bundleVersion é a versão do formato do bundle de investigação.
+const bundleVersion = 1
+
+// maxOwnerDepth limita a subida na cadeia de owners (Pod → ReplicaSet → Deployment).
+const maxOwnerDepth = 5
+
+// Arquivos do bundle de investigação (tar.gz).
+const (
+	bundleManifestFile = "manifest.json"
+	bundlePodFile      = "pod.json"
+	bundleOwnersFile   = "owners.json"
+	bundleLogsFile     = "logs.txt"
+	bundleEventsFile   = "events.json"
+	bundleUsageFile    = "usage.json"
+	bundleWorkloadFile = "workload.json"
+	bundlePromptFile   = "prompt.txt"
+	bundleAnalysisFile = "analysis.json"
+)
+
+// InvestigationBundle é a evidência bruta de uma investigação, gravada com
+// `investigate --save-bundle` e reanalisada offline por `analyze --bundle`.
+type InvestigationBundle struct {
+	Manifest BundleManifest
+	// Pod investigado (spec e status). Nil para investigações de workload.
+	Pod *corev1.Pod
+	// Owners é a cadeia de controllers do alvo, do mais próximo ao mais alto.
+	Owners []OwnerObject
+	Logs   string
+	Events []corev1.Event
+	Usage  []kubemetrics.UsageRow
+	// Workload é o bundle correlacionado de Deployment/StatefulSet/Job.
+	Workload *WorkloadBundle
+	// Prompt é o system prompt usado na análise original.
+	Prompt string
+	// Analysis é o resultado original (IA ou regra), quando houve análise.
+	Analysis *AnalysisResult
+}
+
+// BundleManifest identifica o alvo e a origem da evidência.
+type BundleManifest struct {
+	Version     int       `json:"version"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace"`
+	CollectedAt time.Time `json:"collected_at"`
+	PromptName  string    `json:"prompt_name"`
+	// Provider é o provider de IA da análise original (vazio se não houve IA).
+	Provider string `json:"provider,omitempty"`
+}
+
+// OwnerObject é um controller da cadeia de owners, com o objeto completo.
+type OwnerObject struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	Object json.RawMessage `json:"object"`
+}
+
+// newInvestigationBundle cria o bundle com o manifesto preenchido.
+func newInvestigationBundle(kind, name, namespace, promptName string) *InvestigationBundle {
+	return &InvestigationBundle{Manifest: BundleManifest{
+		Version:     bundleVersion,
+		Kind:        kind,
+		Name:        name,
+		Namespace:   namespace,
+		CollectedAt: time.Now().UTC(),
+		PromptName:  promptName,
+	}}
+}
+
+// podAIContext monta o contexto enviado à IA na investigação de pods. O
+// analyze usa a mesma função para que a reanálise receba a mesma entrada.
+func podAIContext(logs string, events []corev1.Event, usage []kubemetrics.UsageRow) string {
+	eventsStr := "[]"
+	if len(events) > 0 {
+		eventsBytes, _ := json.Marshal(events)
+		eventsStr = string(eventsBytes)
+	}
+	metricsStr := "Metricas indisponiveis"
+	if len(usage) > 0 {
+		metricsStr = kubemetrics.FormatUsageTable(usage)
+	}
+	return fmt.Sprintf("LOGS:\n%s\n\nEVENTS (JSON):\n%s\n\nUSAGE VS REQUESTS/LIMITS:\n%s", logs, eventsStr, metricsStr)
+}
+
+// AIContext retorna a entrada da IA reconstruída a partir da evidência.
+func (b *InvestigationBundle) AIContext() (string, error) {
+	if b.Manifest.Kind == "Pod" {
+		return podAIContext(b.Logs, b.Events, b.Usage), nil
+	}
+	if b.Workload == nil {
+		return "", fmt.Errorf("bundle de %s sem %s", b.Manifest.Kind, bundleWorkloadFile)
+	}
+	data, err := json.MarshalIndent(b.Workload, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar workload: %w", err)
+	}
+	return string(data), nil
+}
+
+// collectOwnerChain sobe a cadeia de controllers a partir das owner references
+// (ex: Pod → ReplicaSet → Deployment, Job → CronJob). Owners inacessíveis
+// encerram a cadeia sem erro.
+func collectOwnerChain(ctx context.Context, client kubernetes.Interface, namespace string, refs []metav1.OwnerReference) []OwnerObject {
+	var chain []OwnerObject
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		ref := controllerRef(refs)
+		if ref == nil {
+			break
+		}
+		obj, err := getOwnerObject(ctx, client, namespace, ref.Kind, ref.Name)
+		if err != nil || obj == nil {
+			break
+		}
+		obj.SetManagedFields(nil)
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			break
+		}
+		chain = append(chain, OwnerObject{Kind: ref.Kind, Name: ref.Name, Object: raw})
+		refs = obj.GetOwnerReferences()
+	}
+	return chain
+}
+
+func controllerRef(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+// getOwnerObject busca os controllers conhecidos. Tipos desconhecidos (CRDs)
+// retornam nil.
+func getOwnerObject(ctx context.Context, client kubernetes.Interface, namespace, kind, name string) (metav1.Object, error) {
+	opts := metav1.GetOptions{}
+	switch kind {
+	case "ReplicaSet":
+		return client.AppsV1().ReplicaSets(namespace).Get(ctx, name, opts)
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Get(ctx, name, opts)
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(namespace).Get(ctx, name, opts)
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(namespace).Get(ctx, name, opts)
+	case "Job":
+		return client.BatchV1().Jobs(namespace).Get(ctx, name, opts)
+	case "CronJob":
+		return client.BatchV1().CronJobs(namespace).Get(ctx, name, opts)
+	}
+	return nil, nil
+}
+
+// writeBundle grava o bundle como tar.gz. Partes vazias são omitidas.
+func writeBundle(path string, b *InvestigationBundle) error {
+	files := []struct {
+		name  string
+		value interface{}
+		text  string
+	}{
+		{name: bundleManifestFile, value: b.Manifest},
+		{name: bundlePodFile, value: b.Pod},
+		{name: bundleOwnersFile, value: b.Owners},
+		{name: bundleEventsFile, value: b.Events},
+		{name: bundleUsageFile, value: b.Usage},
+		{name: bundleWorkloadFile, value: b.Workload},
+		{name: bundleAnalysisFile, value: b.Analysis},
+		{name: bundleLogsFile, text: b.Logs},
+		{name: bundlePromptFile, text: b.Prompt},
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		var data []byte
+		if f.value != nil {
+			if isEmptyBundlePart(f.value) {
+				continue
+			}
+			var err error
+			if data, err = json.MarshalIndent(f.value, "", "  "); err != nil {
+				return fmt.Errorf("falha ao serializar %s: %w", f.name, err)
+			}
+		} else {
+			if f.text == "" {
+				continue
+			}
+			data = []byte(f.text)
+		}
+		hdr := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(data)), ModTime: b.Manifest.CollectedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("falha ao escrever bundle: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("falha ao escrever bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("falha ao escrever bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("falha ao escrever bundle: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("falha ao criar diretório do bundle: %w", err)
+		}
+	}
+	// Logs e eventos podem conter dados sensíveis
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func isEmptyBundlePart(v interface{}) bool {
+	switch p := v.(type) {
+	case *corev1.Pod:
+		return p == nil
+	case []OwnerObject:
+		return len(p) == 0
+	case []corev1.Event:
+		return len(p) == 0
+	case []kubemetrics.UsageRow:
+		return len(p) == 0
+	case *WorkloadBundle:
+		return p == nil
+	case *AnalysisResult:
+		return p == nil
+	}
+	return false
+}
+
+// readBundle lê um bundle gravado por writeBundle.
+func readBundle(path string) (*InvestigationBundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s nao e um tar.gz valido: %w", path, err)
+	}
+	defer gz.Close()
+
+	b := &InvestigationBundle{}
+	targets := map[string]interface{}{
+		bundleManifestFile: &b.Manifest,
+		bundlePodFile:      &b.Pod,
+		bundleOwnersFile:   &b.Owners,
+		bundleEventsFile:   &b.Events,
+		bundleUsageFile:    &b.Usage,
+		bundleWorkloadFile: &b.Workload,
+		bundleAnalysisFile: &b.Analysis,
+	}
+	hasManifest := false
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler bundle: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler %s do bundle: %w", hdr.Name, err)
+		}
+		switch hdr.Name {
+		case bundleLogsFile:
+			b.Logs = string(data)
+		case bundlePromptFile:
+			b.Prompt = string(data)
+		default:
+			target, ok := targets[hdr.Name]
+			if !ok {
+				continue // arquivos desconhecidos de versões futuras
+			}
+			if err := json.Unmarshal(data, target); err != nil {
+				return nil, fmt.Errorf("falha ao parsear %s do bundle: %w", hdr.Name, err)
+			}
+			if hdr.Name == bundleManifestFile {
+				hasManifest = true
+			}
+		}
+	}
+
+	if !hasManifest {
+		return nil, fmt.Errorf("bundle %s sem %s", path, bundleManifestFile)
+	}
+	if b.Manifest.Version > bundleVersion {
+		return nil, fmt.Errorf("bundle versao %d nao suportado (maximo: %d)", b.Manifest.Version, bundleVersion)
+	}
+	return b, nil
+}
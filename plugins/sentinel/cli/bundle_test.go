@@ -0,0 +1,171 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func samplePodBundle() *InvestigationBundle {
+	b := newInvestigationBundle("Pod", "api-x1", "prod", "sentinel.investigate")
+	b.Pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-x1", Namespace: "prod"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "api:2"}}}}
+	b.Owners = []OwnerObject{{Kind: "ReplicaSet", Name: "api-7d9f", Object: []byte(`{"metadata":{"name":"api-7d9f"}}`)}}
+	b.Logs = "panic: connection refused\n"
+	b.Events = []corev1.Event{{Reason: "BackOff", Type: corev1.EventTypeWarning, Message: "Back-off restarting failed container"}}
+	b.Usage = []kubemetrics.UsageRow{{Container: "app", CPUUsage: "10m", MemoryUsage: "200Mi", MemoryLimit: "256Mi"}}
+	b.Prompt = "system prompt"
+	patch := "kubectl rollout undo deployment/api"
+	b.Manifest.Provider = "openai"
+	b.Analysis = &AnalysisResult{RootCause: "Banco indisponível", Confidence: 80, KubectlPatch: &patch}
+	return b
+}
+
+func TestWriteReadBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evidencias", "out.tar.gz")
+	original := samplePodBundle()
+
+	if err := writeBundle(path, original); err != nil {
+		t.Fatalf("erro ao gravar bundle: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("bundle deveria ter permissão 0600, tem %v", info.Mode().Perm())
+	}
+
+	b, err := readBundle(path)
+	if err != nil {
+		t.Fatalf("erro ao ler bundle: %v", err)
+	}
+	if b.Manifest.Name != "api-x1" || b.Manifest.Version != bundleVersion || b.Manifest.Provider != "openai" {
+		t.Errorf("manifesto inesperado: %+v", b.Manifest)
+	}
+	if b.Pod == nil || b.Pod.Spec.Containers[0].Image != "api:2" {
+		t.Errorf("pod spec não preservada: %+v", b.Pod)
+	}
+	if len(b.Owners) != 1 || b.Owners[0].Kind != "ReplicaSet" {
+		t.Errorf("cadeia de owners não preservada: %+v", b.Owners)
+	}
+	if b.Logs != original.Logs || b.Prompt != "system prompt" || len(b.Events) != 1 || len(b.Usage) != 1 {
+		t.Errorf("evidência não preservada: %+v", b)
+	}
+	if b.Analysis == nil || *b.Analysis.KubectlPatch != "kubectl rollout undo deployment/api" {
+		t.Errorf("análise original não preservada: %+v", b.Analysis)
+	}
+
+	// A reanálise recebe exatamente a mesma entrada da investigação original
+	got, _ := b.AIContext()
+	want, _ := original.AIContext()
+	if got != want {
+		t.Errorf("contexto reconstruído difere do original:\n%s\n---\n%s", got, want)
+	}
+}
+
+func TestWriteBundle_OmitePartesVazias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.tar.gz")
+	if err := writeBundle(path, newInvestigationBundle("Pod", "api", "prod", "sentinel.investigate")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBundle(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Pod != nil || b.Analysis != nil || b.Logs != "" {
+		t.Errorf("bundle vazio não deveria ter evidência: %+v", b)
+	}
+}
+
+func TestReadBundle_Invalido(t *testing.T) {
+	dir := t.TempDir()
+	notGzip := filepath.Join(dir, "x.tar.gz")
+	if err := os.WriteFile(notGzip, []byte("nao e gzip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBundle(notGzip); err == nil {
+		t.Error("esperava erro para arquivo que não é tar.gz")
+	}
+	if _, err := readBundle(filepath.Join(dir, "inexistente.tar.gz")); err == nil {
+		t.Error("esperava erro para arquivo inexistente")
+	}
+
+	future := samplePodBundle()
+	future.Manifest.Version = bundleVersion + 1
+	path := filepath.Join(dir, "future.tar.gz")
+	if err := writeBundle(path, future); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBundle(path); err == nil || !strings.Contains(err.Error(), "nao suportado") {
+		t.Errorf("esperava erro de versão, obteve %v", err)
+	}
+}
+
+func TestPodAIContext(t *testing.T) {
+	ctx := podAIContext("logs", nil, nil)
+	if !strings.Contains(ctx, "EVENTS (JSON):\n[]") || !strings.Contains(ctx, "Metricas indisponiveis") {
+		t.Errorf("contexto sem eventos/métricas inesperado: %s", ctx)
+	}
+	ctx = podAIContext("logs", samplePodBundle().Events, samplePodBundle().Usage)
+	if !strings.Contains(ctx, "BackOff") || !strings.Contains(ctx, "CONTAINER") {
+		t.Errorf("contexto deveria ter eventos e tabela de uso: %s", ctx)
+	}
+}
+
+func TestAIContext_Workload(t *testing.T) {
+	b := newInvestigationBundle("Deployment", "api", "prod", "sentinel.investigate.workload")
+	if _, err := b.AIContext(); err == nil {
+		t.Error("bundle de workload sem workload.json deveria falhar")
+	}
+	b.Workload = &WorkloadBundle{Kind: "Deployment", Name: "api", Namespace: "prod"}
+	ctx, err := b.AIContext()
+	if err != nil || !strings.Contains(ctx, `"kind": "Deployment"`) {
+		t.Errorf("contexto de workload inesperado: %s (%v)", ctx, err)
+	}
+}
+
+func TestCollectOwnerChain(t *testing.T) {
+	isController := true
+	client := fake.NewSimpleClientset(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "api-7d9f", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "api", Controller: &isController}},
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kube-controller-manager"}},
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name: "backup-123", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", Controller: &isController}},
+		}},
+	)
+
+	chain := collectOwnerChain(context.Background(), client, "prod", []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "api-7d9f", Controller: &isController}})
+	if len(chain) != 2 || chain[0].Kind != "ReplicaSet" || chain[1].Kind != "Deployment" {
+		t.Fatalf("cadeia inesperada: %+v", chain)
+	}
+	if strings.Contains(string(chain[0].Object), "managedFields") {
+		t.Error("managedFields deveria ser removido do objeto")
+	}
+
+	// CronJob inexistente encerra a cadeia sem erro
+	chain = collectOwnerChain(context.Background(), client, "prod", []metav1.OwnerReference{{Kind: "Job", Name: "backup-123", Controller: &isController}})
+	if len(chain) != 1 || chain[0].Name != "backup-123" {
+		t.Errorf("cadeia inesperada: %+v", chain)
+	}
+
+	// Owner de tipo desconhecido (CRD) é ignorado
+	if chain := collectOwnerChain(context.Background(), client, "prod", []metav1.OwnerReference{{Kind: "Rollout", Name: "api", Controller: &isController}}); len(chain) != 0 {
+		t.Errorf("owner desconhecido não deveria entrar na cadeia: %+v", chain)
+	}
+}
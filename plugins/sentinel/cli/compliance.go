@@ -0,0 +1,427 @@
+//go:build k8s
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/profiles"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ControlStatus é o resultado da avaliação de um controle de compliance.
+type ControlStatus string
+
+const (
+	ControlPass       ControlStatus = "pass"
+	ControlFail       ControlStatus = "fail"
+	ControlNotCovered ControlStatus = "not-covered"
+)
+
+// ControlEvidence é um recurso que reprovou um controle.
+type ControlEvidence struct {
+	CheckID   string          `json:"check_id"`
+	Severity  checks.Severity `json:"severity"`
+	Namespace string          `json:"namespace"`
+	Resource  string          `json:"resource"`
+	Message   string          `json:"message"`
+}
+
+// ControlResult registra o status de um controle, os checks que o verificaram
+// e a evidência coletada.
+type ControlResult struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Status    ControlStatus     `json:"status"`
+	CheckIDs  []string          `json:"check_ids,omitempty"`
+	CheckedAt *time.Time        `json:"checked_at,omitempty"`
+	Evidence  []ControlEvidence `json:"evidence,omitempty"`
+	// Notes explica controles não cobertos (sem check automatizado, check indisponível ou com erro).
+	Notes []string `json:"notes,omitempty"`
+}
+
+// ComplianceReport é o relatório de evidências de um perfil de compliance.
+type ComplianceReport struct {
+	Profile     string          `json:"profile"`
+	Description string          `json:"description"`
+	Context     string          `json:"context"`
+	Target      string          `json:"target"`
+	Namespaces  []string        `json:"namespaces"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Summary     map[string]int  `json:"summary"`
+	Controls    []ControlResult `json:"controls"`
+}
+
+// checkExecution guarda o resultado de um check em todos os namespaces avaliados.
+type checkExecution struct {
+	registered bool
+	findings   []checks.SecurityFinding
+	errs       []string
+	ranAt      time.Time
+}
+
+// resolveScanTargets retorna o cliente e os namespaces a avaliar conforme as
+// opções de scan: manifests locais (--path), cluster inteiro (-A) ou um namespace.
+func resolveScanTargets(ctx context.Context, opts scanOptions) (kubernetes.Interface, []string, error) {
+	if opts.Path != "" {
+		client, namespaces, _, err := loadManifestClient(opts.humanOutput(), opts.Path, opts.Namespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("falha ao carregar manifests: %w", err)
+		}
+		return client, opts.Selector.filter(namespaces), nil
+	}
+
+	client, err := getKubeClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao obter cliente Kubernetes: %w", err)
+	}
+	if !opts.AllNamespaces {
+		return client, []string{opts.Namespace}, nil
+	}
+	namespaces, err := listClusterNamespaces(ctx, client, opts.Selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, namespaces, nil
+}
+
+// evaluateCompliance executa os checks do perfil nos namespaces e classifica
+// cada controle: fail se algum check encontrou violações, not-covered se o
+// controle não tem check automatizado ou algum check não pôde rodar, e pass
+// caso contrário.
+func evaluateCompliance(ctx context.Context, client kubernetes.Interface, namespaces []string, profile profiles.ComplianceProfile, now func() time.Time) []ControlResult {
+	executions := make(map[string]*checkExecution)
+	execute := func(id string) *checkExecution {
+		if e, ok := executions[id]; ok {
+			return e
+		}
+		e := &checkExecution{}
+		executions[id] = e
+		found := checks.GetByIDs([]string{id})
+		if len(found) == 0 {
+			return e
+		}
+		e.registered = true
+		seen := make(map[string]bool)
+		for _, ns := range namespaces {
+			fs, err := found[0].Run(ctx, client, ns)
+			if err != nil {
+				e.errs = append(e.errs, fmt.Sprintf("%s: %v", ns, err))
+				continue
+			}
+			// Checks de RBAC retornam recursos de cluster em todo namespace
+			for _, f := range fs {
+				if key := baselineKey(f); !seen[key] {
+					seen[key] = true
+					e.findings = append(e.findings, f)
+				}
+			}
+		}
+		e.ranAt = now().UTC()
+		return e
+	}
+
+	results := make([]ControlResult, 0, len(profile.Controls))
+	for _, control := range profile.Controls {
+		result := ControlResult{ID: control.ID, Title: control.Title, CheckIDs: control.CheckIDs}
+		if len(control.CheckIDs) == 0 {
+			result.Status = ControlNotCovered
+			result.Notes = append(result.Notes, "sem verificação automatizada; requer evidência manual")
+			results = append(results, result)
+			continue
+		}
+
+		incomplete := false
+		for _, id := range control.CheckIDs {
+			e := execute(id)
+			if !e.registered {
+				incomplete = true
+				result.Notes = append(result.Notes, fmt.Sprintf("check %s não disponível", id))
+				continue
+			}
+			for _, err := range e.errs {
+				incomplete = true
+				result.Notes = append(result.Notes, fmt.Sprintf("check %s falhou em %s", id, err))
+			}
+			if result.CheckedAt == nil || e.ranAt.After(*result.CheckedAt) {
+				ranAt := e.ranAt
+				result.CheckedAt = &ranAt
+			}
+			for _, f := range e.findings {
+				result.Evidence = append(result.Evidence, ControlEvidence{
+					CheckID:   f.CheckID,
+					Severity:  findingSeverity(f),
+					Namespace: f.Namespace,
+					Resource:  f.Resource,
+					Message:   f.Message,
+				})
+			}
+		}
+
+		switch {
+		case len(result.Evidence) > 0:
+			result.Status = ControlFail
+		case incomplete:
+			result.Status = ControlNotCovered
+		default:
+			result.Status = ControlPass
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// summarizeControls conta os controles por status.
+func summarizeControls(controls []ControlResult) map[string]int {
+	summary := map[string]int{
+		string(ControlPass):       0,
+		string(ControlFail):       0,
+		string(ControlNotCovered): 0,
+	}
+	for _, c := range controls {
+		summary[string(c.Status)]++
+	}
+	return summary
+}
+
+// runCompliance gera o relatório de evidências de um perfil. Retorna false se
+// o relatório não pôde ser gerado.
+func runCompliance(args []string) bool {
+	opts := parseScanArgs(args)
+	if opts.Profile == "" {
+		fmt.Println("Uso: yby sentinel compliance --profile <perfil> [-n namespace | -A | --path dir] [-o markdown|html|json] [-f arquivo]")
+		return false
+	}
+	profile, ok := profiles.GetProfile(opts.Profile)
+	if !ok {
+		var names []string
+		for _, p := range profiles.ListProfiles() {
+			names = append(names, p.Name)
+		}
+		sort.Strings(names)
+		fmt.Printf("❌ Perfil '%s' nao encontrado (disponiveis: %s)\n", opts.Profile, strings.Join(names, ", "))
+		return false
+	}
+	format := opts.OutputFormat
+	if format == "" || format == "terminal" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "html" && format != "json" {
+		fmt.Printf("❌ Formato invalido para compliance: %s (use markdown, html ou json)\n", format)
+		return false
+	}
+
+	ctx := context.Background()
+	client, namespaces, err := resolveScanTargets(ctx, opts)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	if len(namespaces) == 0 {
+		fmt.Println("❌ Nenhum namespace para avaliar")
+		return false
+	}
+
+	report := ComplianceReport{
+		Profile:     profile.Name,
+		Description: profile.Description,
+		Context:     currentClusterContext(),
+		Target:      opts.Namespace,
+		Namespaces:  namespaces,
+		Controls:    evaluateCompliance(ctx, client, namespaces, profile, time.Now),
+		GeneratedAt: time.Now().UTC(),
+	}
+	if opts.Path != "" {
+		report.Context = offlineHistoryContext
+		report.Target = opts.Path
+	} else if opts.AllNamespaces {
+		report.Target = allNamespacesLabel
+	}
+	report.Summary = summarizeControls(report.Controls)
+
+	var content string
+	switch format {
+	case "html":
+		content, err = exportComplianceHTML(report)
+	case "json":
+		var data []byte
+		data, err = json.MarshalIndent(report, "", "  ")
+		content = string(data)
+	default:
+		content = exportComplianceMarkdown(report)
+	}
+	if err != nil {
+		fmt.Printf("❌ Falha ao gerar relatorio: %v\n", err)
+		return false
+	}
+
+	outputFile := opts.OutputFile
+	if outputFile == "" {
+		outputFile = defaultComplianceReportPath(report, format)
+	}
+	if err := writeReport(content, outputFile); err != nil {
+		fmt.Printf("❌ Falha ao escrever relatorio: %v\n", err)
+		return false
+	}
+
+	fmt.Printf("Compliance %s: %d pass, %d fail, %d not-covered\n", profile.Name,
+		report.Summary[string(ControlPass)], report.Summary[string(ControlFail)], report.Summary[string(ControlNotCovered)])
+	if outputFile != "" {
+		fmt.Printf("Relatorio salvo em %s\n", outputFile)
+	}
+	return true
+}
+
+// defaultComplianceReportPath retorna o caminho padrão do relatório em
+// ~/.yby/reports, ou vazio (stdout) se o diretório não puder ser criado.
+func defaultComplianceReportPath(report ComplianceReport, format string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	reportsDir := filepath.Join(home, ".yby", "reports")
+	if err := os.MkdirAll(reportsDir, 0755); err != nil {
+		return ""
+	}
+	ext := map[string]string{"markdown": "md", "html": "html", "json": "json"}[format]
+	filename := fmt.Sprintf("sentinel-compliance-%s-%s.%s", report.Profile, report.GeneratedAt.Format("2006-01-02"), ext)
+	return filepath.Join(reportsDir, filename)
+}
+
+// controlStatusLabel retorna o rótulo exibido para cada status.
+func controlStatusLabel(s ControlStatus) string {
+	switch s {
+	case ControlPass:
+		return "PASS"
+	case ControlFail:
+		return "FAIL"
+	default:
+		return "NOT COVERED"
+	}
+}
+
+// exportComplianceMarkdown gera o relatório de compliance em Markdown.
+func exportComplianceMarkdown(report ComplianceReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Relatório de Compliance — %s\n\n", report.Profile))
+	sb.WriteString(fmt.Sprintf("%s\n\n", report.Description))
+	sb.WriteString(fmt.Sprintf("**Contexto:** %s  \n", report.Context))
+	sb.WriteString(fmt.Sprintf("**Alvo:** %s  \n", report.Target))
+	sb.WriteString(fmt.Sprintf("**Namespaces avaliados:** %s  \n", strings.Join(report.Namespaces, ", ")))
+	sb.WriteString(fmt.Sprintf("**Gerado em:** %s\n\n", report.GeneratedAt.Format(time.RFC3339)))
+
+	sb.WriteString("## Resumo\n\n")
+	sb.WriteString("| Status | Controles |\n|--------|-----------|\n")
+	sb.WriteString(fmt.Sprintf("| PASS | %d |\n", report.Summary[string(ControlPass)]))
+	sb.WriteString(fmt.Sprintf("| FAIL | %d |\n", report.Summary[string(ControlFail)]))
+	sb.WriteString(fmt.Sprintf("| NOT COVERED | %d |\n\n", report.Summary[string(ControlNotCovered)]))
+
+	sb.WriteString("## Controles\n\n")
+	sb.WriteString("| Controle | Título | Status | Checks | Verificado em |\n|----------|--------|--------|--------|---------------|\n")
+	for _, c := range report.Controls {
+		checkedAt := "-"
+		if c.CheckedAt != nil {
+			checkedAt = c.CheckedAt.Format(time.RFC3339)
+		}
+		checkIDs := "-"
+		if len(c.CheckIDs) > 0 {
+			checkIDs = strings.Join(c.CheckIDs, ", ")
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", c.ID, c.Title, controlStatusLabel(c.Status), checkIDs, checkedAt))
+	}
+
+	sb.WriteString("\n## Evidências\n\n")
+	for _, c := range report.Controls {
+		if len(c.Evidence) == 0 && len(c.Notes) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("### %s — %s (%s)\n\n", c.ID, c.Title, controlStatusLabel(c.Status)))
+		for _, note := range c.Notes {
+			sb.WriteString(fmt.Sprintf("> %s\n\n", note))
+		}
+		if len(c.Evidence) > 0 {
+			sb.WriteString("| Check | Severidade | Namespace | Recurso | Mensagem |\n|-------|------------|-----------|---------|----------|\n")
+			for _, e := range c.Evidence {
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", e.CheckID, e.Severity, e.Namespace, e.Resource, strings.ReplaceAll(e.Message, "|", "\\|")))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+var complianceHTMLTemplate = template.Must(template.New("compliance").Funcs(template.FuncMap{
+	"label": controlStatusLabel,
+	"join":  strings.Join,
+	"stamp": func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Compliance {{.Profile}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #ccc; padding: .4rem .6rem; text-align: left; vertical-align: top; }
+th { background: #f2f2f2; }
+.pass { color: #1a7f37; font-weight: bold; }
+.fail { color: #cf222e; font-weight: bold; }
+.not-covered { color: #9a6700; font-weight: bold; }
+.note { color: #555; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Relatório de Compliance — {{.Profile}}</h1>
+<p>{{.Description}}</p>
+<p>
+<strong>Contexto:</strong> {{.Context}}<br>
+<strong>Alvo:</strong> {{.Target}}<br>
+<strong>Namespaces avaliados:</strong> {{join .Namespaces ", "}}<br>
+<strong>Gerado em:</strong> {{.GeneratedAt.Format "2006-01-02T15:04:05Z07:00"}}
+</p>
+<h2>Resumo</h2>
+<table>
+<tr><th>Status</th><th>Controles</th></tr>
+<tr><td class="pass">PASS</td><td>{{index .Summary "pass"}}</td></tr>
+<tr><td class="fail">FAIL</td><td>{{index .Summary "fail"}}</td></tr>
+<tr><td class="not-covered">NOT COVERED</td><td>{{index .Summary "not-covered"}}</td></tr>
+</table>
+<h2>Controles</h2>
+<table>
+<tr><th>Controle</th><th>Título</th><th>Status</th><th>Checks</th><th>Verificado em</th><th>Evidências</th></tr>
+{{range .Controls}}<tr>
+<td>{{.ID}}</td>
+<td>{{.Title}}</td>
+<td class="{{.Status}}">{{label .Status}}</td>
+<td>{{if .CheckIDs}}{{join .CheckIDs ", "}}{{else}}-{{end}}</td>
+<td>{{stamp .CheckedAt}}</td>
+<td>{{range .Notes}}<div class="note">{{.}}</div>{{end}}{{if .Evidence}}<ul>{{range .Evidence}}<li>[{{.Severity}}] {{.CheckID}} {{.Namespace}}/{{.Resource}}: {{.Message}}</li>{{end}}</ul>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// exportComplianceHTML gera o relatório de compliance em HTML autocontido.
+func exportComplianceHTML(report ComplianceReport) (string, error) {
+	var buf bytes.Buffer
+	if err := complianceHTMLTemplate.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("falha ao renderizar relatório HTML: %w", err)
+	}
+	return buf.String(), nil
+}
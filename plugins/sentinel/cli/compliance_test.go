@@ -0,0 +1,123 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/profiles"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var complianceNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func complianceProfile() profiles.ComplianceProfile {
+	return profiles.ComplianceProfile{
+		Name:        "teste",
+		Description: "perfil de teste",
+		CheckIDs:    []string{"POD_PRIVILEGED", "POD_HOST_PORTS"},
+		Controls: []profiles.Control{
+			{ID: "C1", Title: "Sem containers privilegiados", CheckIDs: []string{"POD_PRIVILEGED"}},
+			{ID: "C2", Title: "Sem host ports", CheckIDs: []string{"POD_HOST_PORTS"}},
+			{ID: "C3", Title: "Check inexistente", CheckIDs: []string{"CHECK_INEXISTENTE"}},
+			{ID: "C4", Title: "Controle manual"},
+		},
+	}
+}
+
+func TestEvaluateCompliance_ClassificaControles(t *testing.T) {
+	priv := true
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:            "app",
+				SecurityContext: &corev1.SecurityContext{Privileged: &priv},
+			}},
+		},
+	})
+
+	results := evaluateCompliance(context.Background(), client, []string{"prod"}, complianceProfile(), func() time.Time { return complianceNow })
+	if len(results) != 4 {
+		t.Fatalf("esperava 4 controles, obteve %d", len(results))
+	}
+
+	want := []ControlStatus{ControlFail, ControlPass, ControlNotCovered, ControlNotCovered}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("controle %s: esperava %s, obteve %s", r.ID, want[i], r.Status)
+		}
+	}
+
+	fail := results[0]
+	if len(fail.Evidence) == 0 || fail.Evidence[0].Namespace != "prod" || fail.Evidence[0].CheckID != "POD_PRIVILEGED" {
+		t.Errorf("evidência inesperada: %+v", fail.Evidence)
+	}
+	if fail.CheckedAt == nil || !fail.CheckedAt.Equal(complianceNow) {
+		t.Errorf("esperava timestamp da verificação, obteve %v", fail.CheckedAt)
+	}
+	if results[2].CheckedAt != nil || len(results[2].Notes) == 0 {
+		t.Errorf("check inexistente deveria gerar nota sem timestamp: %+v", results[2])
+	}
+
+	summary := summarizeControls(results)
+	if summary["pass"] != 1 || summary["fail"] != 1 || summary["not-covered"] != 2 {
+		t.Errorf("resumo inesperado: %v", summary)
+	}
+}
+
+func complianceReport() ComplianceReport {
+	checkedAt := complianceNow
+	controls := []ControlResult{
+		{ID: "C1", Title: "Sem containers privilegiados", Status: ControlFail, CheckIDs: []string{"POD_PRIVILEGED"}, CheckedAt: &checkedAt,
+			Evidence: []ControlEvidence{{CheckID: "POD_PRIVILEGED", Severity: "critical", Namespace: "prod", Resource: "api/app", Message: "container <privilegiado>"}}},
+		{ID: "C4", Title: "Controle manual", Status: ControlNotCovered, Notes: []string{"sem verificação automatizada; requer evidência manual"}},
+	}
+	return ComplianceReport{
+		Profile:     "soc2",
+		Description: "SOC2",
+		Context:     "kind-dev",
+		Target:      "prod",
+		Namespaces:  []string{"prod"},
+		GeneratedAt: complianceNow,
+		Summary:     summarizeControls(controls),
+		Controls:    controls,
+	}
+}
+
+func TestExportComplianceMarkdown(t *testing.T) {
+	out := exportComplianceMarkdown(complianceReport())
+	for _, want := range []string{
+		"# Relatório de Compliance — soc2",
+		"| C1 | Sem containers privilegiados | FAIL | POD_PRIVILEGED | 2026-03-10T12:00:00Z |",
+		"| C4 | Controle manual | NOT COVERED | - | - |",
+		"| POD_PRIVILEGED | critical | prod | api/app |",
+		"> sem verificação automatizada",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown sem %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportComplianceHTML(t *testing.T) {
+	out, err := exportComplianceHTML(complianceReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<td class="fail">FAIL</td>`,
+		`<td class="not-covered">NOT COVERED</td>`,
+		"prod/api/app: container &lt;privilegiado&gt;",
+		"2026-03-10T12:00:00Z",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html sem %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/diagnosis"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// loadDiagnosisEngine cria o motor de regras com as regras nativas e as
+// customizadas do arquivo. Regras customizadas inválidas são reportadas e
+// ignoradas, mantendo as nativas.
+func loadDiagnosisEngine(rulesFile string) *diagnosis.Engine {
+	custom, err := diagnosis.LoadRules(rulesFile)
+	if err != nil {
+		fmt.Printf("⚠️  Regras customizadas ignoradas: %v\n", err)
+	}
+	return diagnosis.NewEngine(custom...)
+}
+
+// diagnosePod aplica as regras determinísticas ao pod. Retorna o resultado e
+// true quando uma regra reconhece a falha, dispensando a IA.
+func diagnosePod(ctx context.Context, client kubernetes.Interface, engine *diagnosis.Engine, pod *corev1.Pod, events *corev1.EventList, logs string) (AnalysisResult, bool) {
+	in := collectDiagnosisInput(ctx, client, pod, events, logs)
+	d, ok := engine.Diagnose(in)
+	if !ok {
+		return AnalysisResult{}, false
+	}
+	result := AnalysisResult{
+		RootCause:       d.RootCause,
+		TechnicalDetail: d.TechnicalDetail,
+		Confidence:      d.Confidence,
+		SuggestedFix:    d.SuggestedFix,
+		RuleID:          d.RuleID,
+	}
+	if d.KubectlPatch != "" {
+		patch := d.KubectlPatch
+		result.KubectlPatch = &patch
+	}
+	return result, true
+}
+
+// collectDiagnosisInput reúne o contexto que as regras inspecionam: node,
+// PVCs e Secrets/ConfigMaps referenciados. Falhas de leitura apenas deixam o
+// dado de fora, sem impedir as demais regras.
+func collectDiagnosisInput(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, events *corev1.EventList, logs string) *diagnosis.Input {
+	in := &diagnosis.Input{Pod: pod, Logs: logs, PVCs: make(map[string]*corev1.PersistentVolumeClaim)}
+	if events != nil {
+		in.Events = events.Items
+	}
+
+	if pod.Spec.NodeName != "" {
+		if node, err := client.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{}); err == nil {
+			in.Node = node
+		}
+	}
+
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil {
+			continue
+		}
+		claim := v.PersistentVolumeClaim.ClaimName
+		pvc, err := client.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, claim, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			in.PVCs[claim] = pvc
+		case apierrors.IsNotFound(err):
+			in.PVCs[claim] = nil
+		}
+	}
+
+	secrets, configMaps := requiredRefs(pod)
+	for _, name := range secrets {
+		if _, err := client.CoreV1().Secrets(pod.Namespace).Get(ctx, name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			in.MissingRefs = append(in.MissingRefs, "Secret/"+name)
+		}
+	}
+	for _, name := range configMaps {
+		if _, err := client.CoreV1().ConfigMaps(pod.Namespace).Get(ctx, name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			in.MissingRefs = append(in.MissingRefs, "ConfigMap/"+name)
+		}
+	}
+	return in
+}
+
+// requiredRefs retorna os Secrets e ConfigMaps obrigatórios (não optional)
+// referenciados pelo pod em volumes, env e envFrom.
+func requiredRefs(pod *corev1.Pod) (secrets, configMaps []string) {
+	secretSet := make(map[string]bool)
+	configMapSet := make(map[string]bool)
+	required := func(optional *bool) bool { return optional == nil || !*optional }
+
+	for _, v := range pod.Spec.Volumes {
+		if v.Secret != nil && required(v.Secret.Optional) {
+			secretSet[v.Secret.SecretName] = true
+		}
+		if v.ConfigMap != nil && required(v.ConfigMap.Optional) {
+			configMapSet[v.ConfigMap.Name] = true
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.Secret != nil && required(s.Secret.Optional) {
+					secretSet[s.Secret.Name] = true
+				}
+				if s.ConfigMap != nil && required(s.ConfigMap.Optional) {
+					configMapSet[s.ConfigMap.Name] = true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil && required(ref.Optional) {
+				secretSet[ref.Name] = true
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && required(ref.Optional) {
+				configMapSet[ref.Name] = true
+			}
+		}
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil && required(from.SecretRef.Optional) {
+				secretSet[from.SecretRef.Name] = true
+			}
+			if from.ConfigMapRef != nil && required(from.ConfigMapRef.Optional) {
+				configMapSet[from.ConfigMapRef.Name] = true
+			}
+		}
+	}
+	return sortedKeys(secretSet), sortedKeys(configMapSet)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podWithRefs() *corev1.Pod {
+	optional := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+				{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "tls"}}},
+				{Name: "extra", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Optional: &optional}}},
+			},
+			Containers: []corev1.Container{{
+				Name:    "app",
+				Env:     []corev1.EnvVar{{Name: "DB_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"}, Key: "password"}}}},
+				EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}}}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func TestRequiredRefs(t *testing.T) {
+	secrets, configMaps := requiredRefs(podWithRefs())
+	if !reflect.DeepEqual(secrets, []string{"db-credentials", "tls"}) {
+		t.Errorf("secrets inesperados: %v", secrets)
+	}
+	// ConfigMap optional não é obrigatório
+	if !reflect.DeepEqual(configMaps, []string{"app-config"}) {
+		t.Errorf("configmaps inesperados: %v", configMaps)
+	}
+}
+
+func TestCollectDiagnosisInput(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "prod"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "prod"}},
+	)
+
+	in := collectDiagnosisInput(context.Background(), client, podWithRefs(), nil, "logs")
+	if in.Node == nil || in.Node.Name != "node-1" {
+		t.Errorf("node deveria ser coletado: %+v", in.Node)
+	}
+	if pvc, known := in.PVCs["data"]; !known || pvc != nil {
+		t.Errorf("PVC inexistente deveria ser registrado como nil: %v", in.PVCs)
+	}
+	if !reflect.DeepEqual(in.MissingRefs, []string{"Secret/db-credentials"}) {
+		t.Errorf("referências ausentes inesperadas: %v", in.MissingRefs)
+	}
+}
+
+func TestDiagnosePod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	pod := podWithRefs()
+	pod.Spec.Volumes = nil
+	pod.Spec.Containers[0].EnvFrom = nil
+
+	result, ok := diagnosePod(context.Background(), client, loadDiagnosisEngine(filepath.Join(t.TempDir(), "rules.yaml")), pod, nil, "")
+	if !ok {
+		t.Fatal("esperava diagnóstico determinístico")
+	}
+	if result.RuleID != "MISSING_REF" || !strings.Contains(result.RootCause, "Secret/db-credentials") {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+	if result.KubectlPatch != nil {
+		t.Errorf("regra sem comando não deveria sugerir patch: %s", *result.KubectlPatch)
+	}
+
+	md := exportMarkdown(result, pod.Name, pod.Namespace)
+	if !strings.Contains(md, "regra determinística `MISSING_REF`") {
+		t.Error("relatório deveria indicar a regra que gerou o diagnóstico")
+	}
+}
+
+func TestDiagnosePod_RegraCustomizada(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `
+rules:
+  - id: FEATURE_FLAG
+    match: {logs: 'FLAGS_URL not set'}
+    root_cause: Variável FLAGS_URL ausente em {{.Pod}}
+    kubectl_patch: kubectl get configmap flags -n {{.Namespace}}
+`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}}
+
+	result, ok := diagnosePod(context.Background(), fake.NewSimpleClientset(), loadDiagnosisEngine(rulesFile), pod, nil, "fatal: FLAGS_URL not set")
+	if !ok || result.RuleID != "FEATURE_FLAG" || result.RootCause != "Variável FLAGS_URL ausente em api" {
+		t.Fatalf("esperava regra customizada, obteve %+v", result)
+	}
+	if result.KubectlPatch == nil {
+		t.Error("esperava comando sugerido")
+	}
+
+	if _, ok := diagnosePod(context.Background(), fake.NewSimpleClientset(), loadDiagnosisEngine(rulesFile), pod, nil, "panic: runtime error"); ok {
+		t.Error("sem assinatura conhecida o diagnóstico deveria ficar com a IA")
+	}
+}
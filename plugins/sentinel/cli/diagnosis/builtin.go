@@ -0,0 +1,338 @@
+//go:build k8s
+
+package diagnosis
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// builtinRule é uma regra nativa implementada em Go.
+type builtinRule struct {
+	id    string
+	match func(in *Input) *Diagnosis
+}
+
+func (r builtinRule) ID() string                 { return r.id }
+func (r builtinRule) Match(in *Input) *Diagnosis { return r.match(in) }
+
+// builtinRules em ordem de avaliação: causas mais específicas primeiro (uma
+// probe de liveness falhando também gera CrashLoopBackOff com exit 137, por
+// exemplo, então PROBE_FAILURE vem antes de CRASHLOOP_EXIT_CODE).
+var builtinRules = []Rule{
+	builtinRule{"MISSING_REF", matchMissingRef},
+	builtinRule{"PVC_UNBOUND", matchPVCUnbound},
+	builtinRule{"IMAGE_PULL_AUTH", matchImagePullAuth},
+	builtinRule{"OOMKILLED", matchOOMKilled},
+	builtinRule{"NODE_PRESSURE", matchNodePressure},
+	builtinRule{"PROBE_FAILURE", matchProbeFailure},
+	builtinRule{"CRASHLOOP_EXIT_CODE", matchCrashLoopExitCode},
+}
+
+var (
+	missingRefPattern = regexp.MustCompile(`(secret|configmap) "([^"]+)" not found`)
+	pullAuthPattern   = regexp.MustCompile(`(?i)(unauthorized|authentication required|access denied|no basic auth credentials|insufficient_scope|403 forbidden|401)`)
+)
+
+// containerStatuses retorna os status de init containers e containers do pod.
+func containerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
+// containerSpec retorna a spec do container (ou init container) pelo nome.
+func containerSpec(pod *corev1.Pod, name string) *corev1.Container {
+	for _, list := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range list {
+			if list[i].Name == name {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ownerWorkload retorna o workload dono do pod no formato do kubectl
+// (deployment/api, statefulset/db), ou vazio quando não identificável.
+func ownerWorkload(pod *corev1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		switch ref.Kind {
+		case "ReplicaSet":
+			if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+				return "deployment/" + strings.TrimSuffix(ref.Name, "-"+hash)
+			}
+		case "StatefulSet", "DaemonSet", "Job":
+			return strings.ToLower(ref.Kind) + "/" + ref.Name
+		}
+	}
+	return ""
+}
+
+func matchMissingRef(in *Input) *Diagnosis {
+	refs := make(map[string]bool)
+	for _, r := range in.MissingRefs {
+		refs[r] = true
+	}
+	// O kubelet também reporta a referência ausente no status e nos eventos
+	var texts []string
+	for _, cs := range containerStatuses(in.Pod) {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason == "CreateContainerConfigError" {
+			texts = append(texts, cs.State.Waiting.Message)
+		}
+	}
+	for _, e := range in.Events {
+		if e.Reason == "FailedMount" || e.Reason == "Failed" {
+			texts = append(texts, e.Message)
+		}
+	}
+	for _, text := range texts {
+		for _, m := range missingRefPattern.FindAllStringSubmatch(text, -1) {
+			kind := "Secret"
+			if m[1] == "configmap" {
+				kind = "ConfigMap"
+			}
+			refs[kind+"/"+m[2]] = true
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for r := range refs {
+		names = append(names, r)
+	}
+	sort.Strings(names)
+	list := strings.Join(names, ", ")
+	return &Diagnosis{
+		RootCause:       fmt.Sprintf("Referência a recurso inexistente: %s", list),
+		TechnicalDetail: fmt.Sprintf("O pod '%s' referencia %s, ausente(s) no namespace '%s'. Sem o recurso o kubelet não monta o volume nem as variáveis de ambiente e o container não inicia (CreateContainerConfigError/FailedMount).", in.Pod.Name, list, in.Pod.Namespace),
+		Confidence:      95,
+		SuggestedFix:    "Crie o recurso no namespace do pod ou corrija o nome referenciado no manifesto. Se a referência não for obrigatória, marque-a com optional: true.",
+	}
+}
+
+func matchPVCUnbound(in *Input) *Diagnosis {
+	var problems []string
+	for _, v := range in.Pod.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil {
+			continue
+		}
+		claim := v.PersistentVolumeClaim.ClaimName
+		pvc, known := in.PVCs[claim]
+		switch {
+		case !known:
+			continue
+		case pvc == nil:
+			problems = append(problems, fmt.Sprintf("PVC '%s' não existe", claim))
+		case pvc.Status.Phase != corev1.ClaimBound:
+			sc := "padrão"
+			if pvc.Spec.StorageClassName != nil {
+				sc = *pvc.Spec.StorageClassName
+			}
+			problems = append(problems, fmt.Sprintf("PVC '%s' está %s (StorageClass %s)", claim, pvc.Status.Phase, sc))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &Diagnosis{
+		RootCause:       "PersistentVolumeClaim não vinculado a um volume",
+		TechnicalDetail: fmt.Sprintf("O pod '%s' depende de volumes que não estão disponíveis: %s. O scheduler não agenda o pod enquanto o claim não estiver Bound.", in.Pod.Name, strings.Join(problems, "; ")),
+		Confidence:      90,
+		SuggestedFix:    "Verifique se a StorageClass existe e tem provisioner funcionando (ou se há PV estático compatível em tamanho, accessMode e zona) e crie o PVC ausente. Os eventos do PVC (kubectl describe pvc) indicam o motivo do provisionamento falhar.",
+	}
+}
+
+func matchImagePullAuth(in *Input) *Diagnosis {
+	for _, cs := range containerStatuses(in.Pod) {
+		w := cs.State.Waiting
+		if w == nil || (w.Reason != "ImagePullBackOff" && w.Reason != "ErrImagePull") {
+			continue
+		}
+		texts := []string{w.Message}
+		for _, e := range in.Events {
+			if e.Reason == "Failed" && strings.Contains(e.Message, cs.Image) {
+				texts = append(texts, e.Message)
+			}
+		}
+		var evidence string
+		for _, text := range texts {
+			if pullAuthPattern.MatchString(text) {
+				evidence = text
+				break
+			}
+		}
+		if evidence == "" {
+			continue
+		}
+
+		sa := in.Pod.Spec.ServiceAccountName
+		if sa == "" {
+			sa = "default"
+		}
+		secrets := "nenhum imagePullSecret configurado"
+		if len(in.Pod.Spec.ImagePullSecrets) > 0 {
+			var names []string
+			for _, s := range in.Pod.Spec.ImagePullSecrets {
+				names = append(names, s.Name)
+			}
+			secrets = "imagePullSecrets: " + strings.Join(names, ", ")
+		}
+		return &Diagnosis{
+			RootCause:       fmt.Sprintf("Falha de autenticação ao baixar a imagem '%s'", cs.Image),
+			TechnicalDetail: fmt.Sprintf("O registry recusou o pull da imagem do container '%s' (%s): %s", cs.Name, secrets, evidence),
+			Confidence:      90,
+			SuggestedFix:    "Crie um Secret do tipo docker-registry com credenciais válidas para o registry e referencie-o em imagePullSecrets do pod ou da ServiceAccount. Se o Secret já existe, verifique se as credenciais não expiraram e se têm permissão de leitura no repositório.",
+			KubectlPatch:    fmt.Sprintf(`kubectl patch serviceaccount %s -n %s -p '{"imagePullSecrets":[{"name":"<secret-do-registry>"}]}'`, sa, in.Pod.Namespace),
+		}
+	}
+	return nil
+}
+
+func matchOOMKilled(in *Input) *Diagnosis {
+	for _, cs := range containerStatuses(in.Pod) {
+		term := cs.State.Terminated
+		if term == nil || term.Reason != "OOMKilled" {
+			term = cs.LastTerminationState.Terminated
+		}
+		if term == nil || term.Reason != "OOMKilled" {
+			continue
+		}
+
+		d := &Diagnosis{
+			RootCause:  fmt.Sprintf("Container '%s' encerrado por falta de memória (OOMKilled)", cs.Name),
+			Confidence: 95,
+		}
+		var mem resource.Quantity
+		hasLimit := false
+		if spec := containerSpec(in.Pod, cs.Name); spec != nil {
+			mem, hasLimit = spec.Resources.Limits[corev1.ResourceMemory]
+		}
+		if !hasLimit {
+			d.TechnicalDetail = fmt.Sprintf("O kernel matou o processo do container '%s' (exit %d, %d restarts). O container não tem limit de memória, então o OOM veio da pressão de memória do node.", cs.Name, term.ExitCode, cs.RestartCount)
+			d.SuggestedFix = "Defina requests e limits de memória condizentes com o consumo real do container e verifique se há vazamento de memória na aplicação."
+			return d
+		}
+
+		d.TechnicalDetail = fmt.Sprintf("O processo do container '%s' ultrapassou o limit de memória de %dMi e foi morto pelo kernel (exit %d, %d restarts).", cs.Name, mem.Value()/(1024*1024), term.ExitCode, cs.RestartCount)
+		d.SuggestedFix = "Aumente o limit de memória do container ou reduza o consumo da aplicação (heap da JVM/runtime, caches, vazamentos). Compare o uso real com o limit antes de ajustar."
+		if workload := ownerWorkload(in.Pod); workload != "" {
+			d.KubectlPatch = fmt.Sprintf("kubectl set resources %s -n %s -c %s --limits=memory=%dMi", workload, in.Pod.Namespace, cs.Name, 2*mem.Value()/(1024*1024))
+		}
+		return d
+	}
+	return nil
+}
+
+func matchNodePressure(in *Input) *Diagnosis {
+	if in.Pod.Status.Reason == "Evicted" {
+		return &Diagnosis{
+			RootCause:       "Pod despejado (Evicted) por pressão de recursos no node",
+			TechnicalDetail: fmt.Sprintf("O kubelet do node '%s' despejou o pod: %s", in.Pod.Spec.NodeName, in.Pod.Status.Message),
+			Confidence:      90,
+			SuggestedFix:    "Defina requests realistas para que o scheduler não sobrecarregue o node, libere disco (imagens e logs) ou aumente a capacidade do node pool. Pods Evicted podem ser removidos com kubectl delete pod.",
+			KubectlPatch:    fmt.Sprintf("kubectl delete pod %s -n %s", in.Pod.Name, in.Pod.Namespace),
+		}
+	}
+	if in.Node == nil {
+		return nil
+	}
+	var pressures []string
+	for _, c := range in.Node.Status.Conditions {
+		switch c.Type {
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+			if c.Status == corev1.ConditionTrue {
+				pressures = append(pressures, string(c.Type))
+			}
+		}
+	}
+	if len(pressures) == 0 {
+		return nil
+	}
+	return &Diagnosis{
+		RootCause:       fmt.Sprintf("Node '%s' sob pressão de recursos (%s)", in.Node.Name, strings.Join(pressures, ", ")),
+		TechnicalDetail: fmt.Sprintf("O node onde o pod '%s' roda reporta %s. O kubelet pode despejar pods e recusar novos containers até a pressão ser aliviada.", in.Pod.Name, strings.Join(pressures, ", ")),
+		Confidence:      85,
+		SuggestedFix:    "Identifique os pods que mais consomem o recurso no node (kubectl top pods --field-selector spec.nodeName=<node>), libere disco ou processos e ajuste requests/limits. Se recorrente, aumente a capacidade do node pool.",
+	}
+}
+
+func matchProbeFailure(in *Input) *Diagnosis {
+	for _, probe := range []string{"Startup", "Liveness", "Readiness"} {
+		prefix := probe + " probe failed"
+		for _, e := range in.Events {
+			if e.Reason != "Unhealthy" || !strings.HasPrefix(e.Message, prefix) {
+				continue
+			}
+			effect := "o container é reiniciado pelo kubelet a cada falha consecutiva"
+			if probe == "Readiness" {
+				effect = "o pod fica fora dos endpoints do Service e não recebe tráfego"
+			}
+			return &Diagnosis{
+				RootCause:       fmt.Sprintf("%s probe falhando", probe),
+				TechnicalDetail: fmt.Sprintf("%s (%d ocorrências); %s.", e.Message, max(e.Count, 1), effect),
+				Confidence:      85,
+				SuggestedFix:    "Confirme que o path, a porta e o esquema da probe correspondem ao que a aplicação expõe. Se a aplicação demora a subir, use uma startupProbe ou aumente initialDelaySeconds/failureThreshold; se a probe depende de serviços externos, torne-a local ao processo.",
+			}
+		}
+	}
+	return nil
+}
+
+// exitCodeCauses mapeia exit codes com causa conhecida. Exit codes genéricos
+// (1, 2) dependem dos logs e ficam com a IA.
+var exitCodeCauses = map[int32]struct{ cause, fix string }{
+	0: {
+		"O processo principal terminou com sucesso, mas o restartPolicy Always reinicia o container",
+		"O comando do container deve permanecer em execução (servidor em foreground). Para tarefas que terminam, use um Job.",
+	},
+	126: {
+		"O comando do container existe mas não é executável (permissão negada)",
+		"Ajuste a permissão de execução do entrypoint na imagem (chmod +x) ou verifique se o securityContext/readOnlyRootFilesystem impede a execução.",
+	},
+	127: {
+		"O comando ou entrypoint do container não foi encontrado na imagem",
+		"Verifique command/args do manifesto e o ENTRYPOINT da imagem; confira se o binário existe no PATH da imagem (imagens distroless não têm shell).",
+	},
+	137: {
+		"O container foi morto com SIGKILL sem OOMKilled (timeout de encerramento ou kill externo)",
+		"Verifique se o processo trata SIGTERM dentro do terminationGracePeriodSeconds e se há preStop hooks ou agentes externos encerrando o container.",
+	},
+	139: {
+		"O processo terminou com falha de segmentação (SIGSEGV)",
+		"Falha nativa da aplicação ou incompatibilidade de arquitetura/bibliotecas da imagem. Verifique se a imagem corresponde à arquitetura do node (amd64/arm64).",
+	},
+}
+
+func matchCrashLoopExitCode(in *Input) *Diagnosis {
+	for _, cs := range containerStatuses(in.Pod) {
+		if cs.State.Waiting == nil || cs.State.Waiting.Reason != "CrashLoopBackOff" {
+			continue
+		}
+		term := cs.LastTerminationState.Terminated
+		if term == nil {
+			continue
+		}
+		known, ok := exitCodeCauses[term.ExitCode]
+		if !ok {
+			continue
+		}
+		return &Diagnosis{
+			RootCause:       fmt.Sprintf("Container '%s' em CrashLoopBackOff (exit %d)", cs.Name, term.ExitCode),
+			TechnicalDetail: fmt.Sprintf("%s. Último término: %s, %d restarts.", known.cause, term.Reason, cs.RestartCount),
+			Confidence:      80,
+			SuggestedFix:    known.fix,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+//go:build k8s
+
+package diagnosis
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(statuses ...corev1.ContainerStatus) *corev1.Pod {
+	isController := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "api-7d9f-x1", Namespace: "prod",
+			Labels:          map[string]string{"pod-template-hash": "7d9f"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "api-7d9f", Controller: &isController}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app", Image: "registry.example.com/api:1",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+	}
+	return pod
+}
+
+func crashLoop(exitCode int32, reason string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name: "app", Image: "registry.example.com/api:1", RestartCount: 5,
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason, ExitCode: exitCode}},
+	}
+}
+
+func waiting(reason, message string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name: "app", Image: "registry.example.com/api:1",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+	}
+}
+
+func event(reason, message string) corev1.Event {
+	return corev1.Event{Type: corev1.EventTypeWarning, Reason: reason, Message: message, Count: 3}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  *Input
+		ruleID string
+		want   string
+	}{
+		{
+			name:   "oom killed",
+			input:  &Input{Pod: testPod(crashLoop(137, "OOMKilled"))},
+			ruleID: "OOMKILLED",
+			want:   "256Mi",
+		},
+		{
+			name:   "image pull com erro de autenticação",
+			input:  &Input{Pod: testPod(waiting("ImagePullBackOff", `Back-off pulling image "registry.example.com/api:1"`)), Events: []corev1.Event{event("Failed", `Failed to pull image "registry.example.com/api:1": 401 Unauthorized`)}},
+			ruleID: "IMAGE_PULL_AUTH",
+			want:   "401 Unauthorized",
+		},
+		{
+			name:   "crashloop com comando inexistente",
+			input:  &Input{Pod: testPod(crashLoop(127, "Error"))},
+			ruleID: "CRASHLOOP_EXIT_CODE",
+			want:   "não foi encontrado",
+		},
+		{
+			name:   "liveness probe",
+			input:  &Input{Pod: testPod(crashLoop(137, "Error")), Events: []corev1.Event{event("Unhealthy", "Liveness probe failed: HTTP probe failed with statuscode: 500")}},
+			ruleID: "PROBE_FAILURE",
+			want:   "statuscode: 500",
+		},
+		{
+			name:   "secret ausente no status",
+			input:  &Input{Pod: testPod(waiting("CreateContainerConfigError", `secret "db-credentials" not found`))},
+			ruleID: "MISSING_REF",
+			want:   "Secret/db-credentials",
+		},
+		{
+			name:   "configmap ausente coletado",
+			input:  &Input{Pod: testPod(), MissingRefs: []string{"ConfigMap/app-config"}},
+			ruleID: "MISSING_REF",
+			want:   "ConfigMap/app-config",
+		},
+		{
+			name: "pod evicted",
+			input: &Input{Pod: func() *corev1.Pod {
+				p := testPod()
+				p.Status.Phase, p.Status.Reason, p.Status.Message = corev1.PodFailed, "Evicted", "The node was low on resource: ephemeral-storage."
+				return p
+			}()},
+			ruleID: "NODE_PRESSURE",
+			want:   "ephemeral-storage",
+		},
+		{
+			name: "node com memory pressure",
+			input: &Input{Pod: testPod(crashLoop(1, "Error")), Node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue}}},
+			}},
+			ruleID: "NODE_PRESSURE",
+			want:   "MemoryPressure",
+		},
+	}
+
+	engine := NewEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := engine.Diagnose(tt.input)
+			if !ok {
+				t.Fatalf("esperava diagnóstico %s, nenhuma regra casou", tt.ruleID)
+			}
+			if d.RuleID != tt.ruleID {
+				t.Fatalf("esperava regra %s, obteve %s (%s)", tt.ruleID, d.RuleID, d.RootCause)
+			}
+			if text := d.RootCause + d.TechnicalDetail; !strings.Contains(text, tt.want) {
+				t.Errorf("diagnóstico deveria citar %q: %s", tt.want, text)
+			}
+			if d.Confidence < 80 || d.SuggestedFix == "" {
+				t.Errorf("diagnóstico determinístico deveria ter alta confiança e correção: %+v", d)
+			}
+		})
+	}
+}
+
+func TestOOMKilled_SugereLimitDobrado(t *testing.T) {
+	d, ok := NewEngine().Diagnose(&Input{Pod: testPod(crashLoop(137, "OOMKilled"))})
+	if !ok {
+		t.Fatal("esperava diagnóstico")
+	}
+	want := "kubectl set resources deployment/api -n prod -c app --limits=memory=512Mi"
+	if d.KubectlPatch != want {
+		t.Errorf("patch esperado %q, obtido %q", want, d.KubectlPatch)
+	}
+}
+
+func TestPVCUnbound(t *testing.T) {
+	pod := testPod()
+	pod.Status.Phase = corev1.PodPending
+	pod.Spec.Volumes = []corev1.Volume{
+		{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+		{Name: "cache", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "cache"}}},
+	}
+	sc := "fast-ssd"
+	in := &Input{Pod: pod, PVCs: map[string]*corev1.PersistentVolumeClaim{
+		"data":  {Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &sc}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+		"cache": nil,
+	}}
+
+	d, ok := NewEngine().Diagnose(in)
+	if !ok || d.RuleID != "PVC_UNBOUND" {
+		t.Fatalf("esperava PVC_UNBOUND, obteve %+v", d)
+	}
+	if !strings.Contains(d.TechnicalDetail, "fast-ssd") || !strings.Contains(d.TechnicalDetail, "'cache' não existe") {
+		t.Errorf("detalhe deveria citar os dois claims: %s", d.TechnicalDetail)
+	}
+}
+
+func TestBuiltinRules_SemAssinaturaConhecida(t *testing.T) {
+	tests := map[string]*Input{
+		// Exit 1 é genérico: depende dos logs e fica com a IA
+		"crashloop exit 1": {Pod: testPod(crashLoop(1, "Error"))},
+		"image pull sem erro de autenticação": {
+			Pod:    testPod(waiting("ImagePullBackOff", "")),
+			Events: []corev1.Event{event("Failed", `Failed to pull image "registry.example.com/api:1": manifest unknown`)},
+		},
+		"pvc bound": {Pod: testPod(), PVCs: map[string]*corev1.PersistentVolumeClaim{"data": {Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}}}},
+	}
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d, ok := NewEngine().Diagnose(in); ok {
+				t.Errorf("não esperava diagnóstico, obteve %s: %s", d.RuleID, d.RootCause)
+			}
+		})
+	}
+}
+
+func TestOwnerWorkload(t *testing.T) {
+	isController := true
+	sts := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &isController}}}}
+	if got := ownerWorkload(sts); got != "statefulset/db" {
+		t.Errorf("esperado statefulset/db, obtido %s", got)
+	}
+	if got := ownerWorkload(testPod()); got != "deployment/api" {
+		t.Errorf("esperado deployment/api, obtido %s", got)
+	}
+	if got := ownerWorkload(&corev1.Pod{}); got != "" {
+		t.Errorf("pod sem dono não deveria ter workload, obtido %s", got)
+	}
+}
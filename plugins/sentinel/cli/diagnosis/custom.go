@@ -0,0 +1,284 @@
+//go:build k8s
+
+package diagnosis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultRulesFile é o arquivo de regras customizadas do projeto.
+const DefaultRulesFile = ".yby/sentinel-rules.yaml"
+
+// defaultCustomConfidence é a confiança de regras customizadas que não a definem.
+const defaultCustomConfidence = 90
+
+// RulesFile é o formato do arquivo de regras customizadas.
+type RulesFile struct {
+	Rules []CustomRule `yaml:"rules"`
+}
+
+// CustomRule é uma regra declarada em YAML. Todas as condições de match
+// informadas precisam ser satisfeitas. Os textos do diagnóstico aceitam
+// templates com {{.Pod}}, {{.Namespace}}, {{.Container}} e {{.Workload}}.
+type CustomRule struct {
+	RuleID          string    `yaml:"id"`
+	Conditions      MatchSpec `yaml:"match"`
+	RootCause       string    `yaml:"root_cause"`
+	TechnicalDetail string    `yaml:"technical_detail"`
+	SuggestedFix    string    `yaml:"suggested_fix"`
+	KubectlPatch    string    `yaml:"kubectl_patch"`
+	Confidence      int       `yaml:"confidence"`
+
+	message *regexp.Regexp
+	logs    *regexp.Regexp
+	tmpl    *template.Template
+}
+
+// MatchSpec são as condições de uma regra customizada.
+type MatchSpec struct {
+	// Container restringe as condições de container a um nome (vazio = qualquer).
+	Container string `yaml:"container"`
+	// Reason é o motivo do estado atual ou do último término de um container
+	// (ex: CrashLoopBackOff, CreateContainerError, Error).
+	Reason string `yaml:"reason"`
+	// ExitCode é o exit code do término atual ou anterior do container.
+	ExitCode *int32 `yaml:"exit_code"`
+	// EventReason exige um evento do pod com esse motivo (ex: FailedScheduling).
+	EventReason string `yaml:"event_reason"`
+	// Message é uma regex sobre as mensagens de eventos e de estado do pod.
+	Message string `yaml:"message"`
+	// Logs é uma regex sobre os logs do pod.
+	Logs string `yaml:"logs"`
+}
+
+func (m MatchSpec) empty() bool {
+	return m.Container == "" && m.Reason == "" && m.ExitCode == nil && m.EventReason == "" && m.Message == "" && m.Logs == ""
+}
+
+// templateData são os valores disponíveis nos templates das regras customizadas.
+type templateData struct {
+	Pod       string
+	Namespace string
+	Container string
+	Workload  string
+}
+
+// LoadRules lê as regras customizadas do arquivo. Arquivo inexistente não é erro.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler regras %s: %w", path, err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("regras invalidas em %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules valida e compila regras customizadas em YAML.
+func ParseRules(data []byte) ([]Rule, error) {
+	var file RulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("falha ao parsear regras: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	rules := make([]Rule, 0, len(file.Rules))
+	for i := range file.Rules {
+		r := &file.Rules[i]
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+		if seen[r.RuleID] {
+			return nil, fmt.Errorf("regra '%s' duplicada", r.RuleID)
+		}
+		seen[r.RuleID] = true
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r *CustomRule) compile() error {
+	if r.RuleID == "" {
+		return fmt.Errorf("regra sem id")
+	}
+	if r.RootCause == "" {
+		return fmt.Errorf("regra '%s' sem root_cause", r.RuleID)
+	}
+	if r.Conditions.empty() {
+		return fmt.Errorf("regra '%s' sem condicoes em match", r.RuleID)
+	}
+	if r.Confidence == 0 {
+		r.Confidence = defaultCustomConfidence
+	}
+	if r.Confidence < 0 || r.Confidence > 100 {
+		return fmt.Errorf("regra '%s': confidence deve estar entre 0 e 100", r.RuleID)
+	}
+
+	var err error
+	if r.Conditions.Message != "" {
+		if r.message, err = regexp.Compile(r.Conditions.Message); err != nil {
+			return fmt.Errorf("regra '%s': regex de message invalida: %w", r.RuleID, err)
+		}
+	}
+	if r.Conditions.Logs != "" {
+		if r.logs, err = regexp.Compile(r.Conditions.Logs); err != nil {
+			return fmt.Errorf("regra '%s': regex de logs invalida: %w", r.RuleID, err)
+		}
+	}
+
+	r.tmpl = template.New(r.RuleID).Option("missingkey=error")
+	for name, text := range map[string]string{
+		"root_cause":       r.RootCause,
+		"technical_detail": r.TechnicalDetail,
+		"suggested_fix":    r.SuggestedFix,
+		"kubectl_patch":    r.KubectlPatch,
+	} {
+		if _, err := r.tmpl.New(name).Parse(text); err != nil {
+			return fmt.Errorf("regra '%s': template de %s invalido: %w", r.RuleID, name, err)
+		}
+	}
+	return nil
+}
+
+// ID implementa Rule.
+func (r *CustomRule) ID() string {
+	return r.RuleID
+}
+
+// Match implementa Rule.
+func (r *CustomRule) Match(in *Input) *Diagnosis {
+	container, ok := r.matchContainer(in.Pod)
+	if !ok {
+		return nil
+	}
+	if r.Conditions.EventReason != "" && !hasEventReason(in.Events, r.Conditions.EventReason) {
+		return nil
+	}
+	if r.message != nil && !matchesAny(r.message, podMessages(in)) {
+		return nil
+	}
+	if r.logs != nil && !r.logs.MatchString(in.Logs) {
+		return nil
+	}
+
+	data := templateData{Pod: in.Pod.Name, Namespace: in.Pod.Namespace, Container: container, Workload: ownerWorkload(in.Pod)}
+	return &Diagnosis{
+		RootCause:       r.render("root_cause", data),
+		TechnicalDetail: r.render("technical_detail", data),
+		Confidence:      r.Confidence,
+		SuggestedFix:    r.render("suggested_fix", data),
+		KubectlPatch:    r.render("kubectl_patch", data),
+	}
+}
+
+// matchContainer procura um container que satisfaça as condições de container
+// da regra e retorna seu nome. Sem condições de container, qualquer pod serve.
+func (r *CustomRule) matchContainer(pod *corev1.Pod) (string, bool) {
+	m := r.Conditions
+	if m.Container == "" && m.Reason == "" && m.ExitCode == nil {
+		if len(pod.Spec.Containers) > 0 {
+			return pod.Spec.Containers[0].Name, true
+		}
+		return "", true
+	}
+	for _, cs := range containerStatuses(pod) {
+		if m.Container != "" && cs.Name != m.Container {
+			continue
+		}
+		terms := []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated}
+		if m.Reason != "" {
+			reasons := []string{}
+			if cs.State.Waiting != nil {
+				reasons = append(reasons, cs.State.Waiting.Reason)
+			}
+			for _, t := range terms {
+				if t != nil {
+					reasons = append(reasons, t.Reason)
+				}
+			}
+			if !contains(reasons, m.Reason) {
+				continue
+			}
+		}
+		if m.ExitCode != nil {
+			found := false
+			for _, t := range terms {
+				if t != nil && t.ExitCode == *m.ExitCode {
+					found = true
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		return cs.Name, true
+	}
+	return "", false
+}
+
+func (r *CustomRule) render(name string, data templateData) string {
+	var buf bytes.Buffer
+	if err := r.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// podMessages reúne as mensagens de eventos, de estado dos containers e do pod.
+func podMessages(in *Input) []string {
+	messages := []string{in.Pod.Status.Message}
+	for _, e := range in.Events {
+		messages = append(messages, e.Message)
+	}
+	for _, cs := range containerStatuses(in.Pod) {
+		if cs.State.Waiting != nil {
+			messages = append(messages, cs.State.Waiting.Message)
+		}
+		for _, t := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+			if t != nil {
+				messages = append(messages, t.Message)
+			}
+		}
+	}
+	return messages
+}
+
+func hasEventReason(events []corev1.Event, reason string) bool {
+	for _, e := range events {
+		if e.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(re *regexp.Regexp, texts []string) bool {
+	for _, t := range texts {
+		if t != "" && re.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
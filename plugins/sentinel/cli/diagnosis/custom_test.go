@@ -0,0 +1,127 @@
+//go:build k8s
+
+package diagnosis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const customRulesYAML = `
+rules:
+  - id: DB_CONNECTION_REFUSED
+    match:
+      reason: CrashLoopBackOff
+      exit_code: 1
+      logs: 'dial tcp .*:5432: connect: connection refused'
+    root_cause: "{{.Workload}} sem acesso ao Postgres"
+    technical_detail: "O container {{.Container}} do pod {{.Pod}} não conecta no banco."
+    suggested_fix: Verifique o Service do Postgres e as NetworkPolicies.
+    kubectl_patch: "kubectl get endpoints postgres -n {{.Namespace}}"
+  - id: QUOTA_EXCEEDED
+    match:
+      event_reason: FailedCreate
+      message: exceeded quota
+    root_cause: ResourceQuota do namespace esgotada
+    confidence: 99
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(customRulesYAML))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID() != "DB_CONNECTION_REFUSED" {
+		t.Fatalf("regras inesperadas: %v", rules)
+	}
+	if rules[0].(*CustomRule).Confidence != defaultCustomConfidence || rules[1].(*CustomRule).Confidence != 99 {
+		t.Error("confidence deveria usar o padrão quando omitida")
+	}
+}
+
+func TestParseRules_Invalidas(t *testing.T) {
+	tests := map[string]string{
+		"sem id":            "rules: [{root_cause: x, match: {reason: Error}}]",
+		"sem root_cause":    "rules: [{id: A, match: {reason: Error}}]",
+		"sem condicoes":     "rules: [{id: A, root_cause: x}]",
+		"regex invalida":    "rules: [{id: A, root_cause: x, match: {logs: '('}}]",
+		"template invalido": "rules: [{id: A, root_cause: '{{.Pod', match: {reason: Error}}]",
+		"confidence":        "rules: [{id: A, root_cause: x, confidence: 150, match: {reason: Error}}]",
+		"duplicada":         "rules: [{id: A, root_cause: x, match: {reason: Error}}, {id: A, root_cause: y, match: {reason: Error}}]",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRules([]byte(data)); err == nil {
+				t.Error("esperava erro de validação")
+			}
+		})
+	}
+}
+
+func TestCustomRule_Match(t *testing.T) {
+	rules, err := ParseRules([]byte(customRulesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(rules...)
+
+	in := &Input{Pod: testPod(crashLoop(1, "Error")), Logs: "panic: dial tcp 10.0.0.5:5432: connect: connection refused"}
+	d, ok := engine.Diagnose(in)
+	if !ok || d.RuleID != "DB_CONNECTION_REFUSED" {
+		t.Fatalf("esperava regra customizada, obteve %+v", d)
+	}
+	if d.RootCause != "deployment/api sem acesso ao Postgres" || !strings.Contains(d.TechnicalDetail, "container app do pod api-7d9f-x1") {
+		t.Errorf("templates não renderizados: %+v", d)
+	}
+	if d.KubectlPatch != "kubectl get endpoints postgres -n prod" {
+		t.Errorf("patch inesperado: %s", d.KubectlPatch)
+	}
+
+	// Sem o log característico, a regra não casa e exit 1 segue sem diagnóstico
+	in.Logs = "panic: nil pointer dereference"
+	if d, ok := engine.Diagnose(in); ok {
+		t.Errorf("não esperava diagnóstico, obteve %s", d.RuleID)
+	}
+
+	quota := &Input{Pod: testPod(), Events: []corev1.Event{event("FailedCreate", `pods "api-x" is forbidden: exceeded quota: compute`)}}
+	if d, ok := engine.Diagnose(quota); !ok || d.RuleID != "QUOTA_EXCEEDED" {
+		t.Errorf("esperava QUOTA_EXCEEDED, obteve %+v", d)
+	}
+}
+
+func TestCustomRule_SobrescreveNativa(t *testing.T) {
+	rules, err := ParseRules([]byte(`
+rules:
+  - id: JVM_HEAP
+    match: {reason: OOMKilled, logs: 'java.lang.OutOfMemoryError'}
+    root_cause: Heap da JVM maior que o limit do container
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := &Input{Pod: testPod(crashLoop(137, "OOMKilled")), Logs: "Exception: java.lang.OutOfMemoryError: Java heap space"}
+	if d, _ := NewEngine(rules...).Diagnose(in); d == nil || d.RuleID != "JVM_HEAP" {
+		t.Errorf("regra customizada deveria ter precedência sobre OOMKILLED, obteve %+v", d)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	rules, err := LoadRules(filepath.Join(dir, "inexistente.yaml"))
+	if err != nil || rules != nil {
+		t.Errorf("arquivo inexistente não deveria ser erro: %v", err)
+	}
+
+	path := filepath.Join(dir, "rules.yaml")
+	if err := os.WriteFile(path, []byte("rules: [{id: A}]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRules(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("erro deveria citar o arquivo: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build k8s
+
+package diagnosis
+
+// Engine avalia as regras em ordem e retorna o primeiro diagnóstico.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine cria o motor com as regras customizadas seguidas das nativas.
+// Regras customizadas vêm primeiro para que times possam sobrescrever um
+// diagnóstico nativo com um mais específico do seu ambiente.
+func NewEngine(custom ...Rule) *Engine {
+	rules := make([]Rule, 0, len(custom)+len(builtinRules))
+	rules = append(rules, custom...)
+	rules = append(rules, builtinRules...)
+	return &Engine{rules: rules}
+}
+
+// Rules retorna as regras do motor na ordem de avaliação.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Diagnose retorna o diagnóstico da primeira regra que reconhece a falha.
+func (e *Engine) Diagnose(in *Input) (*Diagnosis, bool) {
+	if in == nil || in.Pod == nil {
+		return nil, false
+	}
+	for _, r := range e.rules {
+		if d := r.Match(in); d != nil {
+			d.RuleID = r.ID()
+			return d, true
+		}
+	}
+	return nil, false
+}
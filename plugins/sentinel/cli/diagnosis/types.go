@@ -0,0 +1,41 @@
+//go:build k8s
+
+// Package diagnosis implementa o motor de regras determinísticas do Sentinel:
+// assinaturas conhecidas de falha (OOMKilled, ImagePullBackOff por autenticação,
+// CrashLoopBackOff por exit code, probes, PVC, pressão no node e referências
+// ausentes) diagnosticadas localmente, sem chamada à IA.
+package diagnosis
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Input reúne o que as regras podem inspecionar sobre um pod com problema.
+type Input struct {
+	Pod    *corev1.Pod
+	Events []corev1.Event
+	Logs   string
+	// Node em que o pod está agendado (nil quando não agendado ou inacessível).
+	Node *corev1.Node
+	// PVCs referenciados pelo pod, por nome de claim. Valor nil indica claim inexistente.
+	PVCs map[string]*corev1.PersistentVolumeClaim
+	// MissingRefs lista Secrets e ConfigMaps obrigatórios referenciados pelo pod
+	// que não existem no namespace (ex: "Secret/db-credentials").
+	MissingRefs []string
+}
+
+// Diagnosis é o resultado de uma regra que reconheceu a falha.
+type Diagnosis struct {
+	RuleID          string
+	RootCause       string
+	TechnicalDetail string
+	Confidence      int
+	SuggestedFix    string
+	KubectlPatch    string
+}
+
+// Rule reconhece uma assinatura de falha. Match retorna nil quando a regra não se aplica.
+type Rule interface {
+	ID() string
+	Match(in *Input) *Diagnosis
+}
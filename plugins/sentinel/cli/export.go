@@ -0,0 +1,273 @@
+//go:build k8s
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sentinelURI  = "https://github.com/casheiro/yby-cli/tree/main/plugins/sentinel"
+)
+
+// sarifLog é a raiz de um documento SARIF 2.1.0.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name,omitempty"`
+	ShortDescription sarifMessage      `json:"shortDescription"`
+	Help             *sarifMessage     `json:"help,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// exportScanSARIF gera o relatório de scan em formato SARIF 2.1.0.
+// Cada CheckID vira uma regra e cada finding um resultado com localização lógica
+// no recurso afetado, permitindo ingestão em dashboards de code scanning.
+func exportScanSARIF(report ScanReport) (string, error) {
+	var rules []sarifRule
+	ruleIndex := make(map[string]int)
+	results := make([]sarifResult, 0, len(report.Findings))
+
+	for _, f := range report.Findings {
+		sev := findingSeverity(f)
+		idx, ok := ruleIndex[f.CheckID]
+		if !ok {
+			idx = len(rules)
+			ruleIndex[f.CheckID] = idx
+			rule := sarifRule{
+				ID:               f.CheckID,
+				Name:             f.CheckID,
+				ShortDescription: sarifMessage{Text: f.Message},
+				Properties: map[string]string{
+					"category":          string(f.Category),
+					"security-severity": sarifSecuritySeverity(sev),
+				},
+			}
+			if f.Recommendation != "" {
+				rule.Help = &sarifMessage{Text: f.Recommendation}
+			}
+			rules = append(rules, rule)
+		}
+
+		namespace := f.Namespace
+		if namespace == "" {
+			namespace = report.Namespace
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    f.CheckID,
+			RuleIndex: idx,
+			Level:     sarifLevel(sev),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               f.Resource,
+					FullyQualifiedName: fmt.Sprintf("%s/%s", namespace, f.Resource),
+					Kind:               "resource",
+				}},
+			}},
+			Properties: map[string]string{
+				"severity":       string(sev),
+				"namespace":      namespace,
+				"recommendation": f.Recommendation,
+			},
+		})
+	}
+
+	if rules == nil {
+		rules = []sarifRule{}
+	}
+
+	doc := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "sentinel",
+				InformationURI: sentinelURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar relatório SARIF: %w", err)
+	}
+	return string(data), nil
+}
+
+// sarifLevel mapeia a severidade do Sentinel para o nível SARIF.
+func sarifLevel(sev checks.Severity) string {
+	switch sev {
+	case checks.SeverityCritical, checks.SeverityHigh:
+		return "error"
+	case checks.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifSecuritySeverity retorna a pontuação numérica usada por ferramentas de
+// code scanning para classificar a severidade do alerta.
+func sarifSecuritySeverity(sev checks.Severity) string {
+	switch sev {
+	case checks.SeverityCritical:
+		return "9.0"
+	case checks.SeverityHigh:
+		return "7.0"
+	case checks.SeverityMedium:
+		return "5.0"
+	case checks.SeverityLow:
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// junitTestSuites é a raiz de um relatório JUnit XML.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// exportScanJUnit gera o relatório de scan em formato JUnit XML.
+// Cada namespace vira uma testsuite e cada finding um testcase com falha,
+// para que test reporters de CI exibam os problemas como testes quebrados.
+func exportScanJUnit(report ScanReport) (string, error) {
+	byNamespace := make(map[string][]checks.SecurityFinding)
+	for _, f := range report.Findings {
+		ns := f.Namespace
+		if ns == "" {
+			ns = report.Namespace
+		}
+		byNamespace[ns] = append(byNamespace[ns], f)
+	}
+
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	root := junitTestSuites{Name: "sentinel"}
+
+	for _, ns := range namespaces {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("sentinel.%s", ns),
+			Timestamp: timestamp,
+		}
+		for _, f := range byNamespace[ns] {
+			sev := findingSeverity(f)
+			content := f.Message
+			if f.Recommendation != "" {
+				content += "\nRecomendação: " + f.Recommendation
+			}
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      fmt.Sprintf("%s: %s", f.CheckID, f.Resource),
+				ClassName: fmt.Sprintf("sentinel.%s", f.Category),
+				Failure: &junitFailure{
+					Message: f.Message,
+					Type:    string(sev),
+					Content: content,
+				},
+			})
+		}
+		suite.Tests = len(suite.TestCases)
+		suite.Failures = len(suite.TestCases)
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Suites = append(root.Suites, suite)
+	}
+
+	// Scan sem findings ainda gera uma suite vazia para o namespace escaneado
+	if len(root.Suites) == 0 {
+		root.Suites = append(root.Suites, junitTestSuite{
+			Name:      fmt.Sprintf("sentinel.%s", report.Namespace),
+			Timestamp: timestamp,
+		})
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar relatório JUnit: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
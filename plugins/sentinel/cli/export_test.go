@@ -0,0 +1,119 @@
+//go:build k8s
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+)
+
+func sampleScanReport() ScanReport {
+	return ScanReport{
+		Namespace: "prod",
+		Findings: []checks.SecurityFinding{
+			{CheckID: "POD_PRIVILEGED", Severity: checks.SeverityCritical, Category: checks.CategoryPodSecurity, Namespace: "prod", Resource: "api/app", Message: "container privilegiado", Recommendation: "remova privileged"},
+			{CheckID: "POD_PRIVILEGED", Severity: checks.SeverityCritical, Category: checks.CategoryPodSecurity, Namespace: "prod", Resource: "worker/app", Message: "container privilegiado"},
+			{CheckID: "POD_RESOURCE_LIMITS", Severity: checks.SeverityMedium, Category: checks.CategoryPodSecurity, Namespace: "prod", Resource: "api/app", Message: "sem limites"},
+		},
+	}
+}
+
+// --- exportScanSARIF ---
+
+func TestExportScanSARIF_RegrasEResultados(t *testing.T) {
+	content, err := exportScanSARIF(sampleScanReport())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	var doc sarifLog
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("SARIF inválido: %v", err)
+	}
+	if doc.Version != "2.1.0" {
+		t.Errorf("esperava versão 2.1.0, obteve %s", doc.Version)
+	}
+	if len(doc.Runs) != 1 {
+		t.Fatalf("esperava 1 run, obteve %d", len(doc.Runs))
+	}
+
+	run := doc.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("esperava 2 regras (uma por CheckID), obteve %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("esperava 3 resultados, obteve %d", len(run.Results))
+	}
+	if run.Results[0].Level != "error" {
+		t.Errorf("finding critical deveria ter level error, obteve %s", run.Results[0].Level)
+	}
+	if run.Results[2].Level != "warning" {
+		t.Errorf("finding medium deveria ter level warning, obteve %s", run.Results[2].Level)
+	}
+	if run.Results[2].RuleIndex != 1 {
+		t.Errorf("esperava ruleIndex 1, obteve %d", run.Results[2].RuleIndex)
+	}
+	if got := run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName; got != "prod/api/app" {
+		t.Errorf("localização inesperada: %s", got)
+	}
+	if run.Tool.Driver.Rules[0].Help == nil || run.Tool.Driver.Rules[0].Help.Text != "remova privileged" {
+		t.Error("regra deveria carregar a recomendação como help")
+	}
+}
+
+func TestExportScanSARIF_SemFindings(t *testing.T) {
+	content, err := exportScanSARIF(ScanReport{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(content, `"results": []`) {
+		t.Errorf("SARIF sem findings deveria ter results vazio, conteúdo: %s", content)
+	}
+}
+
+// --- exportScanJUnit ---
+
+func TestExportScanJUnit_TestcasePorFinding(t *testing.T) {
+	content, err := exportScanJUnit(sampleScanReport())
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.HasPrefix(content, "<?xml") {
+		t.Error("JUnit deveria começar com cabeçalho XML")
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(content), &doc); err != nil {
+		t.Fatalf("JUnit inválido: %v", err)
+	}
+	if doc.Tests != 3 || doc.Failures != 3 {
+		t.Errorf("esperava 3 testes e 3 falhas, obteve %d/%d", doc.Tests, doc.Failures)
+	}
+	if len(doc.Suites) != 1 || doc.Suites[0].Name != "sentinel.prod" {
+		t.Fatalf("esperava suite sentinel.prod, obteve %+v", doc.Suites)
+	}
+	tc := doc.Suites[0].TestCases[0]
+	if tc.Name != "POD_PRIVILEGED: api/app" {
+		t.Errorf("nome de testcase inesperado: %s", tc.Name)
+	}
+	if tc.Failure == nil || tc.Failure.Type != "critical" {
+		t.Error("testcase deveria ter falha do tipo critical")
+	}
+}
+
+func TestExportScanJUnit_SemFindings(t *testing.T) {
+	content, err := exportScanJUnit(ScanReport{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !strings.Contains(content, `name="sentinel.default"`) {
+		t.Errorf("esperava suite vazia para o namespace, conteúdo: %s", content)
+	}
+	if !strings.Contains(content, `failures="0"`) {
+		t.Errorf("esperava zero falhas, conteúdo: %s", content)
+	}
+}
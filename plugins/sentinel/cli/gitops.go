@@ -0,0 +1,65 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/remediation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// runGitOpsRemediation aplica os patches nos manifests do repositório em vez
+// do cluster: cada patch é associado ao workload dono do pod e vira um commit
+// em uma branch nova. Com --fix-dry-run, apenas lista os arquivos que mudariam.
+func runGitOpsRemediation(ctx context.Context, client kubernetes.Interface, patches []remediation.RemediationPatch, opts scanOptions) {
+	repoDir := opts.Path
+	if repoDir == "" {
+		repoDir = "."
+	}
+
+	fixes := make([]remediation.GitOpsFix, 0, len(patches))
+	for _, p := range patches {
+		fixes = append(fixes, remediation.GitOpsFix{
+			Patch:    p,
+			Workload: remediation.ResolveWorkload(ctx, client, p.Namespace, p.ResourceName),
+		})
+	}
+
+	out := opts.humanOutput()
+	var result remediation.GitOpsResult
+	var err error
+	if opts.FixDryRun {
+		fmt.Fprintf(out, "\nDry-run GitOps: %d patches de remediacao (a partir do HEAD; alteracoes nao commitadas sao ignoradas)\n", len(patches))
+		result, err = remediation.PlanGitOps(repoDir, fixes)
+	} else {
+		fmt.Fprintf(out, "\nAplicando %d patches de remediacao no repositorio...\n", len(patches))
+		result, err = remediation.CommitGitOps(repoDir, opts.GitOpsBranch, fixes)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "  Erro: %v\n", err)
+		return
+	}
+
+	for i, c := range result.Changes {
+		switch {
+		case c.Skipped != "":
+			fmt.Fprintf(out, "  %d. [ignorado] %s - %s: %s\n", i+1, c.Workload, c.Patch.Description, c.Skipped)
+		case c.Commit != "":
+			fmt.Fprintf(out, "  %d. [%s] %s - %s\n", i+1, c.Commit, c.Source.File, c.Patch.Description)
+		default:
+			fmt.Fprintf(out, "  %d. [%s] %s - %s\n", i+1, c.Source.Type, c.Source.File, c.Patch.Description)
+		}
+	}
+
+	if opts.FixDryRun {
+		return
+	}
+	if result.Branch == "" {
+		fmt.Fprintln(out, "  Nenhuma correcao aplicada; branch nao criada.")
+		return
+	}
+	fmt.Fprintf(out, "  %d commits na branch %s. Abra um pull request para revisao:\n", result.Applied(), result.Branch)
+	fmt.Fprintf(out, "    git push -u origin %s\n", result.Branch)
+}
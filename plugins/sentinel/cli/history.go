@@ -0,0 +1,403 @@
+//go:build k8s
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/plugin/sdk"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+)
+
+const (
+	historyDir = ".yby/sentinel/history"
+	// offlineHistoryContext identifica scans de manifests (--path), sem cluster.
+	offlineHistoryContext = "offline"
+	// defaultTrendRuns é quantos scans a tabela de evolução mostra por padrão.
+	defaultTrendRuns = 10
+)
+
+// HistoryRecord é uma linha do histórico de scans (JSONL).
+type HistoryRecord struct {
+	Timestamp      time.Time                `json:"timestamp"`
+	Context        string                   `json:"context"`
+	Namespace      string                   `json:"namespace"`
+	Path           string                   `json:"path,omitempty"`
+	Profile        string                   `json:"profile,omitempty"`
+	Score          int                      `json:"score"`
+	CategoryScores map[string]int           `json:"category_scores"`
+	Findings       []checks.SecurityFinding `json:"findings"`
+}
+
+// scope identifica o alvo do scan; só registros do mesmo escopo são comparáveis.
+func (r HistoryRecord) scope() string {
+	return fmt.Sprintf("%s|%s|%s", r.Namespace, r.Path, r.Profile)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// historyFile retorna o arquivo JSONL do histórico de um contexto de cluster.
+func historyFile(clusterContext string) string {
+	name := unsafeFileChars.ReplaceAllString(clusterContext, "_")
+	return filepath.Join(historyDir, name+".jsonl")
+}
+
+// currentClusterContext retorna o contexto kube do SDK, o ambiente do projeto
+// ou "default", nessa ordem.
+func currentClusterContext() string {
+	if ctx := sdk.GetFullContext(); ctx != nil {
+		if ctx.Infra.KubeContext != "" {
+			return ctx.Infra.KubeContext
+		}
+		if ctx.Environment != "" {
+			return ctx.Environment
+		}
+	}
+	return "default"
+}
+
+// newHistoryRecord monta o registro de histórico de um scan.
+func newHistoryRecord(report ScanReport, clusterContext, profile string, now time.Time) HistoryRecord {
+	return HistoryRecord{
+		Timestamp:      now.UTC(),
+		Context:        clusterContext,
+		Namespace:      report.Namespace,
+		Path:           report.Path,
+		Profile:        profile,
+		Score:          securityScore(report.Findings),
+		CategoryScores: categoryScores(report.Findings),
+		Findings:       report.Findings,
+	}
+}
+
+// categoryScores calcula a pontuação de cada categoria presente nos findings
+// e das categorias conhecidas sem findings (pontuação 100).
+func categoryScores(findings []checks.SecurityFinding) map[string]int {
+	byCategory := map[string][]checks.SecurityFinding{
+		string(checks.CategoryPodSecurity): nil,
+		string(checks.CategoryRBAC):        nil,
+		string(checks.CategoryNetwork):     nil,
+		string(checks.CategorySecrets):     nil,
+		string(checks.CategorySupplyChain): nil,
+	}
+	for _, f := range findings {
+		byCategory[string(f.Category)] = append(byCategory[string(f.Category)], f)
+	}
+	scores := make(map[string]int, len(byCategory))
+	for cat, fs := range byCategory {
+		scores[cat] = securityScore(fs)
+	}
+	return scores
+}
+
+// appendHistory grava o registro no final do arquivo do contexto.
+func appendHistory(record HistoryRecord) (string, error) {
+	path := historyFile(record.Context)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("falha ao criar diretório de histórico: %w", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar histórico: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("falha ao abrir histórico: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return "", fmt.Errorf("falha ao gravar histórico: %w", err)
+	}
+	return path, nil
+}
+
+// loadHistory lê todos os registros de um contexto, em ordem cronológica.
+// Linhas corrompidas são ignoradas.
+func loadHistory(clusterContext string) ([]HistoryRecord, error) {
+	f, err := os.Open(historyFile(clusterContext))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir histórico: %w", err)
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var r HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("falha ao ler histórico: %w", err)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// TrendDiff compara os findings de dois scans.
+type TrendDiff struct {
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	New        []checks.SecurityFinding `json:"new"`
+	Fixed      []checks.SecurityFinding `json:"fixed"`
+	Persisting []checks.SecurityFinding `json:"persisting"`
+}
+
+// diffRecords classifica os findings entre dois scans como novos, corrigidos
+// ou persistentes, usando CheckID+Namespace+Resource como identidade.
+func diffRecords(from, to HistoryRecord) TrendDiff {
+	diff := TrendDiff{From: from.Timestamp, To: to.Timestamp}
+
+	before := make(map[string]bool, len(from.Findings))
+	for _, f := range from.Findings {
+		before[baselineKey(f)] = true
+	}
+	after := make(map[string]bool, len(to.Findings))
+	for _, f := range to.Findings {
+		key := baselineKey(f)
+		if after[key] {
+			continue
+		}
+		after[key] = true
+		if before[key] {
+			diff.Persisting = append(diff.Persisting, f)
+		} else {
+			diff.New = append(diff.New, f)
+		}
+	}
+	seenFixed := make(map[string]bool)
+	for _, f := range from.Findings {
+		key := baselineKey(f)
+		if !after[key] && !seenFixed[key] {
+			seenFixed[key] = true
+			diff.Fixed = append(diff.Fixed, f)
+		}
+	}
+	return diff
+}
+
+// trendOptions agrupa as flags do subcomando trend.
+type trendOptions struct {
+	Context      string
+	Namespace    string
+	Path         string
+	Last         int
+	From         int
+	To           int
+	OutputFormat string
+}
+
+// parseTrendArgs interpreta os argumentos do subcomando trend. From/To são
+// índices 1-based dos scans listados (0 = penúltimo e último).
+func parseTrendArgs(args []string) trendOptions {
+	opts := trendOptions{Last: defaultTrendRuns}
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			break
+		}
+		switch args[i] {
+		case "--context":
+			opts.Context = args[i+1]
+		case "-n", "--namespace":
+			opts.Namespace = args[i+1]
+		case "--path":
+			opts.Path = args[i+1]
+		case "--last":
+			opts.Last, _ = strconv.Atoi(args[i+1])
+		case "--from":
+			opts.From, _ = strconv.Atoi(args[i+1])
+		case "--to":
+			opts.To, _ = strconv.Atoi(args[i+1])
+		case "-o", "--output":
+			opts.OutputFormat = args[i+1]
+		default:
+			continue
+		}
+		i++
+	}
+	if opts.Context == "" {
+		opts.Context = currentClusterContext()
+	}
+	if opts.Last <= 0 {
+		opts.Last = defaultTrendRuns
+	}
+	return opts
+}
+
+// selectTrendRecords filtra os registros pelo escopo pedido. Sem namespace ou
+// path explícitos, usa o escopo do scan mais recente.
+func selectTrendRecords(records []HistoryRecord, opts trendOptions) []HistoryRecord {
+	if len(records) == 0 {
+		return nil
+	}
+	var scope string
+	if opts.Namespace == "" && opts.Path == "" {
+		scope = records[len(records)-1].scope()
+	}
+
+	var selected []HistoryRecord
+	for _, r := range records {
+		if scope != "" {
+			if r.scope() == scope {
+				selected = append(selected, r)
+			}
+			continue
+		}
+		if opts.Namespace != "" && r.Namespace != opts.Namespace {
+			continue
+		}
+		if opts.Path != "" && r.Path != opts.Path {
+			continue
+		}
+		selected = append(selected, r)
+	}
+	return selected
+}
+
+// runTrend exibe a evolução dos scans de um contexto.
+func runTrend(args []string) {
+	opts := parseTrendArgs(args)
+
+	records, err := loadHistory(opts.Context)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	records = selectTrendRecords(records, opts)
+	if len(records) == 0 {
+		fmt.Printf("Nenhum scan no historico do contexto '%s' (%s).\n", opts.Context, historyFile(opts.Context))
+		return
+	}
+
+	var diff *TrendDiff
+	if len(records) >= 2 {
+		fromIdx, toIdx := len(records)-2, len(records)-1
+		if opts.From > 0 {
+			fromIdx = opts.From - 1
+		}
+		if opts.To > 0 {
+			toIdx = opts.To - 1
+		}
+		if fromIdx < 0 || toIdx >= len(records) || fromIdx >= toIdx {
+			fmt.Printf("❌ Intervalo invalido: --from %d --to %d (scans disponiveis: 1-%d)\n", fromIdx+1, toIdx+1, len(records))
+			return
+		}
+		d := diffRecords(records[fromIdx], records[toIdx])
+		diff = &d
+	}
+
+	window := records
+	if len(window) > opts.Last {
+		window = window[len(window)-opts.Last:]
+	}
+
+	if opts.OutputFormat == "json" {
+		data, _ := json.MarshalIndent(map[string]interface{}{
+			"context": opts.Context,
+			"scans":   trendSummaries(window),
+			"diff":    diff,
+		}, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Print(renderTrend(opts.Context, window, len(records)-len(window), diff))
+}
+
+// trendSummary é a visão resumida de um scan na tabela de evolução.
+type trendSummary struct {
+	Timestamp      time.Time      `json:"timestamp"`
+	Findings       int            `json:"findings"`
+	Score          int            `json:"score"`
+	CategoryScores map[string]int `json:"category_scores"`
+}
+
+func trendSummaries(records []HistoryRecord) []trendSummary {
+	summaries := make([]trendSummary, 0, len(records))
+	for _, r := range records {
+		summaries = append(summaries, trendSummary{
+			Timestamp:      r.Timestamp,
+			Findings:       len(r.Findings),
+			Score:          r.Score,
+			CategoryScores: r.CategoryScores,
+		})
+	}
+	return summaries
+}
+
+// renderTrend gera a saída de terminal do trend: tabela de score por
+// categoria ao longo dos scans e o diff entre os dois scans comparados.
+// offset é a quantidade de scans anteriores omitidos da janela, para numeração.
+func renderTrend(clusterContext string, records []HistoryRecord, offset int, diff *TrendDiff) string {
+	var sb strings.Builder
+	first := records[0]
+	target := first.Namespace
+	if first.Path != "" {
+		target = first.Path
+	}
+	sb.WriteString(fmt.Sprintf("\nSentinel Trend — contexto %s, alvo %s\n\n", clusterContext, target))
+
+	categories := make(map[string]bool)
+	for _, r := range records {
+		for cat := range r.CategoryScores {
+			categories[cat] = true
+		}
+	}
+	catList := make([]string, 0, len(categories))
+	for cat := range categories {
+		catList = append(catList, cat)
+	}
+	sort.Strings(catList)
+
+	sb.WriteString(fmt.Sprintf("  %-4s %-17s %8s %6s", "#", "Data", "Findings", "Score"))
+	for _, cat := range catList {
+		sb.WriteString(fmt.Sprintf(" %13s", cat))
+	}
+	sb.WriteString("\n")
+	for i, r := range records {
+		sb.WriteString(fmt.Sprintf("  %-4d %-17s %8d %6d", offset+i+1, r.Timestamp.Local().Format("2006-01-02 15:04"), len(r.Findings), r.Score))
+		for _, cat := range catList {
+			sb.WriteString(fmt.Sprintf(" %13d", r.CategoryScores[cat]))
+		}
+		sb.WriteString("\n")
+	}
+
+	if diff == nil {
+		sb.WriteString("\nApenas um scan no historico; rode outro scan para comparar.\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("\nComparacao %s → %s\n", diff.From.Local().Format("2006-01-02 15:04"), diff.To.Local().Format("2006-01-02 15:04")))
+	sb.WriteString(fmt.Sprintf("  Novos: %d  Corrigidos: %d  Persistentes: %d\n", len(diff.New), len(diff.Fixed), len(diff.Persisting)))
+	writeTrendFindings(&sb, "Novos", "+", diff.New)
+	writeTrendFindings(&sb, "Corrigidos", "-", diff.Fixed)
+	return sb.String()
+}
+
+func writeTrendFindings(sb *strings.Builder, title, marker string, findings []checks.SecurityFinding) {
+	if len(findings) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n  %s:\n", title))
+	for _, f := range findings {
+		sb.WriteString(fmt.Sprintf("    %s [%s] %s %s/%s\n", marker, findingSeverity(f), f.CheckID, f.Namespace, f.Resource))
+	}
+}
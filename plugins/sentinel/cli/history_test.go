@@ -0,0 +1,140 @@
+//go:build k8s
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+)
+
+var historyNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func historyReport(findings ...checks.SecurityFinding) ScanReport {
+	return ScanReport{Namespace: "prod", Findings: findings}
+}
+
+func TestHistoryFile_SanitizaContexto(t *testing.T) {
+	got := historyFile("arn:aws:eks:us-east-1:123/prod")
+	if strings.ContainsAny(got[len(historyDir):], ":") || !strings.HasSuffix(got, ".jsonl") {
+		t.Errorf("caminho de histórico inválido: %s", got)
+	}
+}
+
+func TestAppendLoadHistory_RoundTrip(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	first := newHistoryRecord(historyReport(
+		checks.SecurityFinding{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Severity: checks.SeverityCritical, Category: checks.CategoryPodSecurity},
+	), "kind-dev", "", historyNow)
+	second := newHistoryRecord(historyReport(), "kind-dev", "", historyNow.Add(time.Hour))
+
+	// Grava fora de ordem para garantir a ordenação cronológica na leitura
+	if _, err := appendHistory(second); err != nil {
+		t.Fatal(err)
+	}
+	path, err := appendHistory(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Linha corrompida deve ser ignorada
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{corrompido\n")
+	f.Close()
+
+	records, err := loadHistory("kind-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("esperava 2 registros, obteve %d", len(records))
+	}
+	if !records[0].Timestamp.Equal(historyNow) {
+		t.Errorf("registros fora de ordem: %v", records[0].Timestamp)
+	}
+	if records[0].Score != 90 || records[0].CategoryScores["pod-security"] != 90 || records[0].CategoryScores["rbac"] != 100 {
+		t.Errorf("pontuações inesperadas: %d %v", records[0].Score, records[0].CategoryScores)
+	}
+
+	other, err := loadHistory("outro-contexto")
+	if err != nil || len(other) != 0 {
+		t.Errorf("contexto sem histórico deveria retornar vazio, obteve %v, %v", other, err)
+	}
+}
+
+func TestDiffRecords_ClassificaFindings(t *testing.T) {
+	persistente := checks.SecurityFinding{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app"}
+	corrigido := checks.SecurityFinding{CheckID: "POD_RESOURCE_LIMITS", Namespace: "prod", Resource: "api/app"}
+	novo := checks.SecurityFinding{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "worker/app"}
+
+	from := HistoryRecord{Timestamp: historyNow, Findings: []checks.SecurityFinding{persistente, corrigido}}
+	to := HistoryRecord{Timestamp: historyNow.Add(time.Hour), Findings: []checks.SecurityFinding{persistente, novo, novo}}
+
+	diff := diffRecords(from, to)
+	if len(diff.New) != 1 || diff.New[0].Resource != "worker/app" {
+		t.Errorf("novos inesperados: %v", diff.New)
+	}
+	if len(diff.Fixed) != 1 || diff.Fixed[0].CheckID != "POD_RESOURCE_LIMITS" {
+		t.Errorf("corrigidos inesperados: %v", diff.Fixed)
+	}
+	if len(diff.Persisting) != 1 || diff.Persisting[0].CheckID != "POD_PRIVILEGED" {
+		t.Errorf("persistentes inesperados: %v", diff.Persisting)
+	}
+}
+
+func TestSelectTrendRecords_UsaEscopoDoUltimoScan(t *testing.T) {
+	records := []HistoryRecord{
+		{Namespace: "prod"},
+		{Namespace: "staging"},
+		{Namespace: "prod", Profile: "cis-l1"},
+		{Namespace: "prod"},
+	}
+
+	got := selectTrendRecords(records, trendOptions{})
+	if len(got) != 2 {
+		t.Errorf("esperava 2 registros do escopo prod sem perfil, obteve %d", len(got))
+	}
+
+	got = selectTrendRecords(records, trendOptions{Namespace: "prod"})
+	if len(got) != 3 {
+		t.Errorf("esperava 3 registros de prod, obteve %d", len(got))
+	}
+}
+
+func TestParseTrendArgs(t *testing.T) {
+	opts := parseTrendArgs([]string{"--context", "kind-dev", "-n", "prod", "--from", "2", "--to", "5", "--last", "0", "-o", "json"})
+	if opts.Context != "kind-dev" || opts.Namespace != "prod" || opts.From != 2 || opts.To != 5 || opts.OutputFormat != "json" {
+		t.Errorf("opções inesperadas: %+v", opts)
+	}
+	if opts.Last != defaultTrendRuns {
+		t.Errorf("--last inválido deveria usar o padrão, obteve %d", opts.Last)
+	}
+}
+
+func TestRenderTrend(t *testing.T) {
+	records := []HistoryRecord{
+		{Timestamp: historyNow, Namespace: "prod", Score: 90, CategoryScores: map[string]int{"pod-security": 90}},
+		{Timestamp: historyNow.Add(time.Hour), Namespace: "prod", Score: 100, CategoryScores: map[string]int{"pod-security": 100}},
+	}
+	diff := TrendDiff{
+		From:  records[0].Timestamp,
+		To:    records[1].Timestamp,
+		Fixed: []checks.SecurityFinding{{CheckID: "POD_PRIVILEGED", Namespace: "prod", Resource: "api/app", Severity: checks.SeverityCritical}},
+	}
+
+	out := renderTrend("kind-dev", records, 3, &diff)
+	for _, want := range []string{"kind-dev", "pod-security", "Corrigidos: 1", "- [critical] POD_PRIVILEGED prod/api/app", "  4 "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("saída sem %q:\n%s", want, out)
+		}
+	}
+
+	single := renderTrend("kind-dev", records[:1], 0, nil)
+	if !strings.Contains(single, "Apenas um scan") {
+		t.Errorf("saída com um scan deveria avisar que não há comparação:\n%s", single)
+	}
+}
@@ -3,11 +3,37 @@
 package main
 
 import (
+	"context"
+
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
 	"github.com/casheiro/yby-cli/pkg/plugin/sdk"
 	"k8s.io/client-go/kubernetes"
 )
 
-// getKubeClient retorna o cliente Kubernetes via SDK do plugin.
-func getKubeClient() (kubernetes.Interface, error) {
-	return sdk.GetKubeClient()
+// getKubeClient e getMetricsClient são variáveis para que os testes
+// injetem clientsets fake no lugar do cluster real.
+var (
+	// getKubeClient retorna o cliente Kubernetes via SDK do plugin.
+	getKubeClient = func() (kubernetes.Interface, error) {
+		return sdk.GetKubeClient()
+	}
+
+	// getMetricsClient retorna o cliente da API metrics.k8s.io com a mesma
+	// configuração do cliente Kubernetes.
+	getMetricsClient = func() (*kubemetrics.Client, error) {
+		config, err := sdk.GetRESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		return kubemetrics.NewForConfig(config)
+	}
+)
+
+// fetchPodUsage lê o uso atual do pod no metrics-server.
+func fetchPodUsage(ctx context.Context, namespace, name string) (*kubemetrics.PodUsage, error) {
+	mc, err := getMetricsClient()
+	if err != nil {
+		return nil, err
+	}
+	return mc.PodUsage(ctx, namespace, name)
 }
@@ -5,22 +5,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/casheiro/yby-cli/pkg/ai"
 	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
 	"github.com/casheiro/yby-cli/pkg/plugin"
 	"github.com/casheiro/yby-cli/pkg/plugin/sdk"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/diagnosis"
 	"github.com/charmbracelet/lipgloss"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var execCommand = exec.Command
-
 func main() {
 	// Initialize SDK
 	if err := sdk.Init(); err != nil {
@@ -65,34 +66,97 @@ func printSentinelHelp() {
 	fmt.Println()
 	fmt.Println("Subcomandos:")
 	fmt.Println("  scan                  Escaneia vulnerabilidades de seguranca")
-	fmt.Println("  investigate <pod>     Investiga um pod com IA")
+	fmt.Println("  investigate <alvo>    Investiga um pod ou workload (deployment/, statefulset/, job/) com IA")
+	fmt.Println("  analyze --bundle <f>  Reanalisa offline a evidencia gravada com investigate --save-bundle")
+	fmt.Println("  baseline update       Gera/atualiza o baseline de findings aceitos")
+	fmt.Println("  policy test [dir]     Roda os testes Rego das politicas customizadas")
+	fmt.Println("  compliance            Relatorio de evidencias por controle de um perfil (CIS, PCI, SOC2)")
+	fmt.Println("  trend                 Compara scans do historico e mostra a evolucao do score")
+	fmt.Println("  watch                 Monitora o cluster e emite findings novos/resolvidos em tempo real")
 	fmt.Println()
 	fmt.Println("Flags (scan):")
 	fmt.Println("  -n, --namespace       Namespace a escanear (padrao: default)")
-	fmt.Println("  -o, --output          Formato de saida: terminal, json, markdown")
+	fmt.Println("  -o, --output          Formato de saida: terminal, json, markdown, sarif, junit")
 	fmt.Println("  -f, --file            Salvar resultado em arquivo")
 	fmt.Println("  -p, --profile         Perfil de compliance: cis-l1, cis-l2, pci-dss, soc2")
+	fmt.Println("  -A, --all-namespaces  Escaneia todos os namespaces do cluster")
+	fmt.Println("  --include-namespaces  Padroes glob de namespaces a incluir (ex: team-*,payments)")
+	fmt.Println("  --exclude-namespaces  Padroes glob de namespaces a excluir (ex: kube-*)")
+	fmt.Println("  -j, --concurrency     Backends/checks em paralelo (padrao: 4)")
+	fmt.Println("  --path                Escaneia manifests do projeto (Helm, Kustomize, YAML) sem cluster")
+	fmt.Println("  --fail-on             Sai com codigo 1 se houver findings com severidade >= valor")
+	fmt.Println("  --baseline            Arquivo de baseline (padrao: .yby/sentinel-baseline.yaml)")
+	fmt.Println("  --no-baseline         Ignora o baseline e reporta todos os findings")
+	fmt.Println("  --no-history          Nao registra o scan em .yby/sentinel/history")
 	fmt.Println("  --fix-dry-run         Mostrar patches de remediacao sem aplicar")
 	fmt.Println("  --fix                 Aplicar patches de remediacao")
+	fmt.Println("  --gitops              Com --fix/--fix-dry-run: corrige manifests/values do repositorio em uma branch")
+	fmt.Println("  --gitops-branch       Nome da branch de remediacao (padrao: sentinel/remediation-<data>)")
+	fmt.Println()
+	fmt.Println("Flags (baseline update): mesmas do scan, mais")
+	fmt.Println("  --owner               Responsavel pelas novas suppressoes")
+	fmt.Println("  --justification       Justificativa das novas suppressoes")
+	fmt.Println("  --expires             Validade das novas suppressoes (YYYY-MM-DD, padrao: +90 dias)")
+	fmt.Println()
+	fmt.Println("Flags (compliance): -n, -A, --path e seletores do scan, mais")
+	fmt.Println("  -p, --profile         Perfil obrigatorio: cis-l1, cis-l2, pci-dss, soc2")
+	fmt.Println("  -o, --output          Formato: markdown (padrao), html, json")
+	fmt.Println("  -f, --file            Arquivo de saida (padrao: ~/.yby/reports/sentinel-compliance-<perfil>-<data>)")
+	fmt.Println()
+	fmt.Println("Flags (trend):")
+	fmt.Println("  --context             Contexto do cluster (padrao: contexto atual; 'offline' para --path)")
+	fmt.Println("  -n, --namespace       Filtra scans do namespace (padrao: alvo do ultimo scan)")
+	fmt.Println("  --path                Filtra scans offline do diretorio")
+	fmt.Println("  --from, --to          Numeros dos scans a comparar (padrao: penultimo e ultimo)")
+	fmt.Println("  --last                Quantidade de scans na tabela de evolucao (padrao: 10)")
+	fmt.Println("  -o, --output          Formato de saida: terminal, json")
+	fmt.Println()
+	fmt.Println("Flags (watch): -n, -A, --include-namespaces, --exclude-namespaces, -p, --baseline e --no-baseline do scan, mais")
+	fmt.Println("  --webhook             URL que recebe cada evento via POST (padrao: JSON lines no stdout)")
+	fmt.Println("  --min-severity        Emite apenas findings com severidade >= valor")
+	fmt.Println("  --debounce            Janela para agrupar mudancas proximas (padrao: 2s)")
 	fmt.Println()
 	fmt.Println("Flags (investigate):")
-	fmt.Println("  -n, --namespace       Namespace do pod (padrao: default)")
+	fmt.Println("  -n, --namespace       Namespace do pod/workload (padrao: default)")
 	fmt.Println("  --no-cache            Ignorar cache de analises anteriores")
+	fmt.Println("  --rules               Arquivo de regras de diagnostico customizadas (padrao: .yby/sentinel-rules.yaml)")
+	fmt.Println("  --no-rules            Desativar o motor de regras e analisar direto com IA")
+	fmt.Println("  --save-bundle         Grava a evidencia bruta (pod, owners, logs, eventos, uso, prompt) em um tar.gz")
+	fmt.Println()
+	fmt.Println("Flags (analyze):")
+	fmt.Println("  -b, --bundle          Bundle gravado por investigate --save-bundle")
+	fmt.Println("  --provider            Provider de IA da reanalise (padrao: auto)")
+	fmt.Println("  --prompt-file         System prompt alternativo (padrao: versao atual do prompt da coleta)")
+	fmt.Println("  -o, --output / -f     Formato e arquivo de saida, como no investigate")
 	fmt.Println()
 	fmt.Println("Exemplos:")
 	fmt.Println("  yby sentinel scan -n default")
 	fmt.Println("  yby sentinel scan -n production --profile cis-l1")
 	fmt.Println("  yby sentinel scan -n default --fix-dry-run")
+	fmt.Println("  yby sentinel scan --path . --fix --gitops")
+	fmt.Println("  yby sentinel scan -A --exclude-namespaces 'kube-*' -j 8")
+	fmt.Println("  yby sentinel scan --path ./charts --fail-on high")
+	fmt.Println("  yby sentinel scan -n default -o sarif -f sentinel.sarif --fail-on high")
+	fmt.Println("  yby sentinel compliance --profile soc2 -n production -o html -f soc2.html")
+	fmt.Println("  yby sentinel trend -n production")
+	fmt.Println("  yby sentinel watch -A --exclude-namespaces 'kube-*' --webhook http://localhost:9000/sentinel")
 	fmt.Println("  yby sentinel investigate meu-pod -n default")
+	fmt.Println("  yby sentinel investigate meu-pod -n default --rules ./sentinel-rules.yaml")
+	fmt.Println("  yby sentinel investigate deployment/api -n production")
+	fmt.Println("  yby sentinel investigate meu-pod -n default --save-bundle incidente.tar.gz")
+	fmt.Println("  yby sentinel analyze --bundle incidente.tar.gz --provider openai")
 }
 
-// AnalysisResult define a estrutura esperada da resposta da IA
+// AnalysisResult define a estrutura esperada da resposta da IA (ou do diagnóstico por regra)
 type AnalysisResult struct {
 	RootCause       string  `json:"root_cause"`
 	TechnicalDetail string  `json:"technical_detail"`
 	Confidence      int     `json:"confidence"`
 	SuggestedFix    string  `json:"suggested_fix"`
 	KubectlPatch    *string `json:"kubectl_patch"`
+	// RuleID identifica a regra determinística que produziu o diagnóstico
+	// (vazio quando a análise veio da IA).
+	RuleID string `json:"rule_id,omitempty"`
 }
 
 func handlePluginRequest() {
@@ -122,9 +186,10 @@ func handlePluginRequest() {
 		switch args[0] {
 		case "investigate":
 			// Expect "yby sentinel investigate [pod-name] [flags]"
-			// Flags: -n/--namespace, -o/--output, -f/--file, --no-cache
-			var podName, namespace, outputFormat, outputFile string
+			// Flags: -n/--namespace, -o/--output, -f/--file, --no-cache, --rules, --no-rules, --save-bundle
+			var podName, namespace, outputFormat, outputFile, saveBundle string
 			var noCache bool
+			rulesFile := diagnosis.DefaultRulesFile
 			remainingArgs := args[1:]
 
 			for i := 0; i < len(remainingArgs); i++ {
@@ -163,6 +228,30 @@ func handlePluginRequest() {
 					continue
 				}
 
+				// Arquivo de regras de diagnóstico customizadas
+				if arg == "--rules" {
+					if i+1 < len(remainingArgs) {
+						rulesFile = remainingArgs[i+1]
+						i++
+					}
+					continue
+				}
+
+				// Desabilita o motor de regras e envia direto para a IA
+				if arg == "--no-rules" {
+					rulesFile = ""
+					continue
+				}
+
+				// Grava a evidência coletada em um bundle tar.gz
+				if arg == "--save-bundle" {
+					if i+1 < len(remainingArgs) {
+						saveBundle = remainingArgs[i+1]
+						i++
+					}
+					continue
+				}
+
 				// Se não é flag e podName ainda está vazio, deve ser o nome do pod
 				if !strings.HasPrefix(arg, "-") && podName == "" {
 					podName = arg
@@ -187,63 +276,91 @@ func handlePluginRequest() {
 			}
 
 			if podName == "" {
-				fmt.Println("❌ Nome do Pod é obrigatório. Uso: yby sentinel investigate <pod> [-n namespace]")
+				fmt.Println("❌ Nome do Pod é obrigatório. Uso: yby sentinel investigate <pod|deployment/nome|statefulset/nome|job/nome> [-n namespace]")
 				return
 			}
 
-			investigate(podName, namespace, outputFormat, outputFile, noCache)
+			target, err := parseInvestigateTarget(podName)
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+			opts := investigateOptions{
+				Namespace:    namespace,
+				OutputFormat: outputFormat,
+				OutputFile:   outputFile,
+				RulesFile:    rulesFile,
+				SaveBundle:   saveBundle,
+				NoCache:      noCache,
+			}
+			if target.Kind != "Pod" {
+				investigateWorkload(target, opts)
+				return
+			}
+
+			investigate(target.Name, opts)
+
+		case "analyze":
+			// Expect "yby sentinel analyze --bundle out.tar.gz [--provider nome] [--prompt-file arquivo]"
+			if !runAnalyze(args[1:]) {
+				os.Exit(1)
+			}
 
 		case "scan":
-			// Expect "yby sentinel scan [-n namespace] [-o format] [-f file] [--profile name] [--fix] [--fix-dry-run]"
-			var namespace, outputFormat, outputFile, profile string
-			var fix, fixDryRun bool
-			remainingArgs := args[1:]
+			// Expect "yby sentinel scan [-n namespace] [-o format] [-f file] [--profile name] [--fix] [--fix-dry-run] [--fail-on severity] [--path dir]"
+			opts := parseScanArgs(args[1:])
 
-			for i := 0; i < len(remainingArgs); i++ {
-				arg := remainingArgs[i]
-				if arg == "-n" || arg == "--namespace" {
-					if i+1 < len(remainingArgs) {
-						namespace = remainingArgs[i+1]
-						i++
-					}
-					continue
-				}
-				if arg == "--output" || arg == "-o" {
-					if i+1 < len(remainingArgs) {
-						outputFormat = remainingArgs[i+1]
-						i++
-					}
-					continue
-				}
-				if arg == "--file" || arg == "-f" {
-					if i+1 < len(remainingArgs) {
-						outputFile = remainingArgs[i+1]
-						i++
-					}
-					continue
-				}
-				if arg == "--profile" || arg == "-p" {
-					if i+1 < len(remainingArgs) {
-						profile = remainingArgs[i+1]
-						i++
-					}
-					continue
-				}
-				if arg == "--fix" {
-					fix = true
-					continue
+			if opts.FailOn != "" && severityRank(checks.Severity(opts.FailOn)) < 0 {
+				fmt.Printf("❌ Severidade invalida para --fail-on: %s (use critical, high, medium, low ou info)\n", opts.FailOn)
+				os.Exit(2)
+			}
+
+			report := scanNamespace(opts)
+
+			if opts.FailOn != "" {
+				if report == nil {
+					fmt.Fprintln(os.Stderr, "scan nao concluido; reprovando por --fail-on")
+					os.Exit(1)
 				}
-				if arg == "--fix-dry-run" {
-					fixDryRun = true
-					continue
+				if exceedsThreshold(report.Findings, checks.Severity(opts.FailOn)) {
+					fmt.Fprintf(os.Stderr, "findings com severidade >= %s encontrados; reprovando scan\n", opts.FailOn)
+					os.Exit(1)
 				}
 			}
 
-			if namespace == "" {
-				namespace = "default"
+		case "baseline":
+			// Expect "yby sentinel baseline update [flags de scan] [--owner x] [--justification y] [--expires YYYY-MM-DD]"
+			if len(args) < 2 || args[1] != "update" {
+				fmt.Println("Uso: yby sentinel baseline update [-n namespace] [--path dir] [--owner nome] [--justification texto] [--expires YYYY-MM-DD]")
+				return
 			}
+			runBaselineUpdate(args[2:])
 
-			scanNamespace(namespace, outputFormat, outputFile, profile, fix, fixDryRun)
+		case "policy":
+			// Expect "yby sentinel policy test [dir]"
+			if len(args) < 2 || args[1] != "test" {
+				fmt.Println("Uso: yby sentinel policy test [diretorio] (padrao: .yby/policies)")
+				return
+			}
+			if !runPolicyTest(args[2:]) {
+				os.Exit(1)
+			}
+
+		case "compliance":
+			// Expect "yby sentinel compliance --profile soc2 [-n ns | -A | --path dir] [-o html|markdown|json]"
+			if !runCompliance(args[1:]) {
+				os.Exit(1)
+			}
+
+		case "trend":
+			// Expect "yby sentinel trend [--context x] [-n ns] [--from N --to M]"
+			runTrend(args[1:])
+
+		case "watch":
+			// Expect "yby sentinel watch [-n ns | -A] [--webhook url] [--min-severity high]"
+			if !runWatch(args[1:]) {
+				os.Exit(1)
+			}
 
 		default:
 			fmt.Printf("Subcomando desconhecido: %s\n\n", args[0])
@@ -260,11 +377,11 @@ func handlePluginRequest() {
 	}
 }
 
-func investigate(podName, namespace, outputFormat, outputFile string, noCache bool) {
-	// Configuração de Estilo
-	width := 80 // Largura confortável para leitura
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true).Padding(0, 1)
-	boxStyle := lipgloss.NewStyle().
+// investigateStyles retorna a largura e os estilos usados na saída do investigate.
+func investigateStyles() (width int, titleStyle, boxStyle, labelStyle lipgloss.Style) {
+	width = 80 // Largura confortável para leitura
+	titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true).Padding(0, 1)
+	boxStyle = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		Padding(0, 1).
 		BorderForeground(lipgloss.Color("63")).
@@ -272,11 +389,44 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 
 	// Estilos de texto internos também precisam respeitar ou serem menores,
 	// mas o box com Width já deve forçar o wrap do conteúdo string.
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Width(width - 4) // width - padding/border
+	labelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Width(width - 4) // width - padding/border
+	return width, titleStyle, boxStyle, labelStyle
+}
+
+// investigateOptions são as flags de `sentinel investigate`.
+type investigateOptions struct {
+	Namespace    string
+	OutputFormat string
+	OutputFile   string
+	// RulesFile é o arquivo de regras customizadas; vazio desabilita o motor de regras.
+	RulesFile string
+	// SaveBundle é o caminho do bundle tar.gz com a evidência coletada.
+	SaveBundle string
+	NoCache    bool
+}
+
+// saveEvidence grava o bundle de evidência quando --save-bundle foi informado.
+func saveEvidence(opts investigateOptions, evidence *InvestigationBundle) {
+	if opts.SaveBundle == "" {
+		return
+	}
+	if err := writeBundle(opts.SaveBundle, evidence); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("📦 Evidencia salva em %s (reanalise com: yby sentinel analyze --bundle %s)\n", opts.SaveBundle, opts.SaveBundle)
+}
+
+// investigate diagnostica um pod. Falhas com assinatura conhecida são
+// resolvidas pelo motor de regras; a IA só é consultada quando nenhuma regra
+// casa.
+func investigate(podName string, opts investigateOptions) {
+	namespace := opts.Namespace
+	width, titleStyle, boxStyle, labelStyle := investigateStyles()
 
 	fmt.Println(titleStyle.Render(fmt.Sprintf("\n🛡️  Sentinel Investigation: %s/%s", namespace, podName)))
 
-	k8sClient, err := sdk.GetKubeClient()
+	k8sClient, err := getKubeClient()
 	if err != nil {
 		fmt.Printf("⚠️  Falha ao obter cliente Kubernetes: %v\n", err)
 		return
@@ -284,6 +434,10 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 
 	ctx := context.Background()
 
+	// A evidência é gravada ao final, qualquer que seja o desfecho
+	evidence := newInvestigationBundle("Pod", podName, namespace, "sentinel.investigate")
+	defer saveEvidence(opts, evidence)
+
 	// 1. Get Pod Logs via client-go
 	fmt.Print("🔍 Coletando logs...")
 	tailLines := int64(50)
@@ -299,12 +453,14 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 		logsStr = string(podLogs)
 		fmt.Println("\r✅ Logs coletados")
 	}
+	evidence.Logs = logsStr
 
-	// Verificar cache antes de continuar a coleta
-	if !noCache {
+	// Verificar cache antes de continuar a coleta. Com --save-bundle a coleta
+	// segue completa para que o bundle tenha toda a evidência.
+	if !opts.NoCache && opts.SaveBundle == "" {
 		if cached, ok := loadCache(namespace, podName, logsStr); ok {
 			fmt.Println("\n📦 Resultado do cache (use --no-cache para forçar re-análise)")
-			renderResult(*cached, podName, namespace, outputFormat, outputFile, width, titleStyle, boxStyle, labelStyle)
+			renderResult(*cached, podName, namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
 			return
 		}
 	}
@@ -314,54 +470,36 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 	events, err := k8sClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("involvedObject.name=%s", podName),
 	})
-
-	eventsStr := "[]"
 	if err != nil {
 		fmt.Printf("\r⚠️  Falha ao obter eventos (%v). Continuando...\n", err)
 	} else {
-		// Serialize events to JSON for AI
-		eventsBytes, _ := json.Marshal(events.Items)
-		eventsStr = string(eventsBytes)
+		evidence.Events = events.Items
 		fmt.Println("\r✅ Eventos coletados")
 	}
 
-	// 3. Get Metrics (CPU/RAM)
-	// Client-go core doesn't have Metrics. API call directly or fallback to kubectl top?
-	// Given we want to avoid shelling out if possible, but metrics client is separate.
-	// For now, let's skip metrics strictly via client-go to save time on setting up metrics client deps,
-	// or try to shell out BUT ensuring we use the context.
-	// SDK gives us KubeConfig/Context.
-
+	// 3. Uso de CPU/memória vs requests e limits (metrics.k8s.io)
 	fmt.Print("🔍 Coletando métricas...")
-
-	metricsStr := "Metrics unavailable (client-go metrics not implemented yet)"
-
-	// Fallback to kubectl top ONLY if we have context info to pass
-	fullCtx := sdk.GetFullContext()
-	if fullCtx != nil {
-		args := []string{"top", "pod", podName, "-n", namespace, "--no-headers"}
-		if fullCtx.Infra.KubeConfig != "" {
-			args = append(args, "--kubeconfig", fullCtx.Infra.KubeConfig)
-		}
-		if fullCtx.Infra.KubeContext != "" {
-			args = append(args, "--context", fullCtx.Infra.KubeContext)
+	pod, podErr := k8sClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if podErr == nil {
+		usage, err := fetchPodUsage(ctx, namespace, podName)
+		if err != nil {
+			fmt.Printf("\r⚠️  Métricas de uso indisponíveis (%v)\n", err)
+		} else {
+			fmt.Println("\r✅ Métricas coletadas")
 		}
+		// Sem métricas, a tabela ainda traz requests e limits
+		evidence.Usage = kubemetrics.CompareUsage(pod, usage)
 
-		// We re-enable exec just for this fallback
-		cmdMetrics := execCommand("kubectl", args...)
-		out, err := cmdMetrics.CombinedOutput()
-		if err == nil {
-			metricsStr = string(out)
-			fmt.Println("\r✅ Métricas coletadas (via kubectl)")
-		} else {
-			fmt.Println("\r⚠️  Métricas indisponíveis")
+		if opts.SaveBundle != "" {
+			evidence.Pod = pod.DeepCopy()
+			evidence.Pod.ManagedFields = nil
+			evidence.Owners = collectOwnerChain(ctx, k8sClient, namespace, pod.OwnerReferences)
 		}
 	} else {
-		fmt.Println("\r⚠️  Métricas indisponíveis (sem contexto)")
+		fmt.Printf("\r⚠️  Métricas indisponíveis (%v)\n", podErr)
 	}
 
 	// Verificar se o pod tem sinais de problema antes de enviar pra IA
-	pod, podErr := k8sClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if podErr == nil {
 		healthy := isPodHealthy(pod, events)
 		if healthy {
@@ -374,8 +512,20 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 		}
 	}
 
+	// Regras determinísticas antes da IA: assinaturas conhecidas não custam
+	// chamada ao provider e têm resultado reprodutível
+	if podErr == nil && opts.RulesFile != "" {
+		engine := loadDiagnosisEngine(opts.RulesFile)
+		if result, ok := diagnosePod(ctx, k8sClient, engine, pod, events, logsStr); ok {
+			evidence.Analysis = &result
+			fmt.Printf("\n📐 Diagnóstico pela regra %s (sem IA)\n", result.RuleID)
+			renderResult(result, podName, namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+			return
+		}
+	}
+
 	// Construct Context for AI
-	realContext := fmt.Sprintf("LOGS:\n%s\n\nEVENTS (JSON):\n%s\n\nMETRICS:\n%s", logsStr, eventsStr, metricsStr)
+	realContext := podAIContext(logsStr, evidence.Events, evidence.Usage)
 
 	if len(strings.TrimSpace(realContext)) < 20 {
 		fmt.Println("Dados insuficientes (logs/eventos) coletados para analise.")
@@ -390,28 +540,55 @@ func investigate(podName, namespace, outputFormat, outputFile string, noCache bo
 		return
 	}
 
-	analysisJSON, err := provider.Completion(ctx, prompts.Get("sentinel.investigate"), realContext)
-	if err != nil {
-		fmt.Printf("Erro na chamada da IA: %v\n", err)
-		return
-	}
-
-	// Parse JSON output
-	var result AnalysisResult
-	// Tentar limpar blocos de código markdown se houver (```json ... ```)
-	analysisClean := strings.ReplaceAll(analysisJSON, "```json", "")
-	analysisClean = strings.ReplaceAll(analysisClean, "```", "")
+	systemPrompt := prompts.Get("sentinel.investigate")
+	evidence.Prompt = systemPrompt
+	evidence.Manifest.Provider = provider.Name()
 
-	if err := json.Unmarshal([]byte(analysisClean), &result); err != nil {
-		fmt.Printf("⚠️  Erro ao parsear resposta da IA: %v\nConteúdo bruto:\n%s\n", err, analysisJSON)
+	result, err := requestAnalysis(ctx, provider, systemPrompt, realContext)
+	if err != nil {
+		printAnalysisError(err)
 		return
 	}
+	evidence.Analysis = &result
 
 	// Salvar no cache
 	saveCache(namespace, podName, logsStr, result)
 
 	// Renderizar resultado (visual ou exportar)
-	renderResult(result, podName, namespace, outputFormat, outputFile, width, titleStyle, boxStyle, labelStyle)
+	renderResult(result, podName, namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+}
+
+// requestAnalysis pede a análise à IA em modo JSON estruturado. Respostas
+// fora do schema de AnalysisResult são devolvidas ao modelo para correção.
+func requestAnalysis(ctx context.Context, provider ai.Provider, systemPrompt, input string) (AnalysisResult, error) {
+	var result AnalysisResult
+	err := ai.CompleteJSON(ctx, provider, systemPrompt, input, &result, ai.JSONOptions{
+		Validate: func() error { return validateAnalysis(result) },
+	})
+	// rule_id é exclusivo do motor de regras
+	result.RuleID = ""
+	return result, err
+}
+
+// validateAnalysis aplica as regras de AnalysisResult que o schema não expressa.
+func validateAnalysis(result AnalysisResult) error {
+	if strings.TrimSpace(result.RootCause) == "" {
+		return fmt.Errorf("root_cause nao pode ser vazio")
+	}
+	if result.Confidence < 0 || result.Confidence > 100 {
+		return fmt.Errorf("confidence deve estar entre 0 e 100, obteve %d", result.Confidence)
+	}
+	return nil
+}
+
+// printAnalysisError exibe a falha da análise com a resposta bruta da IA, quando houver.
+func printAnalysisError(err error) {
+	var invalid *ai.JSONValidationError
+	if errors.As(err, &invalid) {
+		fmt.Printf("⚠️  Erro ao parsear resposta da IA: %v\nConteúdo bruto:\n%s\n", invalid.Err, invalid.Raw)
+		return
+	}
+	fmt.Printf("Erro na chamada da IA: %v\n", err)
 }
 
 // renderResult lida com a renderização visual ou exportação do resultado da análise.
@@ -500,6 +677,11 @@ func renderResult(result AnalysisResult, podName, namespace, outputFormat, outpu
 	if result.Confidence < 50 {
 		confidenceColor = "196" // Red
 	}
+	source := "IA"
+	if result.RuleID != "" {
+		source = "regra " + result.RuleID
+	}
+	sb.WriteString(fmt.Sprintf("Fonte: %s\n", source))
 	sb.WriteString(fmt.Sprintf("Confiança: %s%%\n", lipgloss.NewStyle().Foreground(lipgloss.Color(confidenceColor)).Render(fmt.Sprintf("%d", result.Confidence))))
 
 	sb.WriteString(lipgloss.NewStyle().Bold(true).Render("\n💡 Sugestão de Correção:") + "\n")
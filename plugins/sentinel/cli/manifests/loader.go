@@ -0,0 +1,318 @@
+//go:build k8s
+
+// Package manifests renderiza charts Helm, overlays Kustomize e YAML puro do
+// projeto em objetos Kubernetes, permitindo rodar o scan do Sentinel offline.
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// execCommand permite substituir a execução de helm/kubectl nos testes.
+var execCommand = exec.Command
+
+// SourceAnnotation marca os pods sintetizados com o workload de origem.
+const SourceAnnotation = "sentinel.yby.dev/source"
+
+// SourceKind identifica como uma fonte de manifests é renderizada.
+type SourceKind string
+
+const (
+	SourceHelm      SourceKind = "helm"
+	SourceKustomize SourceKind = "kustomize"
+	SourceYAML      SourceKind = "yaml"
+)
+
+// Source representa um chart, overlay ou arquivo YAML descoberto no projeto.
+type Source struct {
+	Kind SourceKind
+	Path string
+}
+
+// Result contém os objetos carregados e as fontes que os originaram.
+type Result struct {
+	Objects  []runtime.Object
+	Sources  []Source
+	Warnings []string
+}
+
+// clusterScopedKinds lista os kinds que não recebem namespace padrão.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"PersistentVolume":         true,
+	"StorageClass":             true,
+	"PriorityClass":            true,
+	"CustomResourceDefinition": true,
+}
+
+// Discover percorre root e identifica charts Helm (Chart.yaml), overlays
+// Kustomize (kustomization.yaml) e arquivos YAML soltos. Diretórios de um
+// chart ou overlay não são percorridos novamente.
+func Discover(root string) ([]Source, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao acessar %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		return []Source{{Kind: SourceYAML, Path: root}}, nil
+	}
+
+	var sources []Source
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if fileExists(filepath.Join(path, "Chart.yaml")) {
+				sources = append(sources, Source{Kind: SourceHelm, Path: path})
+				return filepath.SkipDir
+			}
+			for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+				if fileExists(filepath.Join(path, name)) {
+					sources = append(sources, Source{Kind: SourceKustomize, Path: path})
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext == ".yaml" || ext == ".yml" {
+			sources = append(sources, Source{Kind: SourceYAML, Path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao percorrer %s: %w", root, err)
+	}
+	return sources, nil
+}
+
+// Render retorna o YAML multi-documento produzido por uma fonte.
+func Render(src Source) ([]byte, error) {
+	switch src.Kind {
+	case SourceHelm:
+		release := filepath.Base(src.Path)
+		out, err := execCommand("helm", "template", release, src.Path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("helm template %s falhou: %w", src.Path, err)
+		}
+		return out, nil
+	case SourceKustomize:
+		out, err := execCommand("kubectl", "kustomize", src.Path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("kubectl kustomize %s falhou: %w", src.Path, err)
+		}
+		return out, nil
+	default:
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler %s: %w", src.Path, err)
+		}
+		return data, nil
+	}
+}
+
+// Decode converte YAML multi-documento em objetos tipados. Documentos sem
+// apiVersion/kind (ex: values.yaml) e kinds desconhecidos (CRDs) são ignorados.
+func Decode(data []byte) ([]runtime.Object, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	decoder := scheme.Codecs.UniversalDeserializer()
+
+	var objects []runtime.Object
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return objects, fmt.Errorf("falha ao ler documento YAML: %w", err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc, &meta); err != nil || meta.Kind == "" || meta.APIVersion == "" {
+			continue
+		}
+
+		if meta.Kind == "List" {
+			var list corev1.List
+			if err := yaml.Unmarshal(doc, &list); err != nil {
+				continue
+			}
+			for _, item := range list.Items {
+				obj, _, err := decoder.Decode(item.Raw, nil, nil)
+				if err == nil {
+					objects = append(objects, obj)
+				}
+			}
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// Load descobre, renderiza e decodifica todos os manifests sob root.
+// Falhas de renderização de uma fonte viram avisos e não abortam o carregamento.
+func Load(root string) (*Result, error) {
+	sources, err := Discover(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, src := range sources {
+		data, err := Render(src)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		objs, err := Decode(data)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", src.Path, err))
+		}
+		if len(objs) == 0 {
+			continue
+		}
+		result.Objects = append(result.Objects, objs...)
+		result.Sources = append(result.Sources, src)
+	}
+	return result, nil
+}
+
+// NewClient carrega os objetos em um clientset em memória. Objetos namespaced
+// sem namespace recebem defaultNamespace, e workloads (Deployment, StatefulSet,
+// DaemonSet, ReplicaSet, Job, CronJob) geram um Pod equivalente ao seu template
+// para que os checks de pod security os avaliem. Retorna também os namespaces
+// presentes nos objetos, ordenados.
+func NewClient(objects []runtime.Object, defaultNamespace string) (kubernetes.Interface, []string) {
+	nsSet := make(map[string]bool)
+	seen := make(map[string]bool)
+	var all []runtime.Object
+
+	for _, obj := range objects {
+		accessor, err := metaAccessor(obj)
+		if err != nil {
+			continue
+		}
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if !clusterScopedKinds[gvk.Kind] {
+			if accessor.GetNamespace() == "" {
+				accessor.SetNamespace(defaultNamespace)
+			}
+			nsSet[accessor.GetNamespace()] = true
+		}
+
+		// O clientset em memória rejeita objetos duplicados (mesmo chart em
+		// overlays diferentes); mantém a primeira ocorrência.
+		key := fmt.Sprintf("%s|%s|%s", gvk.String(), accessor.GetNamespace(), accessor.GetName())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		all = append(all, obj)
+
+		if pod := podFromWorkload(obj); pod != nil {
+			podKey := fmt.Sprintf("Pod|%s|%s", pod.Namespace, pod.Name)
+			if !seen[podKey] {
+				seen[podKey] = true
+				all = append(all, pod)
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(nsSet))
+	for ns := range nsSet {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	return fake.NewSimpleClientset(all...), namespaces
+}
+
+// podFromWorkload sintetiza um Pod a partir do template de um workload.
+func podFromWorkload(obj runtime.Object) *corev1.Pod {
+	var (
+		meta     metav1.ObjectMeta
+		template corev1.PodTemplateSpec
+		kind     string
+	)
+
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		meta, template, kind = w.ObjectMeta, w.Spec.Template, "Deployment"
+	case *appsv1.StatefulSet:
+		meta, template, kind = w.ObjectMeta, w.Spec.Template, "StatefulSet"
+	case *appsv1.DaemonSet:
+		meta, template, kind = w.ObjectMeta, w.Spec.Template, "DaemonSet"
+	case *appsv1.ReplicaSet:
+		meta, template, kind = w.ObjectMeta, w.Spec.Template, "ReplicaSet"
+	case *batchv1.Job:
+		meta, template, kind = w.ObjectMeta, w.Spec.Template, "Job"
+	case *batchv1.CronJob:
+		meta, template, kind = w.ObjectMeta, w.Spec.JobTemplate.Spec.Template, "CronJob"
+	default:
+		return nil
+	}
+
+	annotations := make(map[string]string, len(template.Annotations)+1)
+	for k, v := range template.Annotations {
+		annotations[k] = v
+	}
+	annotations[SourceAnnotation] = fmt.Sprintf("%s/%s", kind, meta.Name)
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        meta.Name,
+			Namespace:   meta.Namespace,
+			Labels:      template.Labels,
+			Annotations: annotations,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+}
+
+func metaAccessor(obj runtime.Object) (metav1.Object, error) {
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("objeto %T não possui metadata", obj)
+	}
+	return accessor, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
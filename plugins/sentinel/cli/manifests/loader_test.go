@@ -0,0 +1,161 @@
+//go:build k8s
+
+package manifests
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const deploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: app
+          image: nginx:latest
+          securityContext:
+            privileged: true
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: everything
+rules:
+  - apiGroups: ["*"]
+    resources: ["*"]
+    verbs: ["*"]
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover_IdentificaFontes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "charts", "api", "Chart.yaml"), "name: api\n")
+	writeFile(t, filepath.Join(root, "charts", "api", "templates", "deploy.yaml"), "kind: Deployment\n")
+	writeFile(t, filepath.Join(root, "overlays", "prod", "kustomization.yaml"), "resources: []\n")
+	writeFile(t, filepath.Join(root, "raw", "deploy.yaml"), deploymentYAML)
+	writeFile(t, filepath.Join(root, ".git", "config.yaml"), "x: y\n")
+
+	sources, err := Discover(root)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	kinds := make(map[SourceKind]int)
+	for _, s := range sources {
+		kinds[s.Kind]++
+	}
+	if kinds[SourceHelm] != 1 || kinds[SourceKustomize] != 1 || kinds[SourceYAML] != 1 {
+		t.Errorf("fontes inesperadas: %+v", sources)
+	}
+}
+
+func TestDecode_IgnoraDocumentosSemKind(t *testing.T) {
+	data := []byte(deploymentYAML + "---\nreplicaCount: 3\n---\napiVersion: example.com/v1\nkind: Custom\nmetadata:\n  name: x\n")
+
+	objs, err := Decode(data)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("esperava 2 objetos (Deployment e ClusterRole), obteve %d", len(objs))
+	}
+}
+
+func TestLoad_RenderizaHelmViaComando(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "Chart.yaml"), "name: api\n")
+	rendered := filepath.Join(t.TempDir(), "rendered.yaml")
+	writeFile(t, rendered, deploymentYAML)
+
+	var called []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		called = append([]string{name}, args...)
+		return exec.Command("cat", rendered)
+	}
+	defer func() { execCommand = exec.Command }()
+
+	result, err := Load(root)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(called) < 2 || called[0] != "helm" || called[1] != "template" {
+		t.Errorf("esperava chamada a helm template, obteve %v", called)
+	}
+	if len(result.Objects) != 2 {
+		t.Errorf("esperava 2 objetos renderizados, obteve %d", len(result.Objects))
+	}
+}
+
+func TestLoad_FalhaDeRenderViraAviso(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "overlay", "kustomization.yaml"), "resources: []\n")
+
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	result, err := Load(root)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("esperava 1 aviso, obteve %v", result.Warnings)
+	}
+}
+
+func TestNewClient_SintetizaPodsENamespace(t *testing.T) {
+	objs, err := Decode([]byte(deploymentYAML + deploymentYAML))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	client, namespaces := NewClient(objs, "apps")
+	if len(namespaces) != 1 || namespaces[0] != "apps" {
+		t.Errorf("esperava namespace [apps], obteve %v", namespaces)
+	}
+
+	ctx := context.Background()
+	pods, err := client.CoreV1().Pods("apps").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(pods.Items) != 1 {
+		t.Fatalf("esperava 1 pod sintetizado, obteve %d", len(pods.Items))
+	}
+	if pods.Items[0].Annotations[SourceAnnotation] != "Deployment/api" {
+		t.Errorf("anotação de origem inesperada: %v", pods.Items[0].Annotations)
+	}
+
+	roles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(roles.Items) != 1 {
+		t.Errorf("esperava 1 ClusterRole, obteve %d", len(roles.Items))
+	}
+}
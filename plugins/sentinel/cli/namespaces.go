@@ -0,0 +1,254 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/backends"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// allNamespacesLabel identifica o relatório de um scan de cluster inteiro.
+	allNamespacesLabel = "all"
+	// clusterScopeLabel agrupa findings de recursos sem namespace (ClusterRoles, bindings).
+	clusterScopeLabel = "(cluster)"
+	// defaultScanConcurrency é o limite padrão de backends/checks executando em paralelo.
+	defaultScanConcurrency = 4
+)
+
+// namespaceSelector filtra namespaces por padrões glob de inclusão e exclusão.
+type namespaceSelector struct {
+	Include []string
+	Exclude []string
+}
+
+// matches indica se o namespace passa pelo seletor. Sem padrões de inclusão,
+// todos os namespaces são incluídos; exclusões sempre prevalecem.
+func (s namespaceSelector) matches(name string) bool {
+	for _, pattern := range s.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, pattern := range s.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filter retorna os namespaces que passam pelo seletor, preservando a ordem.
+func (s namespaceSelector) filter(names []string) []string {
+	var result []string
+	for _, n := range names {
+		if s.matches(n) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// parsePatternList divide uma lista separada por vírgulas em padrões.
+func parsePatternList(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// listClusterNamespaces lista os namespaces do cluster aplicando o seletor.
+func listClusterNamespaces(ctx context.Context, client kubernetes.Interface, selector namespaceSelector) ([]string, error) {
+	nsList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar namespaces: %w", err)
+	}
+	names := make([]string, 0, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return selector.filter(names), nil
+}
+
+// scanNamespacesParallel escaneia vários namespaces limitando a quantidade de
+// backends e checks executando ao mesmo tempo a concurrency. Em cada namespace,
+// os backends rodam primeiro; os checks internos rodam se nenhum backend
+// produzir resultado ou se alwaysRunChecks for true. Os findings são
+// retornados na ordem dos namespaces.
+func scanNamespacesParallel(ctx context.Context, client kubernetes.Interface, namespaces []string, bs []backends.SecurityBackend, selected []checks.SecurityCheck, concurrency int, alwaysRunChecks bool) ([]checks.SecurityFinding, []string) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	perNamespace := make([][]checks.SecurityFinding, len(namespaces))
+	sourceSet := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, ns := range namespaces {
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			findings, sources := runBackends(ctx, client, ns, bs)
+			<-sem
+
+			if alwaysRunChecks || len(sources) == 0 {
+				findings = append(findings, runChecksParallel(ctx, client, ns, selected, sem)...)
+				sources = append(sources, "checks-internos")
+			}
+
+			mu.Lock()
+			perNamespace[i] = findings
+			for _, s := range sources {
+				sourceSet[s] = true
+			}
+			mu.Unlock()
+		}(i, ns)
+	}
+	wg.Wait()
+
+	var findings []checks.SecurityFinding
+	for _, f := range perNamespace {
+		findings = append(findings, f...)
+	}
+	sources := make([]string, 0, len(sourceSet))
+	for s := range sourceSet {
+		sources = append(sources, s)
+	}
+	sort.Strings(sources)
+	return findings, sources
+}
+
+// runChecksParallel executa os checks de um namespace em paralelo, usando sem
+// para limitar a concorrência global. A ordem dos findings segue a dos checks.
+func runChecksParallel(ctx context.Context, client kubernetes.Interface, namespace string, selected []checks.SecurityCheck, sem chan struct{}) []checks.SecurityFinding {
+	results := make([][]checks.SecurityFinding, len(selected))
+	var wg sync.WaitGroup
+	for i, check := range selected {
+		wg.Add(1)
+		go func(i int, check checks.SecurityCheck) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runChecks(ctx, client, namespace, []checks.SecurityCheck{check})
+		}(i, check)
+	}
+	wg.Wait()
+
+	var findings []checks.SecurityFinding
+	for _, r := range results {
+		findings = append(findings, r...)
+	}
+	return findings
+}
+
+// NamespaceSummary resume os findings e a pontuação de um namespace.
+type NamespaceSummary struct {
+	Namespace  string         `json:"namespace"`
+	Findings   int            `json:"findings"`
+	BySeverity map[string]int `json:"by_severity,omitempty"`
+	Score      int            `json:"score"`
+}
+
+// severityPenalty é o peso de cada severidade no cálculo da pontuação.
+var severityPenalty = map[checks.Severity]int{
+	checks.SeverityCritical: 10,
+	checks.SeverityHigh:     5,
+	checks.SeverityMedium:   2,
+	checks.SeverityLow:      1,
+}
+
+// securityScore calcula uma pontuação de 0 a 100 a partir dos findings,
+// descontando pontos conforme a severidade.
+func securityScore(findings []checks.SecurityFinding) int {
+	score := 100
+	for _, f := range findings {
+		score -= severityPenalty[findingSeverity(f)]
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// summarizeNamespaces agrupa os findings por namespace e calcula a pontuação
+// de cada um. Namespaces sem findings aparecem com pontuação 100; findings de
+// recursos sem namespace são agrupados em "(cluster)".
+func summarizeNamespaces(findings []checks.SecurityFinding, namespaces []string) []NamespaceSummary {
+	byNamespace := make(map[string][]checks.SecurityFinding)
+	for _, f := range findings {
+		ns := f.Namespace
+		if ns == "" {
+			ns = clusterScopeLabel
+		}
+		byNamespace[ns] = append(byNamespace[ns], f)
+	}
+
+	names := append([]string{}, namespaces...)
+	if _, ok := byNamespace[clusterScopeLabel]; ok {
+		names = append(names, clusterScopeLabel)
+	}
+	for ns := range byNamespace {
+		if !containsString(names, ns) {
+			names = append(names, ns)
+		}
+	}
+	sort.Strings(names)
+
+	summaries := make([]NamespaceSummary, 0, len(names))
+	for _, ns := range names {
+		nsFindings := byNamespace[ns]
+		summary := NamespaceSummary{
+			Namespace: ns,
+			Findings:  len(nsFindings),
+			Score:     securityScore(nsFindings),
+		}
+		if len(nsFindings) > 0 {
+			summary.BySeverity = make(map[string]int)
+			for _, f := range nsFindings {
+				summary.BySeverity[string(findingSeverity(f))]++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// namespaceNames retorna os nomes dos namespaces resumidos, exceto o escopo de cluster.
+func namespaceNames(summaries []NamespaceSummary) []string {
+	var names []string
+	for _, s := range summaries {
+		if s.Namespace != clusterScopeLabel {
+			names = append(names, s.Namespace)
+		}
+	}
+	return names
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
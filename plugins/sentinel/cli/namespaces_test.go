@@ -0,0 +1,177 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/backends"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func privilegedPod(name, namespace string) *corev1.Pod {
+	priv := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:            "app",
+			SecurityContext: &corev1.SecurityContext{Privileged: &priv},
+		}}},
+	}
+}
+
+func TestNamespaceSelector_IncludeExclude(t *testing.T) {
+	sel := namespaceSelector{
+		Include: parsePatternList("team-*, payments"),
+		Exclude: parsePatternList("team-legacy"),
+	}
+
+	cases := map[string]bool{
+		"team-a":      true,
+		"payments":    true,
+		"team-legacy": false,
+		"kube-system": false,
+	}
+	for ns, want := range cases {
+		if got := sel.matches(ns); got != want {
+			t.Errorf("matches(%s) = %v, esperava %v", ns, got, want)
+		}
+	}
+
+	if !(namespaceSelector{Exclude: []string{"kube-*"}}).matches("default") {
+		t.Error("sem include, namespaces não excluídos deveriam passar")
+	}
+}
+
+func TestListClusterNamespaces_AplicaSeletor(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+	)
+
+	names, err := listClusterNamespaces(context.Background(), client, namespaceSelector{Exclude: []string{"kube-*"}})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(names) != 2 || names[0] != "dev" || names[1] != "prod" {
+		t.Errorf("esperava [dev prod], obteve %v", names)
+	}
+}
+
+func TestScanNamespacesParallel_AgregaNaOrdemDosNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		privilegedPod("api", "ns-b"),
+		privilegedPod("api", "ns-a"),
+		privilegedPod("worker", "ns-a"),
+	)
+	selected := checks.GetByIDs([]string{"POD_PRIVILEGED", "POD_RESOURCE_LIMITS"})
+
+	for _, concurrency := range []int{1, 8} {
+		findings, sources := scanNamespacesParallel(context.Background(), client, []string{"ns-a", "ns-b", "ns-c"}, nil, selected, concurrency, false)
+
+		privileged := 0
+		for _, f := range findings {
+			if f.CheckID == "POD_PRIVILEGED" {
+				privileged++
+			}
+		}
+		if privileged != 3 {
+			t.Errorf("concorrência %d: esperava 3 findings privilegiados, obteve %d", concurrency, privileged)
+		}
+		if findings[0].Namespace != "ns-a" || findings[len(findings)-1].Namespace != "ns-b" {
+			t.Errorf("concorrência %d: findings fora da ordem dos namespaces", concurrency)
+		}
+		if len(sources) != 1 || sources[0] != "checks-internos" {
+			t.Errorf("esperava fonte checks-internos, obteve %v", sources)
+		}
+	}
+}
+
+func TestScanNamespacesParallel_ChecksSempreComAlwaysRunChecks(t *testing.T) {
+	client := fake.NewSimpleClientset(privilegedPod("api", "prod"))
+	selected := checks.GetByIDs([]string{"POD_PRIVILEGED"})
+	opa := backends.NewOPABackendWithPolicyDirs()
+
+	findings, sources := scanNamespacesParallel(context.Background(), client, []string{"prod"}, []backends.SecurityBackend{opa}, selected, 2, true)
+
+	hasCheck := false
+	for _, f := range findings {
+		if f.CheckID == "POD_PRIVILEGED" {
+			hasCheck = true
+		}
+	}
+	if !hasCheck {
+		t.Error("checks internos deveriam rodar mesmo com backend produzindo findings")
+	}
+	if len(sources) != 2 {
+		t.Errorf("esperava fontes opa e checks-internos, obteve %v", sources)
+	}
+}
+
+func TestSummarizeNamespaces_PontuacaoPorNamespace(t *testing.T) {
+	findings := []checks.SecurityFinding{
+		{Namespace: "prod", Severity: checks.SeverityCritical},
+		{Namespace: "prod", Severity: checks.SeverityMedium},
+		{Namespace: "", Severity: checks.SeverityHigh},
+	}
+
+	summaries := summarizeNamespaces(findings, []string{"prod", "dev"})
+	if len(summaries) != 3 {
+		t.Fatalf("esperava 3 resumos (cluster, dev, prod), obteve %d", len(summaries))
+	}
+
+	byName := make(map[string]NamespaceSummary)
+	for _, s := range summaries {
+		byName[s.Namespace] = s
+	}
+	if byName["prod"].Score != 88 || byName["prod"].Findings != 2 {
+		t.Errorf("resumo de prod inesperado: %+v", byName["prod"])
+	}
+	if byName["dev"].Score != 100 {
+		t.Errorf("namespace sem findings deveria ter score 100, obteve %d", byName["dev"].Score)
+	}
+	if byName[clusterScopeLabel].BySeverity["high"] != 1 {
+		t.Errorf("findings sem namespace deveriam ir para %s: %+v", clusterScopeLabel, byName[clusterScopeLabel])
+	}
+
+	names := namespaceNames(summaries)
+	if len(names) != 2 {
+		t.Errorf("namespaceNames não deveria incluir o escopo de cluster, obteve %v", names)
+	}
+}
+
+func TestSecurityScore_NaoNegativo(t *testing.T) {
+	var findings []checks.SecurityFinding
+	for i := 0; i < 20; i++ {
+		findings = append(findings, checks.SecurityFinding{Severity: checks.SeverityCritical})
+	}
+	if score := securityScore(findings); score != 0 {
+		t.Errorf("esperava score mínimo 0, obteve %d", score)
+	}
+}
+
+func TestDeduplicateFindings_MantemNamespacesDiferentes(t *testing.T) {
+	findings := []checks.SecurityFinding{
+		{Namespace: "a", Resource: "Deployment/api", Message: "x"},
+		{Namespace: "b", Resource: "Deployment/api", Message: "x"},
+		{Namespace: "a", Resource: "Deployment/api", Message: "x"},
+	}
+	if got := deduplicateFindings(findings); len(got) != 2 {
+		t.Errorf("esperava 2 findings após dedup, obteve %d", len(got))
+	}
+}
+
+func TestParseScanArgs_MultiNamespace(t *testing.T) {
+	opts := parseScanArgs([]string{"-A", "--exclude-namespaces", "kube-*,cert-manager", "-j", "8"})
+	if !opts.AllNamespaces || opts.Concurrency != 8 || len(opts.Selector.Exclude) != 2 {
+		t.Errorf("flags multi-namespace não interpretadas: %+v", opts)
+	}
+	if parseScanArgs(nil).Concurrency != defaultScanConcurrency {
+		t.Error("concorrência padrão não aplicada")
+	}
+}
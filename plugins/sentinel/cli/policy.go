@@ -0,0 +1,52 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/backends"
+)
+
+// runPolicyTest executa os testes Rego das políticas do usuário e retorna
+// true se todos passaram.
+func runPolicyTest(args []string) bool {
+	dir := filepath.Join(".yby", backends.PolicyDirName)
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	results, err := backends.RunPolicyTests(context.Background(), dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	if len(results) == 0 {
+		fmt.Printf("Nenhum teste (regras test_*) encontrado em %s\n", dir)
+		return true
+	}
+
+	passed, failed, skipped := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+			fmt.Printf("  SKIP  %s.%s\n", r.Package, r.Name)
+		case r.Passed:
+			passed++
+			fmt.Printf("  PASS  %s.%s\n", r.Package, r.Name)
+		default:
+			failed++
+			fmt.Printf("  FAIL  %s.%s (%s)\n", r.Package, r.Name, r.File)
+			if r.Error != "" {
+				fmt.Fprintf(os.Stderr, "        %s\n", r.Error)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d passaram, %d falharam, %d ignorados\n", passed, failed, skipped)
+	return failed == 0
+}
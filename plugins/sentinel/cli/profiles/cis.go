@@ -2,6 +2,29 @@
 
 package profiles
 
+// Controles da seção 5 (Policies) do CIS Kubernetes Benchmark. Checks sem
+// controle CIS equivalente ficam em controles SENTINEL-*.
+var (
+	cisClusterAdmin     = Control{ID: "5.1.1", Title: "Garantir que o role cluster-admin seja usado apenas onde necessário", CheckIDs: []string{"RBAC_CLUSTER_ADMIN"}}
+	cisSecretsAccess    = Control{ID: "5.1.2", Title: "Minimizar o acesso a secrets", CheckIDs: []string{"RBAC_SECRETS_ACCESS"}}
+	cisWildcard         = Control{ID: "5.1.3", Title: "Minimizar o uso de wildcards em Roles e ClusterRoles", CheckIDs: []string{"RBAC_WILDCARD"}}
+	cisDefaultSA        = Control{ID: "5.1.5", Title: "Garantir que service accounts default não sejam usadas ativamente"}
+	cisSATokenMount     = Control{ID: "5.1.6", Title: "Garantir que tokens de service account sejam montados apenas onde necessário", CheckIDs: []string{"POD_SERVICE_ACCOUNT_TOKEN"}}
+	cisPrivileged       = Control{ID: "5.2.2", Title: "Minimizar a admissão de containers privilegiados", CheckIDs: []string{"POD_PRIVILEGED"}}
+	cisHostNamespaces   = Control{ID: "5.2.3-5.2.5", Title: "Minimizar containers que compartilham PID, IPC ou rede do host", CheckIDs: []string{"POD_HOST_NAMESPACES"}}
+	cisPrivEscalation   = Control{ID: "5.2.6", Title: "Minimizar a admissão de containers com allowPrivilegeEscalation", CheckIDs: []string{"POD_PRIVILEGE_ESCALATION"}}
+	cisRoot             = Control{ID: "5.2.7", Title: "Minimizar a admissão de containers root", CheckIDs: []string{"POD_ROOT_CONTAINER"}}
+	cisCapabilities     = Control{ID: "5.2.9", Title: "Minimizar a admissão de containers com capabilities adicionadas", CheckIDs: []string{"POD_CAPABILITIES"}}
+	cisHostPorts        = Control{ID: "5.2.13", Title: "Minimizar a admissão de containers que usam HostPorts", CheckIDs: []string{"POD_HOST_PORTS"}}
+	cisNetworkPolicies  = Control{ID: "5.3.2", Title: "Garantir que todos os namespaces tenham NetworkPolicies definidas", CheckIDs: []string{"NETPOL_COVERAGE", "NETPOL_DEFAULT_DENY"}}
+	cisSecretsAsFiles   = Control{ID: "5.4.1", Title: "Preferir secrets montados como arquivos a variáveis de ambiente", CheckIDs: []string{"POD_EXPOSED_SECRETS"}}
+	cisSeccomp          = Control{ID: "5.7.2", Title: "Garantir que o perfil seccomp esteja definido nos pods", CheckIDs: []string{"POD_SECCOMP"}}
+	cisSecurityContext  = Control{ID: "5.7.3", Title: "Aplicar SecurityContext a pods e containers", CheckIDs: []string{"POD_READONLY_ROOTFS"}}
+	cisDefaultNamespace = Control{ID: "5.7.4", Title: "O namespace default não deve ser usado"}
+	sentinelLimits      = Control{ID: "SENTINEL-1", Title: "Containers com requests e limits de recursos (boa prática, sem controle CIS equivalente)", CheckIDs: []string{"POD_RESOURCE_LIMITS"}}
+	sentinelPullPolicy  = Control{ID: "SENTINEL-2", Title: "ImagePullPolicy adequada para tags imutáveis (boa prática, sem controle CIS equivalente)", CheckIDs: []string{"POD_IMAGE_PULL_POLICY"}}
+)
+
 func init() {
 	register(ComplianceProfile{
 		Name:        "cis-l1",
@@ -15,6 +38,16 @@ func init() {
 			"POD_HOST_NAMESPACES",
 			"POD_SERVICE_ACCOUNT_TOKEN",
 		},
+		Controls: []Control{
+			cisDefaultSA,
+			cisSATokenMount,
+			cisPrivileged,
+			cisHostNamespaces,
+			cisRoot,
+			cisSecretsAsFiles,
+			sentinelLimits,
+			sentinelPullPolicy,
+		},
 	})
 
 	register(ComplianceProfile{
@@ -42,5 +75,25 @@ func init() {
 			"NETPOL_COVERAGE",
 			"NETPOL_DEFAULT_DENY",
 		},
+		Controls: []Control{
+			cisClusterAdmin,
+			cisSecretsAccess,
+			cisWildcard,
+			cisDefaultSA,
+			cisSATokenMount,
+			cisPrivileged,
+			cisHostNamespaces,
+			cisPrivEscalation,
+			cisRoot,
+			cisCapabilities,
+			cisHostPorts,
+			cisNetworkPolicies,
+			cisSecretsAsFiles,
+			cisSeccomp,
+			cisSecurityContext,
+			cisDefaultNamespace,
+			sentinelLimits,
+			sentinelPullPolicy,
+		},
 	})
 }
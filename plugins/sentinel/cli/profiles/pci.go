@@ -21,5 +21,16 @@ func init() {
 			"POD_ROOT_CONTAINER",
 			"POD_SERVICE_ACCOUNT_TOKEN",
 		},
+		// Requisitos do PCI-DSS v4.0
+		Controls: []Control{
+			{ID: "1.3.1", Title: "Tráfego de entrada para o CDE restrito ao necessário", CheckIDs: []string{"NETPOL_DEFAULT_DENY"}},
+			{ID: "1.4.1", Title: "Controles de segurança de rede entre redes confiáveis e não confiáveis", CheckIDs: []string{"NETPOL_COVERAGE"}},
+			{ID: "2.2.1", Title: "Padrões de configuração de componentes do sistema aplicados", CheckIDs: []string{"POD_PRIVILEGED", "POD_ROOT_CONTAINER"}},
+			{ID: "3.6.1", Title: "Chaves criptográficas e credenciais protegidas contra divulgação", CheckIDs: []string{"POD_EXPOSED_SECRETS"}},
+			{ID: "6.3.3", Title: "Componentes protegidos contra vulnerabilidades conhecidas com patches"},
+			{ID: "7.2.1", Title: "Modelo de controle de acesso definido e com privilégio mínimo", CheckIDs: []string{"RBAC_CLUSTER_ADMIN", "RBAC_WILDCARD"}},
+			{ID: "7.2.5", Title: "Contas de sistema e aplicação com privilégio mínimo", CheckIDs: []string{"RBAC_SECRETS_ACCESS", "POD_SERVICE_ACCOUNT_TOKEN"}},
+			{ID: "10.2.1", Title: "Logs de auditoria habilitados e ativos para todos os componentes"},
+		},
 	})
 }
@@ -73,3 +73,47 @@ func mustGetProfile(t *testing.T, name string) ComplianceProfile {
 	}
 	return p
 }
+
+func TestProfiles_TodoCheckTemControle(t *testing.T) {
+	for _, p := range ListProfiles() {
+		if len(p.Controls) == 0 {
+			t.Errorf("perfil '%s' deveria mapear controles", p.Name)
+			continue
+		}
+		for _, id := range p.CheckIDs {
+			if len(p.ControlsFor(id)) == 0 {
+				t.Errorf("perfil '%s': check %s sem controle associado", p.Name, id)
+			}
+		}
+		for _, c := range p.Controls {
+			if c.ID == "" || c.Title == "" {
+				t.Errorf("perfil '%s': controle sem ID ou título: %+v", p.Name, c)
+			}
+			for _, id := range c.CheckIDs {
+				if !containsID(p.CheckIDs, id) {
+					t.Errorf("perfil '%s': controle %s referencia check %s fora do perfil", p.Name, c.ID, id)
+				}
+			}
+		}
+	}
+}
+
+func TestControlsFor(t *testing.T) {
+	p := mustGetProfile(t, "soc2")
+	controls := p.ControlsFor("NETPOL_DEFAULT_DENY")
+	if len(controls) != 1 || controls[0].ID != "CC6.6" {
+		t.Errorf("esperava CC6.6 para NETPOL_DEFAULT_DENY, obteve %+v", controls)
+	}
+	if got := p.ControlsFor("INEXISTENTE"); len(got) != 0 {
+		t.Errorf("não esperava controles para check inexistente, obteve %+v", got)
+	}
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
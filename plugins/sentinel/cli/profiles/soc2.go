@@ -22,5 +22,15 @@ func init() {
 			"POD_CAPABILITIES",
 			"POD_EXPOSED_SECRETS",
 		},
+		// Critérios Comuns dos Trust Services Criteria (AICPA)
+		Controls: []Control{
+			{ID: "CC6.1", Title: "Controles de acesso lógico a ativos de informação", CheckIDs: []string{"RBAC_CLUSTER_ADMIN", "RBAC_WILDCARD", "RBAC_SECRETS_ACCESS"}},
+			{ID: "CC6.3", Title: "Acesso autorizado por função com privilégio mínimo", CheckIDs: []string{"POD_SERVICE_ACCOUNT_TOKEN"}},
+			{ID: "CC6.6", Title: "Proteção de fronteiras contra ameaças externas", CheckIDs: []string{"NETPOL_COVERAGE", "NETPOL_DEFAULT_DENY"}},
+			{ID: "CC6.7", Title: "Restrição da divulgação de informações confidenciais", CheckIDs: []string{"POD_EXPOSED_SECRETS"}},
+			{ID: "CC6.8", Title: "Prevenção de software não autorizado ou malicioso", CheckIDs: []string{"POD_ROOT_CONTAINER", "POD_PRIVILEGED", "POD_PRIVILEGE_ESCALATION", "POD_CAPABILITIES"}},
+			{ID: "CC7.2", Title: "Monitoramento de componentes para detectar anomalias"},
+			{ID: "CC8.1", Title: "Gestão de mudanças em infraestrutura e software"},
+		},
 	})
 }
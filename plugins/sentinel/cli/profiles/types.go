@@ -8,4 +8,28 @@ type ComplianceProfile struct {
 	Name        string
 	Description string
 	CheckIDs    []string
+	// Controls mapeia os controles do framework para os checks que os verificam.
+	Controls []Control
+}
+
+// Control é um controle do framework (ex: CIS 5.2.2, SOC2 CC6.1). Controles
+// sem CheckIDs não têm verificação automatizada e exigem evidência manual.
+type Control struct {
+	ID       string
+	Title    string
+	CheckIDs []string
+}
+
+// ControlsFor retorna os controles do perfil verificados pelo check.
+func (p ComplianceProfile) ControlsFor(checkID string) []Control {
+	var result []Control
+	for _, c := range p.Controls {
+		for _, id := range c.CheckIDs {
+			if id == checkID {
+				result = append(result, c)
+				break
+			}
+		}
+	}
+	return result
 }
@@ -0,0 +1,393 @@
+//go:build k8s
+
+package remediation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/atlas/discovery"
+	"github.com/casheiro/yby-cli/plugins/atlas/discovery/analyzers"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/manifests"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// execCommand permite substituir a execução do git em testes.
+var execCommand = exec.Command
+
+// gitopsIgnores são os diretórios ignorados na descoberta de manifests.
+var gitopsIgnores = []string{"node_modules", "vendor", ".git", ".idea", ".vscode", ".yby"}
+
+// Workload identifica o recurso declarado no repositório que gera um pod.
+type Workload struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (w Workload) String() string {
+	return fmt.Sprintf("%s/%s/%s", w.Namespace, w.Kind, w.Name)
+}
+
+// ResolveWorkload sobe as ownerReferences do pod (ReplicaSet → Deployment,
+// Job → CronJob) até o workload declarado. Pods sintetizados a partir de
+// manifests locais já trazem o workload na annotation de origem.
+func ResolveWorkload(ctx context.Context, client kubernetes.Interface, namespace, podName string) Workload {
+	w := Workload{Kind: "Pod", Name: podName, Namespace: namespace}
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return w
+	}
+	if src, ok := pod.Annotations[manifests.SourceAnnotation]; ok {
+		if kind, name, found := strings.Cut(src, "/"); found {
+			return Workload{Kind: kind, Name: name, Namespace: namespace}
+		}
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return w
+	}
+	w.Kind, w.Name = owner.Kind, owner.Name
+
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+		if err == nil {
+			if parent := metav1.GetControllerOf(rs); parent != nil {
+				w.Kind, w.Name = parent.Kind, parent.Name
+			}
+		}
+	case "Job":
+		job, err := client.BatchV1().Jobs(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+		if err == nil {
+			if parent := metav1.GetControllerOf(job); parent != nil {
+				w.Kind, w.Name = parent.Kind, parent.Name
+			}
+		}
+	}
+	return w
+}
+
+// SourceType indica onde a correção é aplicada no repositório.
+type SourceType string
+
+const (
+	SourceManifest   SourceType = "manifest"
+	SourceHelmValues SourceType = "helm-values"
+)
+
+// SourceLocation é o arquivo do repositório que declara o workload.
+type SourceLocation struct {
+	Type SourceType
+	// File é relativo à raiz do repositório.
+	File string
+	// ChartDir é o diretório do chart (apenas para SourceHelmValues).
+	ChartDir string
+}
+
+// LocateSource procura o workload na topologia descoberta pelos analyzers do
+// Atlas: primeiro em manifests YAML, depois nos templates de charts Helm, caso
+// em que a correção vai para o values.yaml do chart.
+func LocateSource(bp *discovery.InfraBlueprint, w Workload) (SourceLocation, error) {
+	for _, r := range bp.Resources {
+		if r.APIGroup == "k8s" || r.Kind != w.Kind || r.Name != w.Name {
+			continue
+		}
+		if r.Namespace != "" && w.Namespace != "" && r.Namespace != w.Namespace {
+			continue
+		}
+		return SourceLocation{Type: SourceManifest, File: r.Path}, nil
+	}
+
+	// Templates Helm têm nomes com expressões ({{ include ... }}): casa pelo
+	// nome quando possível ou pelo único chart que declara o tipo de workload.
+	charts := make(map[string]analyzers.InfraResource)
+	for _, r := range bp.Resources {
+		if r.Kind == "HelmChart" {
+			charts[r.ID()] = r
+		}
+	}
+	templates := make(map[string]analyzers.InfraResource)
+	for _, r := range bp.Resources {
+		if r.APIGroup == "k8s" && r.Kind == w.Kind {
+			templates[r.ID()] = r
+		}
+	}
+	var byName, byKind []analyzers.InfraResource
+	seen := make(map[string]bool)
+	for _, rel := range bp.Relations {
+		chart, ok := charts[rel.From]
+		tmpl, isTemplate := templates[rel.To]
+		if rel.Type != "deploys" || !ok || !isTemplate || seen[chart.Path] {
+			continue
+		}
+		seen[chart.Path] = true
+		byKind = append(byKind, chart)
+		if tmpl.Name == w.Name || chart.Name == w.Name || strings.HasSuffix(w.Name, "-"+chart.Name) {
+			byName = append(byName, chart)
+		}
+	}
+
+	var chart *analyzers.InfraResource
+	switch {
+	case len(byName) == 1:
+		chart = &byName[0]
+	case len(byName) == 0 && len(byKind) == 1:
+		chart = &byKind[0]
+	}
+	if chart == nil {
+		return SourceLocation{}, fmt.Errorf("%s não encontrado nos manifests do repositório", w)
+	}
+	chartDir := filepath.Dir(chart.Path)
+	return SourceLocation{Type: SourceHelmValues, File: filepath.Join(chartDir, "values.yaml"), ChartDir: chartDir}, nil
+}
+
+// GitOpsChange é a correção de um finding aplicada ao repositório.
+type GitOpsChange struct {
+	Patch    RemediationPatch
+	Workload Workload
+	Source   SourceLocation
+	// Commit é o hash do commit criado (vazio em dry-run ou quando ignorado).
+	Commit string
+	// Skipped explica por que a correção não foi aplicada.
+	Skipped string
+}
+
+// GitOpsResult é o resultado da remediação via repositório.
+type GitOpsResult struct {
+	Branch  string
+	Changes []GitOpsChange
+}
+
+// Applied retorna quantas correções geraram alteração.
+func (r GitOpsResult) Applied() int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Skipped == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// gitopsPlanner aplica correções sequencialmente sobre uma cópia do
+// repositório, acumulando o conteúdo dos arquivos já alterados.
+type gitopsPlanner struct {
+	root     string
+	bp       *discovery.InfraBlueprint
+	contents map[string][]byte
+}
+
+func newGitOpsPlanner(root string) (*gitopsPlanner, error) {
+	bp, err := discovery.ScanInfra(root, gitopsIgnores)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao descobrir manifests do repositório: %w", err)
+	}
+	return &gitopsPlanner{root: root, bp: bp, contents: make(map[string][]byte)}, nil
+}
+
+// apply localiza a origem do workload e calcula o novo conteúdo do arquivo.
+// Retorna a mudança e se o arquivo foi alterado.
+func (p *gitopsPlanner) apply(patch RemediationPatch, w Workload) (GitOpsChange, bool) {
+	change := GitOpsChange{Patch: patch, Workload: w}
+	loc, err := LocateSource(p.bp, w)
+	if err != nil {
+		change.Skipped = err.Error()
+		return change, false
+	}
+	change.Source = loc
+
+	data, ok := p.contents[loc.File]
+	if !ok {
+		data, err = os.ReadFile(filepath.Join(p.root, loc.File))
+		if err != nil && !(loc.Type == SourceHelmValues && os.IsNotExist(err)) {
+			change.Skipped = fmt.Sprintf("falha ao ler %s: %v", loc.File, err)
+			return change, false
+		}
+	}
+
+	var updated []byte
+	var changed bool
+	if loc.Type == SourceHelmValues {
+		var values map[string]interface{}
+		values, err = HelmValuesPatch(patch, p.chartTemplates(loc.ChartDir))
+		if err == nil {
+			updated, changed, err = ApplyToValues(data, values)
+		}
+	} else {
+		updated, changed, err = ApplyToManifest(data, w, patch)
+	}
+	if err != nil {
+		change.Skipped = err.Error()
+		return change, false
+	}
+	if !changed {
+		change.Skipped = "já corrigido no repositório"
+		return change, false
+	}
+	p.contents[loc.File] = updated
+	return change, true
+}
+
+// chartTemplates concatena os templates do chart para detectar quais values são usados.
+func (p *gitopsPlanner) chartTemplates(chartDir string) string {
+	var sb strings.Builder
+	dir := filepath.Join(p.root, chartDir, "templates")
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			sb.Write(data)
+			sb.WriteString("\n")
+		}
+		return nil
+	})
+	return sb.String()
+}
+
+// GitOpsFix associa um patch ao workload que o originou.
+type GitOpsFix struct {
+	Patch    RemediationPatch
+	Workload Workload
+}
+
+// PlanGitOps calcula as correções no repositório em repoDir sem alterar
+// arquivos. O plano parte do HEAD, em um git worktree temporário, exatamente
+// como CommitGitOps: alterações não commitadas não entram no plano.
+func PlanGitOps(repoDir string, fixes []GitOpsFix) (GitOpsResult, error) {
+	root, err := gitToplevel(repoDir)
+	if err != nil {
+		return GitOpsResult{}, err
+	}
+	worktree, remove, err := addWorktree(root, "--detach")
+	if err != nil {
+		return GitOpsResult{}, err
+	}
+	defer remove()
+
+	planner, err := newGitOpsPlanner(worktree)
+	if err != nil {
+		return GitOpsResult{}, err
+	}
+	var result GitOpsResult
+	for _, f := range fixes {
+		change, _ := planner.apply(f.Patch, f.Workload)
+		result.Changes = append(result.Changes, change)
+	}
+	return result, nil
+}
+
+// CommitGitOps aplica as correções em uma branch nova, com um commit por
+// finding. O trabalho é feito em um git worktree temporário a partir do HEAD,
+// sem tocar na árvore de trabalho do usuário. Se nenhuma correção gerar
+// alteração, a branch é removida.
+func CommitGitOps(repoDir, branch string, fixes []GitOpsFix) (GitOpsResult, error) {
+	root, err := gitToplevel(repoDir)
+	if err != nil {
+		return GitOpsResult{}, err
+	}
+	if branch == "" {
+		branch = fmt.Sprintf("sentinel/remediation-%s", time.Now().Format("20060102-150405"))
+	}
+	result := GitOpsResult{Branch: branch}
+
+	worktree, remove, err := addWorktree(root, "-b", branch)
+	if err != nil {
+		return result, fmt.Errorf("falha ao criar branch %s: %w", branch, err)
+	}
+	defer remove()
+
+	planner, err := newGitOpsPlanner(worktree)
+	if err != nil {
+		return result, err
+	}
+
+	for _, f := range fixes {
+		change, changed := planner.apply(f.Patch, f.Workload)
+		if changed {
+			change.Commit, err = commitChange(worktree, change, planner.contents[change.Source.File])
+			if err != nil {
+				change.Skipped = err.Error()
+				change.Commit = ""
+			}
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	if result.Applied() == 0 {
+		remove()
+		runGit(root, "branch", "-D", branch) //nolint:errcheck
+		result.Branch = ""
+	}
+	return result, nil
+}
+
+// addWorktree cria um git worktree temporário do HEAD com os argumentos extras
+// de `git worktree add` (ex.: "-b branch" ou "--detach"). A função retornada
+// remove o worktree e pode ser chamada mais de uma vez.
+func addWorktree(root string, args ...string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "sentinel-gitops-")
+	if err != nil {
+		return "", nil, fmt.Errorf("falha ao criar diretório temporário: %w", err)
+	}
+	gitArgs := append(append([]string{"worktree", "add"}, args...), dir, "HEAD")
+	if _, err := runGit(root, gitArgs...); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	var once sync.Once
+	return dir, func() {
+		once.Do(func() {
+			runGit(root, "worktree", "remove", "--force", dir) //nolint:errcheck
+			os.RemoveAll(dir)
+		})
+	}, nil
+}
+
+// commitChange grava o arquivo alterado e cria o commit da correção.
+func commitChange(worktree string, change GitOpsChange, content []byte) (string, error) {
+	path := filepath.Join(worktree, change.Source.File)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", fmt.Errorf("falha ao gravar %s: %w", change.Source.File, err)
+	}
+	if _, err := runGit(worktree, "add", "--", change.Source.File); err != nil {
+		return "", err
+	}
+	subject := fmt.Sprintf("fix(sentinel): %s", change.Patch.Description)
+	body := fmt.Sprintf("Workload: %s\nArquivo: %s\nPatch: %s", change.Workload, change.Source.File, change.Patch.Patch)
+	if _, err := runGit(worktree, "commit", "-m", subject, "-m", body); err != nil {
+		return "", err
+	}
+	hash, err := runGit(worktree, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// gitToplevel retorna a raiz do repositório git que contém dir.
+func gitToplevel(dir string) (string, error) {
+	root, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s não está em um repositório git: %w", dir, err)
+	}
+	return root, nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := execCommand("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
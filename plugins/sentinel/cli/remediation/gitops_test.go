@@ -0,0 +1,230 @@
+//go:build k8s
+
+package remediation
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/plugins/atlas/discovery"
+	"github.com/casheiro/yby-cli/plugins/atlas/discovery/analyzers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+}
+
+func TestResolveWorkload(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f-abc", Namespace: "prod", OwnerReferences: controllerRef("ReplicaSet", "api-7d9f")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f", Namespace: "prod", OwnerReferences: controllerRef("Deployment", "api")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "prod", OwnerReferences: controllerRef("StatefulSet", "db")}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "prod", Annotations: map[string]string{"sentinel.yby.dev/source": "DaemonSet/worker"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "solto", Namespace: "prod"}},
+	)
+
+	cases := map[string]Workload{
+		"api-7d9f-abc": {Kind: "Deployment", Name: "api", Namespace: "prod"},
+		"db-0":         {Kind: "StatefulSet", Name: "db", Namespace: "prod"},
+		"worker":       {Kind: "DaemonSet", Name: "worker", Namespace: "prod"},
+		"solto":        {Kind: "Pod", Name: "solto", Namespace: "prod"},
+		"inexistente":  {Kind: "Pod", Name: "inexistente", Namespace: "prod"},
+	}
+	for pod, want := range cases {
+		if got := ResolveWorkload(context.Background(), client, "prod", pod); got != want {
+			t.Errorf("ResolveWorkload(%s) = %+v, esperava %+v", pod, got, want)
+		}
+	}
+}
+
+func TestLocateSource(t *testing.T) {
+	bp := &discovery.InfraBlueprint{
+		Resources: []analyzers.InfraResource{
+			{Kind: "Deployment", APIGroup: "apps/v1", Name: "api", Namespace: "prod", Path: "k8s/api.yaml"},
+			{Kind: "HelmChart", APIGroup: "helm", Name: "worker", Path: "charts/worker/Chart.yaml"},
+			{Kind: "Deployment", APIGroup: "k8s", Name: `{{ include "worker.fullname" . }}`, Path: "charts/worker/templates/deployment.yaml"},
+		},
+		Relations: []analyzers.InfraRelation{
+			{From: "HelmChart/worker", To: `Deployment/{{ include "worker.fullname" . }}`, Type: "deploys"},
+		},
+	}
+
+	loc, err := LocateSource(bp, Workload{Kind: "Deployment", Name: "api", Namespace: "prod"})
+	if err != nil || loc.Type != SourceManifest || loc.File != "k8s/api.yaml" {
+		t.Errorf("esperava manifest k8s/api.yaml, obteve %+v (%v)", loc, err)
+	}
+
+	loc, err = LocateSource(bp, Workload{Kind: "Deployment", Name: "release-worker", Namespace: "prod"})
+	if err != nil || loc.Type != SourceHelmValues || loc.File != filepath.Join("charts", "worker", "values.yaml") {
+		t.Errorf("esperava values do chart worker, obteve %+v (%v)", loc, err)
+	}
+
+	if _, err := LocateSource(bp, Workload{Kind: "StatefulSet", Name: "db"}); err == nil {
+		t.Error("esperava erro para workload sem origem no repositório")
+	}
+}
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git não disponível")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"k8s/api.yaml":                            deploymentManifest,
+		"charts/worker/Chart.yaml":                "apiVersion: v2\nname: worker\nversion: 0.1.0\n",
+		"charts/worker/values.yaml":               "replicaCount: 1\nsecurityContext: {}\n",
+		"charts/worker/templates/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ include \"worker.fullname\" . }}\nspec:\n  template:\n    spec:\n      containers:\n        - name: worker\n          securityContext:\n            {{- toYaml .Values.securityContext | nindent 12 }}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "dev@example.com"},
+		{"config", "user.name", "dev"},
+		{"add", "."},
+		{"commit", "-q", "-m", "inicial"},
+	} {
+		if _, err := runGit(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCommitGitOps_UmCommitPorFinding(t *testing.T) {
+	dir := gitRepo(t)
+	fixes := []GitOpsFix{
+		{Patch: RemediationPatch{Patch: rootPatch("app").Patch, Description: "runAsNonRoot no api"}, Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}},
+		{Patch: RemediationPatch{Patch: `{"spec":{"containers":[{"name":"sidecar","securityContext":{"privileged":false}}]}}`, Description: "privileged no sidecar"}, Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}},
+		// Mesmo patch repetido (outra réplica): sem alteração
+		{Patch: RemediationPatch{Patch: rootPatch("app").Patch, Description: "runAsNonRoot no api"}, Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}},
+		{Patch: RemediationPatch{Patch: rootPatch("worker").Patch, Description: "runAsNonRoot no worker"}, Workload: Workload{Kind: "Deployment", Name: "prod-worker", Namespace: "prod"}},
+		{Patch: RemediationPatch{Patch: rootPatch("db").Patch, Description: "db"}, Workload: Workload{Kind: "StatefulSet", Name: "db", Namespace: "prod"}},
+	}
+
+	result, err := CommitGitOps(dir, "sentinel/teste", fixes)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if result.Branch != "sentinel/teste" || result.Applied() != 3 {
+		t.Fatalf("esperava 3 correções na branch sentinel/teste, obteve %d em %q: %+v", result.Applied(), result.Branch, result.Changes)
+	}
+	if result.Changes[2].Skipped == "" || result.Changes[4].Skipped == "" {
+		t.Errorf("esperava correções repetida e sem origem ignoradas: %+v", result.Changes)
+	}
+
+	log, err := runGit(dir, "log", "--format=%s", "sentinel/teste")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjects := strings.Split(log, "\n")
+	if len(subjects) != 4 || subjects[0] != "fix(sentinel): runAsNonRoot no worker" {
+		t.Errorf("histórico inesperado:\n%s", log)
+	}
+
+	values, err := runGit(dir, "show", "sentinel/teste:charts/worker/values.yaml")
+	if err != nil || !strings.Contains(values, "runAsNonRoot: true") {
+		t.Errorf("values do chart não corrigido:\n%s", values)
+	}
+
+	// A árvore de trabalho do usuário não é alterada
+	status, _ := runGit(dir, "status", "--porcelain")
+	if status != "" {
+		t.Errorf("árvore de trabalho alterada:\n%s", status)
+	}
+	if branch, _ := runGit(dir, "rev-parse", "--abbrev-ref", "HEAD"); branch == "sentinel/teste" {
+		t.Error("branch atual não deveria mudar")
+	}
+}
+
+func TestCommitGitOps_SemAlteracaoNaoCriaBranch(t *testing.T) {
+	dir := gitRepo(t)
+	fixes := []GitOpsFix{{Patch: rootPatch("db"), Workload: Workload{Kind: "StatefulSet", Name: "db"}}}
+
+	result, err := CommitGitOps(dir, "sentinel/vazia", fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Branch != "" {
+		t.Errorf("não esperava branch, obteve %s", result.Branch)
+	}
+	if _, err := runGit(dir, "rev-parse", "--verify", "sentinel/vazia"); err == nil {
+		t.Error("branch sem commits deveria ser removida")
+	}
+}
+
+func TestPlanGitOps_NaoAlteraArquivos(t *testing.T) {
+	dir := gitRepo(t)
+	result, err := PlanGitOps(dir, []GitOpsFix{{Patch: rootPatch("app"), Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied() != 1 || result.Changes[0].Source.File != filepath.Join("k8s", "api.yaml") {
+		t.Errorf("plano inesperado: %+v", result.Changes)
+	}
+	if status, _ := runGit(dir, "status", "--porcelain"); status != "" {
+		t.Errorf("dry-run alterou arquivos:\n%s", status)
+	}
+}
+
+func TestPlanGitOps_PlanejaAPartirDoHEAD(t *testing.T) {
+	dir := gitRepo(t)
+	// Correção já feita na árvore de trabalho, mas não commitada: o commit
+	// partiria do HEAD, então o plano também precisa partir dele
+	manifest := strings.Replace(deploymentManifest, "runAsUser: 1000", "runAsUser: 1000\n            runAsNonRoot: true", 1)
+	if err := os.WriteFile(filepath.Join(dir, "k8s", "api.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixes := []GitOpsFix{{Patch: rootPatch("app"), Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}}}
+	plan, err := PlanGitOps(dir, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Applied() != 1 {
+		t.Errorf("plano deveria refletir o HEAD: %+v", plan.Changes)
+	}
+	result, err := CommitGitOps(dir, "sentinel/head", fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Applied() != plan.Applied() {
+		t.Errorf("plano (%d) e commit (%d) divergem", plan.Applied(), result.Applied())
+	}
+	if worktrees, _ := runGit(dir, "worktree", "list"); strings.Count(worktrees, "\n") != 0 {
+		t.Errorf("worktrees temporários não foram removidos:\n%s", worktrees)
+	}
+}
+
+func TestCommitGitOps_DiffSoNasLinhasAlteradas(t *testing.T) {
+	dir := gitRepo(t)
+	fixes := []GitOpsFix{{Patch: rootPatch("app"), Workload: Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}}}
+	if _, err := CommitGitOps(dir, "sentinel/diff", fixes); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := runGit(dir, "diff", "--numstat", "HEAD", "sentinel/diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\t0\t" + filepath.Join("k8s", "api.yaml"); stat != want {
+		t.Errorf("commit deveria só acrescentar a linha do patch, diff: %q", stat)
+	}
+}
@@ -0,0 +1,628 @@
+//go:build k8s
+
+package remediation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// podSpecPath retorna o caminho até o PodSpec dentro do manifesto de cada tipo de workload.
+func podSpecPath(kind string) ([]string, bool) {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}, true
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		return []string{"spec", "template", "spec"}, true
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}, true
+	default:
+		return nil, false
+	}
+}
+
+// podSpecPatch extrai do patch strategic-merge o trecho aplicado ao PodSpec.
+func podSpecPatch(p RemediationPatch) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal([]byte(p.Patch), &patch); err != nil {
+		return nil, fmt.Errorf("falha ao parsear patch: %w", err)
+	}
+	spec, ok := patch["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patch sem spec de pod")
+	}
+	return spec, nil
+}
+
+// ApplyToManifest aplica o patch ao PodSpec do workload dentro de um arquivo
+// YAML (multi-documento). Só as linhas dos nós alterados são reescritas: o
+// restante do arquivo (indentação, aspas, comentários) fica intacto.
+// Retorna o conteúdo atualizado e se houve alteração.
+func ApplyToManifest(data []byte, w Workload, p RemediationPatch) ([]byte, bool, error) {
+	path, ok := podSpecPath(w.Kind)
+	if !ok {
+		return nil, false, fmt.Errorf("tipo de workload '%s' não suportado", w.Kind)
+	}
+	spec, err := podSpecPatch(p)
+	if err != nil {
+		return nil, false, err
+	}
+	// O patch é aninhado no caminho do PodSpec para que mapas ausentes
+	// (ex.: jobTemplate) sejam criados pelo merge
+	patch := spec
+	for i := len(path) - 1; i >= 0; i-- {
+		patch = map[string]interface{}{path[i]: patch}
+	}
+
+	docs, err := decodeYAMLDocuments(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	editor := newYAMLEditor(data, docs)
+	found := false
+	changed := false
+	for _, doc := range docs {
+		root := documentRoot(doc)
+		if root == nil || scalarValue(root, "kind") != w.Kind || nestedScalar(root, "metadata", "name") != w.Name {
+			continue
+		}
+		if ns := nestedScalar(root, "metadata", "namespace"); ns != "" && w.Namespace != "" && ns != w.Namespace {
+			continue
+		}
+		found = true
+		if editor.merge(root, patch) {
+			changed = true
+		}
+	}
+	if !found {
+		return nil, false, fmt.Errorf("%s/%s não encontrado no arquivo", w.Kind, w.Name)
+	}
+	if !changed {
+		return data, false, nil
+	}
+	out, err := editor.apply()
+	return out, true, err
+}
+
+// HelmValuesPatch traduz o patch do PodSpec para as chaves convencionais do
+// values.yaml de um chart (as geradas por `helm create`): securityContext,
+// resources e serviceAccount.automount. Só usa chaves que os templates do chart
+// referenciam; templates é o conteúdo concatenado de templates/.
+func HelmValuesPatch(p RemediationPatch, templates string) (map[string]interface{}, error) {
+	spec, err := podSpecPatch(p)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if v, ok := spec["automountServiceAccountToken"]; ok {
+		if !strings.Contains(templates, ".Values.serviceAccount.automount") {
+			return nil, fmt.Errorf("chart não expõe serviceAccount.automount nos values")
+		}
+		values["serviceAccount"] = map[string]interface{}{"automount": v}
+	}
+	containers, _ := spec["containers"].([]interface{})
+	for _, c := range containers {
+		container, _ := c.(map[string]interface{})
+		if sc, ok := container["securityContext"]; ok {
+			if !strings.Contains(templates, ".Values.securityContext") {
+				return nil, fmt.Errorf("chart não expõe securityContext nos values")
+			}
+			values["securityContext"] = sc
+		}
+		if res, ok := container["resources"]; ok {
+			if !strings.Contains(templates, ".Values.resources") {
+				return nil, fmt.Errorf("chart não expõe resources nos values")
+			}
+			values["resources"] = res
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("patch sem equivalente nos values do chart")
+	}
+	return values, nil
+}
+
+// ApplyToValues aplica o patch de values ao values.yaml, reescrevendo apenas
+// as linhas das chaves alteradas.
+func ApplyToValues(data []byte, values map[string]interface{}) ([]byte, bool, error) {
+	docs, err := decodeYAMLDocuments(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(docs) == 0 {
+		// Arquivo vazio ou só com comentários: acrescenta as chaves ao final
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		mergeNode(root, values)
+		rendered, err := renderNode(root, 2)
+		if err != nil {
+			return nil, false, err
+		}
+		out := append([]byte{}, data...)
+		if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+			out = append(out, '\n')
+		}
+		return append(out, strings.Join(rendered, "\n")+"\n"...), true, nil
+	}
+	root := documentRoot(docs[0])
+	if root == nil {
+		return nil, false, fmt.Errorf("values.yaml não é um mapa")
+	}
+	editor := newYAMLEditor(data, docs)
+	if !editor.merge(root, values) {
+		return data, false, nil
+	}
+	out, err := editor.apply()
+	return out, true, err
+}
+
+func decodeYAMLDocuments(data []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("falha ao parsear YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
+// yamlEdit substitui as linhas [start, end] (1-based, inclusivas) do arquivo
+// por lines. Com end = start-1, insere lines antes da linha start.
+type yamlEdit struct {
+	start, end int
+	lines      []string
+	// indent ordena inserções no mesmo ponto: as mais profundas vêm antes,
+	// para que chaves novas de um filho fiquem acima das chaves novas do pai.
+	indent int
+}
+
+// yamlEditor aplica o merge de um patch sobre os nós parseados de um arquivo
+// registrando edições por intervalo de linhas, em vez de serializar o
+// documento inteiro de novo (o que reformataria o arquivo todo).
+type yamlEditor struct {
+	lines []string
+	edits []yamlEdit
+	// indent é a indentação usada pelo arquivo, aplicada aos trechos gerados.
+	indent int
+}
+
+func newYAMLEditor(data []byte, docs []*yaml.Node) *yamlEditor {
+	e := &yamlEditor{lines: strings.Split(string(data), "\n"), indent: 2}
+	for _, doc := range docs {
+		if n := detectIndent(doc); n > 0 {
+			e.indent = n
+			break
+		}
+	}
+	return e
+}
+
+// detectIndent retorna a indentação do primeiro mapa aninhado em bloco do
+// documento, ou 0 quando não há nenhum.
+func detectIndent(n *yaml.Node) int {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if isBlock(value, yaml.MappingNode) && value.Line > key.Line {
+				return value.Content[0].Column - key.Column
+			}
+		}
+	}
+	for _, c := range n.Content {
+		if indent := detectIndent(c); indent > 0 {
+			return indent
+		}
+	}
+	return 0
+}
+
+// apply retorna o conteúdo com as edições aplicadas.
+func (e *yamlEditor) apply() ([]byte, error) {
+	sort.SliceStable(e.edits, func(i, j int) bool {
+		a, b := e.edits[i], e.edits[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		aInsert, bInsert := a.end < a.start, b.end < b.start
+		if aInsert != bInsert {
+			return aInsert
+		}
+		return a.indent > b.indent
+	})
+
+	var out []string
+	next := 1
+	for _, ed := range e.edits {
+		if ed.start < next {
+			return nil, fmt.Errorf("edições sobrepostas na linha %d", ed.start)
+		}
+		out = append(out, e.lines[next-1:ed.start-1]...)
+		out = append(out, ed.lines...)
+		if ed.end >= ed.start {
+			next = ed.end + 1
+		} else {
+			next = ed.start
+		}
+	}
+	out = append(out, e.lines[next-1:]...)
+	return []byte(strings.Join(out, "\n")), nil
+}
+
+// merge aplica src sobre o mapa em bloco dst com a semântica de mergeNode,
+// descendo em mapas e listas em bloco para editar apenas o necessário.
+// Retorna se houve alteração.
+func (e *yamlEditor) merge(dst *yaml.Node, src map[string]interface{}) bool {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changed := false
+	added := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range keys {
+		i := keyIndex(dst, k)
+		if i < 0 {
+			mergeNode(added, map[string]interface{}{k: src[k]})
+			changed = true
+			continue
+		}
+		if e.mergeEntry(dst.Content[i], dst.Content[i+1], k, src[k]) {
+			changed = true
+		}
+	}
+	if len(added.Content) > 0 {
+		e.insertEntries(dst, added)
+	}
+	return changed
+}
+
+// mergeEntry aplica o valor v à entrada key: value de um mapa. Mapas e listas
+// nomeadas em bloco são editados recursivamente; nos demais casos (escalares,
+// estilo flow, mapas vazios) apenas as linhas da entrada são reescritas.
+func (e *yamlEditor) mergeEntry(key, value *yaml.Node, k string, v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if isBlock(value, yaml.MappingNode) {
+			return e.merge(value, val)
+		}
+	case []interface{}:
+		if isNamedList(val) && isBlock(value, yaml.SequenceNode) {
+			return e.mergeNamedList(value, val)
+		}
+	}
+
+	entryKey := &yaml.Node{Kind: key.Kind, Tag: key.Tag, Style: key.Style, Value: key.Value, LineComment: key.LineComment}
+	entryValue := cloneNode(value)
+	entryValue.HeadComment, entryValue.FootComment = "", ""
+	entry := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{entryKey, entryValue}}
+	if !mergeNode(entry, map[string]interface{}{k: v}) {
+		return false
+	}
+	// Mantém o comentário de fim de linha do valor substituído
+	if merged := entry.Content[1]; merged.LineComment == "" && merged != entryValue {
+		merged.LineComment = value.LineComment
+	}
+	e.replace(key, lastLine(value), entry)
+	return true
+}
+
+// mergeNamedList mescla itens por "name" em uma lista em bloco; itens sem
+// correspondente são acrescentados ao final da lista.
+func (e *yamlEditor) mergeNamedList(seq *yaml.Node, items []interface{}) bool {
+	changed := false
+	added := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, item := range items {
+		m := item.(map[string]interface{})
+		name := m["name"].(string)
+		var target *yaml.Node
+		for _, el := range seq.Content {
+			if el.Kind == yaml.MappingNode && scalarValue(el, "name") == name {
+				target = el
+				break
+			}
+		}
+		switch {
+		case target == nil:
+			mergeNamedList(added, []interface{}{m})
+			changed = true
+		case isBlock(target, yaml.MappingNode):
+			if e.merge(target, m) {
+				changed = true
+			}
+		default:
+			clone := cloneNode(target)
+			if mergeNode(clone, m) {
+				e.replace(target, lastLine(target), clone)
+				changed = true
+			}
+		}
+	}
+	if len(added.Content) > 0 {
+		rendered, err := renderNode(added, e.indent)
+		if err != nil {
+			return changed
+		}
+		e.insert(lastLine(seq), seq.Column-1, rendered)
+	}
+	return changed
+}
+
+// insertEntries acrescenta as entradas de added ao final do mapa dst, na
+// indentação das chaves existentes.
+func (e *yamlEditor) insertEntries(dst, added *yaml.Node) {
+	if len(dst.Content) == 0 {
+		// Mapa vazio (raiz "{}"): reescreve o próprio nó
+		e.replace(dst, lastLine(dst), added)
+		return
+	}
+	rendered, err := renderNode(added, e.indent)
+	if err != nil {
+		return
+	}
+	e.insert(lastLine(dst), dst.Content[0].Column-1, rendered)
+}
+
+// insert registra a inserção de lines, indentadas em indent colunas, após a linha after.
+func (e *yamlEditor) insert(after, indent int, lines []string) {
+	pad := strings.Repeat(" ", indent)
+	for i := range lines {
+		lines[i] = pad + lines[i]
+	}
+	e.edits = append(e.edits, yamlEdit{start: after + 1, end: after, lines: lines, indent: indent})
+}
+
+// replace registra a troca das linhas de from.Line até end pelo node
+// renderizado. O trecho da primeira linha antes de from (indentação ou "- ")
+// é mantido e as demais linhas são alinhadas à coluna de from.
+func (e *yamlEditor) replace(from *yaml.Node, end int, node *yaml.Node) {
+	rendered, err := renderNode(node, e.indent)
+	if err != nil {
+		return
+	}
+	first := e.lines[from.Line-1]
+	prefix := first
+	if from.Column-1 < len(first) {
+		prefix = first[:from.Column-1]
+	}
+	pad := strings.Repeat(" ", from.Column-1)
+	for i := range rendered {
+		if i == 0 {
+			rendered[i] = prefix + rendered[i]
+		} else {
+			rendered[i] = pad + rendered[i]
+		}
+	}
+	e.edits = append(e.edits, yamlEdit{start: from.Line, end: max(end, from.Line), lines: rendered, indent: from.Column - 1})
+}
+
+// renderNode serializa o nó em linhas sem indentação inicial.
+func renderNode(node *yaml.Node, indent int) ([]string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(max(indent, 2))
+	if err := enc.Encode(node); err != nil {
+		return nil, fmt.Errorf("falha ao serializar YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("falha ao serializar YAML: %w", err)
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
+
+// lastLine retorna a última linha ocupada pelo nó no arquivo.
+func lastLine(n *yaml.Node) int {
+	end := n.Line
+	if n.Kind == yaml.ScalarNode && n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		end += strings.Count(strings.TrimSuffix(n.Value, "\n"), "\n") + 1
+	}
+	for _, c := range n.Content {
+		if l := lastLine(c); l > end {
+			end = l
+		}
+	}
+	return end
+}
+
+// isBlock indica se o nó é do tipo kind, não vazio e em estilo bloco.
+func isBlock(n *yaml.Node, kind yaml.Kind) bool {
+	return n.Kind == kind && len(n.Content) > 0 && n.Style&yaml.FlowStyle == 0
+}
+
+// cloneNode copia o nó e seus filhos.
+func cloneNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+// keyIndex retorna a posição da chave em Content de um mapa, ou -1.
+func keyIndex(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// documentRoot retorna o mapa raiz de um documento YAML.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		return doc.Content[0]
+	}
+	return nil
+}
+
+// mapValue retorna o nó de valor da chave em um mapa.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(m *yaml.Node, key string) string {
+	if v := mapValue(m, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+func nestedScalar(m *yaml.Node, keys ...string) string {
+	for _, k := range keys[:len(keys)-1] {
+		m = mapValue(m, k)
+		if m == nil || m.Kind != yaml.MappingNode {
+			return ""
+		}
+	}
+	return scalarValue(m, keys[len(keys)-1])
+}
+
+// setMapValue define (ou substitui) o valor da chave no mapa.
+func setMapValue(m *yaml.Node, key string, value *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return value
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// mergeNode aplica src sobre o mapa dst com semântica de strategic merge:
+// mapas são mesclados, listas de objetos com "name" são mescladas por nome e
+// demais valores são substituídos. Retorna se dst foi alterado.
+func mergeNode(dst *yaml.Node, src map[string]interface{}) bool {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	changed := false
+	for _, k := range keys {
+		v := src[k]
+		existing := mapValue(dst, k)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if existing == nil || existing.Kind != yaml.MappingNode {
+				existing = setMapValue(dst, k, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+				changed = true
+			}
+			// Mapas vazios em estilo flow ("securityContext: {}") passam a ser em bloco
+			if len(existing.Content) == 0 {
+				existing.Style = 0
+			}
+			if mergeNode(existing, val) {
+				changed = true
+			}
+		case []interface{}:
+			if isNamedList(val) && existing != nil && existing.Kind == yaml.SequenceNode {
+				if mergeNamedList(existing, val) {
+					changed = true
+				}
+				continue
+			}
+			if setIfDifferent(dst, k, existing, val) {
+				changed = true
+			}
+		default:
+			if setIfDifferent(dst, k, existing, val) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// setIfDifferent substitui o valor da chave quando ele difere de val.
+func setIfDifferent(dst *yaml.Node, key string, existing *yaml.Node, val interface{}) bool {
+	var node yaml.Node
+	if err := node.Encode(val); err != nil {
+		return false
+	}
+	if existing != nil && sameNode(existing, &node) {
+		return false
+	}
+	setMapValue(dst, key, &node)
+	return true
+}
+
+// sameNode compara dois nós pelo valor decodificado.
+func sameNode(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
+
+func isNamedList(items []interface{}) bool {
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// mergeNamedList mescla itens por "name"; itens sem correspondente são adicionados.
+func mergeNamedList(seq *yaml.Node, items []interface{}) bool {
+	changed := false
+	for _, item := range items {
+		m := item.(map[string]interface{})
+		name := m["name"].(string)
+		var target *yaml.Node
+		for _, el := range seq.Content {
+			if el.Kind == yaml.MappingNode && scalarValue(el, "name") == name {
+				target = el
+				break
+			}
+		}
+		if target == nil {
+			var node yaml.Node
+			if err := node.Encode(m); err != nil {
+				continue
+			}
+			// "name" primeiro, como nos manifests escritos à mão
+			if i := keyIndex(&node, "name"); i > 0 {
+				pair := append([]*yaml.Node{}, node.Content[i:i+2]...)
+				node.Content = append(pair, append(node.Content[:i], node.Content[i+2:]...)...)
+			}
+			seq.Content = append(seq.Content, &node)
+			changed = true
+			continue
+		}
+		if mergeNode(target, m) {
+			changed = true
+		}
+	}
+	return changed
+}
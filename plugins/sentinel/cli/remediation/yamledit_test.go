@@ -0,0 +1,230 @@
+//go:build k8s
+
+package remediation
+
+import (
+	"strings"
+	"testing"
+)
+
+const deploymentManifest = `# Serviço de API
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+        - name: app # container principal
+          image: api:1.0
+          securityContext:
+            runAsUser: 1000
+        - name: sidecar
+          image: proxy:1.0
+`
+
+func rootPatch(container string) RemediationPatch {
+	return RemediationPatch{
+		ResourceKind: "Pod",
+		ResourceName: "api",
+		Namespace:    "prod",
+		Patch:        `{"spec":{"containers":[{"name":"` + container + `","securityContext":{"runAsNonRoot":true}}]}}`,
+	}
+}
+
+func TestApplyToManifest_MesclaContainerPorNome(t *testing.T) {
+	out, changed, err := ApplyToManifest([]byte(deploymentManifest), Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}, rootPatch("app"))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if !changed {
+		t.Fatal("esperava alteração no manifest")
+	}
+	content := string(out)
+	for _, want := range []string{
+		"# Serviço de API",
+		"- name: app # container principal",
+		"runAsUser: 1000\n            runAsNonRoot: true",
+		"kind: Service",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("manifest sem %q:\n%s", want, content)
+		}
+	}
+	if strings.Count(content, "runAsNonRoot") != 1 {
+		t.Errorf("patch deveria afetar apenas o container app:\n%s", content)
+	}
+
+	// Aplicar de novo não altera nada
+	_, changed, err = ApplyToManifest(out, Workload{Kind: "Deployment", Name: "api", Namespace: "prod"}, rootPatch("app"))
+	if err != nil || changed {
+		t.Errorf("segunda aplicação não deveria alterar (changed=%v, err=%v)", changed, err)
+	}
+}
+
+func TestApplyToManifest_ReescreveSoAsLinhasAlteradas(t *testing.T) {
+	manifest := `# origem: equipe api
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: "api"
+    labels: {app: api, tier: "web"}
+spec:
+    replicas: 2   # escala manual
+    template:
+        spec:
+            containers:
+            -   name: app
+                image: 'api:1.0'
+                securityContext:
+                    privileged: true # legado
+                args:
+                - |
+                  echo "oi"
+                  sleep 10
+            -   name: sidecar
+                securityContext: {}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: api}
+`
+	patch := RemediationPatch{Patch: `{"spec":{"automountServiceAccountToken":false,"containers":[` +
+		`{"name":"app","securityContext":{"privileged":false,"runAsNonRoot":true}},` +
+		`{"name":"sidecar","securityContext":{"readOnlyRootFilesystem":true}},` +
+		`{"name":"init","image":"busybox:1.36"}]}}`}
+	out, changed, err := ApplyToManifest([]byte(manifest), Workload{Kind: "Deployment", Name: "api"}, patch)
+	if err != nil || !changed {
+		t.Fatalf("esperava alteração (changed=%v, err=%v)", changed, err)
+	}
+
+	expected := `# origem: equipe api
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: "api"
+    labels: {app: api, tier: "web"}
+spec:
+    replicas: 2   # escala manual
+    template:
+        spec:
+            containers:
+            -   name: app
+                image: 'api:1.0'
+                securityContext:
+                    privileged: false # legado
+                    runAsNonRoot: true
+                args:
+                - |
+                  echo "oi"
+                  sleep 10
+            -   name: sidecar
+                securityContext:
+                    readOnlyRootFilesystem: true
+            - name: init
+              image: busybox:1.36
+            automountServiceAccountToken: false
+---
+apiVersion: v1
+kind: Service
+metadata: {name: api}
+`
+	if string(out) != expected {
+		t.Errorf("manifest inesperado:\n%s", out)
+	}
+}
+
+func TestApplyToManifest_WorkloadAusente(t *testing.T) {
+	_, _, err := ApplyToManifest([]byte(deploymentManifest), Workload{Kind: "Deployment", Name: "outro"}, rootPatch("app"))
+	if err == nil {
+		t.Error("esperava erro para workload ausente no arquivo")
+	}
+	_, _, err = ApplyToManifest([]byte(deploymentManifest), Workload{Kind: "Service", Name: "api"}, rootPatch("app"))
+	if err == nil {
+		t.Error("esperava erro para tipo sem PodSpec")
+	}
+}
+
+func TestApplyToManifest_CronJobEPodSpecLevel(t *testing.T) {
+	manifest := `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: "0 3 * * *"
+`
+	patch := RemediationPatch{Patch: `{"spec":{"automountServiceAccountToken":false}}`}
+	out, changed, err := ApplyToManifest([]byte(manifest), Workload{Kind: "CronJob", Name: "backup"}, patch)
+	if err != nil || !changed {
+		t.Fatalf("esperava alteração (changed=%v, err=%v)", changed, err)
+	}
+	if !strings.Contains(string(out), "jobTemplate:\n    spec:\n      template:\n        spec:\n          automountServiceAccountToken: false") {
+		t.Errorf("caminho do PodSpec do CronJob inesperado:\n%s", out)
+	}
+}
+
+func TestHelmValuesPatch(t *testing.T) {
+	templates := `securityContext: {{- toYaml .Values.securityContext | nindent 12 }}
+automountServiceAccountToken: {{ .Values.serviceAccount.automount }}`
+
+	values, err := HelmValuesPatch(rootPatch("app"), templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, ok := values["securityContext"].(map[string]interface{})
+	if !ok || sc["runAsNonRoot"] != true {
+		t.Errorf("values inesperados: %v", values)
+	}
+
+	values, err = HelmValuesPatch(RemediationPatch{Patch: `{"spec":{"automountServiceAccountToken":false}}`}, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["serviceAccount"].(map[string]interface{})["automount"] != false {
+		t.Errorf("values inesperados: %v", values)
+	}
+
+	limits := RemediationPatch{Patch: `{"spec":{"containers":[{"name":"app","resources":{"limits":{"cpu":"500m"}}}]}}`}
+	if _, err := HelmValuesPatch(limits, templates); err == nil {
+		t.Error("esperava erro quando o chart não expõe resources")
+	}
+}
+
+func TestApplyToValues_PreservaComentarios(t *testing.T) {
+	values := `# Valores padrão
+replicaCount: 1
+securityContext: {}
+  # capabilities:
+  #   drop:
+  #   - ALL
+`
+	out, changed, err := ApplyToValues([]byte(values), map[string]interface{}{
+		"securityContext": map[string]interface{}{"runAsNonRoot": true},
+	})
+	if err != nil || !changed {
+		t.Fatalf("esperava alteração (changed=%v, err=%v)", changed, err)
+	}
+	content := string(out)
+	if !strings.Contains(content, "# Valores padrão") || !strings.Contains(content, "securityContext:\n  runAsNonRoot: true") {
+		t.Errorf("values inesperado:\n%s", content)
+	}
+}
+
+func TestApplyToValues_ArquivoSemChaves(t *testing.T) {
+	out, changed, err := ApplyToValues([]byte("# sem valores ainda"), map[string]interface{}{
+		"serviceAccount": map[string]interface{}{"automount": false},
+	})
+	if err != nil || !changed {
+		t.Fatalf("esperava alteração (changed=%v, err=%v)", changed, err)
+	}
+	if string(out) != "# sem valores ainda\nserviceAccount:\n  automount: false\n" {
+		t.Errorf("values inesperado:\n%q", out)
+	}
+}
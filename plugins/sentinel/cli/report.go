@@ -45,6 +45,9 @@ func exportMarkdown(result AnalysisResult, podName, namespace string) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("# Relatório Sentinel — %s/%s\n\n", namespace, podName))
 	sb.WriteString(fmt.Sprintf("**Data:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	if result.RuleID != "" {
+		sb.WriteString(fmt.Sprintf("**Diagnóstico:** regra determinística `%s` (sem IA)\n\n", result.RuleID))
+	}
 	sb.WriteString("## Causa Raiz\n\n")
 	sb.WriteString(result.RootCause + "\n\n")
 	sb.WriteString("## Detalhe Técnico\n\n")
@@ -6,8 +6,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,121 +18,262 @@ import (
 	"github.com/casheiro/yby-cli/pkg/ai/prompts"
 	"github.com/casheiro/yby-cli/plugins/sentinel/cli/backends"
 	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/manifests"
 	"github.com/casheiro/yby-cli/plugins/sentinel/cli/profiles"
 	"github.com/casheiro/yby-cli/plugins/sentinel/cli/remediation"
 	"github.com/charmbracelet/lipgloss"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes"
 )
 
 // ScanReport contém o resultado completo do scan de segurança.
 type ScanReport struct {
 	Namespace       string                   `json:"namespace"`
+	Path            string                   `json:"path,omitempty"`
 	Findings        []checks.SecurityFinding `json:"findings"`
 	Sources         []string                 `json:"sources,omitempty"`
 	Recommendations string                   `json:"recommendations,omitempty"`
+	// Suppressed é a quantidade de findings suprimidos pelo baseline.
+	Suppressed int `json:"suppressed,omitempty"`
+	// ExpiredSuppressions lista as entradas do baseline cuja validade expirou.
+	ExpiredSuppressions []BaselineEntry `json:"expired_suppressions,omitempty"`
+	// Namespaces traz a pontuação por namespace em scans multi-namespace.
+	Namespaces []NamespaceSummary `json:"namespaces,omitempty"`
+
+	// scope delimita os namespaces e objetos avaliados, usado por `baseline update`.
+	scope baselineScope
 }
 
-func scanNamespace(namespace, outputFormat, outputFile, profile string, fix, fixDryRun bool) {
-	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true).Padding(0, 1)
-	fmt.Println(titleStyle.Render(fmt.Sprintf("\n Sentinel Security Scan: %s", namespace)))
-
-	k8sClient, err := getKubeClient()
-	if err != nil {
-		fmt.Printf("Falha ao obter cliente Kubernetes: %v\n", err)
-		return
-	}
-
-	ctx := context.Background()
-
-	// 1. Rodar backends de seguranca (Polaris, OPA)
-	allBackends := []backends.SecurityBackend{
-		backends.NewPolarisBackend(),
-		backends.NewOPABackend(),
-	}
-
-	var backendFindings []backends.Finding
-	var sources []string
+// scanOptions agrupa as flags do subcomando scan.
+type scanOptions struct {
+	Namespace    string
+	OutputFormat string
+	OutputFile   string
+	Profile      string
+	// Path ativa o scan offline dos manifests (Helm, Kustomize, YAML) do projeto.
+	Path      string
+	Fix       bool
+	FixDryRun bool
+	// GitOps aplica a remediação nos manifests/values do repositório, em uma
+	// branch com um commit por finding, em vez de aplicar patches no cluster.
+	GitOps       bool
+	GitOpsBranch string
+	// Baseline sobrescreve o caminho do arquivo de baseline (padrão: .yby/sentinel-baseline.yaml).
+	Baseline   string
+	NoBaseline bool
+	// NoHistory desativa o registro do scan em .yby/sentinel/history.
+	NoHistory bool
+	// AllNamespaces escaneia todos os namespaces do cluster, filtrados pelo Selector.
+	AllNamespaces bool
+	Selector      namespaceSelector
+	// Concurrency limita backends/checks executando em paralelo no scan multi-namespace.
+	Concurrency int
+	// FailOn define a severidade mínima que torna o scan reprovado (exit code != 0).
+	FailOn string
+}
 
-	for _, b := range allBackends {
-		if !b.IsAvailable() {
+// parseScanArgs interpreta os argumentos do subcomando scan.
+func parseScanArgs(args []string) scanOptions {
+	var opts scanOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-n" || arg == "--namespace" {
+			if i+1 < len(args) {
+				opts.Namespace = args[i+1]
+				i++
+			}
 			continue
 		}
-		bf, err := b.ScanCluster(ctx, k8sClient, namespace)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "aviso: backend %s falhou: %v\n", b.Name(), err)
+		if arg == "--output" || arg == "-o" {
+			if i+1 < len(args) {
+				opts.OutputFormat = args[i+1]
+				i++
+			}
+			continue
+		}
+		if arg == "--file" || arg == "-f" {
+			if i+1 < len(args) {
+				opts.OutputFile = args[i+1]
+				i++
+			}
 			continue
 		}
-		if len(bf) > 0 {
-			backendFindings = append(backendFindings, bf...)
-			sources = append(sources, b.Name())
+		if arg == "--profile" || arg == "-p" {
+			if i+1 < len(args) {
+				opts.Profile = args[i+1]
+				i++
+			}
+			continue
 		}
-	}
-
-	// 2. Converter findings dos backends para o formato SecurityFinding
-	var findings []checks.SecurityFinding
-	for _, bf := range backendFindings {
-		findings = append(findings, checks.SecurityFinding{
-			CheckID:        bf.ID,
-			Severity:       checks.Severity(bf.Severity),
-			Category:       checks.Category(bf.Category),
-			Namespace:      bf.Namespace,
-			Resource:       bf.Resource,
-			Message:        bf.Message,
-			Recommendation: bf.Recommendation,
-			Type:           bf.Severity,
-			Description:    bf.Message,
-		})
-	}
-
-	// 3. Se nenhum backend rodou, fallback para checks artesanais
-	if len(sources) == 0 {
-		fmt.Println("Usando checks internos (nenhum backend disponivel)")
-		sources = append(sources, "checks-internos")
-
-		var selectedChecks []checks.SecurityCheck
-		if profile != "" {
-			p, ok := profiles.GetProfile(profile)
-			if !ok {
-				fmt.Printf("Perfil '%s' nao encontrado. Disponiveis:\n", profile)
-				for _, prof := range profiles.ListProfiles() {
-					fmt.Printf("  - %s: %s\n", prof.Name, prof.Description)
+		if arg == "--path" {
+			if i+1 < len(args) {
+				opts.Path = args[i+1]
+				i++
+			}
+			continue
+		}
+		if arg == "--fail-on" {
+			if i+1 < len(args) {
+				opts.FailOn = args[i+1]
+				i++
+			}
+			continue
+		}
+		if arg == "--all-namespaces" || arg == "-A" {
+			opts.AllNamespaces = true
+			continue
+		}
+		if arg == "--include-namespaces" {
+			if i+1 < len(args) {
+				opts.Selector.Include = parsePatternList(args[i+1])
+				i++
+			}
+			continue
+		}
+		if arg == "--exclude-namespaces" {
+			if i+1 < len(args) {
+				opts.Selector.Exclude = parsePatternList(args[i+1])
+				i++
+			}
+			continue
+		}
+		if arg == "--concurrency" || arg == "-j" {
+			if i+1 < len(args) {
+				if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+					opts.Concurrency = n
 				}
-				return
+				i++
 			}
-			selectedChecks = checks.GetByIDs(p.CheckIDs)
-		} else {
-			selectedChecks = checks.GetAll()
+			continue
 		}
-
-		for _, check := range selectedChecks {
-			checkFindings, err := check.Run(ctx, k8sClient, namespace)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "aviso: check '%s' falhou: %v\n", check.Name(), err)
-				continue
+		if arg == "--baseline" {
+			if i+1 < len(args) {
+				opts.Baseline = args[i+1]
+				i++
+			}
+			continue
+		}
+		if arg == "--no-baseline" {
+			opts.NoBaseline = true
+			continue
+		}
+		if arg == "--no-history" {
+			opts.NoHistory = true
+			continue
+		}
+		if arg == "--fix" {
+			opts.Fix = true
+			continue
+		}
+		if arg == "--fix-dry-run" {
+			opts.FixDryRun = true
+			continue
+		}
+		if arg == "--gitops" {
+			opts.GitOps = true
+			continue
+		}
+		if arg == "--gitops-branch" {
+			if i+1 < len(args) {
+				opts.GitOpsBranch = args[i+1]
+				i++
 			}
-			findings = append(findings, checkFindings...)
+			continue
 		}
 	}
 
-	// 4. Deduplicar findings (mesmo recurso + mesma mensagem)
-	findings = deduplicateFindings(findings)
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = defaultScanConcurrency
+	}
+	return opts
+}
 
-	report := ScanReport{
-		Namespace: namespace,
-		Findings:  findings,
-		Sources:   sources,
+// baselinePath retorna o caminho do arquivo de baseline a usar.
+func (o scanOptions) baselinePath() string {
+	if o.Baseline != "" {
+		return o.Baseline
 	}
+	return defaultBaselineFile
+}
+
+// scanNamespace executa o scan de segurança de um namespace e retorna o relatório gerado.
+// Retorna nil quando o scan não pôde ser concluído.
+func scanNamespace(opts scanOptions) *ScanReport {
+	namespace, outputFormat, outputFile := opts.Namespace, opts.OutputFormat, opts.OutputFile
+	fix, fixDryRun := opts.Fix, opts.FixDryRun
+	out := opts.humanOutput()
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true).Padding(0, 1)
+	target := namespace
+	if opts.Path != "" {
+		target = opts.Path
+	} else if opts.AllNamespaces {
+		target = "todos os namespaces"
+	}
+	fmt.Fprintln(out, titleStyle.Render(fmt.Sprintf("\n Sentinel Security Scan: %s", target)))
+
+	ctx := context.Background()
+
+	if opts.Path != "" && fix && !opts.GitOps {
+		fmt.Fprintln(out, "--fix nao e suportado com --path (nao ha cluster para aplicar patches). Use --fix --gitops ou --fix-dry-run.")
+		return nil
+	}
+
+	collected, k8sClient, err := collectScan(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(out, "Falha no scan: %v\n", err)
+		return nil
+	}
+	report := *collected
+
+	// Registrar no histórico todos os findings, antes do baseline, para que
+	// suppressões não apareçam como correções no trend
+	if !opts.NoHistory {
+		clusterContext := currentClusterContext()
+		if opts.Path != "" {
+			clusterContext = offlineHistoryContext
+		}
+		if _, err := appendHistory(newHistoryRecord(report, clusterContext, opts.Profile, time.Now())); err != nil {
+			fmt.Fprintf(os.Stderr, "aviso: historico nao registrado: %v\n", err)
+		}
+	}
+
+	// Suprimir findings aceitos no baseline; suppressões expiradas voltam a ser reportadas
+	if !opts.NoBaseline {
+		baseline, err := loadBaseline(opts.baselinePath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aviso: baseline ignorado: %v\n", err)
+		} else if len(baseline.Suppressions) > 0 {
+			result := applyBaseline(report.Findings, baseline, time.Now())
+			report.Findings = result.Active
+			report.Suppressed = result.Suppressed
+			report.ExpiredSuppressions = result.Expired
+			fmt.Fprintf(out, "Baseline: %d findings suprimidos, %d suppressoes expiradas\n", result.Suppressed, len(result.Expired))
+			if len(report.Namespaces) > 0 {
+				report.Namespaces = summarizeNamespaces(report.Findings, namespaceNames(report.Namespaces))
+			}
+		}
+	}
+	findings := report.Findings
 
 	if len(findings) == 0 {
-		fmt.Println("\nNenhuma vulnerabilidade encontrada!")
-		return
+		fmt.Fprintln(out, "\nNenhuma vulnerabilidade encontrada!")
+		// Formatos de CI sempre geram artefato, mesmo sem findings
+		if isCIFormat(outputFormat) {
+			writeCIReport(out, report, outputFormat, outputFile)
+		}
+		return &report
 	}
 
-	fmt.Printf("\n%d vulnerabilidades encontradas.\n", len(findings))
+	fmt.Fprintf(out, "\n%d vulnerabilidades encontradas.\n", len(findings))
 
 	// Gerar recomendações via IA
 	provider := ai.GetProvider(ctx, "auto")
 	if provider != nil {
-		fmt.Println("Gerando recomendacoes com IA...")
+		fmt.Fprintln(out, "Gerando recomendacoes com IA...")
 		findingsJSON, _ := json.MarshalIndent(findings, "", "  ")
 		recommendations, err := provider.Completion(ctx, prompts.Get("sentinel.scan"), string(findingsJSON))
 		if err == nil {
@@ -143,22 +287,24 @@ func scanNamespace(namespace, outputFormat, outputFile, profile string, fix, fix
 	if fix || fixDryRun {
 		patches := remediation.GeneratePatches(findings)
 		if len(patches) == 0 {
-			fmt.Println("\nNenhum patch de remediacao disponivel para os findings encontrados.")
+			fmt.Fprintln(out, "\nNenhum patch de remediacao disponivel para os findings encontrados.")
+		} else if opts.GitOps {
+			runGitOpsRemediation(ctx, k8sClient, patches, opts)
 		} else if fixDryRun {
-			fmt.Printf("\nDry-run: %d patches de remediacao gerados:\n", len(patches))
+			fmt.Fprintf(out, "\nDry-run: %d patches de remediacao gerados:\n", len(patches))
 			for i, p := range patches {
-				fmt.Printf("  %d. [%s] %s/%s - %s\n", i+1, p.ResourceKind, p.Namespace, p.ResourceName, p.Description)
-				fmt.Printf("     Patch: %s\n", p.Patch)
+				fmt.Fprintf(out, "  %d. [%s] %s/%s - %s\n", i+1, p.ResourceKind, p.Namespace, p.ResourceName, p.Description)
+				fmt.Fprintf(out, "     Patch: %s\n", p.Patch)
 			}
 		} else {
-			fmt.Printf("\nAplicando %d patches de remediacao...\n", len(patches))
+			fmt.Fprintf(out, "\nAplicando %d patches de remediacao...\n", len(patches))
 			errs := remediation.ApplyPatches(ctx, k8sClient, patches)
 			if len(errs) > 0 {
 				for _, e := range errs {
-					fmt.Printf("  Erro: %v\n", e)
+					fmt.Fprintf(out, "  Erro: %v\n", e)
 				}
 			} else {
-				fmt.Printf("  Todos os %d patches aplicados com sucesso!\n", len(patches))
+				fmt.Fprintf(out, "  Todos os %d patches aplicados com sucesso!\n", len(patches))
 			}
 		}
 	}
@@ -167,36 +313,311 @@ func scanNamespace(namespace, outputFormat, outputFile, profile string, fix, fix
 	if outputFormat == "json" {
 		data, _ := json.MarshalIndent(report, "", "  ")
 		if err := writeReport(string(data), outputFile); err != nil {
-			fmt.Printf("Erro ao escrever relatorio: %v\n", err)
-			return
+			fmt.Fprintf(out, "Erro ao escrever relatorio: %v\n", err)
+			return &report
 		}
 		if outputFile != "" {
-			fmt.Printf("Relatorio JSON salvo em %s\n", outputFile)
+			fmt.Fprintf(out, "Relatorio JSON salvo em %s\n", outputFile)
 		}
-		return
+		return &report
 	}
 
 	if outputFormat == "markdown" && outputFile != "" {
 		content := exportScanMarkdown(report)
 		if err := writeReport(content, outputFile); err != nil {
-			fmt.Printf("Erro ao escrever relatorio: %v\n", err)
-			return
+			fmt.Fprintf(out, "Erro ao escrever relatorio: %v\n", err)
+			return &report
 		}
-		fmt.Printf("Relatorio Markdown salvo em %s\n", outputFile)
-		return
+		fmt.Fprintf(out, "Relatorio Markdown salvo em %s\n", outputFile)
+		return &report
+	}
+
+	if isCIFormat(outputFormat) {
+		writeCIReport(out, report, outputFormat, outputFile)
+		return &report
 	}
 
 	// Padrão: resumo no terminal + relatório completo em ~/.yby/reports/
-	reportPath := saveReportToGlobal(report, namespace)
+	reportPath := saveReportToGlobal(report, report.Namespace)
 	renderScanSummary(report, reportPath)
+	return &report
+}
+
+// humanOutput retorna onde escrever progresso e mensagens para humanos: stderr
+// quando o relatório em formato de máquina (json, sarif, junit) vai para o
+// stdout, para que `scan -o sarif > out.sarif` gere um documento válido.
+func (o scanOptions) humanOutput() io.Writer {
+	if o.OutputFile == "" && (o.OutputFormat == "json" || isCIFormat(o.OutputFormat)) {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// isCIFormat indica se o formato de saída é voltado para pipelines de CI.
+func isCIFormat(format string) bool {
+	return format == "sarif" || format == "junit"
+}
+
+// writeCIReport exporta o relatório em SARIF ou JUnit para arquivo ou stdout.
+func writeCIReport(out io.Writer, report ScanReport, format, outputFile string) {
+	var content string
+	var err error
+	if format == "sarif" {
+		content, err = exportScanSARIF(report)
+	} else {
+		content, err = exportScanJUnit(report)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Erro ao exportar relatorio: %v\n", err)
+		return
+	}
+	if err := writeReport(content, outputFile); err != nil {
+		fmt.Fprintf(out, "Erro ao escrever relatorio: %v\n", err)
+		return
+	}
+	if outputFile != "" {
+		fmt.Fprintf(out, "Relatorio %s salvo em %s\n", strings.ToUpper(format), outputFile)
+	}
+}
+
+// severityRank retorna a ordem de gravidade de uma severidade (maior = mais grave).
+// Retorna -1 para valores desconhecidos.
+func severityRank(sev checks.Severity) int {
+	switch sev {
+	case checks.SeverityCritical:
+		return 4
+	case checks.SeverityHigh:
+		return 3
+	case checks.SeverityMedium:
+		return 2
+	case checks.SeverityLow:
+		return 1
+	case checks.SeverityInfo:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// findingSeverity retorna a severidade do finding, usando o campo Type
+// como fallback para findings no formato antigo ("critical"/"warning").
+func findingSeverity(f checks.SecurityFinding) checks.Severity {
+	if f.Severity != "" {
+		return f.Severity
+	}
+	if f.Type == "warning" {
+		return checks.SeverityMedium
+	}
+	return checks.Severity(f.Type)
+}
+
+// exceedsThreshold indica se algum finding atinge ou supera a severidade informada.
+func exceedsThreshold(findings []checks.SecurityFinding, threshold checks.Severity) bool {
+	min := severityRank(threshold)
+	if min < 0 {
+		return false
+	}
+	for _, f := range findings {
+		if severityRank(findingSeverity(f)) >= min {
+			return true
+		}
+	}
+	return false
+}
+
+// collectScan executa backends e checks conforme as opções e retorna o relatório
+// deduplicado, sem renderização. No modo offline (--path), o cliente retornado
+// é o clientset em memória com os manifests do projeto.
+func collectScan(ctx context.Context, opts scanOptions) (*ScanReport, kubernetes.Interface, error) {
+	selectedChecks, err := selectChecks(opts.Profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var k8sClient kubernetes.Interface
+	var findings []checks.SecurityFinding
+	var sources []string
+	var namespaces []string
+	var manifestObjects map[string]bool
+	reportNamespace := opts.Namespace
+
+	if opts.Path != "" {
+		// Modo offline: manifests do projeto carregados em um clientset em memória.
+		// Polaris depende de um cluster real, então roda apenas o OPA + checks internos.
+		client, manifestNamespaces, objects, err := loadManifestClient(opts.humanOutput(), opts.Path, opts.Namespace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("falha ao carregar manifests: %w", err)
+		}
+		k8sClient = client
+		manifestObjects = objects
+		namespaces = opts.Selector.filter(manifestNamespaces)
+		findings, sources = scanNamespacesParallel(ctx, client, namespaces,
+			[]backends.SecurityBackend{backends.NewOPABackend()}, selectedChecks, opts.Concurrency, true)
+	} else {
+		k8sClient, err = getKubeClient()
+		if err != nil {
+			return nil, nil, fmt.Errorf("falha ao obter cliente Kubernetes: %w", err)
+		}
+
+		if opts.AllNamespaces {
+			namespaces, err = listClusterNamespaces(ctx, k8sClient, opts.Selector)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(namespaces) == 0 {
+				return nil, nil, fmt.Errorf("nenhum namespace corresponde ao seletor")
+			}
+			fmt.Fprintf(opts.humanOutput(), "Escaneando %d namespaces (concorrencia %d)\n", len(namespaces), opts.Concurrency)
+			reportNamespace = allNamespacesLabel
+			findings, sources = scanNamespacesParallel(ctx, k8sClient, namespaces, []backends.SecurityBackend{
+				backends.NewPolarisBackend(),
+				backends.NewOPABackend(),
+				backends.NewTrivyBackend(),
+			}, selectedChecks, opts.Concurrency, false)
+		} else {
+			// 1. Rodar backends de seguranca (Polaris, OPA, Trivy se disponivel)
+			findings, sources = runBackends(ctx, k8sClient, opts.Namespace, []backends.SecurityBackend{
+				backends.NewPolarisBackend(),
+				backends.NewOPABackend(),
+				backends.NewTrivyBackend(),
+			})
+
+			// 2. Se nenhum backend rodou, fallback para checks artesanais
+			if len(sources) == 0 {
+				fmt.Fprintln(opts.humanOutput(), "Usando checks internos (nenhum backend disponivel)")
+				sources = append(sources, "checks-internos")
+				findings = append(findings, runChecks(ctx, k8sClient, opts.Namespace, selectedChecks)...)
+			}
+		}
+	}
+
+	// 3. Deduplicar findings (mesmo namespace + recurso + mesma mensagem)
+	report := &ScanReport{
+		Namespace: reportNamespace,
+		Path:      opts.Path,
+		Findings:  deduplicateFindings(findings),
+		Sources:   sources,
+	}
+	if len(namespaces) > 1 || opts.AllNamespaces {
+		report.Namespaces = summarizeNamespaces(report.Findings, namespaces)
+	}
+	if namespaces == nil {
+		namespaces = []string{opts.Namespace}
+	}
+	report.scope = newBaselineScope(namespaces)
+	report.scope.objects = manifestObjects
+	return report, k8sClient, nil
+}
+
+// selectChecks retorna os checks do perfil de compliance, ou todos quando profile é vazio.
+func selectChecks(profile string) ([]checks.SecurityCheck, error) {
+	if profile == "" {
+		return checks.GetAll(), nil
+	}
+	p, ok := profiles.GetProfile(profile)
+	if !ok {
+		var names []string
+		for _, prof := range profiles.ListProfiles() {
+			names = append(names, prof.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("perfil '%s' nao encontrado (disponiveis: %s)", profile, strings.Join(names, ", "))
+	}
+	return checks.GetByIDs(p.CheckIDs), nil
+}
+
+// runBackends executa os backends disponíveis no namespace e converte seus
+// findings para o formato SecurityFinding. Retorna também os backends que
+// produziram resultados.
+func runBackends(ctx context.Context, client kubernetes.Interface, namespace string, bs []backends.SecurityBackend) ([]checks.SecurityFinding, []string) {
+	var findings []checks.SecurityFinding
+	var sources []string
+
+	for _, b := range bs {
+		if !b.IsAvailable() {
+			continue
+		}
+		bf, err := b.ScanCluster(ctx, client, namespace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aviso: backend %s falhou: %v\n", b.Name(), err)
+			continue
+		}
+		if len(bf) == 0 {
+			continue
+		}
+		sources = append(sources, b.Name())
+		for _, f := range bf {
+			findings = append(findings, backendToSecurityFinding(f))
+		}
+	}
+	return findings, sources
 }
 
-// deduplicateFindings remove findings duplicados (mesmo recurso + mesma mensagem).
+// backendToSecurityFinding converte um finding de backend para o formato SecurityFinding.
+func backendToSecurityFinding(bf backends.Finding) checks.SecurityFinding {
+	return checks.SecurityFinding{
+		CheckID:        bf.ID,
+		Severity:       checks.Severity(bf.Severity),
+		Category:       checks.Category(bf.Category),
+		Namespace:      bf.Namespace,
+		Resource:       bf.Resource,
+		Message:        bf.Message,
+		Recommendation: bf.Recommendation,
+		Type:           bf.Severity,
+		Description:    bf.Message,
+	}
+}
+
+// runChecks executa os checks internos selecionados no namespace.
+func runChecks(ctx context.Context, client kubernetes.Interface, namespace string, selected []checks.SecurityCheck) []checks.SecurityFinding {
+	var findings []checks.SecurityFinding
+	for _, check := range selected {
+		checkFindings, err := check.Run(ctx, client, namespace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aviso: check '%s' falhou: %v\n", check.Name(), err)
+			continue
+		}
+		findings = append(findings, checkFindings...)
+	}
+	return findings
+}
+
+// loadManifestClient renderiza os manifests sob path e retorna um clientset em
+// memória com os objetos, junto com os namespaces a escanear e os objetos
+// carregados ("namespace|nome"). O progresso vai para out.
+func loadManifestClient(out io.Writer, path, defaultNamespace string) (kubernetes.Interface, []string, map[string]bool, error) {
+	result, err := manifests.Load(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "aviso: %s\n", w)
+	}
+	if len(result.Objects) == 0 {
+		return nil, nil, nil, fmt.Errorf("nenhum objeto Kubernetes encontrado em %s", path)
+	}
+	fmt.Fprintf(out, "%d objetos carregados de %d fontes\n", len(result.Objects), len(result.Sources))
+
+	client, namespaces := manifests.NewClient(result.Objects, defaultNamespace)
+	if len(namespaces) == 0 {
+		namespaces = []string{defaultNamespace}
+	}
+	// NewClient preenche o namespace padrão nos próprios objetos
+	objects := make(map[string]bool, len(result.Objects))
+	for _, obj := range result.Objects {
+		if accessor, err := meta.Accessor(obj); err == nil {
+			objects[accessor.GetNamespace()+"|"+accessor.GetName()] = true
+		}
+	}
+	return client, namespaces, objects, nil
+}
+
+// deduplicateFindings remove findings duplicados (mesmo namespace + recurso + mesma mensagem).
 func deduplicateFindings(findings []checks.SecurityFinding) []checks.SecurityFinding {
 	seen := make(map[string]bool)
 	var result []checks.SecurityFinding
 	for _, f := range findings {
-		key := fmt.Sprintf("%s|%s|%s", f.Resource, f.Category, f.Message)
+		key := fmt.Sprintf("%s|%s|%s|%s", f.Namespace, f.Resource, f.Category, f.Message)
 		if seen[key] {
 			continue
 		}
@@ -250,6 +671,16 @@ func exportScanMarkdown(report ScanReport) string {
 		sb.WriteString(fmt.Sprintf("**Backends:** %s\n", strings.Join(report.Sources, ", ")))
 	}
 	sb.WriteString("\n")
+
+	if len(report.Namespaces) > 0 {
+		sb.WriteString("## Pontuação por Namespace\n\n")
+		sb.WriteString("| Namespace | Findings | Score |\n|---|---|---|\n")
+		for _, ns := range report.Namespaces {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %d |\n", ns.Namespace, ns.Findings, ns.Score))
+		}
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("## Vulnerabilidades\n\n")
 
 	for _, f := range report.Findings {
@@ -260,6 +691,13 @@ func exportScanMarkdown(report ScanReport) string {
 		sb.WriteString(fmt.Sprintf("- %s **[%s]** `%s`: %s\n", icon, f.Category, f.Resource, f.Description))
 	}
 
+	if len(report.ExpiredSuppressions) > 0 {
+		sb.WriteString("\n## Suppressões Expiradas\n\n")
+		for _, e := range report.ExpiredSuppressions {
+			sb.WriteString(fmt.Sprintf("- `%s` em `%s/%s` (owner: %s, expirou em %s): %s\n", e.CheckID, e.Namespace, e.Resource, e.Owner, e.Expires, e.Justification))
+		}
+	}
+
 	if report.Recommendations != "" {
 		sb.WriteString("\n## Recomendações\n\n")
 		sb.WriteString(report.Recommendations + "\n")
@@ -301,6 +739,26 @@ func renderScanSummary(report ScanReport, reportPath string) {
 		fmt.Printf("    %-20s %d\n", cat, count)
 	}
 
+	if len(report.Namespaces) > 0 {
+		fmt.Println()
+		fmt.Println("  Por namespace (score 0-100):")
+		for _, ns := range report.Namespaces {
+			fmt.Printf("    %-30s %3d findings  score %3d\n", ns.Namespace, ns.Findings, ns.Score)
+		}
+	}
+
+	if report.Suppressed > 0 {
+		fmt.Println()
+		fmt.Printf("  Suprimidos pelo baseline: %d\n", report.Suppressed)
+	}
+	if len(report.ExpiredSuppressions) > 0 {
+		fmt.Println()
+		fmt.Println("  Suppressoes expiradas (renove ou corrija):")
+		for _, e := range report.ExpiredSuppressions {
+			fmt.Printf("    %s %s/%s (owner: %s, expirou em %s)\n", e.CheckID, e.Namespace, e.Resource, e.Owner, e.Expires)
+		}
+	}
+
 	if reportPath != "" {
 		fmt.Println()
 		fmt.Printf("  Relatorio completo: %s\n", reportPath)
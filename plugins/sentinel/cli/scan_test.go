@@ -3,6 +3,12 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -65,3 +71,216 @@ func TestExportScanMarkdown_ComRecomendacoes(t *testing.T) {
 		t.Errorf("markdown deveria conter o texto das recomendações, conteúdo: %s", content)
 	}
 }
+
+// --- parseScanArgs ---
+
+func TestParseScanArgs_FlagsCompletas(t *testing.T) {
+	opts := parseScanArgs([]string{"-n", "prod", "-o", "sarif", "-f", "out.sarif", "--profile", "soc2", "--fail-on", "high", "--fix-dry-run"})
+
+	if opts.Namespace != "prod" || opts.OutputFormat != "sarif" || opts.OutputFile != "out.sarif" {
+		t.Errorf("flags básicas não interpretadas: %+v", opts)
+	}
+	if opts.Profile != "soc2" || opts.FailOn != "high" || !opts.FixDryRun || opts.Fix {
+		t.Errorf("flags de perfil/gate não interpretadas: %+v", opts)
+	}
+}
+
+func TestParseScanArgs_NamespacePadrao(t *testing.T) {
+	opts := parseScanArgs(nil)
+	if opts.Namespace != "default" {
+		t.Errorf("esperava namespace default, obteve %s", opts.Namespace)
+	}
+}
+
+// --- exceedsThreshold ---
+
+func TestExceedsThreshold(t *testing.T) {
+	findings := []checks.SecurityFinding{
+		{Severity: checks.SeverityMedium},
+		{Type: "warning"},
+	}
+
+	if exceedsThreshold(findings, checks.SeverityHigh) {
+		t.Error("findings medium não deveriam reprovar com threshold high")
+	}
+	if !exceedsThreshold(findings, checks.SeverityMedium) {
+		t.Error("finding medium deveria reprovar com threshold medium")
+	}
+	if !exceedsThreshold([]checks.SecurityFinding{{Type: "critical"}}, checks.SeverityHigh) {
+		t.Error("finding critical no formato antigo deveria reprovar com threshold high")
+	}
+	if exceedsThreshold(findings, checks.Severity("bogus")) {
+		t.Error("threshold inválido não deveria reprovar")
+	}
+}
+
+// --- scan offline (--path) ---
+
+func TestLoadManifestClient_ChecksRodamSobreManifests(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.27
+          securityContext:
+            privileged: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, namespaces, objects, err := loadManifestClient(io.Discard, dir, "apps")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(namespaces) != 1 || namespaces[0] != "apps" {
+		t.Fatalf("esperava namespace [apps], obteve %v", namespaces)
+	}
+	if !objects["apps|api"] {
+		t.Errorf("esperava o objeto apps|api entre os carregados, obteve %v", objects)
+	}
+
+	findings := runChecks(context.Background(), client, "apps", checks.GetByIDs([]string{"POD_PRIVILEGED"}))
+	if len(findings) != 1 {
+		t.Fatalf("esperava 1 finding de container privilegiado, obteve %d", len(findings))
+	}
+	if findings[0].Pod != "api" {
+		t.Errorf("esperava finding no pod sintetizado 'api', obteve %s", findings[0].Pod)
+	}
+}
+
+func TestLoadManifestClient_DiretorioVazio(t *testing.T) {
+	if _, _, _, err := loadManifestClient(io.Discard, t.TempDir(), "default"); err == nil {
+		t.Error("esperava erro para diretório sem manifests")
+	}
+}
+
+func TestParseScanArgs_Path(t *testing.T) {
+	opts := parseScanArgs([]string{"--path", "./charts"})
+	if opts.Path != "./charts" {
+		t.Errorf("esperava path ./charts, obteve %s", opts.Path)
+	}
+}
+
+func TestParseScanArgs_GitOps(t *testing.T) {
+	opts := parseScanArgs([]string{"--fix", "--gitops", "--gitops-branch", "fix/sentinel"})
+	if !opts.Fix || !opts.GitOps || opts.GitOpsBranch != "fix/sentinel" {
+		t.Errorf("flags de gitops não interpretadas: %+v", opts)
+	}
+}
+
+// --- saída de máquina no stdout ---
+
+// captureStdout executa fn com os.Stdout redirecionado e retorna o que foi escrito.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("falha ao criar pipe: %v", err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+// offlineScanOptions prepara um scan --path de um Deployment privilegiado, sem
+// histórico, baseline nem provider de IA.
+func offlineScanOptions(t *testing.T, format string) scanOptions {
+	t.Helper()
+	t.Setenv("YBY_AI_PROVIDER", "nenhum")
+	dir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.27
+          securityContext:
+            privileged: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return parseScanArgs([]string{"--path", dir, "-o", format, "--no-history", "--no-baseline", "-n", "apps"})
+}
+
+func TestScanNamespace_SARIFNoStdoutEhDocumentoValido(t *testing.T) {
+	opts := offlineScanOptions(t, "sarif")
+	var report *ScanReport
+	out := captureStdout(t, func() { report = scanNamespace(opts) })
+	if report == nil || len(report.Findings) == 0 {
+		t.Fatalf("esperava findings no scan offline, obteve %+v", report)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("stdout nao e SARIF valido: %v\n%s", err, out)
+	}
+	if doc["version"] != "2.1.0" {
+		t.Errorf("versao SARIF inesperada: %v", doc["version"])
+	}
+}
+
+func TestScanNamespace_JUnitNoStdoutEhDocumentoValido(t *testing.T) {
+	opts := offlineScanOptions(t, "junit")
+	out := captureStdout(t, func() { scanNamespace(opts) })
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("stdout nao e JUnit valido: %v\n%s", err, out)
+	}
+	if doc.Failures == 0 {
+		t.Errorf("esperava falhas no JUnit, obteve %+v", doc)
+	}
+}
+
+func TestScanOptions_HumanOutput(t *testing.T) {
+	tests := []struct {
+		format, file string
+		stderr       bool
+	}{
+		{"", "", false},
+		{"markdown", "", false},
+		{"sarif", "", true},
+		{"junit", "", true},
+		{"json", "", true},
+		{"sarif", "out.sarif", false},
+	}
+	for _, tt := range tests {
+		opts := scanOptions{OutputFormat: tt.format, OutputFile: tt.file}
+		if got := opts.humanOutput() == os.Stderr; got != tt.stderr {
+			t.Errorf("humanOutput(%q, %q) stderr = %v, esperado %v", tt.format, tt.file, got, tt.stderr)
+		}
+	}
+}
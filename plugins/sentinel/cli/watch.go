@@ -0,0 +1,598 @@
+//go:build k8s
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	defaultWatchDebounce = 2 * time.Second
+	webhookTimeout       = 5 * time.Second
+)
+
+// Tipos de evento emitidos pelo watch.
+const (
+	watchEventFinding  = "finding"
+	watchEventResolved = "resolved"
+)
+
+// watchOptions agrupa as flags do subcomando watch.
+type watchOptions struct {
+	Namespace     string
+	AllNamespaces bool
+	Selector      namespaceSelector
+	Profile       string
+	// Webhook recebe cada evento via POST (JSON); vazio emite JSON lines no stdout.
+	Webhook string
+	// MinSeverity descarta findings abaixo da severidade informada.
+	MinSeverity string
+	// Debounce agrupa mudanças próximas em uma única reavaliação.
+	Debounce   time.Duration
+	Baseline   string
+	NoBaseline bool
+}
+
+// WatchEvent é um evento do stream do watch: um finding novo ou um finding que deixou de existir.
+type WatchEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// Trigger é o objeto cuja mudança disparou a reavaliação (ex: Pod/prod/api).
+	Trigger string                 `json:"trigger,omitempty"`
+	Finding checks.SecurityFinding `json:"finding"`
+}
+
+// parseWatchArgs interpreta os argumentos do subcomando watch.
+func parseWatchArgs(args []string) watchOptions {
+	opts := watchOptions{Debounce: defaultWatchDebounce}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-n", "--namespace":
+			if i+1 < len(args) {
+				opts.Namespace = args[i+1]
+				i++
+			}
+		case "-A", "--all-namespaces":
+			opts.AllNamespaces = true
+		case "--include-namespaces":
+			if i+1 < len(args) {
+				opts.Selector.Include = parsePatternList(args[i+1])
+				i++
+			}
+		case "--exclude-namespaces":
+			if i+1 < len(args) {
+				opts.Selector.Exclude = parsePatternList(args[i+1])
+				i++
+			}
+		case "-p", "--profile":
+			if i+1 < len(args) {
+				opts.Profile = args[i+1]
+				i++
+			}
+		case "--webhook":
+			if i+1 < len(args) {
+				opts.Webhook = args[i+1]
+				i++
+			}
+		case "--min-severity":
+			if i+1 < len(args) {
+				opts.MinSeverity = args[i+1]
+				i++
+			}
+		case "--debounce":
+			if i+1 < len(args) {
+				if d, err := time.ParseDuration(args[i+1]); err == nil && d >= 0 {
+					opts.Debounce = d
+				}
+				i++
+			}
+		case "--baseline":
+			if i+1 < len(args) {
+				opts.Baseline = args[i+1]
+				i++
+			}
+		case "--no-baseline":
+			opts.NoBaseline = true
+		}
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	return opts
+}
+
+// checkAffectedBy indica se mudanças em objetos do tipo kind podem alterar o resultado do check.
+func checkAffectedBy(c checks.SecurityCheck, kind string) bool {
+	switch kind {
+	case "Pod":
+		// NETPOL_COVERAGE cruza os pods com as NetworkPolicies do namespace
+		if c.ID() == "NETPOL_COVERAGE" {
+			return true
+		}
+		return c.Category() != checks.CategoryRBAC && c.Category() != checks.CategoryNetwork
+	case "NetworkPolicy":
+		return c.Category() == checks.CategoryNetwork
+	case "Role", "RoleBinding", "ClusterRole", "ClusterRoleBinding":
+		return c.Category() == checks.CategoryRBAC
+	default:
+		return false
+	}
+}
+
+// affectedChecks filtra os checks reavaliados quando um objeto do tipo kind muda.
+func affectedChecks(kind string, selected []checks.SecurityCheck) []checks.SecurityCheck {
+	var result []checks.SecurityCheck
+	for _, c := range selected {
+		if checkAffectedBy(c, kind) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// eventSink é o destino dos eventos do watch.
+type eventSink interface {
+	Emit(event WatchEvent) error
+}
+
+// jsonLinesSink escreve um evento JSON por linha.
+type jsonLinesSink struct {
+	enc *json.Encoder
+}
+
+func newJSONLinesSink(w io.Writer) *jsonLinesSink {
+	return &jsonLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonLinesSink) Emit(event WatchEvent) error {
+	return s.enc.Encode(event)
+}
+
+// webhookSink envia cada evento via POST para um webhook local.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+func (s *webhookSink) Emit(event WatchEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar evento: %w", err)
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("falha ao enviar evento ao webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// watchUnit é a menor unidade de reavaliação: um check em um namespace.
+type watchUnit struct {
+	Namespace string
+	CheckID   string
+}
+
+// sentinelWatcher reavalia os checks afetados pelas mudanças observadas nos
+// informers e emite apenas a diferença em relação ao estado anterior.
+type sentinelWatcher struct {
+	client   kubernetes.Interface
+	checks   map[string]checks.SecurityCheck
+	sink     eventSink
+	debounce time.Duration
+	// filter aplica baseline e severidade mínima aos findings de cada reavaliação.
+	filter func([]checks.SecurityFinding) []checks.SecurityFinding
+	now    func() time.Time
+
+	allNamespaces bool
+	namespace     string
+	selector      namespaceSelector
+
+	mu         sync.Mutex
+	namespaces map[string]bool
+	pending    map[watchUnit]string
+	notify     chan struct{}
+
+	// Estado acessado apenas pelo loop de reavaliação
+	known map[watchUnit]map[string]checks.SecurityFinding
+	refs  map[string]int
+}
+
+func newSentinelWatcher(client kubernetes.Interface, selected []checks.SecurityCheck, sink eventSink, opts watchOptions) *sentinelWatcher {
+	w := &sentinelWatcher{
+		client:        client,
+		checks:        make(map[string]checks.SecurityCheck, len(selected)),
+		sink:          sink,
+		debounce:      opts.Debounce,
+		filter:        func(f []checks.SecurityFinding) []checks.SecurityFinding { return f },
+		now:           time.Now,
+		allNamespaces: opts.AllNamespaces,
+		namespace:     opts.Namespace,
+		selector:      opts.Selector,
+		namespaces:    make(map[string]bool),
+		pending:       make(map[watchUnit]string),
+		notify:        make(chan struct{}, 1),
+		known:         make(map[watchUnit]map[string]checks.SecurityFinding),
+		refs:          make(map[string]int),
+	}
+	for _, c := range selected {
+		w.checks[c.ID()] = c
+	}
+	return w
+}
+
+// selected retorna os checks do watcher em ordem estável.
+func (w *sentinelWatcher) selected() []checks.SecurityCheck {
+	ids := make([]string, 0, len(w.checks))
+	for id := range w.checks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	result := make([]checks.SecurityCheck, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, w.checks[id])
+	}
+	return result
+}
+
+// watches indica se o namespace está no escopo do watch.
+func (w *sentinelWatcher) watches(namespace string) bool {
+	if w.allNamespaces {
+		return w.selector.matches(namespace)
+	}
+	return namespace == w.namespace
+}
+
+// enqueue agenda a reavaliação dos checks afetados por uma mudança no objeto.
+// Objetos sem namespace (ClusterRole, ClusterRoleBinding) afetam todos os
+// namespaces observados.
+func (w *sentinelWatcher) enqueue(kind, namespace, name string) {
+	affected := affectedChecks(kind, w.selected())
+	if len(affected) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	var targets []string
+	trigger := kind + "/" + name
+	if namespace != "" {
+		if !w.watches(namespace) {
+			w.mu.Unlock()
+			return
+		}
+		w.namespaces[namespace] = true
+		targets = []string{namespace}
+		trigger = kind + "/" + namespace + "/" + name
+	} else {
+		for ns := range w.namespaces {
+			targets = append(targets, ns)
+		}
+	}
+	for _, ns := range targets {
+		for _, c := range affected {
+			w.pending[watchUnit{Namespace: ns, CheckID: c.ID()}] = trigger
+		}
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// takePending retira as reavaliações agendadas, em ordem estável.
+func (w *sentinelWatcher) takePending() ([]watchUnit, map[watchUnit]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	triggers := w.pending
+	w.pending = make(map[watchUnit]string)
+
+	units := make([]watchUnit, 0, len(triggers))
+	for u := range triggers {
+		units = append(units, u)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Namespace != units[j].Namespace {
+			return units[i].Namespace < units[j].Namespace
+		}
+		return units[i].CheckID < units[j].CheckID
+	})
+	return units, triggers
+}
+
+// prime registra o estado inicial dos namespaces sem emitir eventos, para que
+// o stream contenha apenas o que mudar a partir do início do watch.
+func (w *sentinelWatcher) prime(ctx context.Context, namespaces []string) int {
+	w.mu.Lock()
+	for _, ns := range namespaces {
+		w.namespaces[ns] = true
+	}
+	w.mu.Unlock()
+
+	for _, ns := range namespaces {
+		for _, c := range w.selected() {
+			w.evaluate(ctx, watchUnit{Namespace: ns, CheckID: c.ID()}, "", false)
+		}
+	}
+	return len(w.refs)
+}
+
+// evaluate roda o check da unidade e emite os findings novos e os resolvidos.
+// Um finding é emitido uma única vez mesmo quando aparece em várias unidades
+// (ex: bindings cluster-admin reavaliados em cada namespace).
+func (w *sentinelWatcher) evaluate(ctx context.Context, unit watchUnit, trigger string, emit bool) {
+	check, ok := w.checks[unit.CheckID]
+	if !ok {
+		return
+	}
+	findings, err := check.Run(ctx, w.client, unit.Namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aviso: check '%s' falhou em %s: %v\n", check.Name(), unit.Namespace, err)
+		return
+	}
+
+	current := make(map[string]checks.SecurityFinding)
+	for _, f := range w.filter(findings) {
+		current[baselineKey(f)] = f
+	}
+	previous := w.known[unit]
+	w.known[unit] = current
+
+	for _, key := range sortedFindingKeys(current) {
+		if _, ok := previous[key]; ok {
+			continue
+		}
+		w.refs[key]++
+		if w.refs[key] == 1 && emit {
+			w.emit(watchEventFinding, trigger, current[key])
+		}
+	}
+	for _, key := range sortedFindingKeys(previous) {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		w.refs[key]--
+		if w.refs[key] <= 0 {
+			delete(w.refs, key)
+			if emit {
+				w.emit(watchEventResolved, trigger, previous[key])
+			}
+		}
+	}
+}
+
+func (w *sentinelWatcher) emit(event, trigger string, f checks.SecurityFinding) {
+	err := w.sink.Emit(WatchEvent{Time: w.now().UTC(), Event: event, Trigger: trigger, Finding: f})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aviso: evento nao entregue: %v\n", err)
+	}
+}
+
+func sortedFindingKeys(m map[string]checks.SecurityFinding) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loop processa as reavaliações agendadas até o contexto ser cancelado.
+func (w *sentinelWatcher) loop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		}
+		// Aguarda o debounce para agrupar rajadas (ex: rollout de um Deployment)
+		if w.debounce > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.debounce):
+			}
+		}
+		units, triggers := w.takePending()
+		for _, u := range units {
+			w.evaluate(ctx, u, triggers[u], true)
+		}
+	}
+}
+
+// handler converte os eventos do informer em reavaliações agendadas.
+func (w *sentinelWatcher) handler(kind string) cache.ResourceEventHandlerFuncs {
+	enqueue := func(obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return
+		}
+		w.enqueue(kind, namespace, name)
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Os checks avaliam spec e metadados; mudanças de status do pod não os afetam
+			if oldPod, ok := oldObj.(*corev1.Pod); ok {
+				newPod := newObj.(*corev1.Pod)
+				if equality.Semantic.DeepEqual(oldPod.Spec, newPod.Spec) &&
+					equality.Semantic.DeepEqual(oldPod.Labels, newPod.Labels) &&
+					equality.Semantic.DeepEqual(oldPod.Annotations, newPod.Annotations) {
+					return
+				}
+			}
+			enqueue(newObj)
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// start registra os informers dos tipos que afetam algum check selecionado e
+// aguarda a sincronização dos caches.
+func (w *sentinelWatcher) start(ctx context.Context) error {
+	var nsOpts []informers.SharedInformerOption
+	if !w.allNamespaces {
+		nsOpts = append(nsOpts, informers.WithNamespace(w.namespace))
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(w.client, 0, nsOpts...)
+	clusterFactory := informers.NewSharedInformerFactory(w.client, 0)
+
+	sources := []struct {
+		kind     string
+		informer func() cache.SharedIndexInformer
+	}{
+		{"Pod", func() cache.SharedIndexInformer { return factory.Core().V1().Pods().Informer() }},
+		{"NetworkPolicy", func() cache.SharedIndexInformer { return factory.Networking().V1().NetworkPolicies().Informer() }},
+		{"Role", func() cache.SharedIndexInformer { return factory.Rbac().V1().Roles().Informer() }},
+		{"RoleBinding", func() cache.SharedIndexInformer { return factory.Rbac().V1().RoleBindings().Informer() }},
+		{"ClusterRole", func() cache.SharedIndexInformer { return clusterFactory.Rbac().V1().ClusterRoles().Informer() }},
+		{"ClusterRoleBinding", func() cache.SharedIndexInformer { return clusterFactory.Rbac().V1().ClusterRoleBindings().Informer() }},
+	}
+	for _, s := range sources {
+		if len(affectedChecks(s.kind, w.selected())) == 0 {
+			continue
+		}
+		if _, err := s.informer().AddEventHandler(w.handler(s.kind)); err != nil {
+			return fmt.Errorf("falha ao registrar informer de %s: %w", s.kind, err)
+		}
+	}
+
+	factory.Start(ctx.Done())
+	clusterFactory.Start(ctx.Done())
+	for _, f := range []informers.SharedInformerFactory{factory, clusterFactory} {
+		for typ, synced := range f.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("falha ao sincronizar cache de %v", typ)
+			}
+		}
+	}
+	return nil
+}
+
+// run sincroniza os informers, registra o estado inicial e processa as mudanças
+// até o contexto ser cancelado. Eventos recebidos durante o registro do estado
+// inicial ficam agendados e são processados em seguida.
+func (w *sentinelWatcher) run(ctx context.Context, namespaces []string) error {
+	if err := w.start(ctx); err != nil {
+		return err
+	}
+	current := w.prime(ctx, namespaces)
+	fmt.Fprintf(os.Stderr, "Sentinel watch: %d namespaces, %d checks, %d findings atuais. Aguardando mudancas...\n",
+		len(namespaces), len(w.checks), current)
+	w.loop(ctx)
+	return nil
+}
+
+// watchFindingFilter monta o filtro de baseline e severidade mínima do watch.
+func watchFindingFilter(opts watchOptions) func([]checks.SecurityFinding) []checks.SecurityFinding {
+	var baseline *Baseline
+	if !opts.NoBaseline {
+		path := opts.Baseline
+		if path == "" {
+			path = defaultBaselineFile
+		}
+		b, err := loadBaseline(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aviso: baseline ignorado: %v\n", err)
+		} else if len(b.Suppressions) > 0 {
+			baseline = b
+		}
+	}
+	minRank := -1
+	if opts.MinSeverity != "" {
+		minRank = severityRank(checks.Severity(opts.MinSeverity))
+	}
+
+	return func(findings []checks.SecurityFinding) []checks.SecurityFinding {
+		if baseline != nil {
+			findings = applyBaseline(findings, baseline, time.Now()).Active
+		}
+		if minRank < 0 {
+			return findings
+		}
+		var result []checks.SecurityFinding
+		for _, f := range findings {
+			if severityRank(findingSeverity(f)) >= minRank {
+				result = append(result, f)
+			}
+		}
+		return result
+	}
+}
+
+// runWatch executa o subcomando watch até receber SIGINT/SIGTERM.
+func runWatch(args []string) bool {
+	opts := parseWatchArgs(args)
+	if opts.MinSeverity != "" && severityRank(checks.Severity(opts.MinSeverity)) < 0 {
+		fmt.Printf("❌ Severidade invalida para --min-severity: %s (use critical, high, medium, low ou info)\n", opts.MinSeverity)
+		return false
+	}
+
+	selected, err := selectChecks(opts.Profile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+
+	client, err := getKubeClient()
+	if err != nil {
+		fmt.Printf("❌ Falha ao obter cliente Kubernetes: %v\n", err)
+		return false
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	namespaces := []string{opts.Namespace}
+	if opts.AllNamespaces {
+		namespaces, err = listClusterNamespaces(ctx, client, opts.Selector)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return false
+		}
+	}
+
+	var sink eventSink = newJSONLinesSink(os.Stdout)
+	if opts.Webhook != "" {
+		sink = newWebhookSink(opts.Webhook)
+	}
+	w := newSentinelWatcher(client, selected, sink, opts)
+	w.filter = watchFindingFilter(opts)
+
+	if err := w.run(ctx, namespaces); err != nil {
+		fmt.Printf("❌ Falha no watch: %v\n", err)
+		return false
+	}
+	return true
+}
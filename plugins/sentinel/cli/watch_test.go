@@ -0,0 +1,233 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/checks"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// chanSink entrega os eventos em um canal para os testes.
+type chanSink chan WatchEvent
+
+func (s chanSink) Emit(event WatchEvent) error {
+	s <- event
+	return nil
+}
+
+func nextEvent(t *testing.T, events chanSink) WatchEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout aguardando evento do watch")
+		return WatchEvent{}
+	}
+}
+
+func TestParseWatchArgs(t *testing.T) {
+	opts := parseWatchArgs([]string{"-A", "--exclude-namespaces", "kube-*", "--webhook", "http://localhost:9000", "--min-severity", "high", "--debounce", "500ms", "--no-baseline"})
+	if !opts.AllNamespaces || opts.Webhook != "http://localhost:9000" || opts.MinSeverity != "high" || !opts.NoBaseline {
+		t.Errorf("opções inesperadas: %+v", opts)
+	}
+	if opts.Debounce != 500*time.Millisecond || len(opts.Selector.Exclude) != 1 {
+		t.Errorf("debounce/seletor inesperados: %+v", opts)
+	}
+
+	defaults := parseWatchArgs(nil)
+	if defaults.Namespace != "default" || defaults.Debounce != defaultWatchDebounce {
+		t.Errorf("padrões inesperados: %+v", defaults)
+	}
+}
+
+func TestAffectedChecks(t *testing.T) {
+	all := checks.GetAll()
+	ids := func(kind string) map[string]bool {
+		result := make(map[string]bool)
+		for _, c := range affectedChecks(kind, all) {
+			result[c.ID()] = true
+		}
+		return result
+	}
+
+	pod := ids("Pod")
+	if !pod["POD_PRIVILEGED"] || !pod["POD_EXPOSED_SECRETS"] || !pod["IMAGE_LATEST_TAG"] || !pod["NETPOL_COVERAGE"] {
+		t.Errorf("mudança em pod deveria reavaliar checks de pod, secrets, imagem e cobertura de rede: %v", pod)
+	}
+	if pod["RBAC_WILDCARD"] || pod["NETPOL_DEFAULT_DENY"] {
+		t.Errorf("mudança em pod não deveria reavaliar RBAC nem default deny: %v", pod)
+	}
+
+	netpol := ids("NetworkPolicy")
+	if len(netpol) != 2 || !netpol["NETPOL_DEFAULT_DENY"] {
+		t.Errorf("checks de NetworkPolicy inesperados: %v", netpol)
+	}
+	for _, kind := range []string{"RoleBinding", "ClusterRoleBinding"} {
+		if rbac := ids(kind); !rbac["RBAC_CLUSTER_ADMIN"] || rbac["POD_PRIVILEGED"] {
+			t.Errorf("checks de %s inesperados: %v", kind, rbac)
+		}
+	}
+	if len(ids("ConfigMap")) != 0 {
+		t.Error("tipo não observado não deveria reavaliar checks")
+	}
+}
+
+func TestSentinelWatcher_EmiteApenasDiferencas(t *testing.T) {
+	client := fake.NewSimpleClientset(privilegedPod("existente", "prod"))
+	events := make(chanSink, 10)
+	w := newSentinelWatcher(client, checks.GetByIDs([]string{"POD_PRIVILEGED"}), events, watchOptions{Namespace: "prod"})
+	ctx := context.Background()
+
+	if n := w.prime(ctx, []string{"prod"}); n != 1 {
+		t.Fatalf("esperava 1 finding no estado inicial, obteve %d", n)
+	}
+	if len(events) != 0 {
+		t.Fatal("estado inicial não deveria emitir eventos")
+	}
+
+	if _, err := client.CoreV1().Pods("prod").Create(ctx, privilegedPod("novo", "prod"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	unit := watchUnit{Namespace: "prod", CheckID: "POD_PRIVILEGED"}
+	w.evaluate(ctx, unit, "Pod/prod/novo", true)
+	e := nextEvent(t, events)
+	if e.Event != watchEventFinding || e.Finding.Pod != "novo" || e.Trigger != "Pod/prod/novo" {
+		t.Errorf("evento inesperado: %+v", e)
+	}
+
+	if err := client.CoreV1().Pods("prod").Delete(ctx, "existente", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	w.evaluate(ctx, unit, "Pod/prod/existente", true)
+	if e := nextEvent(t, events); e.Event != watchEventResolved || e.Finding.Pod != "existente" {
+		t.Errorf("esperava finding resolvido do pod removido: %+v", e)
+	}
+	if len(events) != 0 {
+		t.Errorf("reavaliação sem mudança não deveria emitir eventos, %d pendentes", len(events))
+	}
+}
+
+func TestSentinelWatcher_FindingEmVariasUnidadesEmitidoUmaVez(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	events := make(chanSink, 10)
+	w := newSentinelWatcher(client, checks.GetByIDs([]string{"RBAC_CLUSTER_ADMIN"}), events, watchOptions{AllNamespaces: true})
+	ctx := context.Background()
+	w.prime(ctx, []string{"ns-a", "ns-b"})
+
+	_, err := client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-admin"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", Name: "dev"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Objeto sem namespace agenda a reavaliação em todos os namespaces observados
+	w.enqueue("ClusterRoleBinding", "", "dev-admin")
+	units, triggers := w.takePending()
+	if len(units) != 2 {
+		t.Fatalf("esperava reavaliação em 2 namespaces, obteve %v", units)
+	}
+	for _, u := range units {
+		w.evaluate(ctx, u, triggers[u], true)
+	}
+	if e := nextEvent(t, events); e.Finding.CheckID != "RBAC_CLUSTER_ADMIN" || e.Trigger != "ClusterRoleBinding/dev-admin" {
+		t.Errorf("evento inesperado: %+v", e)
+	}
+	if len(events) != 0 {
+		t.Errorf("finding repetido entre namespaces deveria ser emitido uma vez, %d extras", len(events))
+	}
+}
+
+func TestSentinelWatcher_IgnoraNamespacesForaDoEscopo(t *testing.T) {
+	w := newSentinelWatcher(fake.NewSimpleClientset(), checks.GetAll(), make(chanSink), watchOptions{
+		AllNamespaces: true,
+		Selector:      namespaceSelector{Exclude: []string{"kube-*"}},
+	})
+	w.enqueue("Pod", "kube-system", "coredns")
+	if units, _ := w.takePending(); len(units) != 0 {
+		t.Errorf("namespace excluído não deveria ser reavaliado: %v", units)
+	}
+	w.enqueue("Pod", "prod", "api")
+	if units, _ := w.takePending(); len(units) == 0 {
+		t.Error("namespace incluído deveria ser reavaliado")
+	}
+}
+
+func TestSentinelWatcher_Informers(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	events := make(chanSink, 10)
+	w := newSentinelWatcher(client, checks.GetByIDs([]string{"POD_PRIVILEGED"}), events, watchOptions{Namespace: "prod", Debounce: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.start(ctx); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	w.prime(ctx, []string{"prod"})
+	go w.loop(ctx)
+
+	// Pods fora do namespace observado não geram eventos
+	if _, err := client.CoreV1().Pods("outro").Create(ctx, privilegedPod("api", "outro"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Pods("prod").Create(ctx, privilegedPod("debug", "prod"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	e := nextEvent(t, events)
+	if e.Event != watchEventFinding || e.Finding.Namespace != "prod" || e.Trigger != "Pod/prod/debug" {
+		t.Errorf("evento inesperado: %+v", e)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan WatchEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e WatchEvent
+		if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&e) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- e
+	}))
+	defer server.Close()
+
+	event := WatchEvent{Event: watchEventFinding, Finding: checks.SecurityFinding{CheckID: "POD_PRIVILEGED", Pod: "debug"}}
+	if err := newWebhookSink(server.URL).Emit(event); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if e := <-received; e.Finding.CheckID != "POD_PRIVILEGED" {
+		t.Errorf("evento recebido inesperado: %+v", e)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := newWebhookSink(failing.URL).Emit(event); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("esperava erro HTTP 500, obteve %v", err)
+	}
+}
+
+func TestWatchFindingFilter_SeveridadeMinima(t *testing.T) {
+	filter := watchFindingFilter(watchOptions{NoBaseline: true, MinSeverity: "high"})
+	got := filter([]checks.SecurityFinding{
+		{CheckID: "A", Severity: checks.SeverityCritical},
+		{CheckID: "B", Severity: checks.SeverityLow},
+	})
+	if len(got) != 1 || got[0].CheckID != "A" {
+		t.Errorf("esperava apenas o finding critical, obteve %+v", got)
+	}
+}
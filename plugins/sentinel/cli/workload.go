@@ -0,0 +1,785 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casheiro/yby-cli/pkg/ai"
+	"github.com/casheiro/yby-cli/pkg/ai/prompts"
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
+	"github.com/casheiro/yby-cli/plugins/sentinel/cli/diagnosis"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	workloadLogTailLines = int64(50)
+	// maxBundlePods limita os pods detalhados no bundle; pods com problema têm prioridade.
+	maxBundlePods = 10
+	// maxLogBytes limita os logs de cada container enviados à IA.
+	maxLogBytes = 4000
+)
+
+// investigateTarget é o alvo do investigate: um pod ou um workload.
+type investigateTarget struct {
+	Kind string
+	Name string
+}
+
+// String retorna o alvo no formato aceito pela CLI (ex: deployment/api).
+func (t investigateTarget) String() string {
+	if t.Kind == "Pod" {
+		return t.Name
+	}
+	return strings.ToLower(t.Kind) + "/" + t.Name
+}
+
+// parseInvestigateTarget interpreta "pod", "deployment/api", "sts/db", "job/migrate" etc.
+// Nomes sem tipo são tratados como pods.
+func parseInvestigateTarget(arg string) (investigateTarget, error) {
+	kind, name, found := strings.Cut(arg, "/")
+	if !found {
+		return investigateTarget{Kind: "Pod", Name: arg}, nil
+	}
+	if name == "" {
+		return investigateTarget{}, fmt.Errorf("nome ausente em '%s'", arg)
+	}
+	switch strings.ToLower(kind) {
+	case "pod", "pods", "po":
+		return investigateTarget{Kind: "Pod", Name: name}, nil
+	case "deployment", "deployments", "deploy":
+		return investigateTarget{Kind: "Deployment", Name: name}, nil
+	case "statefulset", "statefulsets", "sts":
+		return investigateTarget{Kind: "StatefulSet", Name: name}, nil
+	case "job", "jobs":
+		return investigateTarget{Kind: "Job", Name: name}, nil
+	default:
+		return investigateTarget{}, fmt.Errorf("tipo '%s' nao suportado (use pod, deployment, statefulset ou job)", kind)
+	}
+}
+
+// WorkloadBundle reúne o estado correlacionado de um workload para a análise da IA.
+type WorkloadBundle struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Status    WorkloadStatus    `json:"status"`
+	Rollout   []RolloutRevision `json:"rollout,omitempty"`
+	HPA       []HPAState        `json:"hpa,omitempty"`
+	Services  []ServiceState    `json:"services,omitempty"`
+	Pods      []PodState        `json:"pods"`
+	// OmittedPods conta os pods saudáveis que ficaram fora do bundle pelo limite.
+	OmittedPods int            `json:"omitted_pods,omitempty"`
+	Events      []EventSummary `json:"events,omitempty"`
+
+	// unhealthy são os pods com problema, na ordem de Pods, para o motor de
+	// regras. Não faz parte da evidência gravada.
+	unhealthy []podEvidence
+}
+
+// podEvidence é o que o motor de regras inspeciona em um pod do workload.
+type podEvidence struct {
+	pod    *corev1.Pod
+	events *corev1.EventList
+	logs   string
+}
+
+// WorkloadStatus resume o status do controller.
+type WorkloadStatus struct {
+	Desired            int32    `json:"desired"`
+	Ready              int32    `json:"ready"`
+	Updated            int32    `json:"updated,omitempty"`
+	Available          int32    `json:"available,omitempty"`
+	Active             int32    `json:"active,omitempty"`
+	Succeeded          int32    `json:"succeeded,omitempty"`
+	Failed             int32    `json:"failed,omitempty"`
+	Generation         int64    `json:"generation,omitempty"`
+	ObservedGeneration int64    `json:"observed_generation,omitempty"`
+	Conditions         []string `json:"conditions,omitempty"`
+}
+
+// RolloutRevision é uma revisão do workload (ReplicaSet ou ControllerRevision).
+type RolloutRevision struct {
+	Revision int64     `json:"revision"`
+	Name     string    `json:"name"`
+	Images   []string  `json:"images,omitempty"`
+	Replicas int32     `json:"replicas"`
+	Ready    int32     `json:"ready"`
+	Target   bool      `json:"target,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// HPAState resume um HorizontalPodAutoscaler que escala o workload.
+type HPAState struct {
+	Name       string   `json:"name"`
+	Min        int32    `json:"min"`
+	Max        int32    `json:"max"`
+	Current    int32    `json:"current"`
+	Desired    int32    `json:"desired"`
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+// ServiceState resume um Service que seleciona os pods do workload.
+type ServiceState struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	ReadyEndpoints    int    `json:"ready_endpoints"`
+	NotReadyEndpoints int    `json:"not_ready_endpoints"`
+}
+
+// PodState resume um pod do workload, com logs atuais e do container anterior.
+type PodState struct {
+	Name       string                 `json:"name"`
+	Phase      string                 `json:"phase"`
+	Node       string                 `json:"node,omitempty"`
+	Revision   string                 `json:"revision,omitempty"`
+	Healthy    bool                   `json:"healthy"`
+	Containers []ContainerState       `json:"containers"`
+	Usage      []kubemetrics.UsageRow `json:"usage,omitempty"`
+}
+
+// ContainerState resume um container de um pod.
+type ContainerState struct {
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	Ready           bool   `json:"ready"`
+	Restarts        int32  `json:"restarts"`
+	State           string `json:"state,omitempty"`
+	LastTermination string `json:"last_termination,omitempty"`
+	Logs            string `json:"logs,omitempty"`
+	PreviousLogs    string `json:"previous_logs,omitempty"`
+}
+
+// EventSummary resume um evento do workload, de suas revisões ou de seus pods.
+type EventSummary struct {
+	Object  string `json:"object"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Count   int32  `json:"count,omitempty"`
+}
+
+// workloadRef guarda o que a coleta precisa do controller: seletor, labels do
+// template e UIDs que podem ser donos dos pods (o próprio controller ou seus ReplicaSets).
+type workloadRef struct {
+	selector       labels.Selector
+	templateLabels map[string]string
+	owners         map[types.UID]bool
+	objects        map[string]bool
+}
+
+// collectWorkloadBundle coleta o estado do workload, seus pods (com logs), o
+// histórico de rollout, HPAs, Services e eventos relacionados. Com metrics
+// não nil, cada pod detalhado traz também o uso vs requests/limits.
+func collectWorkloadBundle(ctx context.Context, client kubernetes.Interface, metrics *kubemetrics.Client, target investigateTarget, namespace string) (*WorkloadBundle, error) {
+	bundle := &WorkloadBundle{Kind: target.Kind, Name: target.Name, Namespace: namespace}
+
+	var ref *workloadRef
+	var err error
+	switch target.Kind {
+	case "Deployment":
+		ref, err = collectDeployment(ctx, client, bundle)
+	case "StatefulSet":
+		ref, err = collectStatefulSet(ctx, client, bundle)
+	case "Job":
+		ref, err = collectJob(ctx, client, bundle)
+	default:
+		return nil, fmt.Errorf("tipo de workload '%s' nao suportado", target.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ref.objects[target.Kind+"/"+target.Name] = true
+
+	podList, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && ref.owners[owner.UID] {
+			pods = append(pods, pod)
+			ref.objects["Pod/"+pod.Name] = true
+		}
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar eventos: %w", err)
+	}
+	podEvents := make(map[string]*corev1.EventList)
+	for _, e := range events.Items {
+		object := e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name
+		if !ref.objects[object] {
+			continue
+		}
+		bundle.Events = append(bundle.Events, EventSummary{Object: object, Type: e.Type, Reason: e.Reason, Message: e.Message, Count: e.Count})
+		if e.InvolvedObject.Kind == "Pod" {
+			if podEvents[e.InvolvedObject.Name] == nil {
+				podEvents[e.InvolvedObject.Name] = &corev1.EventList{}
+			}
+			podEvents[e.InvolvedObject.Name].Items = append(podEvents[e.InvolvedObject.Name].Items, e)
+		}
+	}
+
+	usage := collectPodUsage(ctx, metrics, namespace, ref.selector)
+	bundle.Pods, bundle.OmittedPods = collectPodStates(ctx, client, pods, podEvents, usage)
+	for _, state := range bundle.Pods {
+		if state.Healthy {
+			continue
+		}
+		for i := range pods {
+			if pods[i].Name == state.Name {
+				bundle.unhealthy = append(bundle.unhealthy, podEvidence{pod: &pods[i], events: podEvents[state.Name], logs: state.logs()})
+				break
+			}
+		}
+	}
+
+	if bundle.HPA, err = collectHPAs(ctx, client, target, namespace); err != nil {
+		return nil, err
+	}
+	if bundle.Services, err = collectServices(ctx, client, ref.templateLabels, namespace); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func newWorkloadRef(selector *metav1.LabelSelector, templateLabels map[string]string, owner types.UID) (*workloadRef, error) {
+	sel := labels.Everything()
+	if selector != nil {
+		var err error
+		if sel, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, fmt.Errorf("seletor invalido: %w", err)
+		}
+	}
+	return &workloadRef{
+		selector:       sel,
+		templateLabels: templateLabels,
+		owners:         map[types.UID]bool{owner: true},
+		objects:        make(map[string]bool),
+	}, nil
+}
+
+func collectDeployment(ctx context.Context, client kubernetes.Interface, bundle *WorkloadBundle) (*workloadRef, error) {
+	d, err := client.AppsV1().Deployments(bundle.Namespace).Get(ctx, bundle.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter deployment %s: %w", bundle.Name, err)
+	}
+	bundle.Status = WorkloadStatus{
+		Desired:            replicasOrDefault(d.Spec.Replicas),
+		Ready:              d.Status.ReadyReplicas,
+		Updated:            d.Status.UpdatedReplicas,
+		Available:          d.Status.AvailableReplicas,
+		Generation:         d.Generation,
+		ObservedGeneration: d.Status.ObservedGeneration,
+	}
+	for _, c := range d.Status.Conditions {
+		bundle.Status.Conditions = append(bundle.Status.Conditions, formatCondition(string(c.Type), string(c.Status), c.Reason, c.Message))
+	}
+
+	ref, err := newWorkloadRef(d.Spec.Selector, d.Spec.Template.Labels, d.UID)
+	if err != nil {
+		return nil, err
+	}
+	rsList, err := client.AppsV1().ReplicaSets(bundle.Namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar ReplicaSets: %w", err)
+	}
+	for i := range rsList.Items {
+		rs := &rsList.Items[i]
+		if !metav1.IsControlledBy(rs, d) {
+			continue
+		}
+		ref.owners[rs.UID] = true
+		ref.objects["ReplicaSet/"+rs.Name] = true
+		revision, _ := strconv.ParseInt(rs.Annotations["deployment.kubernetes.io/revision"], 10, 64)
+		bundle.Rollout = append(bundle.Rollout, RolloutRevision{
+			Revision: revision,
+			Name:     rs.Name,
+			Images:   containerImages(rs.Spec.Template.Spec),
+			Replicas: rs.Status.Replicas,
+			Ready:    rs.Status.ReadyReplicas,
+			Created:  rs.CreationTimestamp.Time,
+		})
+	}
+	sortRollout(bundle.Rollout)
+	if len(bundle.Rollout) > 0 {
+		bundle.Rollout[0].Target = true
+	}
+	return ref, nil
+}
+
+func collectStatefulSet(ctx context.Context, client kubernetes.Interface, bundle *WorkloadBundle) (*workloadRef, error) {
+	s, err := client.AppsV1().StatefulSets(bundle.Namespace).Get(ctx, bundle.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter statefulset %s: %w", bundle.Name, err)
+	}
+	bundle.Status = WorkloadStatus{
+		Desired:            replicasOrDefault(s.Spec.Replicas),
+		Ready:              s.Status.ReadyReplicas,
+		Updated:            s.Status.UpdatedReplicas,
+		Available:          s.Status.AvailableReplicas,
+		Generation:         s.Generation,
+		ObservedGeneration: s.Status.ObservedGeneration,
+	}
+	for _, c := range s.Status.Conditions {
+		bundle.Status.Conditions = append(bundle.Status.Conditions, formatCondition(string(c.Type), string(c.Status), c.Reason, c.Message))
+	}
+
+	ref, err := newWorkloadRef(s.Spec.Selector, s.Spec.Template.Labels, s.UID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := client.AppsV1().ControllerRevisions(bundle.Namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar ControllerRevisions: %w", err)
+	}
+	pods, err := client.CoreV1().Pods(bundle.Namespace).List(ctx, metav1.ListOptions{LabelSelector: ref.selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar pods: %w", err)
+	}
+	for i := range revisions.Items {
+		rev := &revisions.Items[i]
+		if !metav1.IsControlledBy(rev, s) {
+			continue
+		}
+		entry := RolloutRevision{
+			Revision: rev.Revision,
+			Name:     rev.Name,
+			Images:   controllerRevisionImages(rev),
+			Target:   rev.Name == s.Status.UpdateRevision,
+			Created:  rev.CreationTimestamp.Time,
+		}
+		for _, pod := range pods.Items {
+			if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != rev.Name {
+				continue
+			}
+			entry.Replicas++
+			if podReady(&pod) {
+				entry.Ready++
+			}
+		}
+		bundle.Rollout = append(bundle.Rollout, entry)
+	}
+	sortRollout(bundle.Rollout)
+	return ref, nil
+}
+
+func collectJob(ctx context.Context, client kubernetes.Interface, bundle *WorkloadBundle) (*workloadRef, error) {
+	j, err := client.BatchV1().Jobs(bundle.Namespace).Get(ctx, bundle.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter job %s: %w", bundle.Name, err)
+	}
+	bundle.Status = WorkloadStatus{
+		Desired:   replicasOrDefault(j.Spec.Completions),
+		Ready:     derefInt32(j.Status.Ready),
+		Active:    j.Status.Active,
+		Succeeded: j.Status.Succeeded,
+		Failed:    j.Status.Failed,
+	}
+	for _, c := range j.Status.Conditions {
+		bundle.Status.Conditions = append(bundle.Status.Conditions, formatCondition(string(c.Type), string(c.Status), c.Reason, c.Message))
+	}
+	return newWorkloadRef(j.Spec.Selector, j.Spec.Template.Labels, j.UID)
+}
+
+// collectPodUsage lê o uso dos pods do workload no metrics-server. Métricas são
+// opcionais: sem client ou com o metrics-server indisponível retorna nil.
+func collectPodUsage(ctx context.Context, metrics *kubemetrics.Client, namespace string, selector labels.Selector) map[string]*kubemetrics.PodUsage {
+	if metrics == nil {
+		return nil
+	}
+	list, err := metrics.ListPodUsage(ctx, namespace, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil
+	}
+	usage := make(map[string]*kubemetrics.PodUsage, len(list))
+	for i := range list {
+		usage[list[i].Name] = &list[i]
+	}
+	return usage
+}
+
+// collectPodStates resume os pods com seus logs e uso de recursos. Pods com
+// problema vêm primeiro e apenas os maxBundlePods primeiros são detalhados.
+func collectPodStates(ctx context.Context, client kubernetes.Interface, pods []corev1.Pod, events map[string]*corev1.EventList, usage map[string]*kubemetrics.PodUsage) ([]PodState, int) {
+	states := make([]PodState, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		revision := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		if revision == "" {
+			revision = pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+		}
+		states = append(states, PodState{
+			Name:     pod.Name,
+			Phase:    string(pod.Status.Phase),
+			Node:     pod.Spec.NodeName,
+			Revision: revision,
+			Healthy:  isPodHealthy(pod, events[pod.Name]),
+		})
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		if states[i].Healthy != states[j].Healthy {
+			return !states[i].Healthy
+		}
+		return states[i].Name < states[j].Name
+	})
+
+	omitted := 0
+	if len(states) > maxBundlePods {
+		omitted = len(states) - maxBundlePods
+		states = states[:maxBundlePods]
+	}
+
+	byName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		byName[pods[i].Name] = &pods[i]
+	}
+	for i := range states {
+		pod := byName[states[i].Name]
+		states[i].Containers = collectContainerStates(ctx, client, pod)
+		states[i].Usage = kubemetrics.CompareUsage(pod, usage[pod.Name])
+	}
+	return states, omitted
+}
+
+// collectContainerStates resume os containers do pod, incluindo os logs do
+// container anterior quando ele já reiniciou.
+func collectContainerStates(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod) []ContainerState {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+
+	var result []ContainerState
+	for _, c := range pod.Spec.Containers {
+		state := ContainerState{Name: c.Name, Image: c.Image}
+		cs, ok := statuses[c.Name]
+		if ok {
+			state.Ready = cs.Ready
+			state.Restarts = cs.RestartCount
+			state.State = describeContainerState(cs.State)
+			if t := cs.LastTerminationState.Terminated; t != nil {
+				state.LastTermination = fmt.Sprintf("%s (exit %d)", t.Reason, t.ExitCode)
+			}
+		}
+		state.Logs = podLogs(ctx, client, pod, c.Name, false)
+		if ok && (cs.RestartCount > 0 || cs.LastTerminationState.Terminated != nil) {
+			state.PreviousLogs = podLogs(ctx, client, pod, c.Name, true)
+		}
+		result = append(result, state)
+	}
+	return result
+}
+
+// podLogs retorna as últimas linhas de log do container; falhas resultam em log vazio.
+func podLogs(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, container string, previous bool) string {
+	tail := workloadLogTailLines
+	data, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tail,
+		Previous:  previous,
+	}).DoRaw(ctx)
+	if err != nil {
+		return ""
+	}
+	logs := string(data)
+	if len(logs) > maxLogBytes {
+		logs = logs[len(logs)-maxLogBytes:]
+	}
+	return logs
+}
+
+func describeContainerState(s corev1.ContainerState) string {
+	switch {
+	case s.Waiting != nil:
+		return "Waiting: " + s.Waiting.Reason
+	case s.Terminated != nil:
+		return fmt.Sprintf("Terminated: %s (exit %d)", s.Terminated.Reason, s.Terminated.ExitCode)
+	case s.Running != nil:
+		return "Running"
+	default:
+		return ""
+	}
+}
+
+// collectHPAs retorna os HPAs cujo scaleTargetRef aponta para o workload.
+func collectHPAs(ctx context.Context, client kubernetes.Interface, target investigateTarget, namespace string) ([]HPAState, error) {
+	list, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar HPAs: %w", err)
+	}
+	var result []HPAState
+	for _, hpa := range list.Items {
+		if hpa.Spec.ScaleTargetRef.Kind != target.Kind || hpa.Spec.ScaleTargetRef.Name != target.Name {
+			continue
+		}
+		state := HPAState{
+			Name:    hpa.Name,
+			Min:     replicasOrDefault(hpa.Spec.MinReplicas),
+			Max:     hpa.Spec.MaxReplicas,
+			Current: hpa.Status.CurrentReplicas,
+			Desired: hpa.Status.DesiredReplicas,
+		}
+		for _, c := range hpa.Status.Conditions {
+			state.Conditions = append(state.Conditions, formatCondition(string(c.Type), string(c.Status), c.Reason, c.Message))
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+// collectServices retorna os Services cujo seletor casa com os labels dos pods
+// do workload, com a contagem de endpoints prontos e não prontos.
+func collectServices(ctx context.Context, client kubernetes.Interface, podLabels map[string]string, namespace string) ([]ServiceState, error) {
+	list, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar services: %w", err)
+	}
+	var result []ServiceState
+	for _, svc := range list.Items {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		state := ServiceState{Name: svc.Name, Type: string(svc.Spec.Type)}
+		slices, err := client.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "kubernetes.io/service-name=" + svc.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao listar endpoints do service %s: %w", svc.Name, err)
+		}
+		for _, slice := range slices.Items {
+			for _, ep := range slice.Endpoints {
+				if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+					state.ReadyEndpoints++
+				} else {
+					state.NotReadyEndpoints++
+				}
+			}
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+// Healthy indica se o workload está estável: rollout concluído, pods saudáveis e sem eventos de Warning.
+func (b *WorkloadBundle) Healthy() bool {
+	s := b.Status
+	if b.Kind == "Job" {
+		if s.Failed > 0 {
+			return false
+		}
+	} else if s.Ready < s.Desired || s.Updated < s.Desired || s.ObservedGeneration < s.Generation {
+		return false
+	}
+	for _, pod := range b.Pods {
+		if !pod.Healthy {
+			return false
+		}
+	}
+	for _, e := range b.Events {
+		if e.Type == corev1.EventTypeWarning {
+			return false
+		}
+	}
+	return true
+}
+
+// logs concatena os logs do bundle, usados como assinatura no cache de análises.
+func (b *WorkloadBundle) logs() string {
+	var sb strings.Builder
+	for _, pod := range b.Pods {
+		sb.WriteString(pod.logs())
+	}
+	for _, e := range b.Events {
+		sb.WriteString(e.Reason)
+	}
+	return sb.String()
+}
+
+// logs concatena os logs dos containers do pod, do anterior ao atual.
+func (p PodState) logs() string {
+	var sb strings.Builder
+	for _, c := range p.Containers {
+		sb.WriteString(c.PreviousLogs)
+		sb.WriteString(c.Logs)
+	}
+	return sb.String()
+}
+
+// diagnoseWorkload aplica as regras determinísticas aos pods com problema do
+// workload e retorna o primeiro diagnóstico, com o pod que o originou.
+func diagnoseWorkload(ctx context.Context, client kubernetes.Interface, engine *diagnosis.Engine, bundle *WorkloadBundle) (AnalysisResult, string, bool) {
+	for _, e := range bundle.unhealthy {
+		if result, ok := diagnosePod(ctx, client, engine, e.pod, e.events, e.logs); ok {
+			return result, e.pod.Name, true
+		}
+	}
+	return AnalysisResult{}, "", false
+}
+
+func formatCondition(condType, status, reason, message string) string {
+	s := condType + "=" + status
+	if reason != "" {
+		s += " (" + reason + ")"
+	}
+	if message != "" {
+		s += ": " + message
+	}
+	return s
+}
+
+func sortRollout(revs []RolloutRevision) {
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Revision > revs[j].Revision })
+}
+
+func containerImages(spec corev1.PodSpec) []string {
+	images := make([]string, 0, len(spec.Containers))
+	for _, c := range spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// controllerRevisionImages extrai as imagens do template gravado na ControllerRevision.
+func controllerRevisionImages(rev *appsv1.ControllerRevision) []string {
+	var data struct {
+		Spec struct {
+			Template struct {
+				Spec corev1.PodSpec `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if len(rev.Data.Raw) == 0 || json.Unmarshal(rev.Data.Raw, &data) != nil {
+		return nil
+	}
+	return containerImages(data.Spec.Template.Spec)
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func replicasOrDefault(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+func derefInt32(v *int32) int32 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// investigateWorkload investiga um Deployment, StatefulSet ou Job: coleta o
+// bundle correlacionado do workload, aplica o motor de regras aos pods com
+// problema e, sem regra que case, o envia à IA em uma única análise.
+func investigateWorkload(target investigateTarget, opts investigateOptions) {
+	namespace := opts.Namespace
+	width, titleStyle, boxStyle, labelStyle := investigateStyles()
+	fmt.Println(titleStyle.Render(fmt.Sprintf("\n🛡️  Sentinel Investigation: %s/%s", namespace, target)))
+
+	k8sClient, err := getKubeClient()
+	if err != nil {
+		fmt.Printf("⚠️  Falha ao obter cliente Kubernetes: %v\n", err)
+		return
+	}
+
+	// Métricas são opcionais: sem metrics-server o bundle traz só requests/limits
+	metricsClient, _ := getMetricsClient()
+
+	ctx := context.Background()
+
+	evidence := newInvestigationBundle(target.Kind, target.Name, namespace, "sentinel.investigate.workload")
+	defer saveEvidence(opts, evidence)
+
+	fmt.Print("🔍 Coletando pods, rollout, HPA, services, métricas e eventos...")
+	bundle, err := collectWorkloadBundle(ctx, k8sClient, metricsClient, target, namespace)
+	if err != nil {
+		fmt.Printf("\r❌ %v\n", err)
+		return
+	}
+	fmt.Printf("\r✅ Bundle coletado: %d pods, %d revisoes, %d services, %d eventos\n",
+		len(bundle.Pods)+bundle.OmittedPods, len(bundle.Rollout), len(bundle.Services), len(bundle.Events))
+	evidence.Workload = bundle
+	evidence.Logs = bundle.logs()
+	if opts.SaveBundle != "" {
+		isController := true
+		evidence.Owners = collectOwnerChain(ctx, k8sClient, namespace, []metav1.OwnerReference{{Kind: target.Kind, Name: target.Name, Controller: &isController}})
+	}
+
+	if bundle.Healthy() {
+		fmt.Printf("\n%s saudavel — nenhum problema identificado.\n", target)
+		fmt.Printf("  Replicas: %d/%d prontas, %d atualizadas\n", bundle.Status.Ready, bundle.Status.Desired, bundle.Status.Updated)
+		return
+	}
+
+	if !opts.NoCache && opts.SaveBundle == "" {
+		if cached, ok := loadCache(namespace, target.String(), bundle.logs()); ok {
+			fmt.Println("\n📦 Resultado do cache (use --no-cache para forçar re-análise)")
+			renderResult(*cached, target.String(), namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+			return
+		}
+	}
+
+	// Regras determinísticas antes da IA, sobre os pods com problema
+	if opts.RulesFile != "" {
+		engine := loadDiagnosisEngine(opts.RulesFile)
+		if result, podName, ok := diagnoseWorkload(ctx, k8sClient, engine, bundle); ok {
+			evidence.Analysis = &result
+			fmt.Printf("\n📐 Diagnóstico pela regra %s no pod %s (sem IA)\n", result.RuleID, podName)
+			renderResult(result, target.String(), namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+			return
+		}
+	}
+
+	// Mesma entrada que o `analyze --bundle` reconstrói na reanálise
+	aiInput, err := evidence.AIContext()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Println("\nAnalisando com IA...")
+
+	provider := ai.GetProvider(ctx, "auto")
+	if provider == nil {
+		fmt.Println("❌ Nenhum provedor de IA disponível. Defina OLLAMA_HOST ou OPENAI_API_KEY.")
+		return
+	}
+
+	systemPrompt := prompts.Get("sentinel.investigate.workload")
+	evidence.Prompt = systemPrompt
+	evidence.Manifest.Provider = provider.Name()
+
+	result, err := requestAnalysis(ctx, provider, systemPrompt, aiInput)
+	if err != nil {
+		printAnalysisError(err)
+		return
+	}
+
+	evidence.Analysis = &result
+
+	saveCache(namespace, target.String(), bundle.logs(), result)
+	renderResult(result, target.String(), namespace, opts.OutputFormat, opts.OutputFile, width, titleStyle, boxStyle, labelStyle)
+}
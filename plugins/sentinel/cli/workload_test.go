@@ -0,0 +1,380 @@
+//go:build k8s
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/casheiro/yby-cli/pkg/kubemetrics"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func ownedBy(kind, name string, uid types.UID) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, UID: uid, Controller: &isController}}
+}
+
+func replicaSet(name, revision, image string, ready int32) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "prod", UID: types.UID(name),
+			Labels:          map[string]string{"app": "api"},
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision},
+			OwnerReferences: ownedBy("Deployment", "api", "uid-api"),
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: image}},
+		}}},
+		Status: appsv1.ReplicaSetStatus{Replicas: 1, ReadyReplicas: ready},
+	}
+}
+
+func workloadPod(name, rs string, healthy bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "prod",
+			Labels:          map[string]string{"app": "api", appsv1.DefaultDeploymentUniqueLabelKey: rs},
+			OwnerReferences: ownedBy("ReplicaSet", rs, types.UID(rs)),
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "api:1"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	cs := corev1.ContainerStatus{Name: "app", Ready: healthy, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	if !healthy {
+		cs.RestartCount = 4
+		cs.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+		cs.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}
+	}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{cs}
+	return pod
+}
+
+func stuckDeploymentObjects() []runtime.Object {
+	replicas := int32(2)
+	ready := true
+	notReady := false
+	return []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod", UID: "uid-api", Generation: 2},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "api"}}},
+			},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 1,
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}},
+			},
+		},
+		replicaSet("api-v1", "1", "api:1", 1),
+		replicaSet("api-v2", "2", "api:2", 0),
+		// ReplicaSet com os mesmos labels, mas de outro Deployment
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "outro", Namespace: "prod", UID: "outro", Labels: map[string]string{"app": "api"}, OwnerReferences: ownedBy("Deployment", "outro", "uid-outro")}},
+		workloadPod("api-v1-a", "api-v1", true),
+		workloadPod("api-v2-b", "api-v2", false),
+		workloadPod("outro-c", "outro", false),
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "ev1", Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-v2-b"},
+			Type:           corev1.EventTypeWarning, Reason: "BackOff", Message: "Back-off restarting failed container",
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "ev2", Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "outro-c"},
+			Type:           corev1.EventTypeWarning, Reason: "BackOff",
+		},
+		&autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+			Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "api"}, MaxReplicas: 5},
+			Status:     autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2, DesiredReplicas: 2},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: map[string]string{"app": "api"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "db"}},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-xyz", Namespace: "prod", Labels: map[string]string{"kubernetes.io/service-name": "api"}},
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+				{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+			},
+		},
+	}
+}
+
+func TestParseInvestigateTarget(t *testing.T) {
+	cases := map[string]investigateTarget{
+		"meu-pod":        {Kind: "Pod", Name: "meu-pod"},
+		"pod/meu-pod":    {Kind: "Pod", Name: "meu-pod"},
+		"deployment/api": {Kind: "Deployment", Name: "api"},
+		"deploy/api":     {Kind: "Deployment", Name: "api"},
+		"sts/postgres":   {Kind: "StatefulSet", Name: "postgres"},
+		"StatefulSet/pg": {Kind: "StatefulSet", Name: "pg"},
+		"job/migrate":    {Kind: "Job", Name: "migrate"},
+	}
+	for arg, want := range cases {
+		got, err := parseInvestigateTarget(arg)
+		if err != nil || got != want {
+			t.Errorf("parseInvestigateTarget(%s) = %+v, %v; esperava %+v", arg, got, err, want)
+		}
+	}
+	for _, arg := range []string{"daemonset/x", "deployment/"} {
+		if _, err := parseInvestigateTarget(arg); err == nil {
+			t.Errorf("esperava erro para %s", arg)
+		}
+	}
+	if (investigateTarget{Kind: "Deployment", Name: "api"}).String() != "deployment/api" {
+		t.Error("String() deveria usar o formato da CLI")
+	}
+}
+
+func TestCollectWorkloadBundle_DeploymentTravado(t *testing.T) {
+	client := fake.NewSimpleClientset(stuckDeploymentObjects()...)
+
+	bundle, err := collectWorkloadBundle(context.Background(), client, nil, investigateTarget{Kind: "Deployment", Name: "api"}, "prod")
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	if bundle.Status.Desired != 2 || bundle.Status.Ready != 1 || len(bundle.Status.Conditions) != 1 {
+		t.Errorf("status inesperado: %+v", bundle.Status)
+	}
+	if len(bundle.Rollout) != 2 || bundle.Rollout[0].Name != "api-v2" || !bundle.Rollout[0].Target || bundle.Rollout[1].Target {
+		t.Fatalf("histórico de rollout inesperado: %+v", bundle.Rollout)
+	}
+	if bundle.Rollout[0].Images[0] != "api:2" || bundle.Rollout[1].Ready != 1 {
+		t.Errorf("revisões inesperadas: %+v", bundle.Rollout)
+	}
+
+	// Apenas os pods dos ReplicaSets do Deployment, com problemas primeiro
+	if len(bundle.Pods) != 2 || bundle.Pods[0].Name != "api-v2-b" || bundle.Pods[0].Healthy || !bundle.Pods[1].Healthy {
+		t.Fatalf("pods inesperados: %+v", bundle.Pods)
+	}
+	crashing := bundle.Pods[0].Containers[0]
+	if crashing.State != "Waiting: CrashLoopBackOff" || crashing.LastTermination != "Error (exit 1)" || crashing.PreviousLogs == "" {
+		t.Errorf("container com restarts deveria trazer estado e logs anteriores: %+v", crashing)
+	}
+	if bundle.Pods[1].Containers[0].PreviousLogs != "" {
+		t.Error("container sem restarts não deveria buscar logs anteriores")
+	}
+	if bundle.Pods[0].Revision != "api-v2" {
+		t.Errorf("pod deveria indicar a revisão: %+v", bundle.Pods[0])
+	}
+
+	if len(bundle.Events) != 1 || bundle.Events[0].Object != "Pod/api-v2-b" {
+		t.Errorf("eventos inesperados: %+v", bundle.Events)
+	}
+	if len(bundle.HPA) != 1 || bundle.HPA[0].Max != 5 || bundle.HPA[0].Min != 1 {
+		t.Errorf("HPA inesperado: %+v", bundle.HPA)
+	}
+	if len(bundle.Services) != 1 || bundle.Services[0].ReadyEndpoints != 1 || bundle.Services[0].NotReadyEndpoints != 1 {
+		t.Errorf("services inesperados: %+v", bundle.Services)
+	}
+	if bundle.Healthy() {
+		t.Error("deployment travado não deveria ser saudável")
+	}
+}
+
+func TestCollectWorkloadBundle_LimitaPods(t *testing.T) {
+	objects := stuckDeploymentObjects()
+	for i := 0; i < maxBundlePods+3; i++ {
+		objects = append(objects, workloadPod(fmt.Sprintf("api-v1-%02d", i), "api-v1", true))
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	bundle, err := collectWorkloadBundle(context.Background(), client, nil, investigateTarget{Kind: "Deployment", Name: "api"}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Pods) != maxBundlePods || bundle.OmittedPods != 5 || bundle.Pods[0].Name != "api-v2-b" {
+		t.Errorf("esperava %d pods (com problema primeiro) e 5 omitidos, obteve %d e %d", maxBundlePods, len(bundle.Pods), bundle.OmittedPods)
+	}
+}
+
+func TestCollectWorkloadBundle_Metricas(t *testing.T) {
+	objects := stuckDeploymentObjects()
+	for _, obj := range objects {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}
+		}
+	}
+	mc := &metricsfake.Clientset{}
+	mc.AddReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-v2-b", Namespace: "prod", Labels: map[string]string{"app": "api"}},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "app", Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("250Mi"),
+			}}},
+		}}}, nil
+	})
+
+	bundle, err := collectWorkloadBundle(context.Background(), fake.NewSimpleClientset(objects...), kubemetrics.New(mc), investigateTarget{Kind: "Deployment", Name: "api"}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := bundle.Pods[0].Usage
+	if len(usage) != 1 || usage[0].MemoryUsage != "250Mi" || usage[0].MemoryLimitPercent == nil || *usage[0].MemoryLimitPercent != 97 {
+		t.Fatalf("uso do pod com problema inesperado: %+v", usage)
+	}
+	if len(usage[0].Notes) != 1 {
+		t.Errorf("memória perto do limit deveria ser destacada: %v", usage[0].Notes)
+	}
+	// Pod sem métrica ainda traz requests/limits
+	if other := bundle.Pods[1].Usage; len(other) != 1 || other[0].MemoryUsage != "N/A" || other[0].MemoryLimit != "256Mi" {
+		t.Errorf("pod sem métrica deveria trazer só limits: %+v", other)
+	}
+}
+
+func TestCollectWorkloadBundle_StatefulSet(t *testing.T) {
+	replicas := int32(2)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod", UID: "uid-db"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: 2, UpdatedReplicas: 1, UpdateRevision: "db-2"},
+	}
+	revision := func(name string, rev int64, image string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod", Labels: map[string]string{"app": "db"}, OwnerReferences: ownedBy("StatefulSet", "db", "uid-db")},
+			Revision:   rev,
+			Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"db","image":"` + image + `"}]}}}}`)},
+		}
+	}
+	pod := func(name, rev string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod", Labels: map[string]string{"app": "db", appsv1.ControllerRevisionHashLabelKey: rev}, OwnerReferences: ownedBy("StatefulSet", "db", "uid-db")},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+		}
+	}
+	client := fake.NewSimpleClientset(sts, revision("db-1", 1, "postgres:15"), revision("db-2", 2, "postgres:16"), pod("db-0", "db-2"), pod("db-1", "db-1"))
+
+	bundle, err := collectWorkloadBundle(context.Background(), client, nil, investigateTarget{Kind: "StatefulSet", Name: "db"}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Rollout) != 2 || bundle.Rollout[0].Name != "db-2" || !bundle.Rollout[0].Target || bundle.Rollout[0].Images[0] != "postgres:16" {
+		t.Fatalf("revisões inesperadas: %+v", bundle.Rollout)
+	}
+	if bundle.Rollout[0].Replicas != 1 || bundle.Rollout[0].Ready != 1 {
+		t.Errorf("réplicas por revisão inesperadas: %+v", bundle.Rollout[0])
+	}
+	if len(bundle.Pods) != 2 || bundle.Pods[0].Revision == "" {
+		t.Errorf("pods inesperados: %+v", bundle.Pods)
+	}
+	if bundle.Healthy() {
+		t.Error("rollout parcial (1/2 atualizados) não deveria ser saudável")
+	}
+}
+
+func TestCollectWorkloadBundle_Job(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "prod", UID: "uid-job"},
+		Spec:       batchv1.JobSpec{Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"job-name": "migrate"}}}},
+		Status: batchv1.JobStatus{
+			Failed:     3,
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"}},
+		},
+	}
+	failed := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate-x", Namespace: "prod", Labels: map[string]string{"job-name": "migrate"}, OwnerReferences: ownedBy("Job", "migrate", "uid-job")},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "migrate", Image: "migrate:1"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed, ContainerStatuses: []corev1.ContainerStatus{{
+			Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 2}},
+		}}},
+	}
+	client := fake.NewSimpleClientset(job, failed)
+
+	bundle, err := collectWorkloadBundle(context.Background(), client, nil, investigateTarget{Kind: "Job", Name: "migrate"}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Status.Failed != 3 || bundle.Status.Conditions[0] != "Failed=True (BackoffLimitExceeded)" {
+		t.Errorf("status inesperado: %+v", bundle.Status)
+	}
+	if len(bundle.Pods) != 1 || bundle.Pods[0].Containers[0].State != "Terminated: Error (exit 2)" {
+		t.Errorf("pods inesperados: %+v", bundle.Pods)
+	}
+	if bundle.Healthy() {
+		t.Error("job com falhas não deveria ser saudável")
+	}
+}
+
+func TestCollectWorkloadBundle_Inexistente(t *testing.T) {
+	_, err := collectWorkloadBundle(context.Background(), fake.NewSimpleClientset(), nil, investigateTarget{Kind: "Deployment", Name: "api"}, "prod")
+	if err == nil {
+		t.Error("esperava erro para deployment inexistente")
+	}
+}
+
+func TestWorkloadBundle_Healthy(t *testing.T) {
+	bundle := WorkloadBundle{
+		Kind:   "Deployment",
+		Status: WorkloadStatus{Desired: 2, Ready: 2, Updated: 2, Generation: 3, ObservedGeneration: 3},
+		Pods:   []PodState{{Name: "a", Healthy: true}, {Name: "b", Healthy: true}},
+	}
+	if !bundle.Healthy() {
+		t.Error("rollout completo com pods saudáveis deveria ser saudável")
+	}
+	bundle.Status.ObservedGeneration = 2
+	if bundle.Healthy() {
+		t.Error("geração ainda não observada pelo controller não deveria ser saudável")
+	}
+}
+
+func TestInvestigateWorkload_RegrasAntesDaIA(t *testing.T) {
+	t.Setenv("YBY_AI_PROVIDER", "nenhum")
+	objects := stuckDeploymentObjects()
+	for _, obj := range objects {
+		if pod, ok := obj.(*corev1.Pod); ok && pod.Name == "api-v2-b" {
+			pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.Reason = "OOMKilled"
+		}
+	}
+	useFakeClients(t, fake.NewSimpleClientset(objects...), nil)
+	target := investigateTarget{Kind: "Deployment", Name: "api"}
+
+	bundlePath := filepath.Join(t.TempDir(), "evidencia.tar.gz")
+	out := captureStdout(t, func() {
+		investigateWorkload(target, investigateOptions{Namespace: "prod", RulesFile: filepath.Join(t.TempDir(), "rules.yaml"), SaveBundle: bundlePath})
+	})
+	if !strings.Contains(out, "no pod api-v2-b (sem IA)") || strings.Contains(out, "Analisando com IA") {
+		t.Errorf("regra deveria diagnosticar o pod sem chamar a IA:\n%s", out)
+	}
+	bundle, err := readBundle(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Analysis == nil || bundle.Analysis.RuleID == "" || !strings.Contains(bundle.Analysis.RootCause, "OOMKilled") {
+		t.Errorf("diagnóstico da regra deveria ser gravado na evidência: %+v", bundle.Analysis)
+	}
+
+	// --no-rules segue direto para a IA
+	out = captureStdout(t, func() {
+		investigateWorkload(target, investigateOptions{Namespace: "prod", NoCache: true})
+	})
+	if strings.Contains(out, "sem IA") || !strings.Contains(out, "Nenhum provedor de IA") {
+		t.Errorf("sem regras o workload deveria ir para a IA:\n%s", out)
+	}
+}
@@ -2,7 +2,7 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -48,12 +48,12 @@ func (a *Agent) Capture(input string) error {
 	// Inject Timestamp to help ID generation
 	promptWithContext := fmt.Sprintf("%s\nCurrent Timestamp: %d", prompts.Get("synapstor.capture"), time.Now().Unix())
 
-	respJson, err := a.Provider.Completion(context.Background(), promptWithContext, input)
+	uki, err := a.generate(promptWithContext, input)
 	if err != nil {
-		return fmt.Errorf("falha na IA: %w", err)
+		return err
 	}
 
-	return a.saveResponse(respJson, "Conhecimento Capturado!")
+	return a.saveResponse(uki, "Conhecimento Capturado!")
 }
 
 // Study scans code and generates documentation
@@ -97,12 +97,12 @@ func (a *Agent) Study(query string) error {
 		return fmt.Errorf("nenhum provedor de IA configurado")
 	}
 
-	respJson, err := a.Provider.Completion(context.Background(), promptWithContext, sb.String())
+	uki, err := a.generate(promptWithContext, sb.String())
 	if err != nil {
-		return fmt.Errorf("falha na IA: %w", err)
+		return err
 	}
 
-	return a.saveResponse(respJson, "Conhecimento Gerado!")
+	return a.saveResponse(uki, "Conhecimento Gerado!")
 }
 
 // validateResponse valida os campos obrigatórios da resposta da IA.
@@ -135,45 +135,24 @@ func validateResponse(resp *SynapstorResponse) error {
 	return nil
 }
 
-func (a *Agent) saveResponse(respJson, successTitle string) error {
-	// Limpar JSON
-	cleanJson := strings.ReplaceAll(respJson, "```json", "")
-	cleanJson = strings.ReplaceAll(cleanJson, "```", "")
-	cleanJson = strings.TrimSpace(cleanJson)
-
+// generate pede a UKI à IA em modo JSON estruturado. Respostas que falham em
+// validateResponse são devolvidas ao modelo para correção.
+func (a *Agent) generate(systemPrompt, input string) (*SynapstorResponse, error) {
 	var uki SynapstorResponse
-	if err := json.Unmarshal([]byte(cleanJson), &uki); err != nil {
-		return fmt.Errorf("falha ao parsear resposta da IA: %w\nResp (Raw): %s", err, respJson)
-	}
-
-	// Validar resposta
-	if err := validateResponse(&uki); err != nil {
-		// Tentar retry com prompt corretivo (1x)
-		fmt.Printf("⚠️  Resposta inválida: %v. Tentando corrigir...\n", err)
-		correctionPrompt := fmt.Sprintf(
-			"A resposta anterior teve o seguinte problema de validação: %v\n"+
-				"Corrija e reenvie no formato JSON correto com os campos: title, filename (formato UKI-TIMESTAMP-SLUG.md), content, summary.\n"+
-				"Resposta original:\n%s", err, cleanJson)
-
-		retryJson, retryErr := a.Provider.Completion(context.Background(), prompts.Get("synapstor.capture"), correctionPrompt)
-		if retryErr != nil {
-			return fmt.Errorf("falha na correção da IA: %w (erro original: %v)", retryErr, err)
-		}
-
-		retryClean := strings.ReplaceAll(retryJson, "```json", "")
-		retryClean = strings.ReplaceAll(retryClean, "```", "")
-		retryClean = strings.TrimSpace(retryClean)
-
-		if err := json.Unmarshal([]byte(retryClean), &uki); err != nil {
-			return fmt.Errorf("falha ao parsear resposta corrigida da IA: %w", err)
-		}
-
-		// Validar novamente (sem retry desta vez)
-		if err := validateResponse(&uki); err != nil {
-			return fmt.Errorf("resposta da IA continua inválida após correção: %w", err)
+	err := ai.CompleteJSON(context.Background(), a.Provider, systemPrompt, input, &uki, ai.JSONOptions{
+		Validate: func() error { return validateResponse(&uki) },
+	})
+	if err != nil {
+		var invalid *ai.JSONValidationError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("falha ao parsear resposta da IA: %w\nResp (Raw): %s", err, invalid.Raw)
 		}
+		return nil, fmt.Errorf("falha na IA: %w", err)
 	}
+	return &uki, nil
+}
 
+func (a *Agent) saveResponse(uki *SynapstorResponse, successTitle string) error {
 	// Preparar diretórios e salvar arquivo
 	synapstorDir := filepath.Join(a.RootDir, ".synapstor", ".uki")
 	if err := os.MkdirAll(synapstorDir, 0755); err != nil {
@@ -182,31 +182,55 @@ func TestValidateResponse_AdicionaExtensaoMd(t *testing.T) {
 	}
 }
 
-// TestSaveResponse_ComValidacaoERetry verifica que saveResponse faz retry quando a resposta é inválida.
-func TestSaveResponse_ComValidacaoERetry(t *testing.T) {
+// sequenceProvider retorna uma resposta diferente a cada chamada.
+type sequenceProvider struct {
+	MockProvider
+	responses []string
+	prompts   []string
+}
+
+func (m *sequenceProvider) Completion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	m.prompts = append(m.prompts, userPrompt)
+	resp := m.responses[0]
+	if len(m.responses) > 1 {
+		m.responses = m.responses[1:]
+	}
+	return resp, nil
+}
+
+// TestCapture_ComValidacaoERetry verifica que a resposta inválida é devolvida à IA para correção.
+func TestCapture_ComValidacaoERetry(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Primeira resposta inválida (título vazio), segunda resposta válida
-	callCount := 0
-	mockProvider := &MockProvider{
-		Response: `{"title": "Corrigido", "filename": "UKI-123-corrigido.md", "content": "conteúdo corrigido", "summary": "resumo"}`,
-	}
+	mockProvider := &sequenceProvider{responses: []string{
+		`{"title": "", "filename": "UKI-123-teste.md", "content": "conteúdo", "summary": "resumo"}`,
+		`{"title": "Corrigido", "filename": "UKI-123-corrigido.md", "content": "conteúdo corrigido", "summary": "resumo"}`,
+	}}
 
-	// Simular resposta inválida na primeira chamada
 	agent := NewAgent(mockProvider, tmpDir)
-
-	// Usar diretamente saveResponse com JSON inválido (título vazio)
-	invalidJson := `{"title": "", "filename": "UKI-123-teste.md", "content": "conteúdo", "summary": "resumo"}`
-	err := agent.saveResponse(invalidJson, "Teste")
-	if err != nil {
-		t.Fatalf("saveResponse deveria ter corrigido via retry, mas falhou: %v", err)
+	if err := agent.Capture("nota"); err != nil {
+		t.Fatalf("Capture deveria ter corrigido via retry, mas falhou: %v", err)
 	}
 
-	_ = callCount // Evitar warning de variável não utilizada
+	if len(mockProvider.prompts) != 2 {
+		t.Fatalf("esperadas 2 chamadas à IA, obtidas %d", len(mockProvider.prompts))
+	}
+	if !strings.Contains(mockProvider.prompts[1], "campo 'title' está vazio") {
+		t.Errorf("a correção deveria trazer o erro de validação: %q", mockProvider.prompts[1])
+	}
 
 	// Verificar que o arquivo corrigido foi criado
-	files, _ := os.ReadDir(filepath.Join(tmpDir, ".synapstor", ".uki"))
-	if len(files) == 0 {
-		t.Error("esperado pelo menos um arquivo UKI criado após retry")
+	if _, err := os.Stat(filepath.Join(tmpDir, ".synapstor", ".uki", "UKI-123-corrigido.md")); err != nil {
+		t.Errorf("esperado arquivo UKI corrigido: %v", err)
+	}
+}
+
+// TestCapture_RespostaInvalida verifica o erro após esgotar as correções.
+func TestCapture_RespostaInvalida(t *testing.T) {
+	agent := NewAgent(&MockProvider{Response: "não é json"}, t.TempDir())
+	err := agent.Capture("nota")
+	if err == nil || !strings.Contains(err.Error(), "não é json") {
+		t.Errorf("erro deveria trazer a resposta bruta: %v", err)
 	}
 }
@@ -3,7 +3,7 @@ package tagger
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,27 +25,24 @@ func TagUKI(ctx context.Context, provider ai.Provider, content string) ([]string
 		return nil, fmt.Errorf("provedor de IA não configurado")
 	}
 
-	resp, err := provider.Completion(ctx, prompts.Get("synapstor.tagger"), content)
+	var tags []string
+	err := ai.CompleteJSON(ctx, provider, prompts.Get("synapstor.tagger"), content, &tags, ai.JSONOptions{
+		Validate: func() error {
+			if len(tags) < 1 {
+				return fmt.Errorf("IA retornou 0 tags")
+			}
+			return nil
+		},
+	})
 	if err != nil {
+		var invalid *ai.JSONValidationError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("erro ao parsear tags da IA: %w (resposta: %s)", err, invalid.Raw)
+		}
 		return nil, fmt.Errorf("erro na IA: %w", err)
 	}
 
-	// Limpar resposta
-	clean := strings.TrimSpace(resp)
-	clean = strings.TrimPrefix(clean, "```json")
-	clean = strings.TrimPrefix(clean, "```")
-	clean = strings.TrimSuffix(clean, "```")
-	clean = strings.TrimSpace(clean)
-
-	var tags []string
-	if err := json.Unmarshal([]byte(clean), &tags); err != nil {
-		return nil, fmt.Errorf("erro ao parsear tags da IA: %w (resposta: %s)", err, resp)
-	}
-
-	// Validar limites
-	if len(tags) < 1 {
-		return nil, fmt.Errorf("IA retornou 0 tags")
-	}
+	// Limitar quantidade
 	if len(tags) > 7 {
 		tags = tags[:7]
 	}
@@ -9,6 +9,7 @@ require (
 	k8s.io/api v0.32.0
 	k8s.io/apimachinery v0.32.0
 	k8s.io/client-go v0.32.0
+	k8s.io/metrics v0.32.0
 	sigs.k8s.io/yaml v1.4.0
 )
 
@@ -2,7 +2,6 @@ package monitor
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,10 +9,13 @@ import (
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // ListFilter contém filtros para listagem de recursos
@@ -39,6 +41,7 @@ type Client interface {
 type K8sClient struct {
 	clientset  *kubernetes.Clientset
 	restConfig *rest.Config
+	metrics    metricsclient.Interface
 }
 
 // Clientset retorna o clientset Kubernetes para uso em operações avançadas
@@ -71,23 +74,10 @@ func NewK8sClient() (*K8sClient, error) {
 		return nil, fmt.Errorf("falha ao criar clientset: %w", err)
 	}
 
-	return &K8sClient{clientset: clientset, restConfig: config}, nil
-}
+	// Sem o client de métricas os pods apenas exibem N/A em CPU e memória
+	metrics, _ := metricsclient.NewForConfig(config)
 
-// podMetricsResult representa a resposta da API de métricas de pods.
-type podMetricsResult struct {
-	Items []struct {
-		Metadata struct {
-			Name      string `json:"name"`
-			Namespace string `json:"namespace"`
-		} `json:"metadata"`
-		Containers []struct {
-			Usage struct {
-				CPU    string `json:"cpu"`
-				Memory string `json:"memory"`
-			} `json:"usage"`
-		} `json:"containers"`
-	} `json:"items"`
+	return &K8sClient{clientset: clientset, restConfig: config, metrics: metrics}, nil
 }
 
 // podMetricsKey gera uma chave única para identificar um pod nas métricas.
@@ -95,34 +85,31 @@ func podMetricsKey(namespace, name string) string {
 	return namespace + "/" + name
 }
 
-// fetchPodMetrics busca métricas de CPU e memória do metrics-server.
-// Retorna um mapa de "namespace/name" -> {cpu, memory}. Falha silenciosamente.
-func (c *K8sClient) fetchPodMetrics() map[string][2]string {
+// fetchPodMetrics busca métricas de CPU e memória do metrics-server, somando
+// todos os containers de cada pod. Retorna um mapa de "namespace/name" -> {cpu, memory}.
+// Falha silenciosamente.
+func (c *K8sClient) fetchPodMetrics(namespace string) map[string][2]string {
 	result := make(map[string][2]string)
+	if c.metrics == nil {
+		return result
+	}
 
-	data, err := c.clientset.RESTClient().
-		Get().
-		AbsPath("/apis/metrics.k8s.io/v1beta1/pods").
-		DoRaw(context.Background())
+	list, err := c.metrics.MetricsV1beta1().PodMetricses(namespace).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		return result // metrics-server indisponível
 	}
 
-	var metrics podMetricsResult
-	if err := json.Unmarshal(data, &metrics); err != nil {
-		return result
-	}
-
-	for _, item := range metrics.Items {
-		var totalCPU, totalMem string
-		if len(item.Containers) > 0 {
-			totalCPU = item.Containers[0].Usage.CPU
-			totalMem = item.Containers[0].Usage.Memory
+	for _, m := range list.Items {
+		var cpu, memory resource.Quantity
+		for _, container := range m.Containers {
+			cpu.Add(container.Usage[corev1.ResourceCPU])
+			memory.Add(container.Usage[corev1.ResourceMemory])
+		}
+		result[podMetricsKey(m.Namespace, m.Name)] = [2]string{
+			fmt.Sprintf("%dm", cpu.MilliValue()),
+			fmt.Sprintf("%dMi", memory.Value()/(1024*1024)),
 		}
-		key := podMetricsKey(item.Metadata.Namespace, item.Metadata.Name)
-		result[key] = [2]string{totalCPU, totalMem}
 	}
-
 	return result
 }
 
@@ -139,20 +126,14 @@ func (c *K8sClient) GetPods(filter ListFilter) ([]Pod, error) {
 	}
 
 	// Buscar métricas (fallback graceful se metrics-server indisponível)
-	metrics := c.fetchPodMetrics()
+	metrics := c.fetchPodMetrics(ns)
 
 	var pods []Pod
 	for _, p := range list.Items {
 		cpu := "N/A"
 		memory := "N/A"
-		key := podMetricsKey(p.Namespace, p.Name)
-		if m, ok := metrics[key]; ok {
-			if m[0] != "" {
-				cpu = m[0]
-			}
-			if m[1] != "" {
-				memory = m[1]
-			}
+		if m, ok := metrics[podMetricsKey(p.Namespace, p.Name)]; ok {
+			cpu, memory = m[0], m[1]
 		}
 		pods = append(pods, Pod{
 			Name:      p.Name,
@@ -3,6 +3,14 @@ package monitor
 import (
 	"fmt"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
 )
 
 // FakeClient implementa a interface Client para testes,
@@ -601,3 +609,33 @@ func TestFakeClient_GetEvents_Erro(t *testing.T) {
 		t.Errorf("esperava nil quando há erro, obtido %v", events)
 	}
 }
+
+// --- Testes de métricas ---
+
+// TestFetchPodMetrics_SomaContainers verifica que o uso dos pods soma todos os
+// containers, e não apenas o primeiro.
+func TestFetchPodMetrics_SomaContainers(t *testing.T) {
+	mc := &metricsfake.Clientset{}
+	mc.AddReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "app", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("200Mi")}},
+				{Name: "sidecar", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("56Mi")}},
+			},
+		}}}, nil
+	})
+	c := &K8sClient{metrics: mc}
+
+	metrics := c.fetchPodMetrics("prod")
+	if got := metrics[podMetricsKey("prod", "api")]; got != [2]string{"300m", "256Mi"} {
+		t.Errorf("esperado {300m 256Mi}, obtido %v", got)
+	}
+}
+
+// TestFetchPodMetrics_SemMetricsServer verifica o fallback silencioso.
+func TestFetchPodMetrics_SemMetricsServer(t *testing.T) {
+	if metrics := (&K8sClient{}).fetchPodMetrics(""); len(metrics) != 0 {
+		t.Errorf("esperava mapa vazio, obtido %v", metrics)
+	}
+}